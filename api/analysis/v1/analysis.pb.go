@@ -0,0 +1,2149 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.1
+// 	protoc        (unknown)
+// source: analysis/v1/analysis.proto
+
+// The analysis API over gRPC, for clients that would rather use typed RPC than
+// the REST endpoints. Messages mirror the JSON models field for field; missing
+// measurements are unset optional fields rather than 0.
+//
+// Calls made on behalf of a user carry their Telegram id in the telegram-user-id
+// metadata key, like the Telegram-User-ID header of the REST API. Failed calls
+// have a google.rpc.ErrorInfo detail whose reason is the REST error_code, e.g.
+// analysis_not_found.
+
+package analysisv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// IdType selects how an analysis is addressed, like the id_type query parameter.
+type IdType int32
+
+const (
+	// The id_analysis assigned by the analysis pipeline
+	IdType_ID_TYPE_UNSPECIFIED IdType = 0
+	IdType_ID_TYPE_EXTERNAL    IdType = 1
+	// The serial id returned as Analysis.id
+	IdType_ID_TYPE_INTERNAL IdType = 2
+)
+
+// Enum value maps for IdType.
+var (
+	IdType_name = map[int32]string{
+		0: "ID_TYPE_UNSPECIFIED",
+		1: "ID_TYPE_EXTERNAL",
+		2: "ID_TYPE_INTERNAL",
+	}
+	IdType_value = map[string]int32{
+		"ID_TYPE_UNSPECIFIED": 0,
+		"ID_TYPE_EXTERNAL":    1,
+		"ID_TYPE_INTERNAL":    2,
+	}
+)
+
+func (x IdType) Enum() *IdType {
+	p := new(IdType)
+	*p = x
+	return p
+}
+
+func (x IdType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (IdType) Descriptor() protoreflect.EnumDescriptor {
+	return file_analysis_v1_analysis_proto_enumTypes[0].Descriptor()
+}
+
+func (IdType) Type() protoreflect.EnumType {
+	return &file_analysis_v1_analysis_proto_enumTypes[0]
+}
+
+func (x IdType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use IdType.Descriptor instead.
+func (IdType) EnumDescriptor() ([]byte, []int) {
+	return file_analysis_v1_analysis_proto_rawDescGZIP(), []int{0}
+}
+
+// AnalysisRef addresses an analysis.
+type AnalysisRef struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id     string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	IdType IdType `protobuf:"varint,2,opt,name=id_type,json=idType,proto3,enum=csort.analysis.v1.IdType" json:"id_type,omitempty"`
+}
+
+func (x *AnalysisRef) Reset() {
+	*x = AnalysisRef{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_analysis_v1_analysis_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AnalysisRef) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AnalysisRef) ProtoMessage() {}
+
+func (x *AnalysisRef) ProtoReflect() protoreflect.Message {
+	mi := &file_analysis_v1_analysis_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AnalysisRef.ProtoReflect.Descriptor instead.
+func (*AnalysisRef) Descriptor() ([]byte, []int) {
+	return file_analysis_v1_analysis_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *AnalysisRef) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *AnalysisRef) GetIdType() IdType {
+	if x != nil {
+		return x.IdType
+	}
+	return IdType_ID_TYPE_UNSPECIFIED
+}
+
+type ListAnalysesRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Limit   int32  `protobuf:"varint,1,opt,name=limit,proto3" json:"limit,omitempty"`
+	Offset  int32  `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	Product string `protobuf:"bytes,3,opt,name=product,proto3" json:"product,omitempty"`
+	Id      string `protobuf:"bytes,4,opt,name=id,proto3" json:"id,omitempty"`
+	// One of pass, warn or fail
+	Verdict string `protobuf:"bytes,5,opt,name=verdict,proto3" json:"verdict,omitempty"`
+	Tag     string `protobuf:"bytes,6,opt,name=tag,proto3" json:"tag,omitempty"`
+	// One of date_time, id or product
+	SortBy string `protobuf:"bytes,7,opt,name=sort_by,json=sortBy,proto3" json:"sort_by,omitempty"`
+	// One of asc or desc
+	SortOrder string `protobuf:"bytes,8,opt,name=sort_order,json=sortOrder,proto3" json:"sort_order,omitempty"`
+}
+
+func (x *ListAnalysesRequest) Reset() {
+	*x = ListAnalysesRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_analysis_v1_analysis_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListAnalysesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAnalysesRequest) ProtoMessage() {}
+
+func (x *ListAnalysesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_analysis_v1_analysis_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAnalysesRequest.ProtoReflect.Descriptor instead.
+func (*ListAnalysesRequest) Descriptor() ([]byte, []int) {
+	return file_analysis_v1_analysis_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ListAnalysesRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListAnalysesRequest) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *ListAnalysesRequest) GetProduct() string {
+	if x != nil {
+		return x.Product
+	}
+	return ""
+}
+
+func (x *ListAnalysesRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ListAnalysesRequest) GetVerdict() string {
+	if x != nil {
+		return x.Verdict
+	}
+	return ""
+}
+
+func (x *ListAnalysesRequest) GetTag() string {
+	if x != nil {
+		return x.Tag
+	}
+	return ""
+}
+
+func (x *ListAnalysesRequest) GetSortBy() string {
+	if x != nil {
+		return x.SortBy
+	}
+	return ""
+}
+
+func (x *ListAnalysesRequest) GetSortOrder() string {
+	if x != nil {
+		return x.SortOrder
+	}
+	return ""
+}
+
+type ListAnalysesResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Analyses []*Analysis `protobuf:"bytes,1,rep,name=analyses,proto3" json:"analyses,omitempty"`
+	Total    int64       `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"`
+	Limit    int32       `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+	Offset   int32       `protobuf:"varint,4,opt,name=offset,proto3" json:"offset,omitempty"`
+}
+
+func (x *ListAnalysesResponse) Reset() {
+	*x = ListAnalysesResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_analysis_v1_analysis_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListAnalysesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAnalysesResponse) ProtoMessage() {}
+
+func (x *ListAnalysesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_analysis_v1_analysis_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAnalysesResponse.ProtoReflect.Descriptor instead.
+func (*ListAnalysesResponse) Descriptor() ([]byte, []int) {
+	return file_analysis_v1_analysis_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListAnalysesResponse) GetAnalyses() []*Analysis {
+	if x != nil {
+		return x.Analyses
+	}
+	return nil
+}
+
+func (x *ListAnalysesResponse) GetTotal() int64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *ListAnalysesResponse) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListAnalysesResponse) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+type GetAnalysisRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Ref *AnalysisRef `protobuf:"bytes,1,opt,name=ref,proto3" json:"ref,omitempty"`
+	// Also return the pipeline's class of objects as original_class
+	OriginalLabels bool `protobuf:"varint,2,opt,name=original_labels,json=originalLabels,proto3" json:"original_labels,omitempty"`
+}
+
+func (x *GetAnalysisRequest) Reset() {
+	*x = GetAnalysisRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_analysis_v1_analysis_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetAnalysisRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetAnalysisRequest) ProtoMessage() {}
+
+func (x *GetAnalysisRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_analysis_v1_analysis_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetAnalysisRequest.ProtoReflect.Descriptor instead.
+func (*GetAnalysisRequest) Descriptor() ([]byte, []int) {
+	return file_analysis_v1_analysis_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetAnalysisRequest) GetRef() *AnalysisRef {
+	if x != nil {
+		return x.Ref
+	}
+	return nil
+}
+
+func (x *GetAnalysisRequest) GetOriginalLabels() bool {
+	if x != nil {
+		return x.OriginalLabels
+	}
+	return false
+}
+
+type ListAnalysisObjectsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Ref *AnalysisRef `protobuf:"bytes,1,opt,name=ref,proto3" json:"ref,omitempty"`
+	// Also return the pipeline's class of objects as original_class
+	OriginalLabels bool `protobuf:"varint,2,opt,name=original_labels,json=originalLabels,proto3" json:"original_labels,omitempty"`
+}
+
+func (x *ListAnalysisObjectsRequest) Reset() {
+	*x = ListAnalysisObjectsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_analysis_v1_analysis_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListAnalysisObjectsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAnalysisObjectsRequest) ProtoMessage() {}
+
+func (x *ListAnalysisObjectsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_analysis_v1_analysis_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAnalysisObjectsRequest.ProtoReflect.Descriptor instead.
+func (*ListAnalysisObjectsRequest) Descriptor() ([]byte, []int) {
+	return file_analysis_v1_analysis_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListAnalysisObjectsRequest) GetRef() *AnalysisRef {
+	if x != nil {
+		return x.Ref
+	}
+	return nil
+}
+
+func (x *ListAnalysisObjectsRequest) GetOriginalLabels() bool {
+	if x != nil {
+		return x.OriginalLabels
+	}
+	return false
+}
+
+type ListObjectsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Ids []int32 `protobuf:"varint,1,rep,packed,name=ids,proto3" json:"ids,omitempty"`
+}
+
+func (x *ListObjectsRequest) Reset() {
+	*x = ListObjectsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_analysis_v1_analysis_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListObjectsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListObjectsRequest) ProtoMessage() {}
+
+func (x *ListObjectsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_analysis_v1_analysis_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListObjectsRequest.ProtoReflect.Descriptor instead.
+func (*ListObjectsRequest) Descriptor() ([]byte, []int) {
+	return file_analysis_v1_analysis_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListObjectsRequest) GetIds() []int32 {
+	if x != nil {
+		return x.Ids
+	}
+	return nil
+}
+
+type Stats struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Min    float32 `protobuf:"fixed32,1,opt,name=min,proto3" json:"min,omitempty"`
+	Max    float32 `protobuf:"fixed32,2,opt,name=max,proto3" json:"max,omitempty"`
+	Avg    float32 `protobuf:"fixed32,3,opt,name=avg,proto3" json:"avg,omitempty"`
+	Median float32 `protobuf:"fixed32,4,opt,name=median,proto3" json:"median,omitempty"`
+}
+
+func (x *Stats) Reset() {
+	*x = Stats{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_analysis_v1_analysis_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Stats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Stats) ProtoMessage() {}
+
+func (x *Stats) ProtoReflect() protoreflect.Message {
+	mi := &file_analysis_v1_analysis_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Stats.ProtoReflect.Descriptor instead.
+func (*Stats) Descriptor() ([]byte, []int) {
+	return file_analysis_v1_analysis_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Stats) GetMin() float32 {
+	if x != nil {
+		return x.Min
+	}
+	return 0
+}
+
+func (x *Stats) GetMax() float32 {
+	if x != nil {
+		return x.Max
+	}
+	return 0
+}
+
+func (x *Stats) GetAvg() float32 {
+	if x != nil {
+		return x.Avg
+	}
+	return 0
+}
+
+func (x *Stats) GetMedian() float32 {
+	if x != nil {
+		return x.Median
+	}
+	return 0
+}
+
+type Analysis struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id           int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	DateTime     *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=date_time,json=dateTime,proto3" json:"date_time,omitempty"`
+	Product      string                 `protobuf:"bytes,3,opt,name=product,proto3" json:"product,omitempty"`
+	ColorRhs     string                 `protobuf:"bytes,4,opt,name=color_rhs,json=colorRhs,proto3" json:"color_rhs,omitempty"`
+	IdUser       string                 `protobuf:"bytes,5,opt,name=id_user,json=idUser,proto3" json:"id_user,omitempty"`
+	TelegramLink string                 `protobuf:"bytes,6,opt,name=telegram_link,json=telegramLink,proto3" json:"telegram_link,omitempty"`
+	Text         string                 `protobuf:"bytes,7,opt,name=text,proto3" json:"text,omitempty"`
+	FileSource   string                 `protobuf:"bytes,8,opt,name=file_source,json=fileSource,proto3" json:"file_source,omitempty"`
+	ScaleMmPixel *float64               `protobuf:"fixed64,9,opt,name=scale_mm_pixel,json=scaleMmPixel,proto3,oneof" json:"scale_mm_pixel,omitempty"`
+	Mass         *float64               `protobuf:"fixed64,10,opt,name=mass,proto3,oneof" json:"mass,omitempty"`
+	Area         *float64               `protobuf:"fixed64,11,opt,name=area,proto3,oneof" json:"area,omitempty"`
+	R            *Stats                 `protobuf:"bytes,12,opt,name=r,proto3" json:"r,omitempty"`
+	G            *Stats                 `protobuf:"bytes,13,opt,name=g,proto3" json:"g,omitempty"`
+	B            *Stats                 `protobuf:"bytes,14,opt,name=b,proto3" json:"b,omitempty"`
+	H            *Stats                 `protobuf:"bytes,15,opt,name=h,proto3" json:"h,omitempty"`
+	S            *Stats                 `protobuf:"bytes,16,opt,name=s,proto3" json:"s,omitempty"`
+	V            *Stats                 `protobuf:"bytes,17,opt,name=v,proto3" json:"v,omitempty"`
+	LabL         *Stats                 `protobuf:"bytes,18,opt,name=lab_l,json=labL,proto3" json:"lab_l,omitempty"`
+	LabA         *Stats                 `protobuf:"bytes,19,opt,name=lab_a,json=labA,proto3" json:"lab_a,omitempty"`
+	LabB         *Stats                 `protobuf:"bytes,20,opt,name=lab_b,json=labB,proto3" json:"lab_b,omitempty"`
+	W            *Stats                 `protobuf:"bytes,21,opt,name=w,proto3" json:"w,omitempty"`
+	L            *Stats                 `protobuf:"bytes,22,opt,name=l,proto3" json:"l,omitempty"`
+	T            *Stats                 `protobuf:"bytes,23,opt,name=t,proto3" json:"t,omitempty"`
+	FileOutput   string                 `protobuf:"bytes,24,opt,name=file_output,json=fileOutput,proto3" json:"file_output,omitempty"`
+	IdAnalysis   int64                  `protobuf:"varint,25,opt,name=id_analysis,json=idAnalysis,proto3" json:"id_analysis,omitempty"`
+	Version      int32                  `protobuf:"varint,26,opt,name=version,proto3" json:"version,omitempty"`
+	Tags         []string               `protobuf:"bytes,27,rep,name=tags,proto3" json:"tags,omitempty"`
+	LotId        *int32                 `protobuf:"varint,28,opt,name=lot_id,json=lotId,proto3,oneof" json:"lot_id,omitempty"`
+	Objects      []*Object              `protobuf:"bytes,29,rep,name=objects,proto3" json:"objects,omitempty"`
+	Verdict      *SpecVerdict           `protobuf:"bytes,30,opt,name=verdict,proto3" json:"verdict,omitempty"`
+}
+
+func (x *Analysis) Reset() {
+	*x = Analysis{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_analysis_v1_analysis_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Analysis) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Analysis) ProtoMessage() {}
+
+func (x *Analysis) ProtoReflect() protoreflect.Message {
+	mi := &file_analysis_v1_analysis_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Analysis.ProtoReflect.Descriptor instead.
+func (*Analysis) Descriptor() ([]byte, []int) {
+	return file_analysis_v1_analysis_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Analysis) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Analysis) GetDateTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DateTime
+	}
+	return nil
+}
+
+func (x *Analysis) GetProduct() string {
+	if x != nil {
+		return x.Product
+	}
+	return ""
+}
+
+func (x *Analysis) GetColorRhs() string {
+	if x != nil {
+		return x.ColorRhs
+	}
+	return ""
+}
+
+func (x *Analysis) GetIdUser() string {
+	if x != nil {
+		return x.IdUser
+	}
+	return ""
+}
+
+func (x *Analysis) GetTelegramLink() string {
+	if x != nil {
+		return x.TelegramLink
+	}
+	return ""
+}
+
+func (x *Analysis) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *Analysis) GetFileSource() string {
+	if x != nil {
+		return x.FileSource
+	}
+	return ""
+}
+
+func (x *Analysis) GetScaleMmPixel() float64 {
+	if x != nil && x.ScaleMmPixel != nil {
+		return *x.ScaleMmPixel
+	}
+	return 0
+}
+
+func (x *Analysis) GetMass() float64 {
+	if x != nil && x.Mass != nil {
+		return *x.Mass
+	}
+	return 0
+}
+
+func (x *Analysis) GetArea() float64 {
+	if x != nil && x.Area != nil {
+		return *x.Area
+	}
+	return 0
+}
+
+func (x *Analysis) GetR() *Stats {
+	if x != nil {
+		return x.R
+	}
+	return nil
+}
+
+func (x *Analysis) GetG() *Stats {
+	if x != nil {
+		return x.G
+	}
+	return nil
+}
+
+func (x *Analysis) GetB() *Stats {
+	if x != nil {
+		return x.B
+	}
+	return nil
+}
+
+func (x *Analysis) GetH() *Stats {
+	if x != nil {
+		return x.H
+	}
+	return nil
+}
+
+func (x *Analysis) GetS() *Stats {
+	if x != nil {
+		return x.S
+	}
+	return nil
+}
+
+func (x *Analysis) GetV() *Stats {
+	if x != nil {
+		return x.V
+	}
+	return nil
+}
+
+func (x *Analysis) GetLabL() *Stats {
+	if x != nil {
+		return x.LabL
+	}
+	return nil
+}
+
+func (x *Analysis) GetLabA() *Stats {
+	if x != nil {
+		return x.LabA
+	}
+	return nil
+}
+
+func (x *Analysis) GetLabB() *Stats {
+	if x != nil {
+		return x.LabB
+	}
+	return nil
+}
+
+func (x *Analysis) GetW() *Stats {
+	if x != nil {
+		return x.W
+	}
+	return nil
+}
+
+func (x *Analysis) GetL() *Stats {
+	if x != nil {
+		return x.L
+	}
+	return nil
+}
+
+func (x *Analysis) GetT() *Stats {
+	if x != nil {
+		return x.T
+	}
+	return nil
+}
+
+func (x *Analysis) GetFileOutput() string {
+	if x != nil {
+		return x.FileOutput
+	}
+	return ""
+}
+
+func (x *Analysis) GetIdAnalysis() int64 {
+	if x != nil {
+		return x.IdAnalysis
+	}
+	return 0
+}
+
+func (x *Analysis) GetVersion() int32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *Analysis) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *Analysis) GetLotId() int32 {
+	if x != nil && x.LotId != nil {
+		return *x.LotId
+	}
+	return 0
+}
+
+func (x *Analysis) GetObjects() []*Object {
+	if x != nil {
+		return x.Objects
+	}
+	return nil
+}
+
+func (x *Analysis) GetVerdict() *SpecVerdict {
+	if x != nil {
+		return x.Verdict
+	}
+	return nil
+}
+
+type Object struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id            int32    `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	IdAnalysis    int64    `protobuf:"varint,2,opt,name=id_analysis,json=idAnalysis,proto3" json:"id_analysis,omitempty"`
+	File          string   `protobuf:"bytes,3,opt,name=file,proto3" json:"file,omitempty"`
+	Class         string   `protobuf:"bytes,4,opt,name=class,proto3" json:"class,omitempty"`
+	OriginalClass string   `protobuf:"bytes,5,opt,name=original_class,json=originalClass,proto3" json:"original_class,omitempty"`
+	Geometry      string   `protobuf:"bytes,6,opt,name=geometry,proto3" json:"geometry,omitempty"`
+	MH            *float64 `protobuf:"fixed64,7,opt,name=m_h,json=mH,proto3,oneof" json:"m_h,omitempty"`
+	MS            *float64 `protobuf:"fixed64,8,opt,name=m_s,json=mS,proto3,oneof" json:"m_s,omitempty"`
+	MV            *float64 `protobuf:"fixed64,9,opt,name=m_v,json=mV,proto3,oneof" json:"m_v,omitempty"`
+	MR            *float64 `protobuf:"fixed64,10,opt,name=m_r,json=mR,proto3,oneof" json:"m_r,omitempty"`
+	MG            *float64 `protobuf:"fixed64,11,opt,name=m_g,json=mG,proto3,oneof" json:"m_g,omitempty"`
+	MB            *float64 `protobuf:"fixed64,12,opt,name=m_b,json=mB,proto3,oneof" json:"m_b,omitempty"`
+	LAvg          *float64 `protobuf:"fixed64,13,opt,name=l_avg,json=lAvg,proto3,oneof" json:"l_avg,omitempty"`
+	WAvg          *float64 `protobuf:"fixed64,14,opt,name=w_avg,json=wAvg,proto3,oneof" json:"w_avg,omitempty"`
+	BrtAvg        *float64 `protobuf:"fixed64,15,opt,name=brt_avg,json=brtAvg,proto3,oneof" json:"brt_avg,omitempty"`
+	RAvg          *float64 `protobuf:"fixed64,16,opt,name=r_avg,json=rAvg,proto3,oneof" json:"r_avg,omitempty"`
+	GAvg          *float64 `protobuf:"fixed64,17,opt,name=g_avg,json=gAvg,proto3,oneof" json:"g_avg,omitempty"`
+	BAvg          *float64 `protobuf:"fixed64,18,opt,name=b_avg,json=bAvg,proto3,oneof" json:"b_avg,omitempty"`
+	HAvg          *float64 `protobuf:"fixed64,19,opt,name=h_avg,json=hAvg,proto3,oneof" json:"h_avg,omitempty"`
+	SAvg          *float64 `protobuf:"fixed64,20,opt,name=s_avg,json=sAvg,proto3,oneof" json:"s_avg,omitempty"`
+	VAvg          *float64 `protobuf:"fixed64,21,opt,name=v_avg,json=vAvg,proto3,oneof" json:"v_avg,omitempty"`
+	H             *float64 `protobuf:"fixed64,22,opt,name=h,proto3,oneof" json:"h,omitempty"`
+	S             *float64 `protobuf:"fixed64,23,opt,name=s,proto3,oneof" json:"s,omitempty"`
+	V             *float64 `protobuf:"fixed64,24,opt,name=v,proto3,oneof" json:"v,omitempty"`
+	HM            *float64 `protobuf:"fixed64,25,opt,name=h_m,json=hM,proto3,oneof" json:"h_m,omitempty"`
+	SM            *float64 `protobuf:"fixed64,26,opt,name=s_m,json=sM,proto3,oneof" json:"s_m,omitempty"`
+	VM            *float64 `protobuf:"fixed64,27,opt,name=v_m,json=vM,proto3,oneof" json:"v_m,omitempty"`
+	RM            *float64 `protobuf:"fixed64,28,opt,name=r_m,json=rM,proto3,oneof" json:"r_m,omitempty"`
+	GM            *float64 `protobuf:"fixed64,29,opt,name=g_m,json=gM,proto3,oneof" json:"g_m,omitempty"`
+	BM            *float64 `protobuf:"fixed64,30,opt,name=b_m,json=bM,proto3,oneof" json:"b_m,omitempty"`
+	BrtM          *float64 `protobuf:"fixed64,31,opt,name=brt_m,json=brtM,proto3,oneof" json:"brt_m,omitempty"`
+	WM            *float64 `protobuf:"fixed64,32,opt,name=w_m,json=wM,proto3,oneof" json:"w_m,omitempty"`
+	LM            *float64 `protobuf:"fixed64,33,opt,name=l_m,json=lM,proto3,oneof" json:"l_m,omitempty"`
+	L             *float64 `protobuf:"fixed64,34,opt,name=l,proto3,oneof" json:"l,omitempty"`
+	W             *float64 `protobuf:"fixed64,35,opt,name=w,proto3,oneof" json:"w,omitempty"`
+	LW            *float64 `protobuf:"fixed64,36,opt,name=l_w,json=lW,proto3,oneof" json:"l_w,omitempty"`
+	Pr            *float64 `protobuf:"fixed64,37,opt,name=pr,proto3,oneof" json:"pr,omitempty"`
+	Sq            *float64 `protobuf:"fixed64,38,opt,name=sq,proto3,oneof" json:"sq,omitempty"`
+	Brt           *float64 `protobuf:"fixed64,39,opt,name=brt,proto3,oneof" json:"brt,omitempty"`
+	R             *float64 `protobuf:"fixed64,40,opt,name=r,proto3,oneof" json:"r,omitempty"`
+	G             *float64 `protobuf:"fixed64,41,opt,name=g,proto3,oneof" json:"g,omitempty"`
+	B             *float64 `protobuf:"fixed64,42,opt,name=b,proto3,oneof" json:"b,omitempty"`
+	Solid         *float64 `protobuf:"fixed64,43,opt,name=solid,proto3,oneof" json:"solid,omitempty"`
+	MinH          *float64 `protobuf:"fixed64,44,opt,name=min_h,json=minH,proto3,oneof" json:"min_h,omitempty"`
+	MinS          *float64 `protobuf:"fixed64,45,opt,name=min_s,json=minS,proto3,oneof" json:"min_s,omitempty"`
+	MinV          *float64 `protobuf:"fixed64,46,opt,name=min_v,json=minV,proto3,oneof" json:"min_v,omitempty"`
+	MaxH          *float64 `protobuf:"fixed64,47,opt,name=max_h,json=maxH,proto3,oneof" json:"max_h,omitempty"`
+	MaxS          *float64 `protobuf:"fixed64,48,opt,name=max_s,json=maxS,proto3,oneof" json:"max_s,omitempty"`
+	MaxV          *float64 `protobuf:"fixed64,49,opt,name=max_v,json=maxV,proto3,oneof" json:"max_v,omitempty"`
+	Entropy       *float64 `protobuf:"fixed64,50,opt,name=entropy,proto3,oneof" json:"entropy,omitempty"`
+	IdImage       *int64   `protobuf:"varint,51,opt,name=id_image,json=idImage,proto3,oneof" json:"id_image,omitempty"`
+	ColorRhs      string   `protobuf:"bytes,52,opt,name=color_rhs,json=colorRhs,proto3" json:"color_rhs,omitempty"`
+	SqSqcrl       *float64 `protobuf:"fixed64,53,opt,name=sq_sqcrl,json=sqSqcrl,proto3,oneof" json:"sq_sqcrl,omitempty"`
+	Hu1           *float64 `protobuf:"fixed64,54,opt,name=hu1,proto3,oneof" json:"hu1,omitempty"`
+	Hu2           *float64 `protobuf:"fixed64,55,opt,name=hu2,proto3,oneof" json:"hu2,omitempty"`
+	Hu3           *float64 `protobuf:"fixed64,56,opt,name=hu3,proto3,oneof" json:"hu3,omitempty"`
+	Hu4           *float64 `protobuf:"fixed64,57,opt,name=hu4,proto3,oneof" json:"hu4,omitempty"`
+	Hu5           *float64 `protobuf:"fixed64,58,opt,name=hu5,proto3,oneof" json:"hu5,omitempty"`
+	Hu6           *float64 `protobuf:"fixed64,59,opt,name=hu6,proto3,oneof" json:"hu6,omitempty"`
+}
+
+func (x *Object) Reset() {
+	*x = Object{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_analysis_v1_analysis_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Object) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Object) ProtoMessage() {}
+
+func (x *Object) ProtoReflect() protoreflect.Message {
+	mi := &file_analysis_v1_analysis_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Object.ProtoReflect.Descriptor instead.
+func (*Object) Descriptor() ([]byte, []int) {
+	return file_analysis_v1_analysis_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Object) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Object) GetIdAnalysis() int64 {
+	if x != nil {
+		return x.IdAnalysis
+	}
+	return 0
+}
+
+func (x *Object) GetFile() string {
+	if x != nil {
+		return x.File
+	}
+	return ""
+}
+
+func (x *Object) GetClass() string {
+	if x != nil {
+		return x.Class
+	}
+	return ""
+}
+
+func (x *Object) GetOriginalClass() string {
+	if x != nil {
+		return x.OriginalClass
+	}
+	return ""
+}
+
+func (x *Object) GetGeometry() string {
+	if x != nil {
+		return x.Geometry
+	}
+	return ""
+}
+
+func (x *Object) GetMH() float64 {
+	if x != nil && x.MH != nil {
+		return *x.MH
+	}
+	return 0
+}
+
+func (x *Object) GetMS() float64 {
+	if x != nil && x.MS != nil {
+		return *x.MS
+	}
+	return 0
+}
+
+func (x *Object) GetMV() float64 {
+	if x != nil && x.MV != nil {
+		return *x.MV
+	}
+	return 0
+}
+
+func (x *Object) GetMR() float64 {
+	if x != nil && x.MR != nil {
+		return *x.MR
+	}
+	return 0
+}
+
+func (x *Object) GetMG() float64 {
+	if x != nil && x.MG != nil {
+		return *x.MG
+	}
+	return 0
+}
+
+func (x *Object) GetMB() float64 {
+	if x != nil && x.MB != nil {
+		return *x.MB
+	}
+	return 0
+}
+
+func (x *Object) GetLAvg() float64 {
+	if x != nil && x.LAvg != nil {
+		return *x.LAvg
+	}
+	return 0
+}
+
+func (x *Object) GetWAvg() float64 {
+	if x != nil && x.WAvg != nil {
+		return *x.WAvg
+	}
+	return 0
+}
+
+func (x *Object) GetBrtAvg() float64 {
+	if x != nil && x.BrtAvg != nil {
+		return *x.BrtAvg
+	}
+	return 0
+}
+
+func (x *Object) GetRAvg() float64 {
+	if x != nil && x.RAvg != nil {
+		return *x.RAvg
+	}
+	return 0
+}
+
+func (x *Object) GetGAvg() float64 {
+	if x != nil && x.GAvg != nil {
+		return *x.GAvg
+	}
+	return 0
+}
+
+func (x *Object) GetBAvg() float64 {
+	if x != nil && x.BAvg != nil {
+		return *x.BAvg
+	}
+	return 0
+}
+
+func (x *Object) GetHAvg() float64 {
+	if x != nil && x.HAvg != nil {
+		return *x.HAvg
+	}
+	return 0
+}
+
+func (x *Object) GetSAvg() float64 {
+	if x != nil && x.SAvg != nil {
+		return *x.SAvg
+	}
+	return 0
+}
+
+func (x *Object) GetVAvg() float64 {
+	if x != nil && x.VAvg != nil {
+		return *x.VAvg
+	}
+	return 0
+}
+
+func (x *Object) GetH() float64 {
+	if x != nil && x.H != nil {
+		return *x.H
+	}
+	return 0
+}
+
+func (x *Object) GetS() float64 {
+	if x != nil && x.S != nil {
+		return *x.S
+	}
+	return 0
+}
+
+func (x *Object) GetV() float64 {
+	if x != nil && x.V != nil {
+		return *x.V
+	}
+	return 0
+}
+
+func (x *Object) GetHM() float64 {
+	if x != nil && x.HM != nil {
+		return *x.HM
+	}
+	return 0
+}
+
+func (x *Object) GetSM() float64 {
+	if x != nil && x.SM != nil {
+		return *x.SM
+	}
+	return 0
+}
+
+func (x *Object) GetVM() float64 {
+	if x != nil && x.VM != nil {
+		return *x.VM
+	}
+	return 0
+}
+
+func (x *Object) GetRM() float64 {
+	if x != nil && x.RM != nil {
+		return *x.RM
+	}
+	return 0
+}
+
+func (x *Object) GetGM() float64 {
+	if x != nil && x.GM != nil {
+		return *x.GM
+	}
+	return 0
+}
+
+func (x *Object) GetBM() float64 {
+	if x != nil && x.BM != nil {
+		return *x.BM
+	}
+	return 0
+}
+
+func (x *Object) GetBrtM() float64 {
+	if x != nil && x.BrtM != nil {
+		return *x.BrtM
+	}
+	return 0
+}
+
+func (x *Object) GetWM() float64 {
+	if x != nil && x.WM != nil {
+		return *x.WM
+	}
+	return 0
+}
+
+func (x *Object) GetLM() float64 {
+	if x != nil && x.LM != nil {
+		return *x.LM
+	}
+	return 0
+}
+
+func (x *Object) GetL() float64 {
+	if x != nil && x.L != nil {
+		return *x.L
+	}
+	return 0
+}
+
+func (x *Object) GetW() float64 {
+	if x != nil && x.W != nil {
+		return *x.W
+	}
+	return 0
+}
+
+func (x *Object) GetLW() float64 {
+	if x != nil && x.LW != nil {
+		return *x.LW
+	}
+	return 0
+}
+
+func (x *Object) GetPr() float64 {
+	if x != nil && x.Pr != nil {
+		return *x.Pr
+	}
+	return 0
+}
+
+func (x *Object) GetSq() float64 {
+	if x != nil && x.Sq != nil {
+		return *x.Sq
+	}
+	return 0
+}
+
+func (x *Object) GetBrt() float64 {
+	if x != nil && x.Brt != nil {
+		return *x.Brt
+	}
+	return 0
+}
+
+func (x *Object) GetR() float64 {
+	if x != nil && x.R != nil {
+		return *x.R
+	}
+	return 0
+}
+
+func (x *Object) GetG() float64 {
+	if x != nil && x.G != nil {
+		return *x.G
+	}
+	return 0
+}
+
+func (x *Object) GetB() float64 {
+	if x != nil && x.B != nil {
+		return *x.B
+	}
+	return 0
+}
+
+func (x *Object) GetSolid() float64 {
+	if x != nil && x.Solid != nil {
+		return *x.Solid
+	}
+	return 0
+}
+
+func (x *Object) GetMinH() float64 {
+	if x != nil && x.MinH != nil {
+		return *x.MinH
+	}
+	return 0
+}
+
+func (x *Object) GetMinS() float64 {
+	if x != nil && x.MinS != nil {
+		return *x.MinS
+	}
+	return 0
+}
+
+func (x *Object) GetMinV() float64 {
+	if x != nil && x.MinV != nil {
+		return *x.MinV
+	}
+	return 0
+}
+
+func (x *Object) GetMaxH() float64 {
+	if x != nil && x.MaxH != nil {
+		return *x.MaxH
+	}
+	return 0
+}
+
+func (x *Object) GetMaxS() float64 {
+	if x != nil && x.MaxS != nil {
+		return *x.MaxS
+	}
+	return 0
+}
+
+func (x *Object) GetMaxV() float64 {
+	if x != nil && x.MaxV != nil {
+		return *x.MaxV
+	}
+	return 0
+}
+
+func (x *Object) GetEntropy() float64 {
+	if x != nil && x.Entropy != nil {
+		return *x.Entropy
+	}
+	return 0
+}
+
+func (x *Object) GetIdImage() int64 {
+	if x != nil && x.IdImage != nil {
+		return *x.IdImage
+	}
+	return 0
+}
+
+func (x *Object) GetColorRhs() string {
+	if x != nil {
+		return x.ColorRhs
+	}
+	return ""
+}
+
+func (x *Object) GetSqSqcrl() float64 {
+	if x != nil && x.SqSqcrl != nil {
+		return *x.SqSqcrl
+	}
+	return 0
+}
+
+func (x *Object) GetHu1() float64 {
+	if x != nil && x.Hu1 != nil {
+		return *x.Hu1
+	}
+	return 0
+}
+
+func (x *Object) GetHu2() float64 {
+	if x != nil && x.Hu2 != nil {
+		return *x.Hu2
+	}
+	return 0
+}
+
+func (x *Object) GetHu3() float64 {
+	if x != nil && x.Hu3 != nil {
+		return *x.Hu3
+	}
+	return 0
+}
+
+func (x *Object) GetHu4() float64 {
+	if x != nil && x.Hu4 != nil {
+		return *x.Hu4
+	}
+	return 0
+}
+
+func (x *Object) GetHu5() float64 {
+	if x != nil && x.Hu5 != nil {
+		return *x.Hu5
+	}
+	return 0
+}
+
+func (x *Object) GetHu6() float64 {
+	if x != nil && x.Hu6 != nil {
+		return *x.Hu6
+	}
+	return 0
+}
+
+type SpecRule struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Type     string   `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Severity string   `protobuf:"bytes,2,opt,name=severity,proto3" json:"severity,omitempty"`
+	Class    string   `protobuf:"bytes,3,opt,name=class,proto3" json:"class,omitempty"`
+	Channel  string   `protobuf:"bytes,4,opt,name=channel,proto3" json:"channel,omitempty"`
+	Field    string   `protobuf:"bytes,5,opt,name=field,proto3" json:"field,omitempty"`
+	Min      *float64 `protobuf:"fixed64,6,opt,name=min,proto3,oneof" json:"min,omitempty"`
+	Max      *float64 `protobuf:"fixed64,7,opt,name=max,proto3,oneof" json:"max,omitempty"`
+	Values   []string `protobuf:"bytes,8,rep,name=values,proto3" json:"values,omitempty"`
+}
+
+func (x *SpecRule) Reset() {
+	*x = SpecRule{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_analysis_v1_analysis_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SpecRule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SpecRule) ProtoMessage() {}
+
+func (x *SpecRule) ProtoReflect() protoreflect.Message {
+	mi := &file_analysis_v1_analysis_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SpecRule.ProtoReflect.Descriptor instead.
+func (*SpecRule) Descriptor() ([]byte, []int) {
+	return file_analysis_v1_analysis_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *SpecRule) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *SpecRule) GetSeverity() string {
+	if x != nil {
+		return x.Severity
+	}
+	return ""
+}
+
+func (x *SpecRule) GetClass() string {
+	if x != nil {
+		return x.Class
+	}
+	return ""
+}
+
+func (x *SpecRule) GetChannel() string {
+	if x != nil {
+		return x.Channel
+	}
+	return ""
+}
+
+func (x *SpecRule) GetField() string {
+	if x != nil {
+		return x.Field
+	}
+	return ""
+}
+
+func (x *SpecRule) GetMin() float64 {
+	if x != nil && x.Min != nil {
+		return *x.Min
+	}
+	return 0
+}
+
+func (x *SpecRule) GetMax() float64 {
+	if x != nil && x.Max != nil {
+		return *x.Max
+	}
+	return 0
+}
+
+func (x *SpecRule) GetValues() []string {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+type SpecViolation struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Rule     *SpecRule       `protobuf:"bytes,1,opt,name=rule,proto3" json:"rule,omitempty"`
+	Severity string          `protobuf:"bytes,2,opt,name=severity,proto3" json:"severity,omitempty"`
+	Actual   *structpb.Value `protobuf:"bytes,3,opt,name=actual,proto3" json:"actual,omitempty"`
+	Message  string          `protobuf:"bytes,4,opt,name=message,proto3" json:"message,omitempty"`
+}
+
+func (x *SpecViolation) Reset() {
+	*x = SpecViolation{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_analysis_v1_analysis_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SpecViolation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SpecViolation) ProtoMessage() {}
+
+func (x *SpecViolation) ProtoReflect() protoreflect.Message {
+	mi := &file_analysis_v1_analysis_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SpecViolation.ProtoReflect.Descriptor instead.
+func (*SpecViolation) Descriptor() ([]byte, []int) {
+	return file_analysis_v1_analysis_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *SpecViolation) GetRule() *SpecRule {
+	if x != nil {
+		return x.Rule
+	}
+	return nil
+}
+
+func (x *SpecViolation) GetSeverity() string {
+	if x != nil {
+		return x.Severity
+	}
+	return ""
+}
+
+func (x *SpecViolation) GetActual() *structpb.Value {
+	if x != nil {
+		return x.Actual
+	}
+	return nil
+}
+
+func (x *SpecViolation) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type SpecVerdict struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	SpecId      int32                  `protobuf:"varint,1,opt,name=spec_id,json=specId,proto3" json:"spec_id,omitempty"`
+	Verdict     string                 `protobuf:"bytes,2,opt,name=verdict,proto3" json:"verdict,omitempty"`
+	Violations  []*SpecViolation       `protobuf:"bytes,3,rep,name=violations,proto3" json:"violations,omitempty"`
+	EvaluatedAt *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=evaluated_at,json=evaluatedAt,proto3" json:"evaluated_at,omitempty"`
+}
+
+func (x *SpecVerdict) Reset() {
+	*x = SpecVerdict{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_analysis_v1_analysis_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SpecVerdict) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SpecVerdict) ProtoMessage() {}
+
+func (x *SpecVerdict) ProtoReflect() protoreflect.Message {
+	mi := &file_analysis_v1_analysis_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SpecVerdict.ProtoReflect.Descriptor instead.
+func (*SpecVerdict) Descriptor() ([]byte, []int) {
+	return file_analysis_v1_analysis_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *SpecVerdict) GetSpecId() int32 {
+	if x != nil {
+		return x.SpecId
+	}
+	return 0
+}
+
+func (x *SpecVerdict) GetVerdict() string {
+	if x != nil {
+		return x.Verdict
+	}
+	return ""
+}
+
+func (x *SpecVerdict) GetViolations() []*SpecViolation {
+	if x != nil {
+		return x.Violations
+	}
+	return nil
+}
+
+func (x *SpecVerdict) GetEvaluatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.EvaluatedAt
+	}
+	return nil
+}
+
+var File_analysis_v1_analysis_proto protoreflect.FileDescriptor
+
+var file_analysis_v1_analysis_proto_rawDesc = []byte{
+	0x0a, 0x1a, 0x61, 0x6e, 0x61, 0x6c, 0x79, 0x73, 0x69, 0x73, 0x2f, 0x76, 0x31, 0x2f, 0x61, 0x6e,
+	0x61, 0x6c, 0x79, 0x73, 0x69, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x11, 0x63, 0x73,
+	0x6f, 0x72, 0x74, 0x2e, 0x61, 0x6e, 0x61, 0x6c, 0x79, 0x73, 0x69, 0x73, 0x2e, 0x76, 0x31, 0x1a,
+	0x1c, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66,
+	0x2f, 0x73, 0x74, 0x72, 0x75, 0x63, 0x74, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x1f, 0x67,
+	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x74,
+	0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x51,
+	0x0a, 0x0b, 0x41, 0x6e, 0x61, 0x6c, 0x79, 0x73, 0x69, 0x73, 0x52, 0x65, 0x66, 0x12, 0x0e, 0x0a,
+	0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x69, 0x64, 0x12, 0x32, 0x0a,
+	0x07, 0x69, 0x64, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x19,
+	0x2e, 0x63, 0x73, 0x6f, 0x72, 0x74, 0x2e, 0x61, 0x6e, 0x61, 0x6c, 0x79, 0x73, 0x69, 0x73, 0x2e,
+	0x76, 0x31, 0x2e, 0x49, 0x64, 0x54, 0x79, 0x70, 0x65, 0x52, 0x06, 0x69, 0x64, 0x54, 0x79, 0x70,
+	0x65, 0x22, 0xd1, 0x01, 0x0a, 0x13, 0x4c, 0x69, 0x73, 0x74, 0x41, 0x6e, 0x61, 0x6c, 0x79, 0x73,
+	0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x6c, 0x69, 0x6d,
+	0x69, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x12,
+	0x16, 0x0a, 0x06, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52,
+	0x06, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x70, 0x72, 0x6f, 0x64, 0x75,
+	0x63, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x70, 0x72, 0x6f, 0x64, 0x75, 0x63,
+	0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x69,
+	0x64, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x64, 0x69, 0x63, 0x74, 0x18, 0x05, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x07, 0x76, 0x65, 0x72, 0x64, 0x69, 0x63, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x74,
+	0x61, 0x67, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x74, 0x61, 0x67, 0x12, 0x17, 0x0a,
+	0x07, 0x73, 0x6f, 0x72, 0x74, 0x5f, 0x62, 0x79, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06,
+	0x73, 0x6f, 0x72, 0x74, 0x42, 0x79, 0x12, 0x1d, 0x0a, 0x0a, 0x73, 0x6f, 0x72, 0x74, 0x5f, 0x6f,
+	0x72, 0x64, 0x65, 0x72, 0x18, 0x08, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x73, 0x6f, 0x72, 0x74,
+	0x4f, 0x72, 0x64, 0x65, 0x72, 0x22, 0x93, 0x01, 0x0a, 0x14, 0x4c, 0x69, 0x73, 0x74, 0x41, 0x6e,
+	0x61, 0x6c, 0x79, 0x73, 0x65, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x37,
+	0x0a, 0x08, 0x61, 0x6e, 0x61, 0x6c, 0x79, 0x73, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b,
+	0x32, 0x1b, 0x2e, 0x63, 0x73, 0x6f, 0x72, 0x74, 0x2e, 0x61, 0x6e, 0x61, 0x6c, 0x79, 0x73, 0x69,
+	0x73, 0x2e, 0x76, 0x31, 0x2e, 0x41, 0x6e, 0x61, 0x6c, 0x79, 0x73, 0x69, 0x73, 0x52, 0x08, 0x61,
+	0x6e, 0x61, 0x6c, 0x79, 0x73, 0x65, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x6f, 0x74, 0x61, 0x6c,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x12, 0x14, 0x0a,
+	0x05, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x6c, 0x69,
+	0x6d, 0x69, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x05, 0x52, 0x06, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x22, 0x6f, 0x0a, 0x12, 0x47,
+	0x65, 0x74, 0x41, 0x6e, 0x61, 0x6c, 0x79, 0x73, 0x69, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x12, 0x30, 0x0a, 0x03, 0x72, 0x65, 0x66, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1e,
+	0x2e, 0x63, 0x73, 0x6f, 0x72, 0x74, 0x2e, 0x61, 0x6e, 0x61, 0x6c, 0x79, 0x73, 0x69, 0x73, 0x2e,
+	0x76, 0x31, 0x2e, 0x41, 0x6e, 0x61, 0x6c, 0x79, 0x73, 0x69, 0x73, 0x52, 0x65, 0x66, 0x52, 0x03,
+	0x72, 0x65, 0x66, 0x12, 0x27, 0x0a, 0x0f, 0x6f, 0x72, 0x69, 0x67, 0x69, 0x6e, 0x61, 0x6c, 0x5f,
+	0x6c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0e, 0x6f, 0x72,
+	0x69, 0x67, 0x69, 0x6e, 0x61, 0x6c, 0x4c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x22, 0x77, 0x0a, 0x1a,
+	0x4c, 0x69, 0x73, 0x74, 0x41, 0x6e, 0x61, 0x6c, 0x79, 0x73, 0x69, 0x73, 0x4f, 0x62, 0x6a, 0x65,
+	0x63, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x30, 0x0a, 0x03, 0x72, 0x65,
+	0x66, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x63, 0x73, 0x6f, 0x72, 0x74, 0x2e,
+	0x61, 0x6e, 0x61, 0x6c, 0x79, 0x73, 0x69, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x41, 0x6e, 0x61, 0x6c,
+	0x79, 0x73, 0x69, 0x73, 0x52, 0x65, 0x66, 0x52, 0x03, 0x72, 0x65, 0x66, 0x12, 0x27, 0x0a, 0x0f,
+	0x6f, 0x72, 0x69, 0x67, 0x69, 0x6e, 0x61, 0x6c, 0x5f, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0e, 0x6f, 0x72, 0x69, 0x67, 0x69, 0x6e, 0x61, 0x6c, 0x4c,
+	0x61, 0x62, 0x65, 0x6c, 0x73, 0x22, 0x26, 0x0a, 0x12, 0x4c, 0x69, 0x73, 0x74, 0x4f, 0x62, 0x6a,
+	0x65, 0x63, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x69,
+	0x64, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x05, 0x52, 0x03, 0x69, 0x64, 0x73, 0x22, 0x55, 0x0a,
+	0x05, 0x53, 0x74, 0x61, 0x74, 0x73, 0x12, 0x10, 0x0a, 0x03, 0x6d, 0x69, 0x6e, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x02, 0x52, 0x03, 0x6d, 0x69, 0x6e, 0x12, 0x10, 0x0a, 0x03, 0x6d, 0x61, 0x78, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x02, 0x52, 0x03, 0x6d, 0x61, 0x78, 0x12, 0x10, 0x0a, 0x03, 0x61, 0x76,
+	0x67, 0x18, 0x03, 0x20, 0x01, 0x28, 0x02, 0x52, 0x03, 0x61, 0x76, 0x67, 0x12, 0x16, 0x0a, 0x06,
+	0x6d, 0x65, 0x64, 0x69, 0x61, 0x6e, 0x18, 0x04, 0x20, 0x01, 0x28, 0x02, 0x52, 0x06, 0x6d, 0x65,
+	0x64, 0x69, 0x61, 0x6e, 0x22, 0xfa, 0x08, 0x0a, 0x08, 0x41, 0x6e, 0x61, 0x6c, 0x79, 0x73, 0x69,
+	0x73, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x02, 0x69,
+	0x64, 0x12, 0x37, 0x0a, 0x09, 0x64, 0x61, 0x74, 0x65, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70,
+	0x52, 0x08, 0x64, 0x61, 0x74, 0x65, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x70, 0x72,
+	0x6f, 0x64, 0x75, 0x63, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x70, 0x72, 0x6f,
+	0x64, 0x75, 0x63, 0x74, 0x12, 0x1b, 0x0a, 0x09, 0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x5f, 0x72, 0x68,
+	0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x52, 0x68,
+	0x73, 0x12, 0x17, 0x0a, 0x07, 0x69, 0x64, 0x5f, 0x75, 0x73, 0x65, 0x72, 0x18, 0x05, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x06, 0x69, 0x64, 0x55, 0x73, 0x65, 0x72, 0x12, 0x23, 0x0a, 0x0d, 0x74, 0x65,
+	0x6c, 0x65, 0x67, 0x72, 0x61, 0x6d, 0x5f, 0x6c, 0x69, 0x6e, 0x6b, 0x18, 0x06, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x0c, 0x74, 0x65, 0x6c, 0x65, 0x67, 0x72, 0x61, 0x6d, 0x4c, 0x69, 0x6e, 0x6b, 0x12,
+	0x12, 0x0a, 0x04, 0x74, 0x65, 0x78, 0x74, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74,
+	0x65, 0x78, 0x74, 0x12, 0x1f, 0x0a, 0x0b, 0x66, 0x69, 0x6c, 0x65, 0x5f, 0x73, 0x6f, 0x75, 0x72,
+	0x63, 0x65, 0x18, 0x08, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x66, 0x69, 0x6c, 0x65, 0x53, 0x6f,
+	0x75, 0x72, 0x63, 0x65, 0x12, 0x29, 0x0a, 0x0e, 0x73, 0x63, 0x61, 0x6c, 0x65, 0x5f, 0x6d, 0x6d,
+	0x5f, 0x70, 0x69, 0x78, 0x65, 0x6c, 0x18, 0x09, 0x20, 0x01, 0x28, 0x01, 0x48, 0x00, 0x52, 0x0c,
+	0x73, 0x63, 0x61, 0x6c, 0x65, 0x4d, 0x6d, 0x50, 0x69, 0x78, 0x65, 0x6c, 0x88, 0x01, 0x01, 0x12,
+	0x17, 0x0a, 0x04, 0x6d, 0x61, 0x73, 0x73, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x01, 0x48, 0x01, 0x52,
+	0x04, 0x6d, 0x61, 0x73, 0x73, 0x88, 0x01, 0x01, 0x12, 0x17, 0x0a, 0x04, 0x61, 0x72, 0x65, 0x61,
+	0x18, 0x0b, 0x20, 0x01, 0x28, 0x01, 0x48, 0x02, 0x52, 0x04, 0x61, 0x72, 0x65, 0x61, 0x88, 0x01,
+	0x01, 0x12, 0x26, 0x0a, 0x01, 0x72, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x63,
+	0x73, 0x6f, 0x72, 0x74, 0x2e, 0x61, 0x6e, 0x61, 0x6c, 0x79, 0x73, 0x69, 0x73, 0x2e, 0x76, 0x31,
+	0x2e, 0x53, 0x74, 0x61, 0x74, 0x73, 0x52, 0x01, 0x72, 0x12, 0x26, 0x0a, 0x01, 0x67, 0x18, 0x0d,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x63, 0x73, 0x6f, 0x72, 0x74, 0x2e, 0x61, 0x6e, 0x61,
+	0x6c, 0x79, 0x73, 0x69, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x73, 0x52, 0x01,
+	0x67, 0x12, 0x26, 0x0a, 0x01, 0x62, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x63,
+	0x73, 0x6f, 0x72, 0x74, 0x2e, 0x61, 0x6e, 0x61, 0x6c, 0x79, 0x73, 0x69, 0x73, 0x2e, 0x76, 0x31,
+	0x2e, 0x53, 0x74, 0x61, 0x74, 0x73, 0x52, 0x01, 0x62, 0x12, 0x26, 0x0a, 0x01, 0x68, 0x18, 0x0f,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x63, 0x73, 0x6f, 0x72, 0x74, 0x2e, 0x61, 0x6e, 0x61,
+	0x6c, 0x79, 0x73, 0x69, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x73, 0x52, 0x01,
+	0x68, 0x12, 0x26, 0x0a, 0x01, 0x73, 0x18, 0x10, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x63,
+	0x73, 0x6f, 0x72, 0x74, 0x2e, 0x61, 0x6e, 0x61, 0x6c, 0x79, 0x73, 0x69, 0x73, 0x2e, 0x76, 0x31,
+	0x2e, 0x53, 0x74, 0x61, 0x74, 0x73, 0x52, 0x01, 0x73, 0x12, 0x26, 0x0a, 0x01, 0x76, 0x18, 0x11,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x63, 0x73, 0x6f, 0x72, 0x74, 0x2e, 0x61, 0x6e, 0x61,
+	0x6c, 0x79, 0x73, 0x69, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x73, 0x52, 0x01,
+	0x76, 0x12, 0x2d, 0x0a, 0x05, 0x6c, 0x61, 0x62, 0x5f, 0x6c, 0x18, 0x12, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x18, 0x2e, 0x63, 0x73, 0x6f, 0x72, 0x74, 0x2e, 0x61, 0x6e, 0x61, 0x6c, 0x79, 0x73, 0x69,
+	0x73, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x73, 0x52, 0x04, 0x6c, 0x61, 0x62, 0x4c,
+	0x12, 0x2d, 0x0a, 0x05, 0x6c, 0x61, 0x62, 0x5f, 0x61, 0x18, 0x13, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x18, 0x2e, 0x63, 0x73, 0x6f, 0x72, 0x74, 0x2e, 0x61, 0x6e, 0x61, 0x6c, 0x79, 0x73, 0x69, 0x73,
+	0x2e, 0x76, 0x31, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x73, 0x52, 0x04, 0x6c, 0x61, 0x62, 0x41, 0x12,
+	0x2d, 0x0a, 0x05, 0x6c, 0x61, 0x62, 0x5f, 0x62, 0x18, 0x14, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18,
+	0x2e, 0x63, 0x73, 0x6f, 0x72, 0x74, 0x2e, 0x61, 0x6e, 0x61, 0x6c, 0x79, 0x73, 0x69, 0x73, 0x2e,
+	0x76, 0x31, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x73, 0x52, 0x04, 0x6c, 0x61, 0x62, 0x42, 0x12, 0x26,
+	0x0a, 0x01, 0x77, 0x18, 0x15, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x63, 0x73, 0x6f, 0x72,
+	0x74, 0x2e, 0x61, 0x6e, 0x61, 0x6c, 0x79, 0x73, 0x69, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x74,
+	0x61, 0x74, 0x73, 0x52, 0x01, 0x77, 0x12, 0x26, 0x0a, 0x01, 0x6c, 0x18, 0x16, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x18, 0x2e, 0x63, 0x73, 0x6f, 0x72, 0x74, 0x2e, 0x61, 0x6e, 0x61, 0x6c, 0x79, 0x73,
+	0x69, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x73, 0x52, 0x01, 0x6c, 0x12, 0x26,
+	0x0a, 0x01, 0x74, 0x18, 0x17, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x63, 0x73, 0x6f, 0x72,
+	0x74, 0x2e, 0x61, 0x6e, 0x61, 0x6c, 0x79, 0x73, 0x69, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x74,
+	0x61, 0x74, 0x73, 0x52, 0x01, 0x74, 0x12, 0x1f, 0x0a, 0x0b, 0x66, 0x69, 0x6c, 0x65, 0x5f, 0x6f,
+	0x75, 0x74, 0x70, 0x75, 0x74, 0x18, 0x18, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x66, 0x69, 0x6c,
+	0x65, 0x4f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x12, 0x1f, 0x0a, 0x0b, 0x69, 0x64, 0x5f, 0x61, 0x6e,
+	0x61, 0x6c, 0x79, 0x73, 0x69, 0x73, 0x18, 0x19, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x69, 0x64,
+	0x41, 0x6e, 0x61, 0x6c, 0x79, 0x73, 0x69, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73,
+	0x69, 0x6f, 0x6e, 0x18, 0x1a, 0x20, 0x01, 0x28, 0x05, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69,
+	0x6f, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x61, 0x67, 0x73, 0x18, 0x1b, 0x20, 0x03, 0x28, 0x09,
+	0x52, 0x04, 0x74, 0x61, 0x67, 0x73, 0x12, 0x1a, 0x0a, 0x06, 0x6c, 0x6f, 0x74, 0x5f, 0x69, 0x64,
+	0x18, 0x1c, 0x20, 0x01, 0x28, 0x05, 0x48, 0x03, 0x52, 0x05, 0x6c, 0x6f, 0x74, 0x49, 0x64, 0x88,
+	0x01, 0x01, 0x12, 0x33, 0x0a, 0x07, 0x6f, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x73, 0x18, 0x1d, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x63, 0x73, 0x6f, 0x72, 0x74, 0x2e, 0x61, 0x6e, 0x61, 0x6c,
+	0x79, 0x73, 0x69, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x4f, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x52, 0x07,
+	0x6f, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x73, 0x12, 0x38, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x64, 0x69,
+	0x63, 0x74, 0x18, 0x1e, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x63, 0x73, 0x6f, 0x72, 0x74,
+	0x2e, 0x61, 0x6e, 0x61, 0x6c, 0x79, 0x73, 0x69, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x70, 0x65,
+	0x63, 0x56, 0x65, 0x72, 0x64, 0x69, 0x63, 0x74, 0x52, 0x07, 0x76, 0x65, 0x72, 0x64, 0x69, 0x63,
+	0x74, 0x42, 0x11, 0x0a, 0x0f, 0x5f, 0x73, 0x63, 0x61, 0x6c, 0x65, 0x5f, 0x6d, 0x6d, 0x5f, 0x70,
+	0x69, 0x78, 0x65, 0x6c, 0x42, 0x07, 0x0a, 0x05, 0x5f, 0x6d, 0x61, 0x73, 0x73, 0x42, 0x07, 0x0a,
+	0x05, 0x5f, 0x61, 0x72, 0x65, 0x61, 0x42, 0x09, 0x0a, 0x07, 0x5f, 0x6c, 0x6f, 0x74, 0x5f, 0x69,
+	0x64, 0x22, 0xce, 0x0e, 0x0a, 0x06, 0x4f, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x12, 0x0e, 0x0a, 0x02,
+	0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x02, 0x69, 0x64, 0x12, 0x1f, 0x0a, 0x0b,
+	0x69, 0x64, 0x5f, 0x61, 0x6e, 0x61, 0x6c, 0x79, 0x73, 0x69, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x0a, 0x69, 0x64, 0x41, 0x6e, 0x61, 0x6c, 0x79, 0x73, 0x69, 0x73, 0x12, 0x12, 0x0a,
+	0x04, 0x66, 0x69, 0x6c, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x66, 0x69, 0x6c,
+	0x65, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x05, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x12, 0x25, 0x0a, 0x0e, 0x6f, 0x72, 0x69, 0x67, 0x69,
+	0x6e, 0x61, 0x6c, 0x5f, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0d, 0x6f, 0x72, 0x69, 0x67, 0x69, 0x6e, 0x61, 0x6c, 0x43, 0x6c, 0x61, 0x73, 0x73, 0x12, 0x1a,
+	0x0a, 0x08, 0x67, 0x65, 0x6f, 0x6d, 0x65, 0x74, 0x72, 0x79, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x08, 0x67, 0x65, 0x6f, 0x6d, 0x65, 0x74, 0x72, 0x79, 0x12, 0x14, 0x0a, 0x03, 0x6d, 0x5f,
+	0x68, 0x18, 0x07, 0x20, 0x01, 0x28, 0x01, 0x48, 0x00, 0x52, 0x02, 0x6d, 0x48, 0x88, 0x01, 0x01,
+	0x12, 0x14, 0x0a, 0x03, 0x6d, 0x5f, 0x73, 0x18, 0x08, 0x20, 0x01, 0x28, 0x01, 0x48, 0x01, 0x52,
+	0x02, 0x6d, 0x53, 0x88, 0x01, 0x01, 0x12, 0x14, 0x0a, 0x03, 0x6d, 0x5f, 0x76, 0x18, 0x09, 0x20,
+	0x01, 0x28, 0x01, 0x48, 0x02, 0x52, 0x02, 0x6d, 0x56, 0x88, 0x01, 0x01, 0x12, 0x14, 0x0a, 0x03,
+	0x6d, 0x5f, 0x72, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x01, 0x48, 0x03, 0x52, 0x02, 0x6d, 0x52, 0x88,
+	0x01, 0x01, 0x12, 0x14, 0x0a, 0x03, 0x6d, 0x5f, 0x67, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x01, 0x48,
+	0x04, 0x52, 0x02, 0x6d, 0x47, 0x88, 0x01, 0x01, 0x12, 0x14, 0x0a, 0x03, 0x6d, 0x5f, 0x62, 0x18,
+	0x0c, 0x20, 0x01, 0x28, 0x01, 0x48, 0x05, 0x52, 0x02, 0x6d, 0x42, 0x88, 0x01, 0x01, 0x12, 0x18,
+	0x0a, 0x05, 0x6c, 0x5f, 0x61, 0x76, 0x67, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x01, 0x48, 0x06, 0x52,
+	0x04, 0x6c, 0x41, 0x76, 0x67, 0x88, 0x01, 0x01, 0x12, 0x18, 0x0a, 0x05, 0x77, 0x5f, 0x61, 0x76,
+	0x67, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x01, 0x48, 0x07, 0x52, 0x04, 0x77, 0x41, 0x76, 0x67, 0x88,
+	0x01, 0x01, 0x12, 0x1c, 0x0a, 0x07, 0x62, 0x72, 0x74, 0x5f, 0x61, 0x76, 0x67, 0x18, 0x0f, 0x20,
+	0x01, 0x28, 0x01, 0x48, 0x08, 0x52, 0x06, 0x62, 0x72, 0x74, 0x41, 0x76, 0x67, 0x88, 0x01, 0x01,
+	0x12, 0x18, 0x0a, 0x05, 0x72, 0x5f, 0x61, 0x76, 0x67, 0x18, 0x10, 0x20, 0x01, 0x28, 0x01, 0x48,
+	0x09, 0x52, 0x04, 0x72, 0x41, 0x76, 0x67, 0x88, 0x01, 0x01, 0x12, 0x18, 0x0a, 0x05, 0x67, 0x5f,
+	0x61, 0x76, 0x67, 0x18, 0x11, 0x20, 0x01, 0x28, 0x01, 0x48, 0x0a, 0x52, 0x04, 0x67, 0x41, 0x76,
+	0x67, 0x88, 0x01, 0x01, 0x12, 0x18, 0x0a, 0x05, 0x62, 0x5f, 0x61, 0x76, 0x67, 0x18, 0x12, 0x20,
+	0x01, 0x28, 0x01, 0x48, 0x0b, 0x52, 0x04, 0x62, 0x41, 0x76, 0x67, 0x88, 0x01, 0x01, 0x12, 0x18,
+	0x0a, 0x05, 0x68, 0x5f, 0x61, 0x76, 0x67, 0x18, 0x13, 0x20, 0x01, 0x28, 0x01, 0x48, 0x0c, 0x52,
+	0x04, 0x68, 0x41, 0x76, 0x67, 0x88, 0x01, 0x01, 0x12, 0x18, 0x0a, 0x05, 0x73, 0x5f, 0x61, 0x76,
+	0x67, 0x18, 0x14, 0x20, 0x01, 0x28, 0x01, 0x48, 0x0d, 0x52, 0x04, 0x73, 0x41, 0x76, 0x67, 0x88,
+	0x01, 0x01, 0x12, 0x18, 0x0a, 0x05, 0x76, 0x5f, 0x61, 0x76, 0x67, 0x18, 0x15, 0x20, 0x01, 0x28,
+	0x01, 0x48, 0x0e, 0x52, 0x04, 0x76, 0x41, 0x76, 0x67, 0x88, 0x01, 0x01, 0x12, 0x11, 0x0a, 0x01,
+	0x68, 0x18, 0x16, 0x20, 0x01, 0x28, 0x01, 0x48, 0x0f, 0x52, 0x01, 0x68, 0x88, 0x01, 0x01, 0x12,
+	0x11, 0x0a, 0x01, 0x73, 0x18, 0x17, 0x20, 0x01, 0x28, 0x01, 0x48, 0x10, 0x52, 0x01, 0x73, 0x88,
+	0x01, 0x01, 0x12, 0x11, 0x0a, 0x01, 0x76, 0x18, 0x18, 0x20, 0x01, 0x28, 0x01, 0x48, 0x11, 0x52,
+	0x01, 0x76, 0x88, 0x01, 0x01, 0x12, 0x14, 0x0a, 0x03, 0x68, 0x5f, 0x6d, 0x18, 0x19, 0x20, 0x01,
+	0x28, 0x01, 0x48, 0x12, 0x52, 0x02, 0x68, 0x4d, 0x88, 0x01, 0x01, 0x12, 0x14, 0x0a, 0x03, 0x73,
+	0x5f, 0x6d, 0x18, 0x1a, 0x20, 0x01, 0x28, 0x01, 0x48, 0x13, 0x52, 0x02, 0x73, 0x4d, 0x88, 0x01,
+	0x01, 0x12, 0x14, 0x0a, 0x03, 0x76, 0x5f, 0x6d, 0x18, 0x1b, 0x20, 0x01, 0x28, 0x01, 0x48, 0x14,
+	0x52, 0x02, 0x76, 0x4d, 0x88, 0x01, 0x01, 0x12, 0x14, 0x0a, 0x03, 0x72, 0x5f, 0x6d, 0x18, 0x1c,
+	0x20, 0x01, 0x28, 0x01, 0x48, 0x15, 0x52, 0x02, 0x72, 0x4d, 0x88, 0x01, 0x01, 0x12, 0x14, 0x0a,
+	0x03, 0x67, 0x5f, 0x6d, 0x18, 0x1d, 0x20, 0x01, 0x28, 0x01, 0x48, 0x16, 0x52, 0x02, 0x67, 0x4d,
+	0x88, 0x01, 0x01, 0x12, 0x14, 0x0a, 0x03, 0x62, 0x5f, 0x6d, 0x18, 0x1e, 0x20, 0x01, 0x28, 0x01,
+	0x48, 0x17, 0x52, 0x02, 0x62, 0x4d, 0x88, 0x01, 0x01, 0x12, 0x18, 0x0a, 0x05, 0x62, 0x72, 0x74,
+	0x5f, 0x6d, 0x18, 0x1f, 0x20, 0x01, 0x28, 0x01, 0x48, 0x18, 0x52, 0x04, 0x62, 0x72, 0x74, 0x4d,
+	0x88, 0x01, 0x01, 0x12, 0x14, 0x0a, 0x03, 0x77, 0x5f, 0x6d, 0x18, 0x20, 0x20, 0x01, 0x28, 0x01,
+	0x48, 0x19, 0x52, 0x02, 0x77, 0x4d, 0x88, 0x01, 0x01, 0x12, 0x14, 0x0a, 0x03, 0x6c, 0x5f, 0x6d,
+	0x18, 0x21, 0x20, 0x01, 0x28, 0x01, 0x48, 0x1a, 0x52, 0x02, 0x6c, 0x4d, 0x88, 0x01, 0x01, 0x12,
+	0x11, 0x0a, 0x01, 0x6c, 0x18, 0x22, 0x20, 0x01, 0x28, 0x01, 0x48, 0x1b, 0x52, 0x01, 0x6c, 0x88,
+	0x01, 0x01, 0x12, 0x11, 0x0a, 0x01, 0x77, 0x18, 0x23, 0x20, 0x01, 0x28, 0x01, 0x48, 0x1c, 0x52,
+	0x01, 0x77, 0x88, 0x01, 0x01, 0x12, 0x14, 0x0a, 0x03, 0x6c, 0x5f, 0x77, 0x18, 0x24, 0x20, 0x01,
+	0x28, 0x01, 0x48, 0x1d, 0x52, 0x02, 0x6c, 0x57, 0x88, 0x01, 0x01, 0x12, 0x13, 0x0a, 0x02, 0x70,
+	0x72, 0x18, 0x25, 0x20, 0x01, 0x28, 0x01, 0x48, 0x1e, 0x52, 0x02, 0x70, 0x72, 0x88, 0x01, 0x01,
+	0x12, 0x13, 0x0a, 0x02, 0x73, 0x71, 0x18, 0x26, 0x20, 0x01, 0x28, 0x01, 0x48, 0x1f, 0x52, 0x02,
+	0x73, 0x71, 0x88, 0x01, 0x01, 0x12, 0x15, 0x0a, 0x03, 0x62, 0x72, 0x74, 0x18, 0x27, 0x20, 0x01,
+	0x28, 0x01, 0x48, 0x20, 0x52, 0x03, 0x62, 0x72, 0x74, 0x88, 0x01, 0x01, 0x12, 0x11, 0x0a, 0x01,
+	0x72, 0x18, 0x28, 0x20, 0x01, 0x28, 0x01, 0x48, 0x21, 0x52, 0x01, 0x72, 0x88, 0x01, 0x01, 0x12,
+	0x11, 0x0a, 0x01, 0x67, 0x18, 0x29, 0x20, 0x01, 0x28, 0x01, 0x48, 0x22, 0x52, 0x01, 0x67, 0x88,
+	0x01, 0x01, 0x12, 0x11, 0x0a, 0x01, 0x62, 0x18, 0x2a, 0x20, 0x01, 0x28, 0x01, 0x48, 0x23, 0x52,
+	0x01, 0x62, 0x88, 0x01, 0x01, 0x12, 0x19, 0x0a, 0x05, 0x73, 0x6f, 0x6c, 0x69, 0x64, 0x18, 0x2b,
+	0x20, 0x01, 0x28, 0x01, 0x48, 0x24, 0x52, 0x05, 0x73, 0x6f, 0x6c, 0x69, 0x64, 0x88, 0x01, 0x01,
+	0x12, 0x18, 0x0a, 0x05, 0x6d, 0x69, 0x6e, 0x5f, 0x68, 0x18, 0x2c, 0x20, 0x01, 0x28, 0x01, 0x48,
+	0x25, 0x52, 0x04, 0x6d, 0x69, 0x6e, 0x48, 0x88, 0x01, 0x01, 0x12, 0x18, 0x0a, 0x05, 0x6d, 0x69,
+	0x6e, 0x5f, 0x73, 0x18, 0x2d, 0x20, 0x01, 0x28, 0x01, 0x48, 0x26, 0x52, 0x04, 0x6d, 0x69, 0x6e,
+	0x53, 0x88, 0x01, 0x01, 0x12, 0x18, 0x0a, 0x05, 0x6d, 0x69, 0x6e, 0x5f, 0x76, 0x18, 0x2e, 0x20,
+	0x01, 0x28, 0x01, 0x48, 0x27, 0x52, 0x04, 0x6d, 0x69, 0x6e, 0x56, 0x88, 0x01, 0x01, 0x12, 0x18,
+	0x0a, 0x05, 0x6d, 0x61, 0x78, 0x5f, 0x68, 0x18, 0x2f, 0x20, 0x01, 0x28, 0x01, 0x48, 0x28, 0x52,
+	0x04, 0x6d, 0x61, 0x78, 0x48, 0x88, 0x01, 0x01, 0x12, 0x18, 0x0a, 0x05, 0x6d, 0x61, 0x78, 0x5f,
+	0x73, 0x18, 0x30, 0x20, 0x01, 0x28, 0x01, 0x48, 0x29, 0x52, 0x04, 0x6d, 0x61, 0x78, 0x53, 0x88,
+	0x01, 0x01, 0x12, 0x18, 0x0a, 0x05, 0x6d, 0x61, 0x78, 0x5f, 0x76, 0x18, 0x31, 0x20, 0x01, 0x28,
+	0x01, 0x48, 0x2a, 0x52, 0x04, 0x6d, 0x61, 0x78, 0x56, 0x88, 0x01, 0x01, 0x12, 0x1d, 0x0a, 0x07,
+	0x65, 0x6e, 0x74, 0x72, 0x6f, 0x70, 0x79, 0x18, 0x32, 0x20, 0x01, 0x28, 0x01, 0x48, 0x2b, 0x52,
+	0x07, 0x65, 0x6e, 0x74, 0x72, 0x6f, 0x70, 0x79, 0x88, 0x01, 0x01, 0x12, 0x1e, 0x0a, 0x08, 0x69,
+	0x64, 0x5f, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x18, 0x33, 0x20, 0x01, 0x28, 0x03, 0x48, 0x2c, 0x52,
+	0x07, 0x69, 0x64, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x88, 0x01, 0x01, 0x12, 0x1b, 0x0a, 0x09, 0x63,
+	0x6f, 0x6c, 0x6f, 0x72, 0x5f, 0x72, 0x68, 0x73, 0x18, 0x34, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08,
+	0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x52, 0x68, 0x73, 0x12, 0x1e, 0x0a, 0x08, 0x73, 0x71, 0x5f, 0x73,
+	0x71, 0x63, 0x72, 0x6c, 0x18, 0x35, 0x20, 0x01, 0x28, 0x01, 0x48, 0x2d, 0x52, 0x07, 0x73, 0x71,
+	0x53, 0x71, 0x63, 0x72, 0x6c, 0x88, 0x01, 0x01, 0x12, 0x15, 0x0a, 0x03, 0x68, 0x75, 0x31, 0x18,
+	0x36, 0x20, 0x01, 0x28, 0x01, 0x48, 0x2e, 0x52, 0x03, 0x68, 0x75, 0x31, 0x88, 0x01, 0x01, 0x12,
+	0x15, 0x0a, 0x03, 0x68, 0x75, 0x32, 0x18, 0x37, 0x20, 0x01, 0x28, 0x01, 0x48, 0x2f, 0x52, 0x03,
+	0x68, 0x75, 0x32, 0x88, 0x01, 0x01, 0x12, 0x15, 0x0a, 0x03, 0x68, 0x75, 0x33, 0x18, 0x38, 0x20,
+	0x01, 0x28, 0x01, 0x48, 0x30, 0x52, 0x03, 0x68, 0x75, 0x33, 0x88, 0x01, 0x01, 0x12, 0x15, 0x0a,
+	0x03, 0x68, 0x75, 0x34, 0x18, 0x39, 0x20, 0x01, 0x28, 0x01, 0x48, 0x31, 0x52, 0x03, 0x68, 0x75,
+	0x34, 0x88, 0x01, 0x01, 0x12, 0x15, 0x0a, 0x03, 0x68, 0x75, 0x35, 0x18, 0x3a, 0x20, 0x01, 0x28,
+	0x01, 0x48, 0x32, 0x52, 0x03, 0x68, 0x75, 0x35, 0x88, 0x01, 0x01, 0x12, 0x15, 0x0a, 0x03, 0x68,
+	0x75, 0x36, 0x18, 0x3b, 0x20, 0x01, 0x28, 0x01, 0x48, 0x33, 0x52, 0x03, 0x68, 0x75, 0x36, 0x88,
+	0x01, 0x01, 0x42, 0x06, 0x0a, 0x04, 0x5f, 0x6d, 0x5f, 0x68, 0x42, 0x06, 0x0a, 0x04, 0x5f, 0x6d,
+	0x5f, 0x73, 0x42, 0x06, 0x0a, 0x04, 0x5f, 0x6d, 0x5f, 0x76, 0x42, 0x06, 0x0a, 0x04, 0x5f, 0x6d,
+	0x5f, 0x72, 0x42, 0x06, 0x0a, 0x04, 0x5f, 0x6d, 0x5f, 0x67, 0x42, 0x06, 0x0a, 0x04, 0x5f, 0x6d,
+	0x5f, 0x62, 0x42, 0x08, 0x0a, 0x06, 0x5f, 0x6c, 0x5f, 0x61, 0x76, 0x67, 0x42, 0x08, 0x0a, 0x06,
+	0x5f, 0x77, 0x5f, 0x61, 0x76, 0x67, 0x42, 0x0a, 0x0a, 0x08, 0x5f, 0x62, 0x72, 0x74, 0x5f, 0x61,
+	0x76, 0x67, 0x42, 0x08, 0x0a, 0x06, 0x5f, 0x72, 0x5f, 0x61, 0x76, 0x67, 0x42, 0x08, 0x0a, 0x06,
+	0x5f, 0x67, 0x5f, 0x61, 0x76, 0x67, 0x42, 0x08, 0x0a, 0x06, 0x5f, 0x62, 0x5f, 0x61, 0x76, 0x67,
+	0x42, 0x08, 0x0a, 0x06, 0x5f, 0x68, 0x5f, 0x61, 0x76, 0x67, 0x42, 0x08, 0x0a, 0x06, 0x5f, 0x73,
+	0x5f, 0x61, 0x76, 0x67, 0x42, 0x08, 0x0a, 0x06, 0x5f, 0x76, 0x5f, 0x61, 0x76, 0x67, 0x42, 0x04,
+	0x0a, 0x02, 0x5f, 0x68, 0x42, 0x04, 0x0a, 0x02, 0x5f, 0x73, 0x42, 0x04, 0x0a, 0x02, 0x5f, 0x76,
+	0x42, 0x06, 0x0a, 0x04, 0x5f, 0x68, 0x5f, 0x6d, 0x42, 0x06, 0x0a, 0x04, 0x5f, 0x73, 0x5f, 0x6d,
+	0x42, 0x06, 0x0a, 0x04, 0x5f, 0x76, 0x5f, 0x6d, 0x42, 0x06, 0x0a, 0x04, 0x5f, 0x72, 0x5f, 0x6d,
+	0x42, 0x06, 0x0a, 0x04, 0x5f, 0x67, 0x5f, 0x6d, 0x42, 0x06, 0x0a, 0x04, 0x5f, 0x62, 0x5f, 0x6d,
+	0x42, 0x08, 0x0a, 0x06, 0x5f, 0x62, 0x72, 0x74, 0x5f, 0x6d, 0x42, 0x06, 0x0a, 0x04, 0x5f, 0x77,
+	0x5f, 0x6d, 0x42, 0x06, 0x0a, 0x04, 0x5f, 0x6c, 0x5f, 0x6d, 0x42, 0x04, 0x0a, 0x02, 0x5f, 0x6c,
+	0x42, 0x04, 0x0a, 0x02, 0x5f, 0x77, 0x42, 0x06, 0x0a, 0x04, 0x5f, 0x6c, 0x5f, 0x77, 0x42, 0x05,
+	0x0a, 0x03, 0x5f, 0x70, 0x72, 0x42, 0x05, 0x0a, 0x03, 0x5f, 0x73, 0x71, 0x42, 0x06, 0x0a, 0x04,
+	0x5f, 0x62, 0x72, 0x74, 0x42, 0x04, 0x0a, 0x02, 0x5f, 0x72, 0x42, 0x04, 0x0a, 0x02, 0x5f, 0x67,
+	0x42, 0x04, 0x0a, 0x02, 0x5f, 0x62, 0x42, 0x08, 0x0a, 0x06, 0x5f, 0x73, 0x6f, 0x6c, 0x69, 0x64,
+	0x42, 0x08, 0x0a, 0x06, 0x5f, 0x6d, 0x69, 0x6e, 0x5f, 0x68, 0x42, 0x08, 0x0a, 0x06, 0x5f, 0x6d,
+	0x69, 0x6e, 0x5f, 0x73, 0x42, 0x08, 0x0a, 0x06, 0x5f, 0x6d, 0x69, 0x6e, 0x5f, 0x76, 0x42, 0x08,
+	0x0a, 0x06, 0x5f, 0x6d, 0x61, 0x78, 0x5f, 0x68, 0x42, 0x08, 0x0a, 0x06, 0x5f, 0x6d, 0x61, 0x78,
+	0x5f, 0x73, 0x42, 0x08, 0x0a, 0x06, 0x5f, 0x6d, 0x61, 0x78, 0x5f, 0x76, 0x42, 0x0a, 0x0a, 0x08,
+	0x5f, 0x65, 0x6e, 0x74, 0x72, 0x6f, 0x70, 0x79, 0x42, 0x0b, 0x0a, 0x09, 0x5f, 0x69, 0x64, 0x5f,
+	0x69, 0x6d, 0x61, 0x67, 0x65, 0x42, 0x0b, 0x0a, 0x09, 0x5f, 0x73, 0x71, 0x5f, 0x73, 0x71, 0x63,
+	0x72, 0x6c, 0x42, 0x06, 0x0a, 0x04, 0x5f, 0x68, 0x75, 0x31, 0x42, 0x06, 0x0a, 0x04, 0x5f, 0x68,
+	0x75, 0x32, 0x42, 0x06, 0x0a, 0x04, 0x5f, 0x68, 0x75, 0x33, 0x42, 0x06, 0x0a, 0x04, 0x5f, 0x68,
+	0x75, 0x34, 0x42, 0x06, 0x0a, 0x04, 0x5f, 0x68, 0x75, 0x35, 0x42, 0x06, 0x0a, 0x04, 0x5f, 0x68,
+	0x75, 0x36, 0x22, 0xd6, 0x01, 0x0a, 0x08, 0x53, 0x70, 0x65, 0x63, 0x52, 0x75, 0x6c, 0x65, 0x12,
+	0x12, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74,
+	0x79, 0x70, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x73, 0x65, 0x76, 0x65, 0x72, 0x69, 0x74, 0x79, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x73, 0x65, 0x76, 0x65, 0x72, 0x69, 0x74, 0x79, 0x12,
+	0x14, 0x0a, 0x05, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05,
+	0x63, 0x6c, 0x61, 0x73, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x63, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x12,
+	0x14, 0x0a, 0x05, 0x66, 0x69, 0x65, 0x6c, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05,
+	0x66, 0x69, 0x65, 0x6c, 0x64, 0x12, 0x15, 0x0a, 0x03, 0x6d, 0x69, 0x6e, 0x18, 0x06, 0x20, 0x01,
+	0x28, 0x01, 0x48, 0x00, 0x52, 0x03, 0x6d, 0x69, 0x6e, 0x88, 0x01, 0x01, 0x12, 0x15, 0x0a, 0x03,
+	0x6d, 0x61, 0x78, 0x18, 0x07, 0x20, 0x01, 0x28, 0x01, 0x48, 0x01, 0x52, 0x03, 0x6d, 0x61, 0x78,
+	0x88, 0x01, 0x01, 0x12, 0x16, 0x0a, 0x06, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x73, 0x18, 0x08, 0x20,
+	0x03, 0x28, 0x09, 0x52, 0x06, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x73, 0x42, 0x06, 0x0a, 0x04, 0x5f,
+	0x6d, 0x69, 0x6e, 0x42, 0x06, 0x0a, 0x04, 0x5f, 0x6d, 0x61, 0x78, 0x22, 0xa6, 0x01, 0x0a, 0x0d,
+	0x53, 0x70, 0x65, 0x63, 0x56, 0x69, 0x6f, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x2f, 0x0a,
+	0x04, 0x72, 0x75, 0x6c, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x63, 0x73,
+	0x6f, 0x72, 0x74, 0x2e, 0x61, 0x6e, 0x61, 0x6c, 0x79, 0x73, 0x69, 0x73, 0x2e, 0x76, 0x31, 0x2e,
+	0x53, 0x70, 0x65, 0x63, 0x52, 0x75, 0x6c, 0x65, 0x52, 0x04, 0x72, 0x75, 0x6c, 0x65, 0x12, 0x1a,
+	0x0a, 0x08, 0x73, 0x65, 0x76, 0x65, 0x72, 0x69, 0x74, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x08, 0x73, 0x65, 0x76, 0x65, 0x72, 0x69, 0x74, 0x79, 0x12, 0x2e, 0x0a, 0x06, 0x61, 0x63,
+	0x74, 0x75, 0x61, 0x6c, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x67, 0x6f, 0x6f,
+	0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x56, 0x61, 0x6c,
+	0x75, 0x65, 0x52, 0x06, 0x61, 0x63, 0x74, 0x75, 0x61, 0x6c, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65,
+	0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6d, 0x65, 0x73,
+	0x73, 0x61, 0x67, 0x65, 0x22, 0xc1, 0x01, 0x0a, 0x0b, 0x53, 0x70, 0x65, 0x63, 0x56, 0x65, 0x72,
+	0x64, 0x69, 0x63, 0x74, 0x12, 0x17, 0x0a, 0x07, 0x73, 0x70, 0x65, 0x63, 0x5f, 0x69, 0x64, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06, 0x73, 0x70, 0x65, 0x63, 0x49, 0x64, 0x12, 0x18, 0x0a,
+	0x07, 0x76, 0x65, 0x72, 0x64, 0x69, 0x63, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07,
+	0x76, 0x65, 0x72, 0x64, 0x69, 0x63, 0x74, 0x12, 0x40, 0x0a, 0x0a, 0x76, 0x69, 0x6f, 0x6c, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x63, 0x73,
+	0x6f, 0x72, 0x74, 0x2e, 0x61, 0x6e, 0x61, 0x6c, 0x79, 0x73, 0x69, 0x73, 0x2e, 0x76, 0x31, 0x2e,
+	0x53, 0x70, 0x65, 0x63, 0x56, 0x69, 0x6f, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x0a, 0x76,
+	0x69, 0x6f, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x3d, 0x0a, 0x0c, 0x65, 0x76, 0x61,
+	0x6c, 0x75, 0x61, 0x74, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
+	0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0b, 0x65, 0x76, 0x61,
+	0x6c, 0x75, 0x61, 0x74, 0x65, 0x64, 0x41, 0x74, 0x2a, 0x4d, 0x0a, 0x06, 0x49, 0x64, 0x54, 0x79,
+	0x70, 0x65, 0x12, 0x17, 0x0a, 0x13, 0x49, 0x44, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x55, 0x4e,
+	0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x14, 0x0a, 0x10, 0x49,
+	0x44, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x45, 0x58, 0x54, 0x45, 0x52, 0x4e, 0x41, 0x4c, 0x10,
+	0x01, 0x12, 0x14, 0x0a, 0x10, 0x49, 0x44, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x49, 0x4e, 0x54,
+	0x45, 0x52, 0x4e, 0x41, 0x4c, 0x10, 0x02, 0x32, 0xa8, 0x02, 0x0a, 0x0f, 0x41, 0x6e, 0x61, 0x6c,
+	0x79, 0x73, 0x69, 0x73, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x5f, 0x0a, 0x0c, 0x4c,
+	0x69, 0x73, 0x74, 0x41, 0x6e, 0x61, 0x6c, 0x79, 0x73, 0x65, 0x73, 0x12, 0x26, 0x2e, 0x63, 0x73,
+	0x6f, 0x72, 0x74, 0x2e, 0x61, 0x6e, 0x61, 0x6c, 0x79, 0x73, 0x69, 0x73, 0x2e, 0x76, 0x31, 0x2e,
+	0x4c, 0x69, 0x73, 0x74, 0x41, 0x6e, 0x61, 0x6c, 0x79, 0x73, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x27, 0x2e, 0x63, 0x73, 0x6f, 0x72, 0x74, 0x2e, 0x61, 0x6e, 0x61, 0x6c,
+	0x79, 0x73, 0x69, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x41, 0x6e, 0x61, 0x6c,
+	0x79, 0x73, 0x65, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x51, 0x0a, 0x0b,
+	0x47, 0x65, 0x74, 0x41, 0x6e, 0x61, 0x6c, 0x79, 0x73, 0x69, 0x73, 0x12, 0x25, 0x2e, 0x63, 0x73,
+	0x6f, 0x72, 0x74, 0x2e, 0x61, 0x6e, 0x61, 0x6c, 0x79, 0x73, 0x69, 0x73, 0x2e, 0x76, 0x31, 0x2e,
+	0x47, 0x65, 0x74, 0x41, 0x6e, 0x61, 0x6c, 0x79, 0x73, 0x69, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x1b, 0x2e, 0x63, 0x73, 0x6f, 0x72, 0x74, 0x2e, 0x61, 0x6e, 0x61, 0x6c, 0x79,
+	0x73, 0x69, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x41, 0x6e, 0x61, 0x6c, 0x79, 0x73, 0x69, 0x73, 0x12,
+	0x61, 0x0a, 0x13, 0x4c, 0x69, 0x73, 0x74, 0x41, 0x6e, 0x61, 0x6c, 0x79, 0x73, 0x69, 0x73, 0x4f,
+	0x62, 0x6a, 0x65, 0x63, 0x74, 0x73, 0x12, 0x2d, 0x2e, 0x63, 0x73, 0x6f, 0x72, 0x74, 0x2e, 0x61,
+	0x6e, 0x61, 0x6c, 0x79, 0x73, 0x69, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x41,
+	0x6e, 0x61, 0x6c, 0x79, 0x73, 0x69, 0x73, 0x4f, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x73, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x19, 0x2e, 0x63, 0x73, 0x6f, 0x72, 0x74, 0x2e, 0x61, 0x6e,
+	0x61, 0x6c, 0x79, 0x73, 0x69, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x4f, 0x62, 0x6a, 0x65, 0x63, 0x74,
+	0x30, 0x01, 0x32, 0x63, 0x0a, 0x0e, 0x4f, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x73, 0x53, 0x65, 0x72,
+	0x76, 0x69, 0x63, 0x65, 0x12, 0x51, 0x0a, 0x0b, 0x4c, 0x69, 0x73, 0x74, 0x4f, 0x62, 0x6a, 0x65,
+	0x63, 0x74, 0x73, 0x12, 0x25, 0x2e, 0x63, 0x73, 0x6f, 0x72, 0x74, 0x2e, 0x61, 0x6e, 0x61, 0x6c,
+	0x79, 0x73, 0x69, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x4f, 0x62, 0x6a, 0x65,
+	0x63, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x19, 0x2e, 0x63, 0x73, 0x6f,
+	0x72, 0x74, 0x2e, 0x61, 0x6e, 0x61, 0x6c, 0x79, 0x73, 0x69, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x4f,
+	0x62, 0x6a, 0x65, 0x63, 0x74, 0x30, 0x01, 0x42, 0x36, 0x5a, 0x34, 0x63, 0x73, 0x6f, 0x72, 0x74,
+	0x2e, 0x72, 0x75, 0x2f, 0x61, 0x6e, 0x61, 0x6c, 0x79, 0x73, 0x69, 0x73, 0x2d, 0x73, 0x65, 0x72,
+	0x76, 0x69, 0x63, 0x65, 0x2f, 0x61, 0x70, 0x69, 0x2f, 0x61, 0x6e, 0x61, 0x6c, 0x79, 0x73, 0x69,
+	0x73, 0x2f, 0x76, 0x31, 0x3b, 0x61, 0x6e, 0x61, 0x6c, 0x79, 0x73, 0x69, 0x73, 0x76, 0x31, 0x62,
+	0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_analysis_v1_analysis_proto_rawDescOnce sync.Once
+	file_analysis_v1_analysis_proto_rawDescData = file_analysis_v1_analysis_proto_rawDesc
+)
+
+func file_analysis_v1_analysis_proto_rawDescGZIP() []byte {
+	file_analysis_v1_analysis_proto_rawDescOnce.Do(func() {
+		file_analysis_v1_analysis_proto_rawDescData = protoimpl.X.CompressGZIP(file_analysis_v1_analysis_proto_rawDescData)
+	})
+	return file_analysis_v1_analysis_proto_rawDescData
+}
+
+var file_analysis_v1_analysis_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_analysis_v1_analysis_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
+var file_analysis_v1_analysis_proto_goTypes = []interface{}{
+	(IdType)(0),                        // 0: csort.analysis.v1.IdType
+	(*AnalysisRef)(nil),                // 1: csort.analysis.v1.AnalysisRef
+	(*ListAnalysesRequest)(nil),        // 2: csort.analysis.v1.ListAnalysesRequest
+	(*ListAnalysesResponse)(nil),       // 3: csort.analysis.v1.ListAnalysesResponse
+	(*GetAnalysisRequest)(nil),         // 4: csort.analysis.v1.GetAnalysisRequest
+	(*ListAnalysisObjectsRequest)(nil), // 5: csort.analysis.v1.ListAnalysisObjectsRequest
+	(*ListObjectsRequest)(nil),         // 6: csort.analysis.v1.ListObjectsRequest
+	(*Stats)(nil),                      // 7: csort.analysis.v1.Stats
+	(*Analysis)(nil),                   // 8: csort.analysis.v1.Analysis
+	(*Object)(nil),                     // 9: csort.analysis.v1.Object
+	(*SpecRule)(nil),                   // 10: csort.analysis.v1.SpecRule
+	(*SpecViolation)(nil),              // 11: csort.analysis.v1.SpecViolation
+	(*SpecVerdict)(nil),                // 12: csort.analysis.v1.SpecVerdict
+	(*timestamppb.Timestamp)(nil),      // 13: google.protobuf.Timestamp
+	(*structpb.Value)(nil),             // 14: google.protobuf.Value
+}
+var file_analysis_v1_analysis_proto_depIdxs = []int32{
+	0,  // 0: csort.analysis.v1.AnalysisRef.id_type:type_name -> csort.analysis.v1.IdType
+	8,  // 1: csort.analysis.v1.ListAnalysesResponse.analyses:type_name -> csort.analysis.v1.Analysis
+	1,  // 2: csort.analysis.v1.GetAnalysisRequest.ref:type_name -> csort.analysis.v1.AnalysisRef
+	1,  // 3: csort.analysis.v1.ListAnalysisObjectsRequest.ref:type_name -> csort.analysis.v1.AnalysisRef
+	13, // 4: csort.analysis.v1.Analysis.date_time:type_name -> google.protobuf.Timestamp
+	7,  // 5: csort.analysis.v1.Analysis.r:type_name -> csort.analysis.v1.Stats
+	7,  // 6: csort.analysis.v1.Analysis.g:type_name -> csort.analysis.v1.Stats
+	7,  // 7: csort.analysis.v1.Analysis.b:type_name -> csort.analysis.v1.Stats
+	7,  // 8: csort.analysis.v1.Analysis.h:type_name -> csort.analysis.v1.Stats
+	7,  // 9: csort.analysis.v1.Analysis.s:type_name -> csort.analysis.v1.Stats
+	7,  // 10: csort.analysis.v1.Analysis.v:type_name -> csort.analysis.v1.Stats
+	7,  // 11: csort.analysis.v1.Analysis.lab_l:type_name -> csort.analysis.v1.Stats
+	7,  // 12: csort.analysis.v1.Analysis.lab_a:type_name -> csort.analysis.v1.Stats
+	7,  // 13: csort.analysis.v1.Analysis.lab_b:type_name -> csort.analysis.v1.Stats
+	7,  // 14: csort.analysis.v1.Analysis.w:type_name -> csort.analysis.v1.Stats
+	7,  // 15: csort.analysis.v1.Analysis.l:type_name -> csort.analysis.v1.Stats
+	7,  // 16: csort.analysis.v1.Analysis.t:type_name -> csort.analysis.v1.Stats
+	9,  // 17: csort.analysis.v1.Analysis.objects:type_name -> csort.analysis.v1.Object
+	12, // 18: csort.analysis.v1.Analysis.verdict:type_name -> csort.analysis.v1.SpecVerdict
+	10, // 19: csort.analysis.v1.SpecViolation.rule:type_name -> csort.analysis.v1.SpecRule
+	14, // 20: csort.analysis.v1.SpecViolation.actual:type_name -> google.protobuf.Value
+	11, // 21: csort.analysis.v1.SpecVerdict.violations:type_name -> csort.analysis.v1.SpecViolation
+	13, // 22: csort.analysis.v1.SpecVerdict.evaluated_at:type_name -> google.protobuf.Timestamp
+	2,  // 23: csort.analysis.v1.AnalysisService.ListAnalyses:input_type -> csort.analysis.v1.ListAnalysesRequest
+	4,  // 24: csort.analysis.v1.AnalysisService.GetAnalysis:input_type -> csort.analysis.v1.GetAnalysisRequest
+	5,  // 25: csort.analysis.v1.AnalysisService.ListAnalysisObjects:input_type -> csort.analysis.v1.ListAnalysisObjectsRequest
+	6,  // 26: csort.analysis.v1.ObjectsService.ListObjects:input_type -> csort.analysis.v1.ListObjectsRequest
+	3,  // 27: csort.analysis.v1.AnalysisService.ListAnalyses:output_type -> csort.analysis.v1.ListAnalysesResponse
+	8,  // 28: csort.analysis.v1.AnalysisService.GetAnalysis:output_type -> csort.analysis.v1.Analysis
+	9,  // 29: csort.analysis.v1.AnalysisService.ListAnalysisObjects:output_type -> csort.analysis.v1.Object
+	9,  // 30: csort.analysis.v1.ObjectsService.ListObjects:output_type -> csort.analysis.v1.Object
+	27, // [27:31] is the sub-list for method output_type
+	23, // [23:27] is the sub-list for method input_type
+	23, // [23:23] is the sub-list for extension type_name
+	23, // [23:23] is the sub-list for extension extendee
+	0,  // [0:23] is the sub-list for field type_name
+}
+
+func init() { file_analysis_v1_analysis_proto_init() }
+func file_analysis_v1_analysis_proto_init() {
+	if File_analysis_v1_analysis_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_analysis_v1_analysis_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AnalysisRef); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_analysis_v1_analysis_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListAnalysesRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_analysis_v1_analysis_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListAnalysesResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_analysis_v1_analysis_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetAnalysisRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_analysis_v1_analysis_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListAnalysisObjectsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_analysis_v1_analysis_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListObjectsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_analysis_v1_analysis_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Stats); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_analysis_v1_analysis_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Analysis); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_analysis_v1_analysis_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Object); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_analysis_v1_analysis_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SpecRule); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_analysis_v1_analysis_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SpecViolation); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_analysis_v1_analysis_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SpecVerdict); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_analysis_v1_analysis_proto_msgTypes[7].OneofWrappers = []interface{}{}
+	file_analysis_v1_analysis_proto_msgTypes[8].OneofWrappers = []interface{}{}
+	file_analysis_v1_analysis_proto_msgTypes[9].OneofWrappers = []interface{}{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_analysis_v1_analysis_proto_rawDesc,
+			NumEnums:      1,
+			NumMessages:   12,
+			NumExtensions: 0,
+			NumServices:   2,
+		},
+		GoTypes:           file_analysis_v1_analysis_proto_goTypes,
+		DependencyIndexes: file_analysis_v1_analysis_proto_depIdxs,
+		EnumInfos:         file_analysis_v1_analysis_proto_enumTypes,
+		MessageInfos:      file_analysis_v1_analysis_proto_msgTypes,
+	}.Build()
+	File_analysis_v1_analysis_proto = out.File
+	file_analysis_v1_analysis_proto_rawDesc = nil
+	file_analysis_v1_analysis_proto_goTypes = nil
+	file_analysis_v1_analysis_proto_depIdxs = nil
+}
@@ -0,0 +1,329 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: analysis/v1/analysis.proto
+
+// The analysis API over gRPC, for clients that would rather use typed RPC than
+// the REST endpoints. Messages mirror the JSON models field for field; missing
+// measurements are unset optional fields rather than 0.
+//
+// Calls made on behalf of a user carry their Telegram id in the telegram-user-id
+// metadata key, like the Telegram-User-ID header of the REST API. Failed calls
+// have a google.rpc.ErrorInfo detail whose reason is the REST error_code, e.g.
+// analysis_not_found.
+
+package analysisv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	AnalysisService_ListAnalyses_FullMethodName        = "/csort.analysis.v1.AnalysisService/ListAnalyses"
+	AnalysisService_GetAnalysis_FullMethodName         = "/csort.analysis.v1.AnalysisService/GetAnalysis"
+	AnalysisService_ListAnalysisObjects_FullMethodName = "/csort.analysis.v1.AnalysisService/ListAnalysisObjects"
+)
+
+// AnalysisServiceClient is the client API for AnalysisService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type AnalysisServiceClient interface {
+	// ListAnalyses returns a page of the calling user's analyses. Requires
+	// telegram-user-id.
+	ListAnalyses(ctx context.Context, in *ListAnalysesRequest, opts ...grpc.CallOption) (*ListAnalysesResponse, error)
+	// GetAnalysis returns an analysis with its objects.
+	GetAnalysis(ctx context.Context, in *GetAnalysisRequest, opts ...grpc.CallOption) (*Analysis, error)
+	// ListAnalysisObjects streams the objects of an analysis in id order, as they
+	// are read from the database.
+	ListAnalysisObjects(ctx context.Context, in *ListAnalysisObjectsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Object], error)
+}
+
+type analysisServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAnalysisServiceClient(cc grpc.ClientConnInterface) AnalysisServiceClient {
+	return &analysisServiceClient{cc}
+}
+
+func (c *analysisServiceClient) ListAnalyses(ctx context.Context, in *ListAnalysesRequest, opts ...grpc.CallOption) (*ListAnalysesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListAnalysesResponse)
+	err := c.cc.Invoke(ctx, AnalysisService_ListAnalyses_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *analysisServiceClient) GetAnalysis(ctx context.Context, in *GetAnalysisRequest, opts ...grpc.CallOption) (*Analysis, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Analysis)
+	err := c.cc.Invoke(ctx, AnalysisService_GetAnalysis_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *analysisServiceClient) ListAnalysisObjects(ctx context.Context, in *ListAnalysisObjectsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Object], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &AnalysisService_ServiceDesc.Streams[0], AnalysisService_ListAnalysisObjects_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ListAnalysisObjectsRequest, Object]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AnalysisService_ListAnalysisObjectsClient = grpc.ServerStreamingClient[Object]
+
+// AnalysisServiceServer is the server API for AnalysisService service.
+// All implementations must embed UnimplementedAnalysisServiceServer
+// for forward compatibility.
+type AnalysisServiceServer interface {
+	// ListAnalyses returns a page of the calling user's analyses. Requires
+	// telegram-user-id.
+	ListAnalyses(context.Context, *ListAnalysesRequest) (*ListAnalysesResponse, error)
+	// GetAnalysis returns an analysis with its objects.
+	GetAnalysis(context.Context, *GetAnalysisRequest) (*Analysis, error)
+	// ListAnalysisObjects streams the objects of an analysis in id order, as they
+	// are read from the database.
+	ListAnalysisObjects(*ListAnalysisObjectsRequest, grpc.ServerStreamingServer[Object]) error
+	mustEmbedUnimplementedAnalysisServiceServer()
+}
+
+// UnimplementedAnalysisServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedAnalysisServiceServer struct{}
+
+func (UnimplementedAnalysisServiceServer) ListAnalyses(context.Context, *ListAnalysesRequest) (*ListAnalysesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListAnalyses not implemented")
+}
+func (UnimplementedAnalysisServiceServer) GetAnalysis(context.Context, *GetAnalysisRequest) (*Analysis, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAnalysis not implemented")
+}
+func (UnimplementedAnalysisServiceServer) ListAnalysisObjects(*ListAnalysisObjectsRequest, grpc.ServerStreamingServer[Object]) error {
+	return status.Errorf(codes.Unimplemented, "method ListAnalysisObjects not implemented")
+}
+func (UnimplementedAnalysisServiceServer) mustEmbedUnimplementedAnalysisServiceServer() {}
+func (UnimplementedAnalysisServiceServer) testEmbeddedByValue()                         {}
+
+// UnsafeAnalysisServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AnalysisServiceServer will
+// result in compilation errors.
+type UnsafeAnalysisServiceServer interface {
+	mustEmbedUnimplementedAnalysisServiceServer()
+}
+
+func RegisterAnalysisServiceServer(s grpc.ServiceRegistrar, srv AnalysisServiceServer) {
+	// If the following call pancis, it indicates UnimplementedAnalysisServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&AnalysisService_ServiceDesc, srv)
+}
+
+func _AnalysisService_ListAnalyses_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAnalysesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AnalysisServiceServer).ListAnalyses(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AnalysisService_ListAnalyses_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AnalysisServiceServer).ListAnalyses(ctx, req.(*ListAnalysesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AnalysisService_GetAnalysis_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetAnalysisRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AnalysisServiceServer).GetAnalysis(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AnalysisService_GetAnalysis_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AnalysisServiceServer).GetAnalysis(ctx, req.(*GetAnalysisRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AnalysisService_ListAnalysisObjects_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListAnalysisObjectsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AnalysisServiceServer).ListAnalysisObjects(m, &grpc.GenericServerStream[ListAnalysisObjectsRequest, Object]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AnalysisService_ListAnalysisObjectsServer = grpc.ServerStreamingServer[Object]
+
+// AnalysisService_ServiceDesc is the grpc.ServiceDesc for AnalysisService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var AnalysisService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "csort.analysis.v1.AnalysisService",
+	HandlerType: (*AnalysisServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListAnalyses",
+			Handler:    _AnalysisService_ListAnalyses_Handler,
+		},
+		{
+			MethodName: "GetAnalysis",
+			Handler:    _AnalysisService_GetAnalysis_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ListAnalysisObjects",
+			Handler:       _AnalysisService_ListAnalysisObjects_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "analysis/v1/analysis.proto",
+}
+
+const (
+	ObjectsService_ListObjects_FullMethodName = "/csort.analysis.v1.ObjectsService/ListObjects"
+)
+
+// ObjectsServiceClient is the client API for ObjectsService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ObjectsServiceClient interface {
+	// ListObjects streams the measurements of objects by id. Only the id and
+	// measurement fields of the objects are set.
+	ListObjects(ctx context.Context, in *ListObjectsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Object], error)
+}
+
+type objectsServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewObjectsServiceClient(cc grpc.ClientConnInterface) ObjectsServiceClient {
+	return &objectsServiceClient{cc}
+}
+
+func (c *objectsServiceClient) ListObjects(ctx context.Context, in *ListObjectsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Object], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ObjectsService_ServiceDesc.Streams[0], ObjectsService_ListObjects_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ListObjectsRequest, Object]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ObjectsService_ListObjectsClient = grpc.ServerStreamingClient[Object]
+
+// ObjectsServiceServer is the server API for ObjectsService service.
+// All implementations must embed UnimplementedObjectsServiceServer
+// for forward compatibility.
+type ObjectsServiceServer interface {
+	// ListObjects streams the measurements of objects by id. Only the id and
+	// measurement fields of the objects are set.
+	ListObjects(*ListObjectsRequest, grpc.ServerStreamingServer[Object]) error
+	mustEmbedUnimplementedObjectsServiceServer()
+}
+
+// UnimplementedObjectsServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedObjectsServiceServer struct{}
+
+func (UnimplementedObjectsServiceServer) ListObjects(*ListObjectsRequest, grpc.ServerStreamingServer[Object]) error {
+	return status.Errorf(codes.Unimplemented, "method ListObjects not implemented")
+}
+func (UnimplementedObjectsServiceServer) mustEmbedUnimplementedObjectsServiceServer() {}
+func (UnimplementedObjectsServiceServer) testEmbeddedByValue()                        {}
+
+// UnsafeObjectsServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ObjectsServiceServer will
+// result in compilation errors.
+type UnsafeObjectsServiceServer interface {
+	mustEmbedUnimplementedObjectsServiceServer()
+}
+
+func RegisterObjectsServiceServer(s grpc.ServiceRegistrar, srv ObjectsServiceServer) {
+	// If the following call pancis, it indicates UnimplementedObjectsServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ObjectsService_ServiceDesc, srv)
+}
+
+func _ObjectsService_ListObjects_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListObjectsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ObjectsServiceServer).ListObjects(m, &grpc.GenericServerStream[ListObjectsRequest, Object]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ObjectsService_ListObjectsServer = grpc.ServerStreamingServer[Object]
+
+// ObjectsService_ServiceDesc is the grpc.ServiceDesc for ObjectsService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ObjectsService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "csort.analysis.v1.ObjectsService",
+	HandlerType: (*ObjectsServiceServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ListObjects",
+			Handler:       _ObjectsService_ListObjects_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "analysis/v1/analysis.proto",
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+
+	"csort.ru/analysis-service/databases/migrations"
+	"csort.ru/analysis-service/internal/config"
+	"csort.ru/analysis-service/internal/database"
+	"csort.ru/analysis-service/internal/logger"
+	"csort.ru/analysis-service/internal/models"
+	"csort.ru/analysis-service/internal/services"
+)
+
+var commands = map[string]func(args []string) error{
+	"backfill-stats":     runBackfillStats,
+	"migrate":            runMigrate,
+	"normalize-products": runNormalizeProducts,
+}
+
+func runCommand(name string, args []string) error {
+	command, ok := commands[name]
+	if !ok {
+		return fmt.Errorf("unknown command %q", name)
+	}
+	return command(args)
+}
+
+func openDatabase() (*database.DB, error) {
+	cfg := config.LoadDBConfig()
+	return database.New(&database.DatabaseConfig{
+		Host:            cfg.Host,
+		Port:            cfg.Port,
+		User:            cfg.User,
+		Password:        cfg.Password,
+		Name:            cfg.Name,
+		MaxConns:        cfg.MaxConns,
+		MinConns:        cfg.MinConns,
+		MaxConnLifetime: cfg.MaxConnLifetime,
+		MaxConnIdleTime: cfg.MaxConnIdleTime,
+	})
+}
+
+// runBackfillStats recomputes analysis Stats from objects and writes the missing or
+// corrupt ones back, e.g. `app backfill-stats -mismatched -batch 200`.
+func runBackfillStats(args []string) error {
+	flags := flag.NewFlagSet("backfill-stats", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "only report inconsistent analyses, don't write anything")
+	mismatched := flags.Bool("mismatched", false, "also overwrite stored stats that disagree with the objects")
+	tolerance := flags.Float64("tolerance", services.DefaultStatsTolerance, "relative tolerance when comparing stats")
+	batchSize := flags.Int("batch", 100, "number of analyses loaded per batch")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	mode := services.StatsRepairBroken
+	if *mismatched {
+		mode = services.StatsRepairAll
+	}
+	if *dryRun {
+		mode = services.StatsRepairNone
+	}
+
+	db, err := openDatabase()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	log := logger.GetLogger("cmd.backfill_stats")
+	queries := database.NewQueries(db.Pool)
+	service := services.NewConsistencyService(queries, services.NewSpecsService(queries, services.NewProductsService(queries)), *tolerance)
+
+	summary, err := service.Backfill(context.Background(), mode, int32(*batchSize), func(report models.StatsConsistencyReport) {
+		for _, channel := range report.Channels {
+			if channel.Status == models.StatsOK || channel.Status == models.StatsNoData {
+				continue
+			}
+			log.Info().
+				Int32("id", report.ID).
+				Str("idAnalysis", report.IDAnalysis).
+				Str("channel", channel.Channel).
+				Str("status", channel.Status).
+				Bool("repaired", channel.Repaired).
+				Msg("Inconsistent stats")
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Info().
+		Int("checked", summary.Checked).
+		Int("inconsistent", summary.Inconsistent).
+		Int("repaired", summary.Repaired).
+		Msg("Stats backfill finished")
+	return nil
+}
+
+// runNormalizeProducts maps the free-text product values of analyses, lots and specs
+// onto the product catalog, e.g. `app normalize-products -dry-run`. Migration 0009
+// already maps the values known when it is applied; this is for re-runs once
+// aliases have been added. Values that match no product are logged so they can be
+// added as aliases.
+func runNormalizeProducts(args []string) error {
+	flags := flag.NewFlagSet("normalize-products", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "only report how values would be mapped, don't write anything")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	db, err := openDatabase()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	log := logger.GetLogger("cmd.normalize_products")
+	service := services.NewProductsService(database.NewQueries(db.Pool))
+
+	summary, err := service.Normalize(context.Background(), *dryRun)
+	if err != nil {
+		return err
+	}
+
+	for _, mapping := range summary.Mappings {
+		log.Info().
+			Str("value", mapping.Value).
+			Str("code", mapping.Code).
+			Int64("uses", mapping.Uses).
+			Int64("analyses", mapping.Analyses).
+			Int64("lots", mapping.Lots).
+			Int64("specs", mapping.Specs).
+			Msg("Product mapped")
+	}
+	for _, mapping := range summary.Unknown {
+		log.Warn().Str("value", mapping.Value).Int64("uses", mapping.Uses).Msg("Unknown product")
+	}
+
+	log.Info().
+		Int("values", summary.Values).
+		Int("mapped", summary.Mapped).
+		Int("unknown", len(summary.Unknown)).
+		Bool("dryRun", *dryRun).
+		Msg("Product normalization finished")
+	return nil
+}
+
+// runMigrate applies or rolls back the schema migrations:
+//
+//	app migrate up               apply every pending migration
+//	app migrate down [-steps n]  roll back the last n migrations (default 1)
+//	app migrate to <version>     migrate up or down to the given version, 0 for none;
+//	                             the pipeline's analysis and objects tables are kept
+//	app migrate status           list the migrations and whether they are applied
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: migrate up|down|to <version>|status")
+	}
+	action := args[0]
+
+	flags := flag.NewFlagSet("migrate "+action, flag.ContinueOnError)
+	steps := flags.Int("steps", 1, "number of migrations rolled back by down")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	db, err := openDatabase()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	log := logger.GetLogger("cmd.migrate")
+	migrator, err := database.NewMigrator(db.Pool, migrations.FS)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	var done []database.Migration
+	switch action {
+	case "up":
+		done, err = migrator.Up(ctx)
+	case "down":
+		done, err = migrator.Down(ctx, *steps)
+	case "to":
+		version, parseErr := strconv.ParseInt(flags.Arg(0), 10, 64)
+		if parseErr != nil {
+			return fmt.Errorf("usage: migrate to <version>")
+		}
+		done, err = migrator.To(ctx, version)
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			event := log.Info()
+			if status.Unknown {
+				event = log.Warn()
+			}
+			if status.AppliedAt != nil {
+				event = event.Time("appliedAt", *status.AppliedAt)
+			}
+			event.
+				Int64("version", status.Version).
+				Str("name", status.Name).
+				Bool("applied", status.Applied).
+				Bool("unknown", status.Unknown).
+				Msg("Migration")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown migrate action %q", action)
+	}
+	if err != nil {
+		return err
+	}
+
+	log.Info().Int("migrations", len(done)).Msg("Migrations finished")
+	return nil
+}
@@ -15,6 +15,14 @@ func init() {
 }
 
 func main() {
+	// Run a maintenance subcommand instead of the server if one was given
+	if len(os.Args) > 1 {
+		if err := runCommand(os.Args[1], os.Args[2:]); err != nil {
+			logger.Logger.Fatal().Err(err).Str("command", os.Args[1]).Msg("Command failed")
+		}
+		return
+	}
+
 	// Load configuration
 	cfg := config.LoadConfig()
 	log := logger.Logger
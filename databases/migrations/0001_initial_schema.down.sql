@@ -0,0 +1,5 @@
+-- The analysis and objects tables are written by the pipeline and may predate the
+-- migrations, so rolling back only drops what this migration added to them
+DROP INDEX IF EXISTS objects_id_analysis_idx;
+DROP INDEX IF EXISTS analysis_id_analysis_idx;
+DROP INDEX IF EXISTS analysis_id_user_date_time_idx;
@@ -1,4 +1,7 @@
-CREATE TABLE analysis (
+-- The schema the service started with. Everything is created only if missing, so
+-- databases set up from the old schema.sql can adopt the migrations as they are
+
+CREATE TABLE IF NOT EXISTS analysis (
     id SERIAL PRIMARY KEY,
     date_time TIMESTAMP,
     product VARCHAR,
@@ -26,7 +29,7 @@ CREATE TABLE analysis (
     id_analysis VARCHAR
 );
 
-CREATE TABLE objects (
+CREATE TABLE IF NOT EXISTS objects (
     id SERIAL PRIMARY KEY,
     id_analysis BIGINT REFERENCES analysis(id),
     file TEXT NULL,
@@ -85,4 +88,9 @@ CREATE TABLE objects (
     hu5 DOUBLE PRECISION NULL,
     hu6 DOUBLE PRECISION NULL,
     class VARCHAR NULL
-); 
\ No newline at end of file
+);
+
+-- Lookups by user (analysis lists), by pipeline id and of the objects of an analysis
+CREATE INDEX IF NOT EXISTS analysis_id_user_date_time_idx ON analysis (id_user, date_time);
+CREATE INDEX IF NOT EXISTS analysis_id_analysis_idx ON analysis (id_analysis);
+CREATE INDEX IF NOT EXISTS objects_id_analysis_idx ON objects (id_analysis);
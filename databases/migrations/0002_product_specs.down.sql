@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS analysis_verdicts;
+DROP TABLE IF EXISTS product_specs;
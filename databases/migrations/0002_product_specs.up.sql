@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS product_specs (
+    id SERIAL PRIMARY KEY,
+    product VARCHAR NOT NULL UNIQUE,
+    name VARCHAR NOT NULL,
+    rules JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS analysis_verdicts (
+    analysis_id INTEGER PRIMARY KEY REFERENCES analysis(id),
+    spec_id INTEGER NOT NULL REFERENCES product_specs(id) ON DELETE CASCADE,
+    verdict VARCHAR NOT NULL,
+    violations JSONB NOT NULL,
+    evaluated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
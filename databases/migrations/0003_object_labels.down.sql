@@ -0,0 +1,2 @@
+DROP VIEW IF EXISTS object_effective_labels;
+DROP TABLE IF EXISTS object_labels;
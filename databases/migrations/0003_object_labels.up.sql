@@ -0,0 +1,17 @@
+-- Manual corrections of objects.class; the pipeline's value is never modified
+CREATE TABLE IF NOT EXISTS object_labels (
+    id SERIAL PRIMARY KEY,
+    object_id INTEGER NOT NULL REFERENCES objects(id) ON DELETE CASCADE,
+    class VARCHAR NOT NULL,
+    id_user VARCHAR NOT NULL,
+    reason VARCHAR NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS object_labels_object_id_idx ON object_labels (object_id, id DESC);
+
+-- The latest label of every relabeled object
+CREATE OR REPLACE VIEW object_effective_labels AS
+SELECT DISTINCT ON (object_id) object_id, class, id_user, reason, created_at
+FROM object_labels
+ORDER BY object_id, id DESC;
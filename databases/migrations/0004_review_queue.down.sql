@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS review_labels;
+DROP TABLE IF EXISTS review_queue;
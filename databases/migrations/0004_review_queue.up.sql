@@ -0,0 +1,24 @@
+-- Objects selected for manual review, e.g. anomalous or of rare classes
+CREATE TABLE IF NOT EXISTS review_queue (
+    id SERIAL PRIMARY KEY,
+    object_id INTEGER NOT NULL UNIQUE REFERENCES objects(id) ON DELETE CASCADE,
+    strategy VARCHAR NOT NULL,
+    score DOUBLE PRECISION NOT NULL DEFAULT 0,
+    required_reviews INTEGER NOT NULL DEFAULT 1,
+    status VARCHAR NOT NULL DEFAULT 'pending',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMPTZ NULL
+);
+
+CREATE INDEX IF NOT EXISTS review_queue_status_idx ON review_queue (status, id);
+
+-- One answer per reviewer and item; class is NULL when the reviewer skipped it
+CREATE TABLE IF NOT EXISTS review_labels (
+    id SERIAL PRIMARY KEY,
+    queue_id INTEGER NOT NULL REFERENCES review_queue(id) ON DELETE CASCADE,
+    id_user VARCHAR NOT NULL,
+    class VARCHAR NULL,
+    skipped BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (queue_id, id_user)
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS analysis_tags;
+ALTER TABLE analysis DROP COLUMN IF EXISTS version;
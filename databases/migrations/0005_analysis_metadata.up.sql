@@ -0,0 +1,12 @@
+-- Incremented by every metadata edit, used as the ETag of the analysis
+ALTER TABLE analysis ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+
+-- Free-form tags operators attach to analyses
+CREATE TABLE IF NOT EXISTS analysis_tags (
+    analysis_id INTEGER NOT NULL REFERENCES analysis(id) ON DELETE CASCADE,
+    tag VARCHAR NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (analysis_id, tag)
+);
+
+CREATE INDEX IF NOT EXISTS analysis_tags_tag_idx ON analysis_tags (tag);
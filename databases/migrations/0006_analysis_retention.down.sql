@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS analysis_audit;
+DROP INDEX IF EXISTS analysis_deleted_at_idx;
+ALTER TABLE analysis DROP COLUMN IF EXISTS deleted_at;
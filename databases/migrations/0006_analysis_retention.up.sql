@@ -0,0 +1,19 @@
+-- Set by a soft delete; the analysis and its objects are hidden until restored
+-- or purged by the retention job
+ALTER TABLE analysis ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ NULL;
+
+CREATE INDEX IF NOT EXISTS analysis_deleted_at_idx ON analysis (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- Deletions, restores and purges of analyses. Not a foreign key, so purged
+-- analyses keep their tombstone
+CREATE TABLE IF NOT EXISTS analysis_audit (
+    id SERIAL PRIMARY KEY,
+    analysis_id INTEGER NOT NULL,
+    id_analysis VARCHAR,
+    action VARCHAR NOT NULL,
+    id_user VARCHAR NULL,
+    details JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS analysis_audit_id_analysis_idx ON analysis_audit (id_analysis, id);
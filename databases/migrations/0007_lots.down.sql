@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS lot_analyses;
+DROP TABLE IF EXISTS lots;
@@ -0,0 +1,25 @@
+-- Physical sample lots; a lot usually spans several analyses of the user who
+-- created it
+CREATE TABLE IF NOT EXISTS lots (
+    id SERIAL PRIMARY KEY,
+    lot_number VARCHAR NOT NULL,
+    supplier VARCHAR NULL,
+    field VARCHAR NULL,
+    harvest_date DATE NULL,
+    product VARCHAR NULL,
+    notes VARCHAR NULL,
+    id_user VARCHAR NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS lots_user_supplier_lot_number_idx ON lots (id_user, COALESCE(supplier, ''), lot_number);
+
+-- The lot of an analysis; an analysis belongs to at most one lot
+CREATE TABLE IF NOT EXISTS lot_analyses (
+    analysis_id INTEGER PRIMARY KEY REFERENCES analysis(id) ON DELETE CASCADE,
+    lot_id INTEGER NOT NULL REFERENCES lots(id) ON DELETE CASCADE,
+    attached_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS lot_analyses_lot_id_idx ON lot_analyses (lot_id);
@@ -0,0 +1,21 @@
+-- Catalog of products. analysis.product, lots.product and product_specs.product
+-- hold the canonical code. Aliases are stored normalized (trimmed, single-spaced,
+-- lower-case) and settings holds the default analysis parameters of the product
+CREATE TABLE IF NOT EXISTS products (
+    id SERIAL PRIMARY KEY,
+    code VARCHAR NOT NULL UNIQUE,
+    names JSONB NOT NULL DEFAULT '{}',
+    aliases TEXT[] NOT NULL DEFAULT '{}',
+    settings JSONB NOT NULL DEFAULT '{}',
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Products a user may choose from; a user without rows may choose any active product
+CREATE TABLE IF NOT EXISTS user_products (
+    id_user VARCHAR NOT NULL,
+    product_code VARCHAR NOT NULL REFERENCES products(code) ON DELETE CASCADE ON UPDATE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (id_user, product_code)
+);
@@ -0,0 +1,7 @@
+-- Mapped product values keep their codes; only seeded products nothing refers to
+-- are removed
+DELETE FROM products p
+WHERE p.code IN ('wheat', 'barley', 'rye', 'oats', 'corn', 'rice', 'buckwheat', 'millet', 'sunflower', 'soybean', 'pea', 'rapeseed', 'lentil')
+  AND NOT EXISTS (SELECT 1 FROM analysis a WHERE a.product = p.code)
+  AND NOT EXISTS (SELECT 1 FROM lots l WHERE l.product = p.code)
+  AND NOT EXISTS (SELECT 1 FROM product_specs s WHERE s.product = p.code);
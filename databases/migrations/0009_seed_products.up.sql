@@ -0,0 +1,70 @@
+-- Seeds the catalog with the known products and maps the free-text product values
+-- of analyses, lots and specs onto it, matching them like the service does:
+-- trimmed, single-spaced and lower-case against the codes, aliases and names.
+-- Values that match no product are left alone; `app normalize-products` maps them
+-- once aliases have been added for them.
+INSERT INTO products (code, names, aliases) VALUES
+    ('wheat', '{"en": "Wheat", "ru": "Пшеница"}', ARRAY['пшеница']),
+    ('barley', '{"en": "Barley", "ru": "Ячмень"}', ARRAY['ячмень']),
+    ('rye', '{"en": "Rye", "ru": "Рожь"}', ARRAY['рожь']),
+    ('oats', '{"en": "Oats", "ru": "Овёс"}', ARRAY['овёс', 'овес', 'oat']),
+    ('corn', '{"en": "Corn", "ru": "Кукуруза"}', ARRAY['кукуруза', 'maize']),
+    ('rice', '{"en": "Rice", "ru": "Рис"}', ARRAY['рис']),
+    ('buckwheat', '{"en": "Buckwheat", "ru": "Гречиха"}', ARRAY['гречиха', 'гречка']),
+    ('millet', '{"en": "Millet", "ru": "Просо"}', ARRAY['просо']),
+    ('sunflower', '{"en": "Sunflower", "ru": "Подсолнечник"}', ARRAY['подсолнечник', 'подсолнух', 'sunflower seeds']),
+    ('soybean', '{"en": "Soybean", "ru": "Соя"}', ARRAY['соя', 'soy', 'soya']),
+    ('pea', '{"en": "Peas", "ru": "Горох"}', ARRAY['горох']),
+    ('rapeseed', '{"en": "Rapeseed", "ru": "Рапс"}', ARRAY['рапс', 'canola']),
+    ('lentil', '{"en": "Lentils", "ru": "Чечевица"}', ARRAY['чечевица'])
+ON CONFLICT (code) DO NOTHING;
+
+CREATE TEMPORARY TABLE product_mapping ON COMMIT DROP AS
+WITH product_keys AS (
+    SELECT code, code AS key FROM products
+    UNION
+    SELECT code, unnest(aliases) FROM products
+    UNION
+    SELECT code, lower(regexp_replace(btrim(name), '\s+', ' ', 'g'))
+    FROM products, jsonb_each_text(names) AS n(lang, name)
+), product_values AS (
+    SELECT product FROM analysis
+    UNION
+    SELECT product FROM lots
+    UNION
+    SELECT product FROM product_specs
+)
+SELECT DISTINCT ON (v.product) v.product, k.code
+FROM product_values v
+JOIN product_keys k ON k.key = lower(regexp_replace(btrim(v.product), '\s+', ' ', 'g'))
+WHERE v.product <> k.code
+ORDER BY v.product, k.code;
+
+UPDATE analysis a
+SET product = m.code,
+    version = a.version + 1
+FROM product_mapping m
+WHERE a.product = m.product;
+
+UPDATE lots l
+SET product = m.code,
+    updated_at = NOW()
+FROM product_mapping m
+WHERE l.product = m.product;
+
+-- A code gets at most one spec: the most recently updated one of its values, if it
+-- has none yet
+UPDATE product_specs s
+SET product = r.code,
+    updated_at = NOW()
+FROM (
+    SELECT DISTINCT ON (m.code) s.id, m.code
+    FROM product_specs s
+    JOIN product_mapping m ON m.product = s.product
+    WHERE NOT EXISTS (SELECT 1 FROM product_specs c WHERE c.product = m.code)
+    ORDER BY m.code, s.updated_at DESC, s.id
+) r
+WHERE s.id = r.id;
+
+-- Dropped here as well as on commit, as sqlc reads the migrations as the schema
+DROP TABLE product_mapping;
@@ -0,0 +1,8 @@
+// Package migrations embeds the versioned schema migrations. Every version has a
+// NNNN_name.up.sql file and a matching NNNN_name.down.sql file that undoes it.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
@@ -3,7 +3,8 @@
 -- name: GetAnalysisByID :one
 SELECT *
 FROM analysis
-WHERE id_analysis = @id_analysis;
+WHERE id_analysis = @id_analysis
+  AND deleted_at IS NULL;
 
 -- name: GetAnalysesByUserTelegramIDPagination :many
 SELECT *
@@ -11,13 +12,17 @@ FROM analysis
 WHERE id_user = @id_user
   AND (@product::TEXT IS NULL OR @product = '' OR product = @product)
   AND (@id_analysis::TEXT IS NULL OR @id_analysis = '' OR CAST(id_analysis AS TEXT) LIKE '%' || @id_analysis || '%')
+  AND (@verdict::TEXT IS NULL OR @verdict = '' OR id IN (SELECT analysis_id FROM analysis_verdicts WHERE verdict = @verdict))
+  AND (@tag::TEXT = '' OR id IN (SELECT analysis_id FROM analysis_tags WHERE tag = @tag))
+  AND deleted_at IS NULL
 ORDER BY
     CASE WHEN @sort_by = 'date_time' AND @sort_order = 'asc' THEN date_time END ASC,
     CASE WHEN @sort_by = 'date_time' AND @sort_order = 'desc' THEN date_time END DESC,
     CASE WHEN @sort_by = 'id' AND @sort_order = 'asc' THEN id END ASC,
     CASE WHEN @sort_by = 'id' AND @sort_order = 'desc' THEN id END DESC,
     CASE WHEN @sort_by = 'product' AND @sort_order = 'asc' THEN product END ASC,
-    CASE WHEN @sort_by = 'product' AND @sort_order = 'desc' THEN product END DESC
+    CASE WHEN @sort_by = 'product' AND @sort_order = 'desc' THEN product END DESC,
+    id
 LIMIT sqlc.arg('limit')::int
 OFFSET sqlc.arg('offset')::int;
 
@@ -26,9 +31,56 @@ SELECT COUNT(*)
 FROM analysis
 WHERE id_user = @id_user
   AND (@product::TEXT IS NULL OR @product = '' OR product = @product)
-  AND (@id_analysis::TEXT IS NULL OR @id_analysis = '' OR CAST(id_analysis AS TEXT) LIKE '%' || @id_analysis || '%');
+  AND (@id_analysis::TEXT IS NULL OR @id_analysis = '' OR CAST(id_analysis AS TEXT) LIKE '%' || @id_analysis || '%')
+  AND (@verdict::TEXT IS NULL OR @verdict = '' OR id IN (SELECT analysis_id FROM analysis_verdicts WHERE verdict = @verdict))
+  AND (@tag::TEXT = '' OR id IN (SELECT analysis_id FROM analysis_tags WHERE tag = @tag))
+  AND deleted_at IS NULL;
 
 -- name: GetAnalysesByIDs :many
 SELECT *
 FROM analysis
-WHERE id = ANY(@ids::int[]);
\ No newline at end of file
+WHERE id = ANY(@ids::int[])
+  AND deleted_at IS NULL;
+
+-- name: GetAnalysesByProduct :many
+SELECT *
+FROM analysis
+WHERE product = @product
+  AND deleted_at IS NULL
+ORDER BY id;
+
+-- name: ListAnalysesAfterID :many
+SELECT *
+FROM analysis
+WHERE id > @after_id
+  AND deleted_at IS NULL
+ORDER BY id
+LIMIT sqlc.arg('limit')::int;
+
+-- name: UpdateAnalysisStats :exec
+UPDATE analysis
+SET r = @r,
+    g = @g,
+    b = @b,
+    h = @h,
+    s = @s,
+    v = @v,
+    lab_l = @lab_l,
+    lab_a = @lab_a,
+    lab_b = @lab_b,
+    w = @w,
+    l = @l,
+    t = @t,
+    version = version + 1
+WHERE id = @id;
+-- name: GetAnalysisByInternalID :one
+SELECT *
+FROM analysis
+WHERE id = @id
+  AND deleted_at IS NULL;
+
+-- name: GetAnalysisIDByInternalID :one
+-- Also finds soft-deleted analyses, so they can be restored by their internal id.
+SELECT id_analysis
+FROM analysis
+WHERE id = @id;
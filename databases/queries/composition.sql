@@ -0,0 +1,36 @@
+-- Queries for object class composition
+
+-- name: GetClassCompositionByAnalysisID :many
+SELECT COALESCE(l.class, o.class, '')::TEXT AS class,
+       COUNT(*) AS objects,
+       COALESCE(SUM(o.sq), 0)::FLOAT8 AS area
+FROM objects o
+LEFT JOIN object_effective_labels l ON l.object_id = o.id
+WHERE o.id_analysis = @analysis_id
+  AND NOT EXISTS (SELECT 1 FROM analysis da WHERE da.id = o.id_analysis AND da.deleted_at IS NOT NULL)
+GROUP BY 1
+ORDER BY objects DESC, 1;
+
+-- name: GetClassCompositionByUserID :many
+WITH filtered AS (
+    SELECT COALESCE(l.class, o.class) AS class,
+           o.sq,
+           a.mass,
+           SUM(o.sq) OVER (PARTITION BY o.id_analysis) AS analysis_area
+    FROM objects o
+    JOIN analysis a ON a.id = o.id_analysis
+    LEFT JOIN object_effective_labels l ON l.object_id = o.id
+    WHERE a.id_user = @id_user
+      AND (@product::TEXT IS NULL OR @product = '' OR a.product = @product)
+      AND (@id_analysis::TEXT IS NULL OR @id_analysis = '' OR CAST(a.id_analysis AS TEXT) LIKE '%' || @id_analysis || '%')
+      AND (@verdict::TEXT IS NULL OR @verdict = '' OR a.id IN (SELECT analysis_id FROM analysis_verdicts WHERE verdict = @verdict))
+      AND (@tag::TEXT = '' OR a.id IN (SELECT analysis_id FROM analysis_tags WHERE tag = @tag))
+      AND a.deleted_at IS NULL
+)
+SELECT COALESCE(class, '')::TEXT AS class,
+       COUNT(*) AS objects,
+       COALESCE(SUM(sq), 0)::FLOAT8 AS area,
+       COALESCE(SUM(mass * sq / NULLIF(analysis_area, 0)), 0)::FLOAT8 AS mass
+FROM filtered
+GROUP BY 1
+ORDER BY objects DESC, class;
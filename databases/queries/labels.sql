@@ -0,0 +1,23 @@
+-- Queries for manual object labels
+
+-- name: CreateObjectLabel :one
+INSERT INTO object_labels (object_id, class, id_user, reason)
+VALUES (@object_id, @class, @id_user, @reason)
+RETURNING *;
+
+-- name: GetEffectiveLabelsByAnalysisID :many
+SELECT l.*
+FROM object_effective_labels l
+JOIN objects o ON o.id = l.object_id
+WHERE o.id_analysis = sqlc.arg(analysis_id);
+
+-- name: GetEffectiveLabelsByObjectIDs :many
+SELECT *
+FROM object_effective_labels
+WHERE object_id = ANY(sqlc.arg(ids)::int[]);
+
+-- name: ListObjectLabels :many
+SELECT *
+FROM object_labels
+WHERE object_id = @object_id
+ORDER BY id DESC;
@@ -0,0 +1,130 @@
+-- Queries for sample lots
+
+-- name: AttachAnalysesToLot :execrows
+-- Attaches the user's analyses to a lot. An analysis already in a lot stays there.
+INSERT INTO lot_analyses (analysis_id, lot_id)
+SELECT id, @lot_id
+FROM analysis
+WHERE id_analysis = ANY(@id_analyses::TEXT[])
+  AND id_user = @id_user
+  AND deleted_at IS NULL
+ON CONFLICT (analysis_id) DO NOTHING;
+
+-- name: CountLotAnalyses :one
+SELECT COUNT(*)
+FROM lot_analyses la
+JOIN analysis a ON a.id = la.analysis_id
+WHERE la.lot_id = @lot_id
+  AND a.deleted_at IS NULL;
+
+-- name: CountLots :one
+SELECT COUNT(*)
+FROM lots
+WHERE id_user = @id_user
+  AND (@supplier::TEXT = '' OR supplier = @supplier)
+  AND (@product::TEXT = '' OR product = @product);
+
+-- name: CreateLot :one
+INSERT INTO lots (lot_number, supplier, field, harvest_date, product, notes, id_user)
+VALUES (@lot_number, sqlc.narg(supplier), sqlc.narg(field), sqlc.narg(harvest_date), sqlc.narg(product), sqlc.narg(notes), @id_user)
+RETURNING *;
+
+-- name: DeleteLot :execrows
+DELETE FROM lots
+WHERE id = @id
+  AND id_user = @id_user;
+
+-- name: DetachAnalysisFromLot :execrows
+DELETE FROM lot_analyses la
+USING analysis a
+WHERE la.analysis_id = a.id
+  AND la.lot_id = @lot_id
+  AND a.id_analysis = @id_analysis
+  AND a.id_user = @id_user;
+
+-- name: GetClassCompositionByLotID :many
+WITH filtered AS (
+    SELECT COALESCE(l.class, o.class) AS class,
+           o.sq,
+           a.mass,
+           SUM(o.sq) OVER (PARTITION BY o.id_analysis) AS analysis_area
+    FROM lot_analyses la
+    JOIN analysis a ON a.id = la.analysis_id
+    JOIN objects o ON o.id_analysis = a.id
+    LEFT JOIN object_effective_labels l ON l.object_id = o.id
+    WHERE la.lot_id = @lot_id
+      AND a.deleted_at IS NULL
+)
+SELECT COALESCE(class, '')::TEXT AS class,
+       COUNT(*) AS objects,
+       COALESCE(SUM(sq), 0)::FLOAT8 AS area,
+       COALESCE(SUM(mass * sq / NULLIF(analysis_area, 0)), 0)::FLOAT8 AS mass
+FROM filtered
+GROUP BY 1
+ORDER BY objects DESC, class;
+
+-- name: GetLotByID :one
+SELECT *
+FROM lots
+WHERE id = @id
+  AND id_user = @id_user;
+
+-- name: GetLotMembershipsByAnalysisIDs :many
+SELECT *
+FROM lot_analyses
+WHERE analysis_id = ANY(sqlc.arg(ids)::int[]);
+
+-- name: ListLotConflicts :many
+-- The given analyses of the user that are in a lot other than lot_id.
+SELECT a.id_analysis, la.lot_id
+FROM lot_analyses la
+JOIN analysis a ON a.id = la.analysis_id
+WHERE a.id_analysis = ANY(@id_analyses::TEXT[])
+  AND a.id_user = @id_user
+  AND la.lot_id <> @lot_id
+ORDER BY a.id_analysis;
+
+-- name: ListLotAnalyses :many
+SELECT a.*
+FROM analysis a
+JOIN lot_analyses la ON la.analysis_id = a.id
+WHERE la.lot_id = @lot_id
+  AND a.deleted_at IS NULL
+ORDER BY a.date_time, a.id;
+
+-- name: ListLotObjects :many
+SELECT o.*
+FROM objects o
+JOIN lot_analyses la ON la.analysis_id = o.id_analysis
+JOIN analysis a ON a.id = la.analysis_id
+WHERE la.lot_id = @lot_id
+  AND a.deleted_at IS NULL
+ORDER BY o.id;
+
+-- name: ListLots :many
+SELECT l.*,
+       (SELECT COUNT(*)
+        FROM lot_analyses la
+        JOIN analysis a ON a.id = la.analysis_id
+        WHERE la.lot_id = l.id
+          AND a.deleted_at IS NULL) AS analyses
+FROM lots l
+WHERE l.id_user = @id_user
+  AND (@supplier::TEXT = '' OR l.supplier = @supplier)
+  AND (@product::TEXT = '' OR l.product = @product)
+ORDER BY l.id DESC
+LIMIT sqlc.arg('limit')::int
+OFFSET sqlc.arg('offset')::int;
+
+-- name: UpdateLot :one
+UPDATE lots
+SET lot_number = @lot_number,
+    supplier = sqlc.narg(supplier),
+    field = sqlc.narg(field),
+    harvest_date = sqlc.narg(harvest_date),
+    product = sqlc.narg(product),
+    notes = sqlc.narg(notes),
+    updated_at = NOW()
+WHERE id = @id
+  AND id_user = @id_user
+RETURNING *;
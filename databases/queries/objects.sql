@@ -2,33 +2,39 @@
 -- name: GetObjectByID :one
 SELECT *
 FROM objects
-WHERE id = sqlc.arg(id);
+WHERE objects.id = sqlc.arg(id)
+  AND NOT EXISTS (SELECT 1 FROM analysis da WHERE da.id = objects.id_analysis AND da.deleted_at IS NOT NULL);
 
 -- name: GetObjectsByAnalysisID :many
 SELECT *
 FROM objects
-WHERE id_analysis = sqlc.arg(analysis_id)
+WHERE objects.id_analysis = sqlc.arg(analysis_id)
+  AND NOT EXISTS (SELECT 1 FROM analysis da WHERE da.id = objects.id_analysis AND da.deleted_at IS NOT NULL)
 ORDER BY id;
 
 -- name: GetObjectsImages :many
 SELECT id, id_analysis, file
 FROM objects
-WHERE id = ANY(sqlc.arg(ids)::int[]);
+WHERE objects.id = ANY(sqlc.arg(ids)::int[])
+  AND NOT EXISTS (SELECT 1 FROM analysis da WHERE da.id = objects.id_analysis AND da.deleted_at IS NOT NULL);
 
 -- name: GetObjectsImagesForAnalysis :many
 SELECT id, id_analysis, file
 FROM objects
-WHERE id_analysis = sqlc.arg(id_analysis);
+WHERE objects.id_analysis = sqlc.arg(id_analysis)
+  AND NOT EXISTS (SELECT 1 FROM analysis da WHERE da.id = objects.id_analysis AND da.deleted_at IS NOT NULL);
 
 -- name: GetObjectsMetadata :many
 SELECT id, id_analysis, m_h, m_s, m_v, m_r, m_g, m_b, l_avg, w_avg, brt_avg, r_avg, g_avg, b_avg, h_avg, s_avg, v_avg, h, s, v, h_m, s_m, v_m, r_m, g_m, b_m, brt_m, w_m, l_m, l, w, l_w, pr, sq, brt, r, g, b, solid, min_h, min_s, min_v, max_h, max_s, max_v, entropy, id_image, color_rhs, geometry, sq_sqcrl, hu1, hu2, hu3, hu4, hu5, hu6
 FROM objects
-WHERE id = ANY(sqlc.arg(ids)::int[]);
+WHERE objects.id = ANY(sqlc.arg(ids)::int[])
+  AND NOT EXISTS (SELECT 1 FROM analysis da WHERE da.id = objects.id_analysis AND da.deleted_at IS NOT NULL);
 
 -- name: GetObjectsMetadataForAnalysis :many
 SELECT id, id_analysis, m_h, m_s, m_v, m_r, m_g, m_b, l_avg, w_avg, brt_avg, r_avg, g_avg, b_avg, h_avg, s_avg, v_avg, h, s, v, h_m, s_m, v_m, r_m, g_m, b_m, brt_m, w_m, l_m, l, w, l_w, pr, sq, brt, r, g, b, solid, min_h, min_s, min_v, max_h, max_s, max_v, entropy, id_image, color_rhs, geometry, sq_sqcrl, hu1, hu2, hu3, hu4, hu5, hu6
 FROM objects
-WHERE id_analysis = sqlc.arg(id_analysis);
+WHERE objects.id_analysis = sqlc.arg(id_analysis)
+  AND NOT EXISTS (SELECT 1 FROM analysis da WHERE da.id = objects.id_analysis AND da.deleted_at IS NOT NULL);
 
 -- name: GetObjectsByIDs :many
 SELECT
@@ -58,4 +64,41 @@ SELECT
     a.id_analysis AS analysis_id_analysis
 FROM objects o
 LEFT JOIN analysis a ON o.id_analysis = a.id
-WHERE o.id = ANY(sqlc.arg(ids)::int[]);
\ No newline at end of file
+WHERE o.id = ANY(sqlc.arg(ids)::int[])
+  AND a.deleted_at IS NULL;
+
+-- name: ListObjectsForExport :many
+SELECT
+    sqlc.embed(o),
+    a.id AS analysis_id,
+    a.date_time AS analysis_date_time,
+    a.product AS analysis_product,
+    a.color_rhs AS analysis_color_rhs,
+    a.id_user AS analysis_id_user,
+    a.scale_mm_pixel AS analysis_scale_mm_pixel,
+    a.mass AS analysis_mass,
+    a.area AS analysis_area,
+    a.id_analysis AS analysis_id_analysis,
+    a.file_source AS analysis_file_source,
+    COALESCE(l.class, o.class, '')::TEXT AS effective_class
+FROM objects o
+LEFT JOIN analysis a ON o.id_analysis = a.id
+LEFT JOIN object_effective_labels l ON l.object_id = o.id
+WHERE o.id > @after_id
+  AND a.id_user = @id_user
+  AND a.deleted_at IS NULL
+  AND (@product::TEXT = '' OR a.product = @product)
+  AND (@class::TEXT = '' OR COALESCE(l.class, o.class) = @class)
+  AND (sqlc.narg(date_from)::TIMESTAMP IS NULL OR a.date_time >= sqlc.narg(date_from))
+  AND (sqlc.narg(date_to)::TIMESTAMP IS NULL OR a.date_time < sqlc.narg(date_to))
+ORDER BY o.id
+LIMIT sqlc.arg('limit')::int;
+
+-- name: ListObjectsWithOwnerAfterID :many
+SELECT sqlc.embed(o), a.id_user
+FROM objects o
+JOIN analysis a ON a.id = o.id_analysis
+WHERE o.id > @after_id
+  AND a.deleted_at IS NULL
+ORDER BY o.id
+LIMIT sqlc.arg('limit')::int;
\ No newline at end of file
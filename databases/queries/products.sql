@@ -0,0 +1,86 @@
+-- Queries for the product catalog
+
+-- name: CreateProduct :one
+INSERT INTO products (code, names, aliases, settings, active)
+VALUES (@code, @names, @aliases::TEXT[], @settings, @active)
+RETURNING *;
+
+-- name: DeleteProduct :execrows
+DELETE FROM products
+WHERE code = @code;
+
+-- name: GetProductByCode :one
+SELECT *
+FROM products
+WHERE code = @code;
+
+-- name: ListProductValues :many
+-- Distinct product values in use, including soft-deleted analyses.
+SELECT product::TEXT AS product, SUM(uses)::BIGINT AS uses
+FROM (
+    SELECT product, COUNT(*) AS uses FROM analysis WHERE product IS NOT NULL GROUP BY product
+    UNION ALL
+    SELECT product, COUNT(*) FROM lots WHERE product IS NOT NULL GROUP BY product
+    UNION ALL
+    SELECT product, COUNT(*) FROM product_specs GROUP BY product
+) used
+GROUP BY product
+ORDER BY product;
+
+-- name: ListProducts :many
+SELECT *
+FROM products
+ORDER BY code;
+
+-- name: ListUserProducts :many
+SELECT product_code
+FROM user_products
+WHERE id_user = @id_user
+ORDER BY product_code;
+
+-- name: RenameProduct :one
+-- Replaces a free-text product value with a catalog code. A spec is only renamed
+-- if the code has no spec yet.
+WITH renamed_analyses AS (
+    UPDATE analysis
+    SET product = @code,
+        version = version + 1
+    WHERE analysis.product = @product
+    RETURNING id
+), renamed_lots AS (
+    UPDATE lots
+    SET product = @code,
+        updated_at = NOW()
+    WHERE lots.product = @product
+    RETURNING id
+), renamed_specs AS (
+    UPDATE product_specs
+    SET product = @code,
+        updated_at = NOW()
+    WHERE product_specs.product = @product
+      AND NOT EXISTS (SELECT 1 FROM product_specs ps WHERE ps.product = @code)
+    RETURNING id
+)
+SELECT (SELECT COUNT(*) FROM renamed_analyses) AS analyses,
+       (SELECT COUNT(*) FROM renamed_lots) AS lots,
+       (SELECT COUNT(*) FROM renamed_specs) AS specs;
+
+-- name: SetUserProducts :exec
+WITH removed AS (
+    DELETE FROM user_products
+    WHERE id_user = @id_user
+      AND product_code <> ALL(@codes::TEXT[])
+)
+INSERT INTO user_products (id_user, product_code)
+SELECT @id_user, unnest(@codes::TEXT[])
+ON CONFLICT DO NOTHING;
+
+-- name: UpdateProduct :one
+UPDATE products
+SET names = @names,
+    aliases = @aliases::TEXT[],
+    settings = @settings,
+    active = @active,
+    updated_at = NOW()
+WHERE code = @code
+RETURNING *;
@@ -0,0 +1,94 @@
+-- Queries for soft deletes, restores and the retention job
+
+-- name: GetAnalysisOwner :one
+-- Owner of an analysis, deleted or not, or of a purged one as its tombstone records.
+SELECT id_user
+FROM analysis
+WHERE analysis.id_analysis = @id_analysis
+UNION ALL
+SELECT details->>'id_user'
+FROM analysis_audit
+WHERE id_analysis = @id_analysis
+  AND action = 'purged'
+LIMIT 1;
+
+-- name: ListAnalysisAudit :many
+SELECT *
+FROM analysis_audit
+WHERE id_analysis = @id_analysis
+ORDER BY id;
+
+-- name: ListExpiredAnalyses :many
+SELECT id
+FROM analysis
+WHERE deleted_at < @deleted_before::TIMESTAMPTZ
+ORDER BY id
+LIMIT sqlc.arg('limit')::int;
+
+-- name: PurgeAnalysis :one
+-- Hard-deletes a soft-deleted analysis with its objects and verdict, leaving a
+-- tombstone in the audit trail. Returns the removed objects and the files that
+-- can be removed.
+WITH target AS (
+    SELECT id
+    FROM analysis
+    WHERE analysis.id = @id
+      AND deleted_at < @deleted_before::TIMESTAMPTZ
+    FOR UPDATE
+), removed_objects AS (
+    DELETE FROM objects
+    WHERE id_analysis IN (SELECT id FROM target)
+    RETURNING id, file
+), removed_verdicts AS (
+    DELETE FROM analysis_verdicts
+    WHERE analysis_id IN (SELECT id FROM target)
+), removed AS (
+    DELETE FROM analysis
+    WHERE id IN (SELECT id FROM target)
+    RETURNING id, id_analysis, product, id_user, date_time, deleted_at, file_source, file_output
+), tombstone AS (
+    INSERT INTO analysis_audit (analysis_id, id_analysis, action, details)
+    SELECT id, id_analysis, 'purged', jsonb_build_object(
+        'product', product,
+        'id_user', id_user,
+        'date_time', date_time,
+        'deleted_at', deleted_at,
+        'objects', (SELECT COUNT(*) FROM removed_objects)
+    )
+    FROM removed
+)
+SELECT r.id,
+       r.id_analysis,
+       r.file_source,
+       r.file_output,
+       ARRAY(SELECT file FROM removed_objects WHERE file IS NOT NULL)::TEXT[] AS object_files,
+       ARRAY(SELECT id FROM removed_objects)::INT[] AS object_ids
+FROM removed r;
+
+-- name: RestoreAnalysis :one
+WITH restored AS (
+    UPDATE analysis
+    SET deleted_at = NULL
+    WHERE analysis.id_analysis = @id_analysis
+      AND analysis.id_user = @id_user
+      AND deleted_at IS NOT NULL
+    RETURNING id, id_analysis
+)
+INSERT INTO analysis_audit (analysis_id, id_analysis, action, id_user)
+SELECT id, id_analysis, 'restored', @id_user
+FROM restored
+RETURNING *;
+
+-- name: SoftDeleteAnalysis :one
+WITH deleted AS (
+    UPDATE analysis
+    SET deleted_at = NOW()
+    WHERE analysis.id_analysis = @id_analysis
+      AND analysis.id_user = @id_user
+      AND deleted_at IS NULL
+    RETURNING id, id_analysis
+)
+INSERT INTO analysis_audit (analysis_id, id_analysis, action, id_user)
+SELECT id, id_analysis, 'deleted', @id_user
+FROM deleted
+RETURNING *;
@@ -0,0 +1,78 @@
+-- Queries for the review queue
+
+-- name: CompleteReviewItem :exec
+UPDATE review_queue q
+SET status = 'done',
+    completed_at = NOW()
+WHERE q.id = @id
+  AND q.status = 'pending'
+  AND (SELECT COUNT(*) FROM review_labels r WHERE r.queue_id = q.id AND NOT r.skipped) >= q.required_reviews;
+
+-- name: CountReviewItemsByStatus :many
+SELECT status, COUNT(*) AS items
+FROM review_queue
+GROUP BY status
+ORDER BY status;
+
+-- name: EnqueueReviewItems :execrows
+INSERT INTO review_queue (object_id, strategy, score, required_reviews)
+SELECT unnest(@object_ids::int[]), @strategy, unnest(@scores::float8[]), @required_reviews
+ON CONFLICT (object_id) DO NOTHING;
+
+-- name: GetNextReviewItem :one
+SELECT *
+FROM review_queue q
+WHERE q.status = 'pending'
+  AND (@strategy::TEXT = '' OR q.strategy = @strategy)
+  AND NOT EXISTS (SELECT 1 FROM review_labels r WHERE r.queue_id = q.id AND r.id_user = @id_user)
+  AND NOT EXISTS (SELECT 1 FROM objects o JOIN analysis da ON da.id = o.id_analysis WHERE o.id = q.object_id AND da.deleted_at IS NOT NULL)
+ORDER BY q.id
+LIMIT 1;
+
+-- name: GetReviewItem :one
+SELECT *
+FROM review_queue
+WHERE id = @id;
+
+-- name: ListReviewLabels :many
+SELECT *
+FROM review_labels
+WHERE NOT EXISTS (
+    SELECT 1
+    FROM review_queue q
+    JOIN objects o ON o.id = q.object_id
+    JOIN analysis da ON da.id = o.id_analysis
+    WHERE q.id = review_labels.queue_id AND da.deleted_at IS NOT NULL
+)
+ORDER BY queue_id, id;
+
+-- name: ListReviewLabelsByQueueIDs :many
+SELECT *
+FROM review_labels
+WHERE queue_id = ANY(sqlc.arg(ids)::int[])
+ORDER BY queue_id, id;
+
+-- name: ListReviewedObjects :many
+SELECT
+    q.id AS queue_id,
+    q.strategy,
+    q.status,
+    sqlc.embed(o),
+    COALESCE(l.class, o.class, '')::TEXT AS effective_class
+FROM review_queue q
+JOIN objects o ON o.id = q.object_id
+LEFT JOIN object_effective_labels l ON l.object_id = o.id
+WHERE q.id > @after_id
+  AND EXISTS (SELECT 1 FROM review_labels r WHERE r.queue_id = q.id AND NOT r.skipped)
+  AND NOT EXISTS (SELECT 1 FROM analysis da WHERE da.id = o.id_analysis AND da.deleted_at IS NOT NULL)
+ORDER BY q.id
+LIMIT sqlc.arg('limit')::int;
+
+-- name: UpsertReviewLabel :one
+INSERT INTO review_labels (queue_id, id_user, class, skipped)
+VALUES (@queue_id, @id_user, sqlc.narg(class), @skipped)
+ON CONFLICT (queue_id, id_user) DO UPDATE
+SET class = EXCLUDED.class,
+    skipped = EXCLUDED.skipped,
+    created_at = NOW()
+RETURNING *;
@@ -0,0 +1,72 @@
+-- Queries for the product_specs and analysis_verdicts tables
+
+-- name: ListProductSpecs :many
+SELECT *
+FROM product_specs
+ORDER BY product;
+
+-- name: GetProductSpecByID :one
+SELECT *
+FROM product_specs
+WHERE id = @id;
+
+-- name: GetProductSpecByProduct :one
+SELECT *
+FROM product_specs
+WHERE product = @product;
+
+-- name: CreateProductSpec :one
+INSERT INTO product_specs (product, name, rules)
+VALUES (@product, @name, @rules)
+RETURNING *;
+
+-- name: UpdateProductSpec :one
+-- Drops the verdicts graded against the old version in the same statement, as
+-- the product may have changed. The grading job regrades the analyses.
+WITH cleared AS (
+    DELETE FROM analysis_verdicts
+    WHERE spec_id = @id
+)
+UPDATE product_specs
+SET product = @product,
+    name = @name,
+    rules = @rules,
+    updated_at = NOW()
+WHERE id = @id
+RETURNING *;
+
+-- name: DeleteProductSpec :execrows
+DELETE FROM product_specs
+WHERE id = @id;
+
+-- name: UpsertAnalysisVerdict :one
+INSERT INTO analysis_verdicts (analysis_id, spec_id, verdict, violations, evaluated_at)
+VALUES (@analysis_id, @spec_id, @verdict, @violations, NOW())
+ON CONFLICT (analysis_id) DO UPDATE
+SET spec_id = EXCLUDED.spec_id,
+    verdict = EXCLUDED.verdict,
+    violations = EXCLUDED.violations,
+    evaluated_at = EXCLUDED.evaluated_at
+RETURNING *;
+
+-- name: DeleteAnalysisVerdict :exec
+DELETE FROM analysis_verdicts
+WHERE analysis_id = @analysis_id;
+
+-- name: GetAnalysisVerdictsByAnalysisIDs :many
+SELECT *
+FROM analysis_verdicts
+WHERE analysis_id = ANY(@ids::int[]);
+
+-- name: ListUngradedAnalyses :many
+-- Analyses whose product has a spec but that have no verdict yet, in id order
+-- from after_id.
+SELECT a.*
+FROM analysis a
+JOIN product_specs ps ON ps.product = a.product
+LEFT JOIN analysis_verdicts v ON v.analysis_id = a.id
+WHERE a.id > @after_id
+  AND a.deleted_at IS NULL
+  AND v.analysis_id IS NULL
+ORDER BY a.id
+LIMIT sqlc.arg('limit');
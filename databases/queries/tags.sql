@@ -0,0 +1,36 @@
+-- Queries for analysis metadata edits and tags
+
+-- name: GetAnalysisTagsByAnalysisIDs :many
+SELECT *
+FROM analysis_tags
+WHERE analysis_id = ANY(sqlc.arg(ids)::int[])
+ORDER BY analysis_id, tag;
+
+-- name: UpdateAnalysisMetadata :one
+-- Applies a metadata edit only if the analysis belongs to the user and is still at
+-- the expected version. Tags are replaced as a whole when replace_tags is set.
+WITH updated AS (
+    UPDATE analysis
+    SET product = COALESCE(sqlc.narg(product), product),
+        text = COALESCE(sqlc.narg(text), text),
+        version = version + 1
+    WHERE id_analysis = @id_analysis
+      AND id_user = @id_user
+      AND version = @version
+      AND deleted_at IS NULL
+    RETURNING *
+), removed AS (
+    DELETE FROM analysis_tags t
+    USING updated u
+    WHERE t.analysis_id = u.id
+      AND @replace_tags::BOOLEAN
+      AND t.tag <> ALL(@tags::TEXT[])
+), added AS (
+    INSERT INTO analysis_tags (analysis_id, tag)
+    SELECT u.id, tag
+    FROM updated u, unnest(@tags::TEXT[]) AS tag
+    WHERE @replace_tags::BOOLEAN
+    ON CONFLICT DO NOTHING
+)
+SELECT *
+FROM updated;
@@ -0,0 +1,98 @@
+// Package apperr defines the domain errors services return. An Error has a Kind,
+// which the HTTP layer maps to a status code, and a stable machine-readable Code
+// clients can match on regardless of the message wording.
+package apperr
+
+import "errors"
+
+type Kind int
+
+const (
+	// KindInternal is any error that isn't a domain error; its details stay in the logs
+	KindInternal Kind = iota
+	KindValidation
+	KindNotFound
+	KindForbidden
+	KindConflict
+	// KindPrecondition is a conditional request whose precondition doesn't hold,
+	// e.g. an If-Match against a stale version
+	KindPrecondition
+	// KindUpstreamUnavailable is a dependency, such as the analysis API, failing
+	KindUpstreamUnavailable
+)
+
+var kindNames = map[Kind]string{
+	KindInternal:            "internal",
+	KindValidation:          "validation",
+	KindNotFound:            "not_found",
+	KindForbidden:           "forbidden",
+	KindConflict:            "conflict",
+	KindPrecondition:        "precondition",
+	KindUpstreamUnavailable: "upstream_unavailable",
+}
+
+func (k Kind) String() string {
+	if name, ok := kindNames[k]; ok {
+		return name
+	}
+	return kindNames[KindInternal]
+}
+
+// Error is a domain error. Services declare them as sentinels and add context by
+// wrapping, e.g. fmt.Errorf("%w: product must be set", ErrInvalidSpec), so the
+// kind and code survive while the message gets specific.
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func New(kind Kind, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func Validation(code, message string) *Error {
+	return New(KindValidation, code, message)
+}
+
+func NotFound(code, message string) *Error {
+	return New(KindNotFound, code, message)
+}
+
+func Forbidden(code, message string) *Error {
+	return New(KindForbidden, code, message)
+}
+
+func Conflict(code, message string) *Error {
+	return New(KindConflict, code, message)
+}
+
+func Precondition(code, message string) *Error {
+	return New(KindPrecondition, code, message)
+}
+
+func UpstreamUnavailable(code, message string) *Error {
+	return New(KindUpstreamUnavailable, code, message)
+}
+
+// As returns the first domain error in err's chain.
+func As(err error) (*Error, bool) {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr, true
+	}
+	return nil, false
+}
+
+// KindOf returns the kind of the domain error in err's chain, KindInternal if
+// there is none.
+func KindOf(err error) Kind {
+	if appErr, ok := As(err); ok {
+		return appErr.Kind
+	}
+	return KindInternal
+}
@@ -1,8 +1,10 @@
 package config
 
 import (
+	"net/url"
 	"os"
 	"strconv"
+	"strings"
 )
 
 type DBConfig struct {
@@ -20,11 +22,78 @@ type DBConfig struct {
 type Config struct {
 	DB          DBConfig
 	AnalysisAPI string
+	// SimilarityRefreshInterval is how often new objects are added to the similarity index, in seconds
+	SimilarityRefreshInterval int64
+	// SpecGradingInterval is how often analyses without a verdict are graded against
+	// their product spec, in seconds
+	SpecGradingInterval int64
+	// ReportTemplatesDir holds per-organization report templates, empty to only use the built-in one
+	ReportTemplatesDir string
+	// ReportFilesDir is where relative analysis output image paths are resolved for reports
+	ReportFilesDir string
+	// DatasetFilesDir is where relative source image and object crop paths are resolved for
+	// dataset exports and reviews
+	DatasetFilesDir string
+	// FileHosts are the hosts source images, crops and output images may be fetched
+	// from when they are referenced by URL; by default the analysis API host
+	FileHosts []string
+	// AnalysisRetentionDays is how long soft-deleted analyses are kept before they are purged,
+	// 0 to keep them forever
+	AnalysisRetentionDays int64
+	// AnalysisRetentionInterval is how often expired analyses are purged, in seconds
+	AnalysisRetentionInterval int64
+	// ProblemTypeBase is prefixed to error codes to form RFC 7807 problem type URIs
+	ProblemTypeBase string
+	// ProblemRoutes are path prefixes, e.g. /api/v1/lots, whose errors are always
+	// written as application/problem+json
+	ProblemRoutes []string
+	// AdminUserIDs are the Telegram users who may change data shared by all users,
+	// such as product specs
+	AdminUserIDs []int64
+	// GRPCPort is the port of the gRPC API, empty, the default, to only serve REST
+	GRPCPort string
+	// GRPCReflection registers the gRPC reflection service, which lists the API
+	// to any client
+	GRPCReflection bool
+	// GRPCCertFile and GRPCKeyFile are the TLS certificate and key of the gRPC API,
+	// both empty to serve it in plaintext
+	GRPCCertFile string
+	GRPCKeyFile  string
 }
 
 func LoadConfig() *Config {
 	cfg := &Config{}
-	cfg.DB = DBConfig{
+	cfg.DB = LoadDBConfig()
+	cfg.AnalysisAPI = getEnv("ANALYSIS_API_URL", "")
+	if cfg.AnalysisAPI == "" {
+		panic("ANALYSIS_API_URL is not set")
+	}
+	cfg.SimilarityRefreshInterval = getEnvAsInt64("SIMILARITY_REFRESH_INTERVAL", 60)
+	cfg.SpecGradingInterval = getEnvAsInt64("SPEC_GRADING_INTERVAL", 60)
+	cfg.ReportTemplatesDir = getEnv("REPORT_TEMPLATES_DIR", "")
+	cfg.ReportFilesDir = getEnv("REPORT_FILES_DIR", "")
+	cfg.DatasetFilesDir = getEnv("DATASET_FILES_DIR", cfg.ReportFilesDir)
+	cfg.FileHosts = getEnvAsList("FILE_HOSTS")
+	if len(cfg.FileHosts) == 0 {
+		if u, err := url.Parse(cfg.AnalysisAPI); err == nil && u.Host != "" {
+			cfg.FileHosts = []string{u.Host}
+		}
+	}
+	cfg.AnalysisRetentionDays = getEnvAsInt64("ANALYSIS_RETENTION_DAYS", 30)
+	cfg.AnalysisRetentionInterval = getEnvAsInt64("ANALYSIS_RETENTION_INTERVAL", 3600)
+	cfg.ProblemTypeBase = getEnv("PROBLEM_TYPE_BASE", "")
+	cfg.ProblemRoutes = getEnvAsList("PROBLEM_ROUTES")
+	cfg.AdminUserIDs = getEnvAsInt64List("ADMIN_USER_IDS")
+	cfg.GRPCPort = getEnv("GRPC_PORT", "")
+	cfg.GRPCReflection = getEnvAsBool("GRPC_REFLECTION", false)
+	cfg.GRPCCertFile = getEnv("GRPC_TLS_CERT_FILE", "")
+	cfg.GRPCKeyFile = getEnv("GRPC_TLS_KEY_FILE", "")
+	return cfg
+}
+
+// LoadDBConfig loads only the database settings, for commands that don't run the server.
+func LoadDBConfig() DBConfig {
+	return DBConfig{
 		Host:            getEnv("DB_HOST", "localhost"),
 		Port:            getEnv("DB_PORT", "5432"),
 		User:            getEnv("DB_USER", "user"),
@@ -35,11 +104,6 @@ func LoadConfig() *Config {
 		MaxConnLifetime: getEnvAsInt64("DB_MAX_CONN_LIFETIME", 3600), // seconds
 		MaxConnIdleTime: getEnvAsInt64("DB_MAX_CONN_IDLE_TIME", 300), // seconds
 	}
-	cfg.AnalysisAPI = getEnv("ANALYSIS_API_URL", "")
-	if cfg.AnalysisAPI == "" {
-		panic("ANALYSIS_API_URL is not set")
-	}
-	return cfg
 }
 
 func getEnv(key, fallback string) string {
@@ -70,3 +134,39 @@ func getEnvAsInt64(key string, fallback int64) int64 {
 	}
 	return fallback
 }
+
+func getEnvAsBool(key string, fallback bool) bool {
+	valueStr := getEnv(key, "")
+	if valueStr == "" {
+		return fallback
+	}
+	if value, err := strconv.ParseBool(valueStr); err == nil {
+		return value
+	}
+	return fallback
+}
+
+// getEnvAsList reads a comma-separated list, skipping empty entries.
+func getEnvAsList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(getEnv(key, ""), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// getEnvAsInt64List reads a comma-separated list of integers. An entry that isn't
+// one panics rather than being skipped, as the lists grant access.
+func getEnvAsInt64List(key string) []int64 {
+	var values []int64
+	for _, entry := range getEnvAsList(key) {
+		value, err := strconv.ParseInt(entry, 10, 64)
+		if err != nil {
+			panic(key + " has an invalid entry " + strconv.Quote(entry))
+		}
+		values = append(values, value)
+	}
+	return values
+}
@@ -0,0 +1,173 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"csort.ru/analysis-service/internal/repository"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// objectsPageSize is the number of objects ObjectPages.Each reads per query.
+const objectsPageSize = 1000
+
+type objectColumn struct {
+	name string
+	dest func(*repository.Object) any
+}
+
+// objectColumns are the columns of objects in the order of
+// repository.GetObjectsByAnalysisID, with the Object field each one is scanned into.
+var objectColumns = []objectColumn{
+	{"id", func(i *repository.Object) any { return &i.ID }},
+	{"id_analysis", func(i *repository.Object) any { return &i.IDAnalysis }},
+	{"file", func(i *repository.Object) any { return &i.File }},
+	{"m_h", func(i *repository.Object) any { return &i.MH }},
+	{"m_s", func(i *repository.Object) any { return &i.MS }},
+	{"m_v", func(i *repository.Object) any { return &i.MV }},
+	{"m_r", func(i *repository.Object) any { return &i.MR }},
+	{"m_g", func(i *repository.Object) any { return &i.MG }},
+	{"m_b", func(i *repository.Object) any { return &i.MB }},
+	{"l_avg", func(i *repository.Object) any { return &i.LAvg }},
+	{"w_avg", func(i *repository.Object) any { return &i.WAvg }},
+	{"brt_avg", func(i *repository.Object) any { return &i.BrtAvg }},
+	{"r_avg", func(i *repository.Object) any { return &i.RAvg }},
+	{"g_avg", func(i *repository.Object) any { return &i.GAvg }},
+	{"b_avg", func(i *repository.Object) any { return &i.BAvg }},
+	{"h_avg", func(i *repository.Object) any { return &i.HAvg }},
+	{"s_avg", func(i *repository.Object) any { return &i.SAvg }},
+	{"v_avg", func(i *repository.Object) any { return &i.VAvg }},
+	{"h", func(i *repository.Object) any { return &i.H }},
+	{"s", func(i *repository.Object) any { return &i.S }},
+	{"v", func(i *repository.Object) any { return &i.V }},
+	{"h_m", func(i *repository.Object) any { return &i.HM }},
+	{"s_m", func(i *repository.Object) any { return &i.SM }},
+	{"v_m", func(i *repository.Object) any { return &i.VM }},
+	{"r_m", func(i *repository.Object) any { return &i.RM }},
+	{"g_m", func(i *repository.Object) any { return &i.GM }},
+	{"b_m", func(i *repository.Object) any { return &i.BM }},
+	{"brt_m", func(i *repository.Object) any { return &i.BrtM }},
+	{"w_m", func(i *repository.Object) any { return &i.WM }},
+	{"l_m", func(i *repository.Object) any { return &i.LM }},
+	{"l", func(i *repository.Object) any { return &i.L }},
+	{"w", func(i *repository.Object) any { return &i.W }},
+	{"l_w", func(i *repository.Object) any { return &i.LW }},
+	{"pr", func(i *repository.Object) any { return &i.Pr }},
+	{"sq", func(i *repository.Object) any { return &i.Sq }},
+	{"brt", func(i *repository.Object) any { return &i.Brt }},
+	{"r", func(i *repository.Object) any { return &i.R }},
+	{"g", func(i *repository.Object) any { return &i.G }},
+	{"b", func(i *repository.Object) any { return &i.B }},
+	{"solid", func(i *repository.Object) any { return &i.Solid }},
+	{"min_h", func(i *repository.Object) any { return &i.MinH }},
+	{"min_s", func(i *repository.Object) any { return &i.MinS }},
+	{"min_v", func(i *repository.Object) any { return &i.MinV }},
+	{"max_h", func(i *repository.Object) any { return &i.MaxH }},
+	{"max_s", func(i *repository.Object) any { return &i.MaxS }},
+	{"max_v", func(i *repository.Object) any { return &i.MaxV }},
+	{"entropy", func(i *repository.Object) any { return &i.Entropy }},
+	{"id_image", func(i *repository.Object) any { return &i.IDImage }},
+	{"color_rhs", func(i *repository.Object) any { return &i.ColorRhs }},
+	{"geometry", func(i *repository.Object) any { return &i.Geometry }},
+	{"sq_sqcrl", func(i *repository.Object) any { return &i.SqSqcrl }},
+	{"hu1", func(i *repository.Object) any { return &i.Hu1 }},
+	{"hu2", func(i *repository.Object) any { return &i.Hu2 }},
+	{"hu3", func(i *repository.Object) any { return &i.Hu3 }},
+	{"hu4", func(i *repository.Object) any { return &i.Hu4 }},
+	{"hu5", func(i *repository.Object) any { return &i.Hu5 }},
+	{"hu6", func(i *repository.Object) any { return &i.Hu6 }},
+	{"class", func(i *repository.Object) any { return &i.Class }},
+}
+
+// objectsPageQuery is repository.GetObjectsByAnalysisID from after_id on, limited,
+// with its column list left to fill in.
+const objectsPageQuery = `SELECT %s
+FROM objects
+WHERE objects.id_analysis = $1
+  AND objects.id > $2
+  AND NOT EXISTS (SELECT 1 FROM analysis da WHERE da.id = objects.id_analysis AND da.deleted_at IS NOT NULL)
+ORDER BY objects.id
+LIMIT $3::int`
+
+// ObjectPages reads the objects of an analysis a page at a time, selecting only
+// the columns a response needs. sqlc can't generate a query whose column list
+// varies, so this one is written by hand, outside the generated repository.
+type ObjectPages struct {
+	db repository.DBTX
+}
+
+func NewObjectPages(db repository.DBTX) *ObjectPages {
+	return &ObjectPages{db: db}
+}
+
+// ObjectsPageParams selects a page of the objects of an analysis.
+type ObjectsPageParams struct {
+	AnalysisID pgtype.Int8
+	AfterID    int32
+	Limit      int32
+	// Columns are the columns to read, the others are left zero; none reads all
+	// of them. The id is always read, as pages are keyed by it.
+	Columns []string
+}
+
+// List reads the objects of an analysis after AfterID, in id order.
+func (p *ObjectPages) List(ctx context.Context, params ObjectsPageParams) ([]repository.Object, error) {
+	for _, name := range params.Columns {
+		if !slices.ContainsFunc(objectColumns, func(column objectColumn) bool { return column.name == name }) {
+			return nil, fmt.Errorf("unknown object column %q", name)
+		}
+	}
+
+	var i repository.Object
+	names := make([]string, 0, len(objectColumns))
+	dest := make([]any, 0, len(objectColumns))
+	for _, column := range objectColumns {
+		if len(params.Columns) == 0 || column.name == "id" || slices.Contains(params.Columns, column.name) {
+			names = append(names, column.name)
+			dest = append(dest, column.dest(&i))
+		}
+	}
+
+	rows, err := p.db.Query(ctx, fmt.Sprintf(objectsPageQuery, strings.Join(names, ", ")), params.AnalysisID, params.AfterID, params.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []repository.Object{}
+	for rows.Next() {
+		i = repository.Object{}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// Each calls fn on the objects of an analysis in id order, reading them a page
+// at a time. No connection is held while fn runs, so a slow consumer, such as a
+// client draining a stream, can't tie up the pool. It stops at the first error,
+// from the database or from fn.
+func (p *ObjectPages) Each(ctx context.Context, analysisID pgtype.Int8, columns []string, fn func(repository.Object) error) error {
+	params := ObjectsPageParams{AnalysisID: analysisID, Limit: objectsPageSize, Columns: columns}
+	for {
+		page, err := p.List(ctx, params)
+		if err != nil {
+			return err
+		}
+		for _, object := range page {
+			if err := fn(object); err != nil {
+				return err
+			}
+		}
+		if len(page) < int(params.Limit) {
+			return nil
+		}
+		params.AfterID = page[len(page)-1].ID
+	}
+}
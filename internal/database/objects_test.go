@@ -0,0 +1,201 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"csort.ru/analysis-service/internal/repository"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// fakeObjects answers objectsPageQuery from objects with ids 1 to count, all of
+// analysis 7 and of class "c<id>".
+type fakeObjects struct {
+	t       *testing.T
+	count   int32
+	queries []string
+}
+
+var selectList = regexp.MustCompile(`^SELECT (\S.*)\nFROM objects\n`)
+
+func (f *fakeObjects) Query(_ context.Context, sql string, args ...any) (pgx.Rows, error) {
+	f.queries = append(f.queries, sql)
+	match := selectList.FindStringSubmatch(sql)
+	if match == nil || strings.Count(sql, "FROM objects") != 1 {
+		f.t.Fatalf("malformed query %q", sql)
+	}
+	columns := strings.Split(match[1], ", ")
+	for _, name := range columns {
+		if !isObjectColumn(name) {
+			f.t.Fatalf("query selects unknown column %q", name)
+		}
+	}
+
+	if analysisID := args[0].(pgtype.Int8); analysisID.Int64 != 7 {
+		return &fakeRows{}, nil
+	}
+	rows := &fakeRows{t: f.t, columns: columns}
+	for id := args[1].(int32) + 1; id <= f.count && len(rows.ids) < int(args[2].(int32)); id++ {
+		rows.ids = append(rows.ids, id)
+	}
+	return rows, nil
+}
+
+func (f *fakeObjects) Exec(context.Context, string, ...any) (pgconn.CommandTag, error) {
+	return pgconn.CommandTag{}, errors.New("not implemented")
+}
+
+func (f *fakeObjects) QueryRow(context.Context, string, ...any) pgx.Row {
+	return nil
+}
+
+func isObjectColumn(name string) bool {
+	for _, column := range objectColumns {
+		if column.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+type fakeRows struct {
+	pgx.Rows
+	t       *testing.T
+	columns []string
+	ids     []int32
+	row     int
+}
+
+func (r *fakeRows) Next() bool {
+	r.row++
+	return r.row <= len(r.ids)
+}
+
+// Scan fills the id and class, and checks there is a destination per column.
+func (r *fakeRows) Scan(dest ...any) error {
+	if len(dest) != len(r.columns) {
+		r.t.Fatalf("got %d scan destinations for %d columns", len(dest), len(r.columns))
+	}
+	id := r.ids[r.row-1]
+	for i, name := range r.columns {
+		switch name {
+		case "id":
+			*dest[i].(*int32) = id
+		case "class":
+			*dest[i].(*pgtype.Text) = pgtype.Text{String: fmt.Sprintf("c%d", id), Valid: true}
+		}
+	}
+	return nil
+}
+
+func (r *fakeRows) Close()     {}
+func (r *fakeRows) Err() error { return nil }
+
+func TestObjectColumnsMatchModel(t *testing.T) {
+	// sqlc generates the fields of repository.Object in the order of the table's
+	// columns, each tagged with its column name
+	model := reflect.TypeOf(repository.Object{})
+	if model.NumField() != len(objectColumns) {
+		t.Fatalf("repository.Object has %d fields, objectColumns %d", model.NumField(), len(objectColumns))
+	}
+	for i, column := range objectColumns {
+		field := model.Field(i)
+		if tag := field.Tag.Get("json"); tag != column.name {
+			t.Errorf("column %d is %q, repository.Object has %q", i, column.name, tag)
+		}
+		var object repository.Object
+		want := reflect.ValueOf(&object).Elem().Field(i).Addr().Interface()
+		if got := column.dest(&object); got != want {
+			t.Errorf("column %q scans into the wrong field, want %s", column.name, field.Name)
+		}
+	}
+}
+
+func TestObjectPagesEach(t *testing.T) {
+	tests := []struct {
+		name        string
+		count       int32
+		wantQueries int
+	}{
+		{"no objects", 0, 1},
+		{"partial page", 10, 1},
+		{"exact pages", 2 * objectsPageSize, 3},
+		{"trailing page", 2*objectsPageSize + 1, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &fakeObjects{t: t, count: tt.count}
+			var ids []int32
+			err := NewObjectPages(db).Each(context.Background(), pgtype.Int8{Int64: 7, Valid: true}, nil, func(object repository.Object) error {
+				ids = append(ids, object.ID)
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(ids) != int(tt.count) {
+				t.Fatalf("got %d objects, want %d", len(ids), tt.count)
+			}
+			for i, id := range ids {
+				if id != int32(i+1) {
+					t.Fatalf("object %d has id %d, want them in id order without gaps", i, id)
+				}
+			}
+			if len(db.queries) != tt.wantQueries {
+				t.Errorf("got %d queries, want %d", len(db.queries), tt.wantQueries)
+			}
+			if got := len(selectList.FindStringSubmatch(db.queries[0])[1]); got == 0 {
+				t.Error("got an empty column list")
+			}
+		})
+	}
+}
+
+func TestObjectPagesProjection(t *testing.T) {
+	db := &fakeObjects{t: t, count: 3}
+	var classes []string
+	err := NewObjectPages(db).Each(context.Background(), pgtype.Int8{Int64: 7, Valid: true}, []string{"class"}, func(object repository.Object) error {
+		classes = append(classes, object.Class.String)
+		if object.File.Valid {
+			t.Error("got a file that wasn't selected")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The id is read for the cursor even when it isn't projected
+	if got := selectList.FindStringSubmatch(db.queries[0])[1]; got != "id, class" {
+		t.Errorf("got columns %q, want id, class", got)
+	}
+	if strings.Join(classes, ",") != "c1,c2,c3" {
+		t.Errorf("got classes %v", classes)
+	}
+
+	if _, err := NewObjectPages(db).List(context.Background(), ObjectsPageParams{Columns: []string{"id; DROP TABLE objects"}}); err == nil {
+		t.Error("got no error for an unknown column")
+	}
+}
+
+func TestObjectPagesEachStops(t *testing.T) {
+	db := &fakeObjects{t: t, count: 2 * objectsPageSize}
+	stop := errors.New("stop")
+	seen := 0
+	err := NewObjectPages(db).Each(context.Background(), pgtype.Int8{Int64: 7, Valid: true}, nil, func(repository.Object) error {
+		seen++
+		if seen == 5 {
+			return stop
+		}
+		return nil
+	})
+	if !errors.Is(err, stop) || seen != 5 || len(db.queries) != 1 {
+		t.Errorf("got error %v after %d objects and %d queries, want to stop at the fifth", err, seen, len(db.queries))
+	}
+}
@@ -0,0 +1,106 @@
+package grpcserver
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	analysisv1 "csort.ru/analysis-service/api/analysis/v1"
+	"csort.ru/analysis-service/internal/models"
+	"csort.ru/analysis-service/internal/services"
+)
+
+type analysisServer struct {
+	analysisv1.UnimplementedAnalysisServiceServer
+	service *services.AnalysisService
+}
+
+func (s *analysisServer) ListAnalyses(ctx context.Context, req *analysisv1.ListAnalysesRequest) (*analysisv1.ListAnalysesResponse, error) {
+	// Checked by the auth interceptor
+	userID, _ := userIDFrom(ctx)
+
+	params := models.GetAnalysesPaginatedRequest{
+		PaginatedRequest: models.PaginatedRequest{
+			Limit:  req.GetLimit(),
+			Offset: req.GetOffset(),
+		},
+		AnalysesFilter: models.AnalysesFilter{
+			Product: req.GetProduct(),
+			ID:      req.GetId(),
+			Verdict: req.GetVerdict(),
+			Tag:     req.GetTag(),
+		},
+		SortBy:    req.GetSortBy(),
+		SortOrder: req.GetSortOrder(),
+	}
+
+	page, err := s.service.GetAnalyses(ctx, userID, params, models.Projection{})
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	response := &analysisv1.ListAnalysesResponse{
+		Analyses: make([]*analysisv1.Analysis, 0, len(page.Data)),
+		Total:    page.Total,
+		Limit:    page.Limit,
+		Offset:   page.Offset,
+	}
+	for i := range page.Data {
+		response.Analyses = append(response.Analyses, analysisMessage(&page.Data[i]))
+	}
+	return response, nil
+}
+
+func (s *analysisServer) GetAnalysis(ctx context.Context, req *analysisv1.GetAnalysisRequest) (*analysisv1.Analysis, error) {
+	ref, err := analysisRef(req.GetRef())
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	labels := models.LabelOptions{Original: req.GetOriginalLabels()}
+	analysis, err := s.service.GetAnalysisByID(ctx, ref, labels, models.Projection{})
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	return analysisMessage(&analysis), nil
+}
+
+// ListAnalysisObjects sends the objects of an analysis as they are read from the
+// database, so large analyses aren't held in memory.
+func (s *analysisServer) ListAnalysisObjects(req *analysisv1.ListAnalysisObjectsRequest, stream analysisv1.AnalysisService_ListAnalysisObjectsServer) error {
+	ref, err := analysisRef(req.GetRef())
+	if err != nil {
+		return errorStatus(err)
+	}
+
+	ctx := stream.Context()
+	labels := models.LabelOptions{Original: req.GetOriginalLabels()}
+	objects, err := s.service.IterObjectsByAnalysisID(ctx, ref, labels, models.Projection{})
+	if err != nil {
+		return errorStatus(err)
+	}
+
+	return errorStatus(objects(ctx, func(object models.Object) error {
+		return stream.Send(objectMessage(&object))
+	}))
+}
+
+// analysisRef converts the reference of a request, validated like the :id and
+// id_type parameters of REST routes.
+func analysisRef(ref *analysisv1.AnalysisRef) (models.AnalysisRef, error) {
+	if ref.GetId() == "" {
+		return models.AnalysisRef{}, fmt.Errorf("%w: missing id", errInvalidRef)
+	}
+
+	switch ref.GetIdType() {
+	case analysisv1.IdType_ID_TYPE_UNSPECIFIED, analysisv1.IdType_ID_TYPE_EXTERNAL:
+		return models.AnalysisRef{ID: ref.GetId(), Type: models.IDTypeExternal}, nil
+	case analysisv1.IdType_ID_TYPE_INTERNAL:
+		if _, err := strconv.ParseInt(ref.GetId(), 10, 32); err != nil {
+			return models.AnalysisRef{}, fmt.Errorf("%w: internal analysis ids are integers", errInvalidRef)
+		}
+		return models.AnalysisRef{ID: ref.GetId(), Type: models.IDTypeInternal}, nil
+	}
+	return models.AnalysisRef{}, fmt.Errorf("%w: unknown id type %d", errInvalidRef, ref.GetIdType())
+}
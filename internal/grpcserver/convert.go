@@ -0,0 +1,229 @@
+package grpcserver
+
+import (
+	analysisv1 "csort.ru/analysis-service/api/analysis/v1"
+	"csort.ru/analysis-service/internal/models"
+
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// The messages of the API mirror the JSON models. Missing measurements stay unset
+// rather than being zeroed, like version 2 REST responses.
+
+func analysisMessage(analysis *models.Analysis) *analysisv1.Analysis {
+	message := &analysisv1.Analysis{
+		Id:           analysis.ID,
+		DateTime:     timestamppb.New(analysis.DateTime),
+		Product:      analysis.Product,
+		ColorRhs:     analysis.ColorRhs,
+		IdUser:       analysis.IDUser,
+		TelegramLink: analysis.TelegramLink,
+		Text:         analysis.Text,
+		FileSource:   analysis.FileSource,
+		ScaleMmPixel: analysis.ScaleMmPixel,
+		Mass:         analysis.Mass,
+		Area:         analysis.Area,
+		R:            statsMessage(analysis.R),
+		G:            statsMessage(analysis.G),
+		B:            statsMessage(analysis.B),
+		H:            statsMessage(analysis.H),
+		S:            statsMessage(analysis.S),
+		V:            statsMessage(analysis.V),
+		LabL:         statsMessage(analysis.LabL),
+		LabA:         statsMessage(analysis.LabA),
+		LabB:         statsMessage(analysis.LabB),
+		W:            statsMessage(analysis.W),
+		L:            statsMessage(analysis.L),
+		T:            statsMessage(analysis.T),
+		FileOutput:   analysis.FileOutput,
+		IdAnalysis:   analysis.IDAnalysis,
+		Version:      analysis.Version,
+		Tags:         analysis.Tags,
+		LotId:        analysis.LotID,
+		Verdict:      verdictMessage(analysis.Verdict),
+	}
+	message.Objects = make([]*analysisv1.Object, 0, len(analysis.Objects))
+	for i := range analysis.Objects {
+		message.Objects = append(message.Objects, objectMessage(&analysis.Objects[i]))
+	}
+	return message
+}
+
+func statsMessage(stats *models.Stats) *analysisv1.Stats {
+	if stats == nil {
+		return nil
+	}
+	return &analysisv1.Stats{
+		Min:    stats.Min,
+		Max:    stats.Max,
+		Avg:    stats.Avg,
+		Median: stats.Median,
+	}
+}
+
+func objectMessage(object *models.Object) *analysisv1.Object {
+	return &analysisv1.Object{
+		Id:            object.ID,
+		IdAnalysis:    object.IdAnalysis,
+		File:          object.File,
+		Class:         object.Class,
+		OriginalClass: object.OriginalClass,
+		Geometry:      object.Geometry,
+		MH:            object.MH,
+		MS:            object.MS,
+		MV:            object.MV,
+		MR:            object.MR,
+		MG:            object.MG,
+		MB:            object.MB,
+		LAvg:          object.LAvg,
+		WAvg:          object.WAvg,
+		BrtAvg:        object.BrtAvg,
+		RAvg:          object.RAvg,
+		GAvg:          object.GAvg,
+		BAvg:          object.BAvg,
+		HAvg:          object.HAvg,
+		SAvg:          object.SAvg,
+		VAvg:          object.VAvg,
+		H:             object.H,
+		S:             object.S,
+		V:             object.V,
+		HM:            object.HM,
+		SM:            object.SM,
+		VM:            object.VM,
+		RM:            object.RM,
+		GM:            object.GM,
+		BM:            object.BM,
+		BrtM:          object.BrtM,
+		WM:            object.WM,
+		LM:            object.LM,
+		L:             object.L,
+		W:             object.W,
+		LW:            object.LW,
+		Pr:            object.Pr,
+		Sq:            object.Sq,
+		Brt:           object.Brt,
+		R:             object.R,
+		G:             object.G,
+		B:             object.B,
+		Solid:         object.Solid,
+		MinH:          object.MinH,
+		MinS:          object.MinS,
+		MinV:          object.MinV,
+		MaxH:          object.MaxH,
+		MaxS:          object.MaxS,
+		MaxV:          object.MaxV,
+		Entropy:       object.Entropy,
+		IdImage:       object.IDImage,
+		ColorRhs:      object.ColorRhs,
+		SqSqcrl:       object.SqSqcrl,
+		Hu1:           object.Hu1,
+		Hu2:           object.Hu2,
+		Hu3:           object.Hu3,
+		Hu4:           object.Hu4,
+		Hu5:           object.Hu5,
+		Hu6:           object.Hu6,
+	}
+}
+
+// metadataMessage converts the metadata of an object, which lacks the fields
+// tying it to its analysis and image.
+func metadataMessage(object *models.ObjectMetadata) *analysisv1.Object {
+	return &analysisv1.Object{
+		Id:       object.ID,
+		Class:    object.Class,
+		Geometry: object.Geometry,
+		MH:       object.MH,
+		MS:       object.MS,
+		MV:       object.MV,
+		MR:       object.MR,
+		MG:       object.MG,
+		MB:       object.MB,
+		LAvg:     object.LAvg,
+		WAvg:     object.WAvg,
+		BrtAvg:   object.BrtAvg,
+		RAvg:     object.RAvg,
+		GAvg:     object.GAvg,
+		BAvg:     object.BAvg,
+		HAvg:     object.HAvg,
+		SAvg:     object.SAvg,
+		VAvg:     object.VAvg,
+		H:        object.H,
+		S:        object.S,
+		V:        object.V,
+		HM:       object.HM,
+		SM:       object.SM,
+		VM:       object.VM,
+		RM:       object.RM,
+		GM:       object.GM,
+		BM:       object.BM,
+		BrtM:     object.BrtM,
+		WM:       object.WM,
+		LM:       object.LM,
+		L:        object.L,
+		W:        object.W,
+		LW:       object.LW,
+		Pr:       object.Pr,
+		Sq:       object.Sq,
+		Brt:      object.Brt,
+		R:        object.R,
+		G:        object.G,
+		B:        object.B,
+		Solid:    object.Solid,
+		MinH:     object.MinH,
+		MinS:     object.MinS,
+		MinV:     object.MinV,
+		MaxH:     object.MaxH,
+		MaxS:     object.MaxS,
+		MaxV:     object.MaxV,
+		Entropy:  object.Entropy,
+		ColorRhs: object.ColorRhs,
+		SqSqcrl:  object.SqSqcrl,
+		Hu1:      object.Hu1,
+		Hu2:      object.Hu2,
+		Hu3:      object.Hu3,
+		Hu4:      object.Hu4,
+		Hu5:      object.Hu5,
+		Hu6:      object.Hu6,
+	}
+}
+
+func verdictMessage(verdict *models.SpecVerdict) *analysisv1.SpecVerdict {
+	if verdict == nil {
+		return nil
+	}
+	message := &analysisv1.SpecVerdict{
+		SpecId:      verdict.SpecID,
+		Verdict:     verdict.Verdict,
+		EvaluatedAt: timestamppb.New(verdict.EvaluatedAt),
+		Violations:  make([]*analysisv1.SpecViolation, 0, len(verdict.Violations)),
+	}
+	for _, violation := range verdict.Violations {
+		// Actual is a number, a string or null, depending on the rule
+		actual, err := structpb.NewValue(violation.Actual)
+		if err != nil {
+			grpcLog.Warn().Err(err).Int32("specID", verdict.SpecID).Msg("Failed to convert violation value")
+			actual = nil
+		}
+		message.Violations = append(message.Violations, &analysisv1.SpecViolation{
+			Rule:     ruleMessage(violation.Rule),
+			Severity: violation.Severity,
+			Actual:   actual,
+			Message:  violation.Message,
+		})
+	}
+	return message
+}
+
+func ruleMessage(rule models.SpecRule) *analysisv1.SpecRule {
+	return &analysisv1.SpecRule{
+		Type:     rule.Type,
+		Severity: rule.Severity,
+		Class:    rule.Class,
+		Channel:  rule.Channel,
+		Field:    rule.Field,
+		Min:      rule.Min,
+		Max:      rule.Max,
+		Values:   rule.Values,
+	}
+}
@@ -0,0 +1,76 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+
+	"csort.ru/analysis-service/internal/apperr"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	errInvalidUserID = apperr.Validation("invalid_user_id", "Invalid telegram-user-id format")
+	errInvalidRef    = apperr.Validation("invalid_parameter", "invalid analysis reference")
+)
+
+// errorDomain is the ErrorInfo domain of the API's error codes.
+const errorDomain = "csort.ru"
+
+// internalErrorCode is the code of errors that aren't domain errors.
+const internalErrorCode = "internal_error"
+
+// codeByKind maps domain error kinds to gRPC status codes.
+var codeByKind = map[apperr.Kind]codes.Code{
+	apperr.KindValidation:          codes.InvalidArgument,
+	apperr.KindNotFound:            codes.NotFound,
+	apperr.KindForbidden:           codes.PermissionDenied,
+	apperr.KindConflict:            codes.AlreadyExists,
+	apperr.KindPrecondition:        codes.FailedPrecondition,
+	apperr.KindUpstreamUnavailable: codes.Unavailable,
+}
+
+// errorStatus converts an error returned by a service to a status, with the
+// error code in a google.rpc.ErrorInfo detail. Domain errors get the status code
+// of their kind and keep their message; anything else is an internal error,
+// logged but not shown to the client.
+func errorStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	code, errorCode, message := codes.Internal, internalErrorCode, "internal server error"
+	switch appErr, ok := apperr.As(err); {
+	case ok && appErr.Kind != apperr.KindInternal:
+		code, errorCode, message = codeByKind[appErr.Kind], appErr.Code, err.Error()
+		// Upstream errors are wrapped with transport details that stay in the logs
+		if appErr.Kind == apperr.KindUpstreamUnavailable {
+			message = appErr.Message
+		}
+	case errors.Is(err, context.Canceled):
+		code, errorCode, message = codes.Canceled, "canceled", "call canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		code, errorCode, message = codes.DeadlineExceeded, "deadline_exceeded", "deadline exceeded"
+	default:
+		grpcLog.Error().Err(err).Msg("Call failed")
+	}
+
+	return newStatus(code, errorCode, message)
+}
+
+// newStatus returns a status error with errorCode in its ErrorInfo detail.
+func newStatus(code codes.Code, errorCode, message string) error {
+	st, err := status.New(code, message).WithDetails(&errdetails.ErrorInfo{
+		Reason: errorCode,
+		Domain: errorDomain,
+	})
+	if err != nil {
+		return status.Error(code, message)
+	}
+	return st.Err()
+}
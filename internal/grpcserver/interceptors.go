@@ -0,0 +1,137 @@
+package grpcserver
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	analysisv1 "csort.ru/analysis-service/api/analysis/v1"
+	"csort.ru/analysis-service/internal/logger"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// userIDMetadataKey is the metadata key of the caller's Telegram id, the
+// Telegram-User-ID header of the REST API.
+const userIDMetadataKey = "telegram-user-id"
+
+// userMethods are the methods that act on behalf of a user, and so require
+// telegram-user-id.
+var userMethods = map[string]bool{
+	analysisv1.AnalysisService_ListAnalyses_FullMethodName: true,
+}
+
+type userIDKey struct{}
+
+// userIDFrom returns the caller's Telegram id, as read by the auth interceptors.
+func userIDFrom(ctx context.Context) (int64, bool) {
+	userID, ok := ctx.Value(userIDKey{}).(int64)
+	return userID, ok
+}
+
+// authenticate reads telegram-user-id into the context. It is required by
+// userMethods, and must be an integer wherever it is given.
+func authenticate(ctx context.Context, method string) (context.Context, error) {
+	values := metadata.ValueFromIncomingContext(ctx, userIDMetadataKey)
+	if len(values) == 0 || values[0] == "" {
+		if userMethods[method] {
+			return nil, newStatus(codes.Unauthenticated, "unauthorized", "telegram-user-id metadata is required")
+		}
+		return ctx, nil
+	}
+
+	userID, err := strconv.ParseInt(values[0], 10, 64)
+	if err != nil {
+		grpcLog.Error().Err(err).Str("userIDStr", values[0]).Msg("Invalid telegram-user-id format")
+		return nil, errorStatus(errInvalidUserID)
+	}
+	return context.WithValue(ctx, userIDKey{}, userID), nil
+}
+
+func authUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, err := authenticate(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func authStream(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := authenticate(stream.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &contextStream{ServerStream: stream, ctx: ctx})
+}
+
+// contextStream is a stream with a context derived from its own.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+// logCall logs a finished call with the fields the REST request log has.
+func logCall(ctx context.Context, method string, start time.Time, err error) {
+	event := logger.Logger.Info()
+	if code := status.Code(err); code == codes.Internal || code == codes.Unknown {
+		event = logger.Logger.Error().Err(err)
+	}
+	ip := ""
+	if p, ok := peer.FromContext(ctx); ok {
+		ip = p.Addr.String()
+	}
+	event.
+		Str("status", status.Code(err).String()).
+		Str("method", method).
+		Str("ip", ip).
+		Dur("latency", time.Since(start)).
+		Msg("gRPC call")
+}
+
+func logUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	logCall(ctx, info.FullMethod, start, err)
+	return resp, err
+}
+
+func logStream(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, stream)
+	logCall(stream.Context(), info.FullMethod, start, err)
+	return err
+}
+
+// recovered turns a panic of a call into an internal error, so it doesn't take
+// the server down.
+func recovered(method string, r any) error {
+	grpcLog.Error().Str("method", method).Msg(fmt.Sprint(r))
+	return newStatus(codes.Internal, internalErrorCode, "internal server error")
+}
+
+func recoverUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = recovered(info.FullMethod, r)
+		}
+	}()
+	return handler(ctx, req)
+}
+
+func recoverStream(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = recovered(info.FullMethod, r)
+		}
+	}()
+	return handler(srv, stream)
+}
@@ -0,0 +1,25 @@
+package grpcserver
+
+import (
+	analysisv1 "csort.ru/analysis-service/api/analysis/v1"
+	"csort.ru/analysis-service/internal/services"
+)
+
+type objectsServer struct {
+	analysisv1.UnimplementedObjectsServiceServer
+	service *services.ObjectsService
+}
+
+func (s *objectsServer) ListObjects(req *analysisv1.ListObjectsRequest, stream analysisv1.ObjectsService_ListObjectsServer) error {
+	objects, err := s.service.GetObjects(stream.Context(), req.GetIds())
+	if err != nil {
+		return errorStatus(err)
+	}
+
+	for _, object := range objects {
+		if err := stream.Send(metadataMessage(object)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+// Package grpcserver serves the analysis API over gRPC, next to the REST API and
+// backed by the same services. The API is defined in api/analysis/v1.
+package grpcserver
+
+import (
+	"errors"
+
+	analysisv1 "csort.ru/analysis-service/api/analysis/v1"
+	"csort.ru/analysis-service/internal/logger"
+	"csort.ru/analysis-service/internal/services"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/reflection"
+)
+
+var grpcLog = logger.GetLogger("grpcserver")
+
+// Config configures the transport of the gRPC server. Both are off by default,
+// so the API isn't exposed more than asked for.
+type Config struct {
+	// Reflection registers the reflection service, which lets tools such as
+	// grpcurl list and call the services without the .proto files
+	Reflection bool
+	// CertFile and KeyFile are the TLS certificate and key, both empty for plaintext
+	CertFile string
+	KeyFile  string
+}
+
+// New creates a gRPC server with the analysis and objects services registered.
+// Every call is logged and recovered from panics, and the caller's
+// telegram-user-id metadata is checked, as the REST middleware does.
+func New(cfg Config, analysis *services.AnalysisService, objects *services.ObjectsService) (*grpc.Server, error) {
+	opts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(logUnary, recoverUnary, authUnary),
+		grpc.ChainStreamInterceptor(logStream, recoverStream, authStream),
+	}
+	if (cfg.CertFile == "") != (cfg.KeyFile == "") {
+		return nil, errors.New("gRPC TLS needs both a certificate and a key file")
+	}
+	if cfg.CertFile != "" {
+		creds, err := credentials.NewServerTLSFromFile(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.Creds(creds))
+	} else {
+		grpcLog.Warn().Msg("Serving gRPC without TLS")
+	}
+	server := grpc.NewServer(opts...)
+
+	analysisv1.RegisterAnalysisServiceServer(server, &analysisServer{service: analysis})
+	analysisv1.RegisterObjectsServiceServer(server, &objectsServer{service: objects})
+	if cfg.Reflection {
+		reflection.Register(server)
+	}
+
+	return server, nil
+}
@@ -1,91 +1,300 @@
 package handlers
 
 import (
+	"bufio"
+	"context"
+	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
+	"csort.ru/analysis-service/internal/apperr"
 	"csort.ru/analysis-service/internal/logger"
+	"csort.ru/analysis-service/internal/middleware"
 	"csort.ru/analysis-service/internal/models"
 	"csort.ru/analysis-service/internal/services"
-	"csort.ru/analysis-service/pkg/utils"
 	"github.com/bytedance/sonic"
 	"github.com/gofiber/fiber/v2"
 )
 
 var analysisHandlerLog = logger.GetLogger("handlers.analysis")
 
+var (
+	errInvalidIfMatch = apperr.Precondition("invalid_if_match", "If-Match does not match the analysis version")
+	errFileRequired   = apperr.Validation("file_required", "file is required")
+)
+
 type AnalysisHandler struct {
 	service *services.AnalysisService
+	lots    *services.LotsService
 }
 
-func NewAnalysisHandler(service *services.AnalysisService) *AnalysisHandler {
+func NewAnalysisHandler(service *services.AnalysisService, lots *services.LotsService) *AnalysisHandler {
 	return &AnalysisHandler{
 		service: service,
+		lots:    lots,
 	}
 }
 
 func (h *AnalysisHandler) GetAnalyses(c *fiber.Ctx) error {
 	// Extract Telegram-User-ID from header
-	userIDStr := c.Get("Telegram-User-ID")
-	if userIDStr == "" {
-		analysisHandlerLog.Error().Msg("Telegram-User-ID header is missing")
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Telegram-User-ID header is required"})
-	}
-
-	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	userID, err := requireUserID(c)
 	if err != nil {
-		analysisHandlerLog.Error().Err(err).Str("userIDStr", userIDStr).Msg("Invalid Telegram-User-ID format")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid Telegram-User-ID format"})
+		return err
 	}
 
 	var params models.GetAnalysesPaginatedRequest
 	if err := c.QueryParser(&params); err != nil {
 		analysisHandlerLog.Error().Err(err).Msg("Error parsing query params")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid query params"})
+		return errInvalidQuery
 	}
 
-	paginatedResponse, err := h.service.GetAnalyses(c.Context(), userID, params)
+	fields, err := parseProjection(c, models.AnalysisFields)
 	if err != nil {
-		analysisHandlerLog.Error().Err(err).Int64("userID", userID).Msg("Error getting analyses")
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal server error"})
+		return err
+	}
+
+	paginatedResponse, err := h.service.GetAnalyses(c.Context(), userID, params, fields)
+	if err != nil {
+		return err
 	}
 
-	return c.JSON(paginatedResponse)
+	return sendProjectedPage(c, fields, paginatedResponse)
 }
 
 func (h *AnalysisHandler) GetAnalysisByID(c *fiber.Ctx) error {
-	id, err := utils.ParseParamWithType[string](c, "id")
+	ref, err := parseAnalysisRef(c, "id")
 	if err != nil {
-		analysisHandlerLog.Error().Err(err).Msg("Failed to parse ID parameter")
 		return err
 	}
 
-	analysis, err := h.service.GetAnalysisByID(c.Context(), id)
+	var labels models.LabelOptions
+	if err := c.QueryParser(&labels); err != nil {
+		analysisHandlerLog.Error().Err(err).Msg("Error parsing query params")
+		return errInvalidQuery
+	}
+
+	fields, err := parseProjection(c, models.AnalysisFields)
 	if err != nil {
-		analysisHandlerLog.Error().Err(err).Str("analysisID", id).Msg("Error getting analysis by id")
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal server error"})
+		return err
+	}
+
+	analysis, err := h.service.GetAnalysisByID(c.Context(), ref, labels, fields)
+	if err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderETag, analysisETag(analysis.Version))
+	return sendProjected(c, fields, &analysis)
+}
+
+// PatchAnalysis edits the product, notes and tags of an analysis. The request must
+// carry the ETag of the version it was based on in If-Match, so concurrent edits
+// are rejected instead of overwriting each other.
+func (h *AnalysisHandler) PatchAnalysis(c *fiber.Ctx) error {
+	userID, err := requireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	ref, err := parseAnalysisRef(c, "id")
+	if err != nil {
+		return err
+	}
+
+	ifMatch := c.Get(fiber.HeaderIfMatch)
+	if ifMatch == "" {
+		return fiber.NewError(fiber.StatusPreconditionRequired, "If-Match header is required")
+	}
+	version, ok := parseAnalysisETag(ifMatch)
+	if !ok {
+		return errInvalidIfMatch
+	}
+
+	var request models.AnalysisPatchRequest
+	if err := c.BodyParser(&request); err != nil {
+		return errInvalidBody
+	}
+
+	analysis, err := h.service.PatchAnalysis(c.Context(), userID, ref, version, request)
+	if err != nil {
+		return err
 	}
 
-	return c.JSON(analysis)
+	c.Set(fiber.HeaderETag, analysisETag(analysis.Version))
+	return sendVersioned(c, &analysis)
 }
 
+// DeleteAnalysis soft-deletes an analysis; it can be restored until the retention
+// job purges it.
+func (h *AnalysisHandler) DeleteAnalysis(c *fiber.Ctx) error {
+	userID, err := requireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	ref, err := parseAnalysisRef(c, "id")
+	if err != nil {
+		return err
+	}
+
+	if err := h.service.DeleteAnalysis(c.Context(), userID, ref); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// RestoreAnalysis undoes a soft delete and returns the restored analysis.
+func (h *AnalysisHandler) RestoreAnalysis(c *fiber.Ctx) error {
+	userID, err := requireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	ref, err := parseAnalysisRef(c, "id")
+	if err != nil {
+		return err
+	}
+
+	analysis, err := h.service.RestoreAnalysis(c.Context(), userID, ref)
+	if err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderETag, analysisETag(analysis.Version))
+	return sendVersioned(c, &analysis)
+}
+
+// GetAnalysisAudit returns the deletion history of an analysis, including the
+// tombstone of a purged one.
+func (h *AnalysisHandler) GetAnalysisAudit(c *fiber.Ctx) error {
+	userID, err := requireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	ref, err := parseAnalysisRef(c, "id")
+	if err != nil {
+		return err
+	}
+
+	entries, err := h.service.GetAudit(c.Context(), userID, ref)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(entries)
+}
+
+// GetAnalysisObjects streams the objects of an analysis. The route took the
+// internal id before analyses could be addressed either way, so id_type is
+// required.
 func (h *AnalysisHandler) GetAnalysisObjects(c *fiber.Ctx) error {
-	id, err := utils.ParseParamWithType[string](c, "id")
+	ref, err := parseTypedAnalysisRef(c, "id")
 	if err != nil {
-		analysisHandlerLog.Error().Err(err).Msg("Failed to parse ID parameter")
 		return err
 	}
 
-	analysisHandlerLog.Info().Str("id_analysis", id).Msg("Fetching objects for analysis")
+	analysisHandlerLog.Info().Str("analysisID", ref.String()).Msg("Fetching objects for analysis")
+
+	var labels models.LabelOptions
+	if err := c.QueryParser(&labels); err != nil {
+		analysisHandlerLog.Error().Err(err).Msg("Error parsing query params")
+		return errInvalidQuery
+	}
+
+	fields, err := parseProjection(c, models.ObjectFields)
+	if err != nil {
+		return err
+	}
+	version, err := responseVersion(c)
+	if err != nil {
+		return err
+	}
 
-	objects, err := h.service.GetObjectsByAnalysisID(c.Context(), id)
+	objects, err := h.service.IterObjectsByAnalysisID(c.Context(), ref, labels, fields)
 	if err != nil {
-		analysisHandlerLog.Error().Err(err).Str("analysisID", id).Msg("Error getting analysis objects by id")
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal server error"})
+		return err
+	}
+
+	return streamObjects(c, version, fields, objects)
+}
+
+// mimeApplicationNDJSON is the media type of newline-delimited JSON.
+const mimeApplicationNDJSON = "application/x-ndjson"
+
+// objectsStreamTimeout bounds how long a single object list may keep streaming.
+const objectsStreamTimeout = 5 * time.Minute
+
+// streamObjects sends the projected fields of objects as they are read from the
+// database: as the JSON array result of the usual envelope, or as one object per
+// line without an envelope for clients accepting application/x-ndjson. The
+// status line is sent before the first object is read, so a failure midway cuts
+// the body short.
+func streamObjects(c *fiber.Ctx, version int, fields models.Projection, objects services.ObjectIterator) error {
+	setResponseVersion(c, version)
+	c.Vary(fiber.HeaderAccept)
+
+	encode := func(w *bufio.Writer, object models.Object) error {
+		if version < models.ResponseV2 {
+			models.ZeroNulls(&object)
+		}
+		data, err := marshalProjected(fields, &object)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
 	}
 
-	return c.JSON(objects)
+	if c.Accepts(fiber.MIMEApplicationJSON, mimeApplicationNDJSON) == mimeApplicationNDJSON {
+		path := c.Path()
+		c.Set(fiber.HeaderContentType, mimeApplicationNDJSON)
+		c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+			ctx, cancel := context.WithTimeout(context.Background(), objectsStreamTimeout)
+			defer cancel()
+
+			err := objects(ctx, func(object models.Object) error {
+				if err := encode(w, object); err != nil {
+					return err
+				}
+				return w.WriteByte('\n')
+			})
+			if err != nil {
+				analysisHandlerLog.Error().Err(err).Str("path", path).Msg("Object stream failed midway")
+				return
+			}
+			if err := w.Flush(); err != nil {
+				analysisHandlerLog.Warn().Err(err).Str("path", path).Msg("Failed to flush object stream")
+			}
+		})
+		return nil
+	}
+
+	return middleware.SendJSONStream(c, func(w *bufio.Writer) error {
+		ctx, cancel := context.WithTimeout(context.Background(), objectsStreamTimeout)
+		defer cancel()
+
+		if err := w.WriteByte('['); err != nil {
+			return err
+		}
+		first := true
+		err := objects(ctx, func(object models.Object) error {
+			if !first {
+				if err := w.WriteByte(','); err != nil {
+					return err
+				}
+			}
+			first = false
+			return encode(w, object)
+		})
+		if err != nil {
+			return err
+		}
+		return w.WriteByte(']')
+	})
 }
 
 func (h *AnalysisHandler) CreateAnalysis(c *fiber.Ctx) error {
@@ -95,11 +304,38 @@ func (h *AnalysisHandler) CreateAnalysis(c *fiber.Ctx) error {
 	// Validate required fields
 	if product == "" {
 		analysisHandlerLog.Error().Msg("Product field is missing")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "product field is required"})
+		return fmt.Errorf("%w: product field is required", errInvalidBody)
 	}
 	if userID == "" {
 		analysisHandlerLog.Error().Msg("UserID field is missing")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "userID field is required"})
+		return fmt.Errorf("%w: userID field is required", errInvalidBody)
+	}
+
+	// Normalize the product against the catalog
+	resolved, err := h.service.ResolveProduct(c.Context(), userID, product)
+	if err != nil {
+		return err
+	}
+	product = resolved.Code
+
+	// The analysis can be attached to a lot of its user right away
+	var lotID int32
+	var lotOwner int64
+	if value := c.FormValue("lot_id"); value != "" {
+		parsed, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			return fmt.Errorf("%w: lot_id must be an integer", errInvalidBody)
+		}
+		lotID = int32(parsed)
+		if lotOwner, err = strconv.ParseInt(userID, 10, 64); err != nil {
+			return fmt.Errorf("%w: userID must be an integer to attach to a lot", errInvalidBody)
+		}
+		// An unknown lot is a bad form field here, not a missing resource
+		if _, err := h.lots.GetLot(c.Context(), lotOwner, lotID); errors.Is(err, services.ErrLotNotFound) {
+			return fmt.Errorf("%w: lot not found", errInvalidBody)
+		} else if err != nil {
+			return err
+		}
 	}
 
 	analysisHandlerLog.Info().Str("product", product).Str("userID", userID).Msg("Creating analysis")
@@ -107,17 +343,17 @@ func (h *AnalysisHandler) CreateAnalysis(c *fiber.Ctx) error {
 	fileHeader, err := c.FormFile("files")
 	if err != nil {
 		analysisHandlerLog.Error().Err(err).Msg("Failed to get file")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "file is required"})
+		return errFileRequired
 	}
 
 	// Validate file
 	if fileHeader == nil {
 		analysisHandlerLog.Error().Msg("File header is nil")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "file is required"})
+		return errFileRequired
 	}
 	if fileHeader.Size == 0 {
 		analysisHandlerLog.Error().Msg("File is empty")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "file cannot be empty"})
+		return fmt.Errorf("%w: file cannot be empty", errInvalidBody)
 	}
 
 	analysisHandlerLog.Info().
@@ -129,15 +365,15 @@ func (h *AnalysisHandler) CreateAnalysis(c *fiber.Ctx) error {
 	file, err := fileHeader.Open()
 	if err != nil {
 		analysisHandlerLog.Error().Err(err).Msg("Failed to open file")
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to open file"})
+		return err
 	}
 	defer file.Close()
 
 	// Call the updated ProxyAnalysisAPICall method
-	status, headers, body, err := h.service.ProxyAnalysisAPICall(c.Context(), product, userID, fileHeader.Filename, file)
+	status, headers, body, err := h.service.ProxyAnalysisAPICall(c.Context(), resolved, userID, fileHeader.Filename, file)
 	if err != nil {
 		analysisHandlerLog.Error().Err(err).Msg("Failed to contact analysis API")
-		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{"error": "failed to contact analysis API"})
+		return err
 	}
 
 	analysisHandlerLog.Info().
@@ -156,7 +392,7 @@ func (h *AnalysisHandler) CreateAnalysis(c *fiber.Ctx) error {
 				Err(err).
 				Str("body", string(body)).
 				Msg("Failed to unmarshal success response from analysis API")
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "invalid response format from analysis API"})
+			return fmt.Errorf("%w: invalid response format", services.ErrAnalysisAPIUnavailable)
 		}
 
 		analysisHandlerLog.Info().Str("analysisID", resp.Response).Msg("Analysis created successfully")
@@ -164,63 +400,52 @@ func (h *AnalysisHandler) CreateAnalysis(c *fiber.Ctx) error {
 		// Wait 2-5 seconds to allow analysis to be added to DB
 		time.Sleep(3 * time.Second)
 
-		analysis, err := h.service.GetAnalysisByID(c.Context(), resp.Response)
+		if lotID != 0 {
+			if _, err := h.lots.AttachAnalyses(c.Context(), lotOwner, lotID, []models.AnalysisRef{{ID: resp.Response}}); err != nil {
+				analysisHandlerLog.Error().
+					Err(err).
+					Str("analysisID", resp.Response).
+					Int32("lotID", lotID).
+					Msg("Failed to attach analysis to lot")
+			}
+		}
+
+		analysis, err := h.service.GetAnalysisByID(c.Context(), models.AnalysisRef{ID: resp.Response}, models.LabelOptions{}, models.Projection{})
 		if err != nil {
 			analysisHandlerLog.Error().
 				Err(err).
 				Str("analysisID", resp.Response).
 				Msg("Failed to fetch analysis")
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to fetch analysis"})
+			// Not a not-found for the client: the analysis was just created
+			return fmt.Errorf("failed to fetch created analysis %s: %v", resp.Response, err)
 		}
 
-		return c.JSON(analysis)
-
-	case fiber.StatusBadRequest:
-		// Try to parse JSON, but fallback to plain text
-		var resp struct {
-			Response string `json:"Response"`
-		}
-		errorMsg := string(body)
-		if err := sonic.Unmarshal(body, &resp); err == nil && resp.Response != "" {
-			errorMsg = resp.Response
-		}
-
-		analysisHandlerLog.Error().
-			Int("status", status).
-			Str("response", errorMsg).
-			Msg("Bad request from analysis API")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": errorMsg})
-
-	case fiber.StatusInternalServerError:
-		// Handle 500 errors specifically
-		errorMsg := string(body)
-		var resp struct {
-			Response string `json:"Response"`
-		}
-		if err := sonic.Unmarshal(body, &resp); err == nil && resp.Response != "" {
-			errorMsg = resp.Response
-		}
+		return sendVersioned(c, &analysis)
 
+	default:
 		analysisHandlerLog.Error().
 			Int("status", status).
-			Str("response", errorMsg).
-			Msg("Internal server error from analysis API")
-		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{"error": fmt.Sprintf("analysis API internal error: %s", errorMsg)})
+			Str("response", string(body)).
+			Msg("Analysis API request failed")
+		return services.AnalysisAPIError(status, body)
+	}
+}
 
-	default:
-		// For other errors, the response might be plain text
-		errorMsg := string(body)
-		var resp struct {
-			Response string `json:"Response"`
-		}
-		if err := sonic.Unmarshal(body, &resp); err == nil && resp.Response != "" {
-			errorMsg = resp.Response
-		}
+func analysisETag(version int32) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
 
-		analysisHandlerLog.Error().
-			Int("status", status).
-			Str("response", errorMsg).
-			Msg("Unexpected response from analysis API")
-		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{"error": fmt.Sprintf("analysis API error (status %d): %s", status, errorMsg)})
+// parseAnalysisETag reads the version out of an If-Match value; "*" matches any
+// version and is returned as zero.
+func parseAnalysisETag(value string) (int32, bool) {
+	value = strings.TrimSpace(value)
+	if value == "*" {
+		return 0, true
+	}
+	value = strings.Trim(strings.TrimPrefix(value, "W/"), `"`)
+	version, err := strconv.ParseInt(value, 10, 32)
+	if err != nil || version <= 0 {
+		return 0, false
 	}
+	return int32(version), true
 }
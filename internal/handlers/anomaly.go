@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"csort.ru/analysis-service/internal/logger"
+	"csort.ru/analysis-service/internal/models"
+	"csort.ru/analysis-service/internal/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+var anomalyHandlerLog = logger.GetLogger("handlers.anomaly")
+
+type AnomalyHandler struct {
+	service *services.AnomalyService
+}
+
+func NewAnomalyHandler(service *services.AnomalyService) *AnomalyHandler {
+	return &AnomalyHandler{
+		service: service,
+	}
+}
+
+// GetAnalysisAnomalies flags anomalous objects of an analysis, e.g.
+// ?features=geometry,h_avg&method=mahalanobis&threshold=4&all=true
+func (h *AnomalyHandler) GetAnalysisAnomalies(c *fiber.Ctx) error {
+	ref, err := parseAnalysisRef(c, "id")
+	if err != nil {
+		return err
+	}
+
+	var request models.AnomalyRequest
+	if err := c.QueryParser(&request); err != nil {
+		anomalyHandlerLog.Error().Err(err).Msg("Error parsing query params")
+		return errInvalidQuery
+	}
+
+	report, err := h.service.DetectAnomalies(c.Context(), ref, request)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(report)
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"csort.ru/analysis-service/internal/logger"
+	"csort.ru/analysis-service/internal/models"
+	"csort.ru/analysis-service/internal/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+var compositionHandlerLog = logger.GetLogger("handlers.composition")
+
+type CompositionHandler struct {
+	service *services.CompositionService
+}
+
+func NewCompositionHandler(service *services.CompositionService) *CompositionHandler {
+	return &CompositionHandler{
+		service: service,
+	}
+}
+
+func (h *CompositionHandler) GetAnalysisComposition(c *fiber.Ctx) error {
+	ref, err := parseAnalysisRef(c, "id")
+	if err != nil {
+		return err
+	}
+
+	composition, err := h.service.GetAnalysisComposition(c.Context(), ref)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(composition)
+}
+
+func (h *CompositionHandler) GetComposition(c *fiber.Ctx) error {
+	userID, err := requireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var filter models.AnalysesFilter
+	if err := c.QueryParser(&filter); err != nil {
+		compositionHandlerLog.Error().Err(err).Msg("Error parsing query params")
+		return errInvalidQuery
+	}
+
+	composition, err := h.service.GetComposition(c.Context(), userID, filter)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(composition)
+}
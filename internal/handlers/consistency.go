@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"csort.ru/analysis-service/internal/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+type ConsistencyHandler struct {
+	service *services.ConsistencyService
+}
+
+func NewConsistencyHandler(service *services.ConsistencyService) *ConsistencyHandler {
+	return &ConsistencyHandler{
+		service: service,
+	}
+}
+
+// CheckStats reports discrepancies between the stored Stats and the ones recomputed from objects.
+func (h *ConsistencyHandler) CheckStats(c *fiber.Ctx) error {
+	ref, err := parseAnalysisRef(c, "id")
+	if err != nil {
+		return err
+	}
+
+	report, err := h.service.CheckAnalysis(c.Context(), ref, services.StatsRepairNone)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(report)
+}
+
+// RepairStats rewrites missing and corrupt Stats of the user's analysis, and
+// mismatched ones too if ?mismatched=true.
+func (h *ConsistencyHandler) RepairStats(c *fiber.Ctx) error {
+	userID, err := requireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	ref, err := parseAnalysisRef(c, "id")
+	if err != nil {
+		return err
+	}
+
+	mode := services.StatsRepairBroken
+	if c.QueryBool("mismatched") {
+		mode = services.StatsRepairAll
+	}
+
+	report, err := h.service.RepairAnalysis(c.Context(), userID, ref, mode)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(report)
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"csort.ru/analysis-service/internal/logger"
+	"csort.ru/analysis-service/internal/models"
+	"csort.ru/analysis-service/internal/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+var datasetHandlerLog = logger.GetLogger("handlers.dataset")
+
+type DatasetHandler struct {
+	service *services.DatasetService
+}
+
+func NewDatasetHandler(service *services.DatasetService) *DatasetHandler {
+	return &DatasetHandler{
+		service: service,
+	}
+}
+
+func (h *DatasetHandler) ExportObjects(c *fiber.Ctx) error {
+	userID, err := requireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var request models.DatasetExportRequest
+	if err := c.QueryParser(&request); err != nil {
+		datasetHandlerLog.Error().Err(err).Msg("Error parsing query params")
+		return errInvalidQuery
+	}
+
+	export, err := h.service.ExportObjects(c.Context(), userID, request)
+	if err != nil {
+		return err
+	}
+
+	return streamExport(c, export)
+}
+
+// ExportCoco exports a COCO detection dataset, e.g. ?product=wheat&split=0.8,0.1,0.1&images=true
+func (h *DatasetHandler) ExportCoco(c *fiber.Ctx) error {
+	userID, err := requireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var request models.CocoExportRequest
+	if err := c.QueryParser(&request); err != nil {
+		datasetHandlerLog.Error().Err(err).Msg("Error parsing query params")
+		return errInvalidQuery
+	}
+
+	export, err := h.service.ExportCoco(c.Context(), userID, request)
+	if err != nil {
+		return err
+	}
+
+	return streamExport(c, export)
+}
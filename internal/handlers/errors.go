@@ -0,0 +1,13 @@
+package handlers
+
+import "csort.ru/analysis-service/internal/apperr"
+
+// Errors of malformed requests, before they reach a service. Handlers return them,
+// like service errors, for middleware.ErrorHandler to write.
+var (
+	errInvalidQuery  = apperr.Validation("invalid_query", "invalid query params")
+	errInvalidBody   = apperr.Validation("invalid_body", "invalid request body")
+	errInvalidParam  = apperr.Validation("invalid_param", "invalid route parameter")
+	errInvalidIDType = apperr.Validation("invalid_id_type", "id_type must be external or internal")
+	errInvalidFields = apperr.Validation("invalid_fields", "invalid fields or exclude parameter")
+)
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"time"
+
+	"csort.ru/analysis-service/internal/logger"
+	"csort.ru/analysis-service/internal/models"
+	"csort.ru/analysis-service/internal/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+var exportHandlerLog = logger.GetLogger("handlers.export")
+
+// exportTimeout bounds how long a single export may keep streaming.
+const exportTimeout = 10 * time.Minute
+
+type ExportHandler struct {
+	service *services.ExportService
+}
+
+func NewExportHandler(service *services.ExportService) *ExportHandler {
+	return &ExportHandler{
+		service: service,
+	}
+}
+
+func (h *ExportHandler) ExportAnalysis(c *fiber.Ctx) error {
+	ref, err := parseAnalysisRef(c, "id")
+	if err != nil {
+		return err
+	}
+
+	var request models.ExportRequest
+	if err := c.QueryParser(&request); err != nil {
+		exportHandlerLog.Error().Err(err).Msg("Error parsing query params")
+		return errInvalidQuery
+	}
+
+	export, err := h.service.ExportAnalysis(c.Context(), ref, request)
+	if err != nil {
+		return err
+	}
+
+	return streamExport(c, export)
+}
+
+func (h *ExportHandler) ExportAnalyses(c *fiber.Ctx) error {
+	userID, err := requireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var request models.ExportAnalysesRequest
+	if err := c.QueryParser(&request); err != nil {
+		exportHandlerLog.Error().Err(err).Msg("Error parsing query params")
+		return errInvalidQuery
+	}
+
+	export, err := h.service.ExportAnalyses(c.Context(), userID, request)
+	if err != nil {
+		return err
+	}
+
+	return streamExport(c, export)
+}
+
+// streamExport sends the export as a chunked attachment. The body is produced
+// after the handler returns, so it never sits in memory as a whole and the
+// response formatter leaves it alone.
+func streamExport(c *fiber.Ctx, export *services.Export) error {
+	c.Set(fiber.HeaderContentType, export.ContentType)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, export.Filename))
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+		defer cancel()
+
+		// The status line is already sent, all we can do on failure is cut the body short
+		if err := export.Write(ctx, w); err != nil {
+			exportHandlerLog.Error().Err(err).Str("file", export.Filename).Msg("Export failed mid-stream")
+			return
+		}
+		if err := w.Flush(); err != nil {
+			exportHandlerLog.Warn().Err(err).Str("file", export.Filename).Msg("Failed to flush export")
+		}
+	})
+
+	return nil
+}
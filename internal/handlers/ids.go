@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+
+	"csort.ru/analysis-service/internal/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+// parseAnalysisRef reads an analysis route parameter along with the id_type query
+// parameter that says how to interpret it, external id_analysis by default.
+func parseAnalysisRef(c *fiber.Ctx, param string) (models.AnalysisRef, error) {
+	ref := models.AnalysisRef{ID: c.Params(param)}
+	if ref.ID == "" {
+		return ref, fmt.Errorf("%w: missing %s parameter", errInvalidParam, param)
+	}
+	idType, err := analysisIDType(c)
+	if err != nil {
+		return ref, err
+	}
+	ref.Type = idType
+
+	if ref.Type == models.IDTypeInternal {
+		if _, err := strconv.ParseInt(ref.ID, 10, 32); err != nil {
+			return ref, fmt.Errorf("%w: internal analysis ids are integers", errInvalidParam)
+		}
+	}
+	return ref, nil
+}
+
+// parseTypedAnalysisRef is parseAnalysisRef for routes that used to take the
+// internal id: id_type must be given, so an old client's bare serial isn't
+// silently looked up as an id_analysis.
+func parseTypedAnalysisRef(c *fiber.Ctx, param string) (models.AnalysisRef, error) {
+	if c.Query("id_type") == "" {
+		return models.AnalysisRef{}, fmt.Errorf("%w: id_type is required on this route", errInvalidIDType)
+	}
+	return parseAnalysisRef(c, param)
+}
+
+// analysisIDType reads the id_type query parameter, external by default.
+func analysisIDType(c *fiber.Ctx) (string, error) {
+	idType := c.Query("id_type", models.IDTypeExternal)
+	if idType != models.IDTypeExternal && idType != models.IDTypeInternal {
+		return "", errInvalidIDType
+	}
+	return idType, nil
+}
+
+// parseObjectID reads an object route parameter. Objects only have an internal id,
+// so an id_type other than internal is rejected.
+func parseObjectID(c *fiber.Ctx, param string) (int32, error) {
+	if idType := c.Query("id_type", models.IDTypeInternal); idType != models.IDTypeInternal {
+		return 0, fmt.Errorf("%w: objects can only be addressed by internal id", errInvalidIDType)
+	}
+
+	parsed, err := strconv.ParseInt(c.Params(param), 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("%w: invalid %s parameter", errInvalidParam, param)
+	}
+	return int32(parsed), nil
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"csort.ru/analysis-service/internal/models"
+	"csort.ru/analysis-service/internal/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+type LabelsHandler struct {
+	service *services.LabelsService
+}
+
+func NewLabelsHandler(service *services.LabelsService) *LabelsHandler {
+	return &LabelsHandler{
+		service: service,
+	}
+}
+
+// RelabelObject overrides the class of an object of the caller.
+func (h *LabelsHandler) RelabelObject(c *fiber.Ctx) error {
+	userID, err := requireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	id, err := parseObjectID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	var request models.ObjectLabelRequest
+	if err := c.BodyParser(&request); err != nil {
+		return errInvalidBody
+	}
+
+	label, err := h.service.Relabel(c.Context(), userID, id, request)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(label)
+}
+
+// GetObjectLabels returns the label revision history of an object of the
+// caller, latest first.
+func (h *LabelsHandler) GetObjectLabels(c *fiber.Ctx) error {
+	userID, err := requireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	id, err := parseObjectID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	labels, err := h.service.History(c.Context(), userID, id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(labels)
+}
@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"csort.ru/analysis-service/internal/logger"
+	"csort.ru/analysis-service/internal/models"
+	"csort.ru/analysis-service/internal/services"
+	"csort.ru/analysis-service/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+var lotsHandlerLog = logger.GetLogger("handlers.lots")
+
+type LotsHandler struct {
+	service *services.LotsService
+}
+
+func NewLotsHandler(service *services.LotsService) *LotsHandler {
+	return &LotsHandler{
+		service: service,
+	}
+}
+
+func (h *LotsHandler) ListLots(c *fiber.Ctx) error {
+	userID, err := requireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var params models.GetLotsPaginatedRequest
+	if err := c.QueryParser(&params); err != nil {
+		lotsHandlerLog.Error().Err(err).Msg("Error parsing query params")
+		return errInvalidQuery
+	}
+
+	lots, err := h.service.ListLots(c.Context(), userID, params)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(lots)
+}
+
+func (h *LotsHandler) GetLot(c *fiber.Ctx) error {
+	userID, err := requireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	id, err := utils.ParseParamWithType[int32](c, "id")
+	if err != nil {
+		return err
+	}
+
+	lot, err := h.service.GetLot(c.Context(), userID, id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(lot)
+}
+
+func (h *LotsHandler) CreateLot(c *fiber.Ctx) error {
+	userID, err := requireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var request models.LotRequest
+	if err := c.BodyParser(&request); err != nil {
+		return errInvalidBody
+	}
+
+	lot, err := h.service.CreateLot(c.Context(), userID, request)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(lot)
+}
+
+func (h *LotsHandler) UpdateLot(c *fiber.Ctx) error {
+	userID, err := requireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	id, err := utils.ParseParamWithType[int32](c, "id")
+	if err != nil {
+		return err
+	}
+
+	var request models.LotRequest
+	if err := c.BodyParser(&request); err != nil {
+		return errInvalidBody
+	}
+
+	lot, err := h.service.UpdateLot(c.Context(), userID, id, request)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(lot)
+}
+
+func (h *LotsHandler) DeleteLot(c *fiber.Ctx) error {
+	userID, err := requireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	id, err := utils.ParseParamWithType[int32](c, "id")
+	if err != nil {
+		return err
+	}
+
+	if err := h.service.DeleteLot(c.Context(), userID, id); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *LotsHandler) GetLotAnalyses(c *fiber.Ctx) error {
+	userID, err := requireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	id, err := utils.ParseParamWithType[int32](c, "id")
+	if err != nil {
+		return err
+	}
+
+	analyses, err := h.service.GetLotAnalyses(c.Context(), userID, id)
+	if err != nil {
+		return err
+	}
+
+	return sendVersioned(c, &analyses)
+}
+
+// AttachAnalyses adds existing analyses to a lot. The analyses are given by
+// id_analysis, or by internal id with ?id_type=internal.
+func (h *LotsHandler) AttachAnalyses(c *fiber.Ctx) error {
+	userID, err := requireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	id, err := utils.ParseParamWithType[int32](c, "id")
+	if err != nil {
+		return err
+	}
+	idType, err := analysisIDType(c)
+	if err != nil {
+		return err
+	}
+
+	var request models.LotAnalysesRequest
+	if err := c.BodyParser(&request); err != nil {
+		return errInvalidBody
+	}
+
+	refs := make([]models.AnalysisRef, 0, len(request.Analyses))
+	for _, analysisID := range request.Analyses {
+		refs = append(refs, models.AnalysisRef{ID: analysisID, Type: idType})
+	}
+
+	response, err := h.service.AttachAnalyses(c.Context(), userID, id, refs)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(response)
+}
+
+func (h *LotsHandler) DetachAnalysis(c *fiber.Ctx) error {
+	userID, err := requireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	id, err := utils.ParseParamWithType[int32](c, "id")
+	if err != nil {
+		return err
+	}
+	ref, err := parseAnalysisRef(c, "analysisId")
+	if err != nil {
+		return err
+	}
+
+	if err := h.service.DetachAnalysis(c.Context(), userID, id, ref); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GetLotStats returns Stats and class composition pooled over every analysis of a lot.
+func (h *LotsHandler) GetLotStats(c *fiber.Ctx) error {
+	userID, err := requireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	id, err := utils.ParseParamWithType[int32](c, "id")
+	if err != nil {
+		return err
+	}
+
+	stats, err := h.service.GetLotStats(c.Context(), userID, id)
+	if err != nil {
+		return err
+	}
+
+	return sendVersioned(c, &stats)
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"csort.ru/analysis-service/internal/logger"
+	"csort.ru/analysis-service/internal/models"
 	"csort.ru/analysis-service/internal/services"
 	"github.com/gofiber/fiber/v2"
 )
@@ -22,16 +23,23 @@ type GetObjectsRequest struct {
 	Objects []int32 `json:"objects"`
 }
 
+// GetObjects returns the measurements of the requested objects, limited to the
+// fields selected by ?fields= and ?exclude=.
 func (h *ObjectsHandler) GetObjects(c *fiber.Ctx) error {
 	request := GetObjectsRequest{}
 	if err := c.BodyParser(&request); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+		return errInvalidBody
+	}
+
+	fields, err := parseProjection(c, models.ObjectMetadataFields)
+	if err != nil {
+		return err
 	}
 
 	objects, err := h.service.GetObjects(c.Context(), request.Objects)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to get objects"})
+		return err
 	}
 
-	return c.JSON(objects)
+	return sendProjectedList(c, fields, objects)
 }
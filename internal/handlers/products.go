@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+
+	"csort.ru/analysis-service/internal/logger"
+	"csort.ru/analysis-service/internal/models"
+	"csort.ru/analysis-service/internal/services"
+	"csort.ru/analysis-service/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+var productsHandlerLog = logger.GetLogger("handlers.products")
+
+type ProductsHandler struct {
+	service *services.ProductsService
+	admins  Admins
+}
+
+func NewProductsHandler(service *services.ProductsService, admins Admins) *ProductsHandler {
+	return &ProductsHandler{
+		service: service,
+		admins:  admins,
+	}
+}
+
+// ListProducts returns the products the caller may choose from, named in the
+// language of the lang query parameter or else the Accept-Language header.
+func (h *ProductsHandler) ListProducts(c *fiber.Ctx) error {
+	userID, err := requireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var params models.ProductsRequest
+	if err := c.QueryParser(&params); err != nil {
+		productsHandlerLog.Error().Err(err).Msg("Error parsing query params")
+		return errInvalidQuery
+	}
+
+	products, err := h.service.ListForUser(c.Context(), userID, productLang(c, params.Lang))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(products)
+}
+
+// ListCatalog returns every product, including inactive ones.
+func (h *ProductsHandler) ListCatalog(c *fiber.Ctx) error {
+	var params models.ProductsRequest
+	if err := c.QueryParser(&params); err != nil {
+		productsHandlerLog.Error().Err(err).Msg("Error parsing query params")
+		return errInvalidQuery
+	}
+
+	products, err := h.service.ListProducts(c.Context(), productLang(c, params.Lang))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(products)
+}
+
+func (h *ProductsHandler) GetProduct(c *fiber.Ctx) error {
+	code, err := utils.ParseParamWithType[string](c, "code")
+	if err != nil {
+		return err
+	}
+
+	var params models.ProductsRequest
+	if err := c.QueryParser(&params); err != nil {
+		productsHandlerLog.Error().Err(err).Msg("Error parsing query params")
+		return errInvalidQuery
+	}
+
+	product, err := h.service.GetProduct(c.Context(), code, productLang(c, params.Lang))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(product)
+}
+
+func (h *ProductsHandler) CreateProduct(c *fiber.Ctx) error {
+	if _, err := requireAdmin(c, h.admins); err != nil {
+		return err
+	}
+
+	var request models.ProductRequest
+	if err := c.BodyParser(&request); err != nil {
+		return errInvalidBody
+	}
+
+	product, err := h.service.CreateProduct(c.Context(), request)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(product)
+}
+
+func (h *ProductsHandler) UpdateProduct(c *fiber.Ctx) error {
+	if _, err := requireAdmin(c, h.admins); err != nil {
+		return err
+	}
+
+	code, err := utils.ParseParamWithType[string](c, "code")
+	if err != nil {
+		return err
+	}
+
+	var request models.ProductRequest
+	if err := c.BodyParser(&request); err != nil {
+		return errInvalidBody
+	}
+
+	product, err := h.service.UpdateProduct(c.Context(), code, request)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(product)
+}
+
+func (h *ProductsHandler) DeleteProduct(c *fiber.Ctx) error {
+	if _, err := requireAdmin(c, h.admins); err != nil {
+		return err
+	}
+
+	code, err := utils.ParseParamWithType[string](c, "code")
+	if err != nil {
+		return err
+	}
+
+	if err := h.service.DeleteProduct(c.Context(), code); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GetUserProducts returns the product codes a user is restricted to; an empty list
+// means the user may choose any active product.
+func (h *ProductsHandler) GetUserProducts(c *fiber.Ctx) error {
+	userID, err := utils.ParseParamWithType[int64](c, "userId")
+	if err != nil {
+		return err
+	}
+
+	codes, err := h.service.GetUserProducts(c.Context(), strconv.FormatInt(userID, 10))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(models.UserProductsRequest{Products: codes})
+}
+
+func (h *ProductsHandler) SetUserProducts(c *fiber.Ctx) error {
+	if _, err := requireAdmin(c, h.admins); err != nil {
+		return err
+	}
+
+	userID, err := utils.ParseParamWithType[int64](c, "userId")
+	if err != nil {
+		return err
+	}
+
+	var request models.UserProductsRequest
+	if err := c.BodyParser(&request); err != nil {
+		return errInvalidBody
+	}
+
+	codes, err := h.service.SetUserProducts(c.Context(), strconv.FormatInt(userID, 10), request.Products)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(models.UserProductsRequest{Products: codes})
+}
+
+// productLang returns the requested language, falling back to the first language
+// of the Accept-Language header.
+func productLang(c *fiber.Ctx, lang string) string {
+	if lang != "" {
+		return lang
+	}
+	first, _, _ := strings.Cut(c.Get(fiber.HeaderAcceptLanguage), ",")
+	first, _, _ = strings.Cut(first, ";")
+	return strings.TrimSpace(first)
+}
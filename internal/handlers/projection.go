@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"csort.ru/analysis-service/internal/models"
+	"github.com/bytedance/sonic"
+	"github.com/gofiber/fiber/v2"
+)
+
+// parseProjection reads the fields and exclude query parameters, which select
+// fields of the model described by list.
+func parseProjection(c *fiber.Ctx, list *models.FieldList) (models.Projection, error) {
+	var request models.ProjectionRequest
+	if err := c.QueryParser(&request); err != nil {
+		return models.Projection{}, errInvalidQuery
+	}
+
+	fields, err := list.Project(request)
+	if err != nil {
+		return models.Projection{}, fmt.Errorf("%w: %v", errInvalidFields, err)
+	}
+	return fields, nil
+}
+
+// marshalProjected encodes the projected fields of v as a JSON object.
+func marshalProjected(fields models.Projection, v any) ([]byte, error) {
+	if fields.All() {
+		return sonic.Marshal(v)
+	}
+
+	buf := []byte{'{'}
+	err := fields.Each(v, func(name string, value any) error {
+		if len(buf) > 1 {
+			buf = append(buf, ',')
+		}
+		buf = strconv.AppendQuote(buf, name)
+		buf = append(buf, ':')
+		data, err := sonic.Marshal(value)
+		buf = append(buf, data...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append(buf, '}'), nil
+}
+
+// sendProjected writes v, a pointer to an analysis, like sendVersioned but with
+// only its projected fields.
+func sendProjected(c *fiber.Ctx, fields models.Projection, v any) error {
+	if fields.All() {
+		return sendVersioned(c, v)
+	}
+	version, err := responseVersion(c)
+	if err != nil {
+		return err
+	}
+	if version < models.ResponseV2 {
+		models.ZeroNulls(v)
+	}
+
+	data, err := marshalProjected(fields, v)
+	if err != nil {
+		return err
+	}
+	setResponseVersion(c, version)
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return c.Send(data)
+}
+
+// sendProjectedList writes a list of pointers to a projected model like
+// sendProjected.
+func sendProjectedList[T any](c *fiber.Ctx, fields models.Projection, items []*T) error {
+	if fields.All() {
+		return sendVersioned(c, &items)
+	}
+	version, err := responseVersion(c)
+	if err != nil {
+		return err
+	}
+	if version < models.ResponseV2 {
+		models.ZeroNulls(&items)
+	}
+
+	projected := make([]json.RawMessage, 0, len(items))
+	for _, item := range items {
+		data, err := marshalProjected(fields, item)
+		if err != nil {
+			return err
+		}
+		projected = append(projected, data)
+	}
+	setResponseVersion(c, version)
+	return c.JSON(projected)
+}
+
+// sendProjectedPage writes a page of analyses like sendProjected.
+func sendProjectedPage[T any](c *fiber.Ctx, fields models.Projection, page *models.PaginatedResponse[T]) error {
+	if fields.All() {
+		return sendVersioned(c, page)
+	}
+	version, err := responseVersion(c)
+	if err != nil {
+		return err
+	}
+	if version < models.ResponseV2 {
+		models.ZeroNulls(page)
+	}
+
+	projected := models.PaginatedResponse[json.RawMessage]{
+		Data:   make([]json.RawMessage, 0, len(page.Data)),
+		Total:  page.Total,
+		Limit:  page.Limit,
+		Offset: page.Offset,
+	}
+	for i := range page.Data {
+		data, err := marshalProjected(fields, &page.Data[i])
+		if err != nil {
+			return err
+		}
+		projected.Data = append(projected.Data, data)
+	}
+	setResponseVersion(c, version)
+	return c.JSON(projected)
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"csort.ru/analysis-service/internal/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestSendProjectedList(t *testing.T) {
+	mass := 1.5
+	tests := []struct {
+		name    string
+		query   string
+		objects []*models.ObjectMetadata
+		want    string
+	}{
+		{
+			name:  "empty result set",
+			query: "?fields=id,class",
+			want:  `[]`,
+		},
+		{
+			name:    "fields and exclude",
+			query:   "?fields=id,class,m_h&exclude=class",
+			objects: []*models.ObjectMetadata{{ID: 1, Class: "broken", MH: &mass}, {ID: 2}},
+			want:    `[{"id":1,"m_h":1.5},{"id":2,"m_h":0}]`,
+		},
+		{
+			name:    "version 2 keeps nulls",
+			query:   "?fields=id,m_h&api_version=2",
+			objects: []*models.ObjectMetadata{{ID: 2}},
+			want:    `[{"id":2,"m_h":null}]`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Get("/", func(c *fiber.Ctx) error {
+				fields, err := parseProjection(c, models.ObjectMetadataFields)
+				if err != nil {
+					return err
+				}
+				return sendProjectedList(c, fields, tt.objects)
+			})
+
+			resp, err := app.Test(httptest.NewRequest("GET", "/"+tt.query, nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(body) != tt.want {
+				t.Errorf("got %s, want %s", body, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"fmt"
+
+	"csort.ru/analysis-service/internal/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+type ReportHandler struct {
+	service *services.ReportService
+}
+
+func NewReportHandler(service *services.ReportService) *ReportHandler {
+	return &ReportHandler{
+		service: service,
+	}
+}
+
+func (h *ReportHandler) GetAnalysisReport(c *fiber.Ctx) error {
+	ref, err := parseAnalysisRef(c, "id")
+	if err != nil {
+		return err
+	}
+
+	report, err := h.service.RenderAnalysisReport(c.Context(), ref, c.Query("template"))
+	if err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderContentType, "application/pdf")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`inline; filename="analysis-%s.pdf"`, ref.ID))
+	return c.Send(report)
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"csort.ru/analysis-service/internal/logger"
+	"csort.ru/analysis-service/internal/models"
+	"csort.ru/analysis-service/internal/services"
+	"csort.ru/analysis-service/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+var reviewHandlerLog = logger.GetLogger("handlers.review")
+
+type ReviewHandler struct {
+	service *services.ReviewService
+}
+
+func NewReviewHandler(service *services.ReviewService) *ReviewHandler {
+	return &ReviewHandler{
+		service: service,
+	}
+}
+
+// PopulateQueue selects objects into the review queue with a strategy.
+func (h *ReviewHandler) PopulateQueue(c *fiber.Ctx) error {
+	// The queue and the labels are shared, but only known users may touch them
+	if _, err := requireUserID(c); err != nil {
+		return err
+	}
+
+	var request models.ReviewPopulateRequest
+	if err := c.BodyParser(&request); err != nil {
+		return errInvalidBody
+	}
+
+	response, err := h.service.Populate(c.Context(), request)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(response)
+}
+
+// GetNextItem returns the next item the caller hasn't reviewed, e.g. ?strategy=anomaly.
+// It responds 204 when there is nothing left.
+func (h *ReviewHandler) GetNextItem(c *fiber.Ctx) error {
+	userID, err := requireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	item, err := h.service.Next(c.Context(), userID, c.Query("strategy"))
+	if errors.Is(err, services.ErrReviewQueueEmpty) {
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+	if err != nil {
+		return err
+	}
+
+	// The crop is served next to this route, wherever the API is mounted
+	item.ImageURL = fmt.Sprintf("%s/%d/image", strings.TrimSuffix(c.Path(), "/next"), item.ID)
+	return sendVersioned(c, &item)
+}
+
+// SubmitLabel records the caller's label of an item, or {"skip": true}.
+func (h *ReviewHandler) SubmitLabel(c *fiber.Ctx) error {
+	userID, err := requireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	id, err := utils.ParseParamWithType[int32](c, "id")
+	if err != nil {
+		return err
+	}
+
+	var request models.ReviewSubmitRequest
+	if err := c.BodyParser(&request); err != nil {
+		return errInvalidBody
+	}
+
+	label, err := h.service.Submit(c.Context(), userID, id, request)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(label)
+}
+
+// GetItemImage serves the crop of the object of an item to a reviewer.
+func (h *ReviewHandler) GetItemImage(c *fiber.Ctx) error {
+	if _, err := requireUserID(c); err != nil {
+		return err
+	}
+
+	id, err := utils.ParseParamWithType[int32](c, "id")
+	if err != nil {
+		return err
+	}
+
+	image, err := h.service.Image(c.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderContentType, http.DetectContentType(image))
+	return c.Send(image)
+}
+
+func (h *ReviewHandler) GetAgreement(c *fiber.Ctx) error {
+	if _, err := requireUserID(c); err != nil {
+		return err
+	}
+
+	agreement, err := h.service.Agreement(c.Context())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(agreement)
+}
+
+// ExportLabels exports the reviewed objects with their consensus labels, e.g. ?format=parquet
+func (h *ReviewHandler) ExportLabels(c *fiber.Ctx) error {
+	if _, err := requireUserID(c); err != nil {
+		return err
+	}
+
+	var request models.ReviewExportRequest
+	if err := c.QueryParser(&request); err != nil {
+		reviewHandlerLog.Error().Err(err).Msg("Error parsing query params")
+		return errInvalidQuery
+	}
+
+	export, err := h.service.Export(c.Context(), request)
+	if err != nil {
+		return err
+	}
+
+	return streamExport(c, export)
+}
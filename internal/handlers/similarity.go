@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"csort.ru/analysis-service/internal/logger"
+	"csort.ru/analysis-service/internal/models"
+	"csort.ru/analysis-service/internal/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+var similarityHandlerLog = logger.GetLogger("handlers.similarity")
+
+type SimilarityHandler struct {
+	service *services.SimilarityService
+}
+
+func NewSimilarityHandler(service *services.SimilarityService) *SimilarityHandler {
+	return &SimilarityHandler{
+		service: service,
+	}
+}
+
+// GetSimilarObjects finds the caller's objects closest to the given one, e.g. ?k=20&features=geometry,h_avg
+func (h *SimilarityHandler) GetSimilarObjects(c *fiber.Ctx) error {
+	userID, err := requireUserID(c)
+	if err != nil {
+		return err
+	}
+
+	id, err := parseObjectID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	var request models.SimilarObjectsRequest
+	if err := c.QueryParser(&request); err != nil {
+		similarityHandlerLog.Error().Err(err).Msg("Error parsing query params")
+		return errInvalidQuery
+	}
+
+	response, err := h.service.FindSimilar(c.Context(), userID, id, request)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(response)
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"csort.ru/analysis-service/internal/models"
+	"csort.ru/analysis-service/internal/services"
+	"csort.ru/analysis-service/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+type SpecsHandler struct {
+	service *services.SpecsService
+	// admins may change specs, which grade the analyses of every user
+	admins Admins
+}
+
+func NewSpecsHandler(service *services.SpecsService, admins Admins) *SpecsHandler {
+	return &SpecsHandler{
+		service: service,
+		admins:  admins,
+	}
+}
+
+func (h *SpecsHandler) ListSpecs(c *fiber.Ctx) error {
+	specs, err := h.service.ListSpecs(c.Context())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(specs)
+}
+
+func (h *SpecsHandler) GetSpec(c *fiber.Ctx) error {
+	id, err := utils.ParseParamWithType[int32](c, "id")
+	if err != nil {
+		return err
+	}
+
+	spec, err := h.service.GetSpec(c.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(spec)
+}
+
+func (h *SpecsHandler) CreateSpec(c *fiber.Ctx) error {
+	if _, err := requireAdmin(c, h.admins); err != nil {
+		return err
+	}
+
+	var request models.ProductSpecRequest
+	if err := c.BodyParser(&request); err != nil {
+		return errInvalidBody
+	}
+
+	spec, err := h.service.CreateSpec(c.Context(), request)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(spec)
+}
+
+func (h *SpecsHandler) UpdateSpec(c *fiber.Ctx) error {
+	if _, err := requireAdmin(c, h.admins); err != nil {
+		return err
+	}
+
+	id, err := utils.ParseParamWithType[int32](c, "id")
+	if err != nil {
+		return err
+	}
+
+	var request models.ProductSpecRequest
+	if err := c.BodyParser(&request); err != nil {
+		return errInvalidBody
+	}
+
+	spec, err := h.service.UpdateSpec(c.Context(), id, request)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(spec)
+}
+
+func (h *SpecsHandler) DeleteSpec(c *fiber.Ctx) error {
+	if _, err := requireAdmin(c, h.admins); err != nil {
+		return err
+	}
+
+	id, err := utils.ParseParamWithType[int32](c, "id")
+	if err != nil {
+		return err
+	}
+
+	if err := h.service.DeleteSpec(c.Context(), id); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// EvaluateSpec queues every analysis of the spec's product for regrading by the
+// grading job, and answers before they are graded.
+func (h *SpecsHandler) EvaluateSpec(c *fiber.Ctx) error {
+	if _, err := requireAdmin(c, h.admins); err != nil {
+		return err
+	}
+
+	id, err := utils.ParseParamWithType[int32](c, "id")
+	if err != nil {
+		return err
+	}
+
+	if err := h.service.EvaluateSpec(c.Context(), id); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{"spec_id": id})
+}
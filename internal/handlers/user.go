@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"slices"
+	"strconv"
+
+	"csort.ru/analysis-service/internal/apperr"
+	"csort.ru/analysis-service/internal/logger"
+	"github.com/gofiber/fiber/v2"
+)
+
+var userHandlerLog = logger.GetLogger("handlers.user")
+
+var (
+	errInvalidUserID = apperr.Validation("invalid_user_id", "Invalid Telegram-User-ID format")
+	errNotAdmin      = apperr.Forbidden("not_admin", "only admins may change shared settings")
+)
+
+// requireUserID reads the caller's Telegram-User-ID header, which is required.
+func requireUserID(c *fiber.Ctx) (int64, error) {
+	userIDStr := c.Get("Telegram-User-ID")
+	if userIDStr == "" {
+		userHandlerLog.Error().Msg("Telegram-User-ID header is missing")
+		return 0, fiber.NewError(fiber.StatusUnauthorized, "Telegram-User-ID header is required")
+	}
+
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		userHandlerLog.Error().Err(err).Str("userIDStr", userIDStr).Msg("Invalid Telegram-User-ID format")
+		return 0, errInvalidUserID
+	}
+
+	return userID, nil
+}
+
+// Admins are the users who may change data shared by all users, such as product
+// specs and the product catalog.
+type Admins []int64
+
+// requireAdmin reads the caller's Telegram-User-ID header, which is required, and
+// checks that the caller is one of admins.
+func requireAdmin(c *fiber.Ctx, admins Admins) (int64, error) {
+	userID, err := requireUserID(c)
+	if err != nil {
+		return 0, err
+	}
+	if !slices.Contains(admins, userID) {
+		userHandlerLog.Warn().Int64("userID", userID).Str("path", c.Path()).Msg("Non-admin tried to change shared settings")
+		return 0, errNotAdmin
+	}
+	return userID, nil
+}
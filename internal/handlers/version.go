@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"strconv"
+
+	"csort.ru/analysis-service/internal/apperr"
+	"csort.ru/analysis-service/internal/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+// apiVersionHeader selects the response version, see models.ResponseV1. The
+// api_version query parameter can be used instead, e.g. for links.
+const apiVersionHeader = "API-Version"
+
+var errUnsupportedVersion = apperr.Validation("unsupported_api_version", "unsupported API-Version, expected 1 to "+strconv.Itoa(models.LatestResponseVersion))
+
+// responseVersion returns the requested response version, defaulting to version 1.
+func responseVersion(c *fiber.Ctx) (int, error) {
+	value := c.Get(apiVersionHeader)
+	if value == "" {
+		value = c.Query("api_version")
+	}
+	if value == "" {
+		return models.ResponseV1, nil
+	}
+
+	version, err := strconv.Atoi(value)
+	if err != nil || version < models.ResponseV1 || version > models.LatestResponseVersion {
+		return 0, errUnsupportedVersion
+	}
+	return version, nil
+}
+
+// sendVersioned writes v, a pointer to a response holding analyses or objects, as
+// JSON in the requested response version.
+func sendVersioned(c *fiber.Ctx, v any) error {
+	version, err := responseVersion(c)
+	if err != nil {
+		return err
+	}
+	if version < models.ResponseV2 {
+		models.ZeroNulls(v)
+	}
+
+	setResponseVersion(c, version)
+	return c.JSON(v)
+}
+
+// setResponseVersion tells the client which response version it got.
+func setResponseVersion(c *fiber.Ctx, version int) {
+	c.Set(apiVersionHeader, strconv.Itoa(version))
+	c.Vary(apiVersionHeader)
+}
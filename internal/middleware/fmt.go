@@ -1,9 +1,14 @@
 package middleware
 
 import (
+	"bufio"
+	"bytes"
+	"errors"
 	"net/http"
 	"strings"
+	"unicode"
 
+	"csort.ru/analysis-service/internal/apperr"
 	"csort.ru/analysis-service/internal/logger"
 	"github.com/bytedance/sonic"
 	"github.com/gofiber/fiber/v2"
@@ -17,10 +22,11 @@ type SuccessResponse struct {
 
 // ErrorInfo defines the structure for detailed error information.
 type ErrorInfo struct {
-	Code    int         `json:"code"`              // HTTP Status Code
-	Message string      `json:"message"`           // User-friendly message
-	Details interface{} `json:"details,omitempty"` // Optional technical details
-	Path    string      `json:"path"`              // Path of the request
+	Code      int         `json:"code"`              // HTTP Status Code
+	ErrorCode string      `json:"error_code"`        // Machine-readable error code, e.g. "analysis_not_found"
+	Message   string      `json:"message"`           // User-friendly message
+	Details   interface{} `json:"details,omitempty"` // Optional technical details
+	Path      string      `json:"path"`              // Path of the request
 }
 
 // ErrorResponse defines the structure for error API responses.
@@ -29,82 +35,167 @@ type ErrorResponse struct {
 	Error   ErrorInfo `json:"error"`
 }
 
+// FmtConfig configures how Fmt and ErrorHandler write errors. Clients sending
+// Accept: application/problem+json get RFC 7807 problem details instead of an
+// ErrorResponse, as do all requests under ProblemRoutes.
+type FmtConfig struct {
+	// ProblemTypeBase is prefixed to error codes to form problem type URIs
+	ProblemTypeBase string
+	// ProblemRoutes are path prefixes, e.g. "/api/v1/lots", whose errors are
+	// always problem details
+	ProblemRoutes []string
+}
+
+func fmtConfigDefault(config ...FmtConfig) FmtConfig {
+	cfg := FmtConfig{}
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if cfg.ProblemTypeBase == "" {
+		cfg.ProblemTypeBase = DefaultProblemTypeBase
+	}
+	return cfg
+}
+
 // Fmt creates a middleware that standardizes JSON API responses.
 // It wraps successful responses and formats errors consistently.
-func Fmt() fiber.Handler {
+func Fmt(config ...FmtConfig) fiber.Handler {
+	cfg := fmtConfigDefault(config...)
+	handleError := ErrorHandler(cfg)
+
 	return func(c *fiber.Ctx) error {
 		// Chain execution to the next handler. If an error occurs in the chain,
 		// handle it. Otherwise, format the response set by the handler.
 		if err := c.Next(); err != nil {
-			return handleNextError(c, err)
+			return handleError(c, err)
 		}
-		return formatResponse(c)
+		return formatResponse(c, cfg)
 	}
 }
 
 var formatLogger = logger.GetLogger("middleware.response_formatter")
 
-// handleNextError handles errors returned directly from the c.Next() call.
-// These are typically errors from other middleware or Fiber itself.
-func handleNextError(c *fiber.Ctx, err error) error {
-	statusCode := fiber.StatusInternalServerError // Default
-	message := "Internal Server Error"
-	var details interface{}
-
-	if e, ok := err.(*fiber.Error); ok {
-		// Use Fiber's specific error code and message
-		statusCode = e.Code
-		message = e.Message
-		if statusCode == fiber.StatusNotFound {
-			formatLogger.Debug().Err(e).Int("status", statusCode).Str("path", c.Path()).Msg("Fiber 404 error caught")
+// statusByKind maps domain error kinds to HTTP status codes.
+var statusByKind = map[apperr.Kind]int{
+	apperr.KindValidation:          fiber.StatusBadRequest,
+	apperr.KindNotFound:            fiber.StatusNotFound,
+	apperr.KindForbidden:           fiber.StatusForbidden,
+	apperr.KindConflict:            fiber.StatusConflict,
+	apperr.KindPrecondition:        fiber.StatusPreconditionFailed,
+	apperr.KindUpstreamUnavailable: fiber.StatusBadGateway,
+}
+
+// ErrorHandler creates the handler that writes errors returned by handlers, as an
+// ErrorResponse or problem details. Domain errors get the status of their kind and
+// their code; Fiber errors keep their status. Anything else is an internal error,
+// logged but not shown to the client. It is also the app's
+// fiber.Config.ErrorHandler, for errors raised outside Fmt.
+func ErrorHandler(config ...FmtConfig) fiber.ErrorHandler {
+	cfg := fmtConfigDefault(config...)
+
+	return func(c *fiber.Ctx, err error) error {
+		info, title := errorInfo(c, err)
+
+		if info.Code >= fiber.StatusInternalServerError {
+			formatLogger.Error().Err(err).Int("status", info.Code).Str("code", info.ErrorCode).Str("path", c.Path()).Msg("Request failed")
 		} else {
-			formatLogger.Warn().Err(e).Int("status", statusCode).Str("path", c.Path()).Msg("Fiber error caught")
+			formatLogger.Debug().Err(err).Int("status", info.Code).Str("code", info.ErrorCode).Str("path", c.Path()).Msg("Request rejected")
 		}
-	} else {
-		// For other unexpected errors, log them as critical
-		details = err.Error()
-		formatLogger.Error().Err(err).Str("path", c.Path()).Msg("Unhandled error from c.Next()")
+
+		return writeError(c, cfg, info, title)
 	}
+}
 
-	response := ErrorResponse{
-		Success: false,
-		Error: ErrorInfo{
-			Code:    statusCode,
-			Message: message,
-			Details: details,
-			Path:    c.Path(),
-		},
+// errorInfo describes err for the client, along with the title of its kind of
+// problem: the unwrapped message of a domain error, else the status text.
+func errorInfo(c *fiber.Ctx, err error) (ErrorInfo, string) {
+	info := ErrorInfo{
+		Code:      fiber.StatusInternalServerError,
+		ErrorCode: internalErrorCode,
+		Message:   "internal server error",
+		Path:      c.Path(),
 	}
 
-	c.Status(statusCode).Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	var fiberErr *fiber.Error
+	if appErr, ok := apperr.As(err); ok && appErr.Kind != apperr.KindInternal {
+		info.Code = statusByKind[appErr.Kind]
+		info.ErrorCode = appErr.Code
+		// Client errors are wrapped with the context the client needs; upstream
+		// ones with transport details that stay in the logs
+		info.Message = err.Error()
+		if appErr.Kind == apperr.KindUpstreamUnavailable {
+			info.Message = appErr.Message
+		}
+		return info, appErr.Message
+	}
+	if errors.As(err, &fiberErr) {
+		info.Code = fiberErr.Code
+		info.ErrorCode = statusErrorCode(fiberErr.Code)
+		info.Message = fiberErr.Message
+	}
+	return info, http.StatusText(info.Code)
+}
+
+// writeError sends info as problem details if the request asks for them, else as
+// an ErrorResponse.
+func writeError(c *fiber.Ctx, cfg FmtConfig, info ErrorInfo, title string) error {
+	c.Status(info.Code)
+	c.Vary(fiber.HeaderAccept)
 	// Prevent Fiber from sending its default error body alongside our JSON
 	c.Response().SetBody(nil)
-	return c.JSON(response)
+
+	if cfg.wantsProblem(c) {
+		return c.JSON(cfg.problem(info, title), MIMEApplicationProblemJSON)
+	}
+	return c.JSON(ErrorResponse{Success: false, Error: info})
+}
+
+// internalErrorCode is the code of errors that aren't domain errors.
+const internalErrorCode = "internal_error"
+
+// statusErrorCode derives a code from an HTTP status, e.g. "precondition_required",
+// for errors that carry only a status.
+func statusErrorCode(statusCode int) string {
+	text := http.StatusText(statusCode)
+	if text == "" {
+		return internalErrorCode
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == ' ' || r == '-':
+			return '_'
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, text)
 }
 
 // formatResponse checks the response set by a handler and formats it if it's JSON.
-func formatResponse(c *fiber.Ctx) error {
+func formatResponse(c *fiber.Ctx, cfg FmtConfig) error {
 	statusCode := c.Response().StatusCode()
 	contentType := string(c.Response().Header.ContentType())
-	body := c.Response().Body()
 
-	// Skip formatting for non-JSON responses (e.g., file downloads, HTML)
-	if !strings.Contains(contentType, fiber.MIMEApplicationJSON) {
+	// Skip formatting for streamed and non-JSON responses (e.g., file downloads, HTML).
+	// Reading the body of a stream would buffer all of it, so check before touching it.
+	if c.Response().IsBodyStream() || !strings.Contains(contentType, fiber.MIMEApplicationJSON) {
 		formatLogger.Debug().Str("contentType", contentType).Str("path", c.Path()).Int("status", statusCode).Msg("Skipping formatting for non-JSON content")
 		return nil // Pass through original response
 	}
 
+	body := c.Response().Body()
+
 	// Clear the original body and set the correct content type for our formatted response
 	c.Response().SetBody(nil)
 	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
 
 	// Format based on status code range
 	if statusCode >= http.StatusBadRequest {
-		return formatErrorBody(c, statusCode, body)
+		return formatErrorBody(c, cfg, statusCode, body)
 	}
 
 	if statusCode >= http.StatusOK && statusCode < http.StatusMultipleChoices {
-		return formatSuccessBody(c, statusCode, body)
+		return formatSuccessBody(c, cfg, statusCode, body)
 	}
 
 	// For other status codes (e.g., 3xx redirects), pass through without formatting.
@@ -116,9 +207,10 @@ func formatResponse(c *fiber.Ctx) error {
 }
 
 // formatErrorBody creates a standardized ErrorResponse.
-func formatErrorBody(c *fiber.Ctx, statusCode int, body []byte) error {
+func formatErrorBody(c *fiber.Ctx, cfg FmtConfig, statusCode int, body []byte) error {
 	var handlerErrorData fiber.Map
 	errorMessage := ""
+	errorCode := statusErrorCode(statusCode)
 	var errorDetails interface{}
 
 	// Try to parse the handler's original error body for custom messages/details
@@ -126,6 +218,9 @@ func formatErrorBody(c *fiber.Ctx, statusCode int, body []byte) error {
 		if msg, ok := handlerErrorData["error"].(string); ok {
 			errorMessage = msg
 		}
+		if code, ok := handlerErrorData["error_code"].(string); ok && code != "" {
+			errorCode = code
+		}
 		errorDetails = handlerErrorData["details"] // Preserve details if provided
 	}
 
@@ -141,43 +236,75 @@ func formatErrorBody(c *fiber.Ctx, statusCode int, body []byte) error {
 		}
 	}
 
-	response := ErrorResponse{
-		Success: false,
-		Error: ErrorInfo{
-			Code:    statusCode,
-			Message: errorMessage,
-			Details: errorDetails,
-			Path:    c.Path(),
-		},
+	info := ErrorInfo{
+		Code:      statusCode,
+		ErrorCode: errorCode,
+		Message:   errorMessage,
+		Details:   errorDetails,
+		Path:      c.Path(),
 	}
-	return c.Status(statusCode).JSON(response)
+	return writeError(c, cfg, info, http.StatusText(statusCode))
 }
 
-// formatSuccessBody creates a standardized SuccessResponse.
-func formatSuccessBody(c *fiber.Ctx, statusCode int, body []byte) error {
+// successPrefix and successSuffix are a SuccessResponse around its result, so
+// handler JSON can be wrapped without decoding and re-encoding it.
+var (
+	successPrefix = []byte(`{"success":true,"result":`)
+	successSuffix = []byte(`}`)
+)
+
+// formatSuccessBody creates a standardized SuccessResponse by splicing the handler's
+// JSON body, which is only validated, into the envelope.
+func formatSuccessBody(c *fiber.Ctx, cfg FmtConfig, statusCode int, body []byte) error {
 	// HTTP 204 No Content must have an empty body.
 	if statusCode == http.StatusNoContent {
 		formatLogger.Debug().Int("status", statusCode).Str("path", c.Path()).Msg("Handling 204 No Content")
 		return c.SendStatus(http.StatusNoContent)
 	}
 
-	var data interface{}
-	if len(body) > 0 {
-		// Assume the original body is the JSON data payload
-		if err := sonic.Unmarshal(body, &data); err != nil {
-			formatLogger.Error().Err(err).Str("body", string(body)).Str("path", c.Path()).Msg("Failed to unmarshal success body, returning error")
-			// Return a formatted internal error if the handler returned invalid JSON
-			return formatErrorBody(c, http.StatusInternalServerError, []byte(`{"error":"Failed to process server response", "details":"Handler returned invalid JSON"}`))
-		}
-	} else {
-		// If body is empty for a 2xx response (not 204), represent data as null
-		data = nil
+	// If body is empty for a 2xx response (not 204), represent data as null
+	result := body
+	if len(bytes.TrimSpace(result)) == 0 {
+		result = []byte("null")
+	} else if !sonic.Valid(result) {
+		formatLogger.Error().Str("body", string(body)).Str("path", c.Path()).Msg("Handler returned invalid JSON, returning error")
+		// Return a formatted internal error if the handler returned invalid JSON
+		return formatErrorBody(c, cfg, http.StatusInternalServerError, []byte(`{"error":"Failed to process server response", "details":"Handler returned invalid JSON"}`))
 	}
 
-	response := SuccessResponse{
-		Success: true,
-		Result:  data,
-	}
+	// body aliases the response buffer, so the envelope goes into a buffer of its own
+	envelope := make([]byte, 0, len(successPrefix)+len(result)+len(successSuffix))
+	envelope = append(envelope, successPrefix...)
+	envelope = append(envelope, result...)
+	envelope = append(envelope, successSuffix...)
 
-	return c.Status(statusCode).JSON(response)
+	c.Status(statusCode)
+	c.Response().SetBodyRaw(envelope)
+	return nil
+}
+
+// SendJSONStream sends the JSON value write produces as the result of a
+// SuccessResponse. The body is written after the handler returns, with the
+// envelope around it, so it never sits in memory as a whole; Fmt passes streamed
+// bodies through untouched. The status line is sent before write runs, so a
+// failure can only cut the body short.
+func SendJSONStream(c *fiber.Ctx, write func(w *bufio.Writer) error) error {
+	path := c.Path()
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		if _, err := w.Write(successPrefix); err != nil {
+			return
+		}
+		if err := write(w); err != nil {
+			formatLogger.Error().Err(err).Str("path", path).Msg("JSON stream failed mid-body")
+			return
+		}
+		if _, err := w.Write(successSuffix); err != nil {
+			return
+		}
+		if err := w.Flush(); err != nil {
+			formatLogger.Warn().Err(err).Str("path", path).Msg("Failed to flush JSON stream")
+		}
+	})
+	return nil
 }
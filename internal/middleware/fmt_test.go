@@ -0,0 +1,227 @@
+package middleware
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"csort.ru/analysis-service/internal/models"
+	"github.com/bytedance/sonic"
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+)
+
+// serve runs handler behind Fmt and returns the status and body of its response.
+func serve(t *testing.T, handler fiber.Handler) (int, string) {
+	t.Helper()
+	app := fiber.New()
+	app.Use(Fmt())
+	app.Get("/", handler)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, string(body)
+}
+
+// sendRaw answers with body as JSON, bypassing c.JSON's encoding.
+func sendRaw(status int, body string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Status(status).Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		c.Response().SetBodyString(body)
+		return nil
+	}
+}
+
+func TestFmtSuccessEnvelope(t *testing.T) {
+	tests := []struct {
+		name       string
+		handler    fiber.Handler
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "object",
+			handler:    func(c *fiber.Ctx) error { return c.JSON(fiber.Map{"id": 1}) },
+			wantStatus: fiber.StatusOK,
+			wantBody:   `{"success":true,"result":{"id":1}}`,
+		},
+		{
+			name:       "created keeps its status",
+			handler:    sendRaw(fiber.StatusCreated, `[1,"два"]`),
+			wantStatus: fiber.StatusCreated,
+			wantBody:   `{"success":true,"result":[1,"два"]}`,
+		},
+		{
+			name:       "no content",
+			handler:    sendRaw(fiber.StatusNoContent, ``),
+			wantStatus: fiber.StatusNoContent,
+			wantBody:   ``,
+		},
+		{
+			name:       "empty body",
+			handler:    sendRaw(fiber.StatusOK, ``),
+			wantStatus: fiber.StatusOK,
+			wantBody:   `{"success":true,"result":null}`,
+		},
+		{
+			name:       "whitespace body",
+			handler:    sendRaw(fiber.StatusOK, " \n"),
+			wantStatus: fiber.StatusOK,
+			wantBody:   `{"success":true,"result":null}`,
+		},
+		{
+			name: "streamed body",
+			handler: func(c *fiber.Ctx) error {
+				return SendJSONStream(c, func(w *bufio.Writer) error {
+					_, err := w.WriteString(`[{"id":1},{"id":2}]`)
+					return err
+				})
+			},
+			wantStatus: fiber.StatusOK,
+			wantBody:   `{"success":true,"result":[{"id":1},{"id":2}]}`,
+		},
+		{
+			name:       "non-JSON passes through",
+			handler:    func(c *fiber.Ctx) error { return c.SendString("plain") },
+			wantStatus: fiber.StatusOK,
+			wantBody:   `plain`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := serve(t, tt.handler)
+			if status != tt.wantStatus || body != tt.wantBody {
+				t.Errorf("got %d %s, want %d %s", status, body, tt.wantStatus, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestFmtInvalidJSON(t *testing.T) {
+	status, body := serve(t, sendRaw(fiber.StatusOK, `{"id":`))
+	if status != fiber.StatusInternalServerError {
+		t.Errorf("got status %d, want 500", status)
+	}
+	var resp ErrorResponse
+	if err := sonic.UnmarshalString(body, &resp); err != nil {
+		t.Fatalf("got body %s: %v", body, err)
+	}
+	if resp.Success || resp.Error.Code != fiber.StatusInternalServerError || resp.Error.Message != "Failed to process server response" || resp.Error.Details != "Handler returned invalid JSON" {
+		t.Errorf("got error %+v", resp.Error)
+	}
+}
+
+// TestFmtSpliceMatchesReencode checks that splicing gives the envelope the
+// decoding path used to, up to key order and whitespace.
+func TestFmtSpliceMatchesReencode(t *testing.T) {
+	body, err := sonic.Marshal(testAnalysis(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := fiber.New()
+
+	spliced := app.AcquireCtx(&fasthttp.RequestCtx{})
+	defer app.ReleaseCtx(spliced)
+	if err := formatSuccessBody(spliced, FmtConfig{}, fiber.StatusOK, body); err != nil {
+		t.Fatal(err)
+	}
+	reencoded := app.AcquireCtx(&fasthttp.RequestCtx{})
+	defer app.ReleaseCtx(reencoded)
+	if err := reencodeSuccessBody(reencoded, body); err != nil {
+		t.Fatal(err)
+	}
+
+	var got, want any
+	if err := sonic.Unmarshal(spliced.Response().Body(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if err := sonic.Unmarshal(reencoded.Response().Body(), &want); err != nil {
+		t.Fatal(err)
+	}
+	gotJSON, _ := sonic.ConfigStd.Marshal(got)
+	wantJSON, _ := sonic.ConfigStd.Marshal(want)
+	if string(gotJSON) != string(wantJSON) {
+		t.Errorf("got %s, want %s", gotJSON, wantJSON)
+	}
+}
+
+// reencodeSuccessBody is formatSuccessBody as it was before splicing, kept to
+// benchmark against: the body is decoded and encoded again inside the envelope.
+func reencodeSuccessBody(c *fiber.Ctx, body []byte) error {
+	var data interface{}
+	if err := sonic.Unmarshal(body, &data); err != nil {
+		return err
+	}
+	return c.JSON(SuccessResponse{Success: true, Result: data})
+}
+
+// testAnalysis returns an analysis with n objects, all measurements set.
+func testAnalysis(n int) models.Analysis {
+	value := func(v float64) *float64 { return &v }
+	analysis := models.Analysis{
+		ID:         1,
+		DateTime:   time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC),
+		Product:    "wheat",
+		IDUser:     "42",
+		FileSource: "source.jpg",
+		Mass:       value(1000),
+		R:          &models.Stats{Avg: 120.5, Median: 118},
+		IDAnalysis: 1001,
+		Version:    1,
+		Tags:       []string{"harvest", "пшеница"},
+		Objects:    make([]models.Object, n),
+	}
+	for i := range analysis.Objects {
+		v := float64(i) + 0.25
+		analysis.Objects[i] = models.Object{
+			ID: int32(i + 1), IdAnalysis: 1001, File: "object.png", Class: "wheat",
+			Geometry: "POLYGON((0 0,10 0,10 10,0 10,0 0))",
+			MH:       value(v), MS: value(v), MV: value(v), MR: value(v), MG: value(v), MB: value(v),
+			LAvg: value(v), WAvg: value(v), BrtAvg: value(v), RAvg: value(v), GAvg: value(v), BAvg: value(v),
+			HAvg: value(v), SAvg: value(v), VAvg: value(v), H: value(v), S: value(v), V: value(v),
+			HM: value(v), SM: value(v), VM: value(v), RM: value(v), GM: value(v), BM: value(v),
+			BrtM: value(v), WM: value(v), LM: value(v), L: value(v), W: value(v), LW: value(v),
+			Pr: value(v), Sq: value(v), Brt: value(v), R: value(v), G: value(v), B: value(v),
+		}
+	}
+	return analysis
+}
+
+func benchmarkSuccessBody(b *testing.B, format func(c *fiber.Ctx, body []byte) error) {
+	body, err := sonic.Marshal(testAnalysis(10000))
+	if err != nil {
+		b.Fatal(err)
+	}
+	app := fiber.New()
+	c := app.AcquireCtx(&fasthttp.RequestCtx{})
+	defer app.ReleaseCtx(c)
+
+	b.SetBytes(int64(len(body)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		if err := format(c, body); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSuccessBodySplice(b *testing.B) {
+	benchmarkSuccessBody(b, func(c *fiber.Ctx, body []byte) error {
+		return formatSuccessBody(c, FmtConfig{}, fiber.StatusOK, body)
+	})
+}
+
+func BenchmarkSuccessBodyReencode(b *testing.B) {
+	benchmarkSuccessBody(b, reencodeSuccessBody)
+}
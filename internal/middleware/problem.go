@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// MIMEApplicationProblemJSON is the media type of RFC 7807 problem details.
+const MIMEApplicationProblemJSON = "application/problem+json"
+
+// DefaultProblemTypeBase is prefixed to error codes to form problem type URIs,
+// e.g. https://csort.ru/problems/lot_not_found.
+const DefaultProblemTypeBase = "https://csort.ru/problems/"
+
+// Problem is an RFC 7807 problem details object. Code, the ErrorInfo error code,
+// and Details are extension members.
+type Problem struct {
+	Type     string      `json:"type"`               // URI identifying the kind of problem
+	Title    string      `json:"title"`              // Summary of the kind of problem
+	Status   int         `json:"status"`             // HTTP Status Code
+	Detail   string      `json:"detail,omitempty"`   // Explanation of this occurrence
+	Instance string      `json:"instance,omitempty"` // Path of the request
+	Code     string      `json:"code"`               // Machine-readable error code
+	Details  interface{} `json:"details,omitempty"`  // Optional technical details
+}
+
+func (cfg FmtConfig) problem(info ErrorInfo, title string) Problem {
+	return Problem{
+		Type:     cfg.ProblemTypeBase + info.ErrorCode,
+		Title:    title,
+		Status:   info.Code,
+		Detail:   info.Message,
+		Instance: info.Path,
+		Code:     info.ErrorCode,
+		Details:  info.Details,
+	}
+}
+
+// wantsProblem reports whether errors of the request are written as problem
+// details: it is under one of ProblemRoutes or accepts application/problem+json.
+func (cfg FmtConfig) wantsProblem(c *fiber.Ctx) bool {
+	path := c.Path()
+	for _, prefix := range cfg.ProblemRoutes {
+		prefix = strings.TrimSuffix(prefix, "/")
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+	}
+	return acceptsProblem(c.Get(fiber.HeaderAccept))
+}
+
+// acceptsProblem reports whether an Accept header lists application/problem+json
+// with a nonzero quality. Wildcards don't count, so the envelope stays the default.
+func acceptsProblem(accept string) bool {
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaType, params, _ := strings.Cut(mediaRange, ";")
+		if !strings.EqualFold(strings.TrimSpace(mediaType), MIMEApplicationProblemJSON) {
+			continue
+		}
+		for _, param := range strings.Split(params, ";") {
+			key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(key, "q") {
+				if q, err := strconv.ParseFloat(value, 64); err == nil && q == 0 {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	return false
+}
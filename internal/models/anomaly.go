@@ -0,0 +1,41 @@
+package models
+
+// Anomaly detection methods.
+const (
+	AnomalyRobustZ     = "robust_z"    // per-feature robust z-scores (median / MAD)
+	AnomalyMahalanobis = "mahalanobis" // distance from the multivariate mean, accounting for correlations
+)
+
+type AnomalyRequest struct {
+	Features  string  `query:"features"` // comma-separated feature or group names
+	Method    string  `query:"method" validate:"omitempty,oneof=robust_z mahalanobis"`
+	Threshold float64 `query:"threshold"`
+	All       bool    `query:"all"` // include objects that are not anomalous
+}
+
+// AnomalyDriver is one feature's contribution to an object's anomaly score.
+type AnomalyDriver struct {
+	Feature string  `json:"feature"`
+	Value   float64 `json:"value"`
+	Score   float64 `json:"score"`
+}
+
+type ObjectAnomaly struct {
+	ObjectID  int32           `json:"object_id"`
+	Class     string          `json:"class"`
+	File      string          `json:"file"`
+	Score     float64         `json:"score"`
+	Anomalous bool            `json:"anomalous"`
+	Drivers   []AnomalyDriver `json:"drivers"`
+}
+
+type AnomalyReport struct {
+	AnalysisID int32           `json:"analysis_id"`
+	Method     string          `json:"method"`
+	Features   []string        `json:"features"`
+	Threshold  float64         `json:"threshold"`
+	Objects    int             `json:"objects"`
+	Scored     int             `json:"scored"` // objects with enough non-NULL features to be scored
+	Anomalies  int             `json:"anomalies"`
+	Items      []ObjectAnomaly `json:"items"`
+}
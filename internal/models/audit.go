@@ -0,0 +1,25 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AnalysisAuditEntry is one deletion, restore or purge of an analysis, with Action
+// deleted, restored or purged. A purge entry is the tombstone of the analysis and
+// keeps its main fields in Details.
+type AnalysisAuditEntry struct {
+	ID         int32           `json:"id"`
+	AnalysisID int32           `json:"analysis_id"`
+	IDAnalysis string          `json:"id_analysis"`
+	Action     string          `json:"action"`
+	IDUser     string          `json:"id_user,omitempty"`
+	Details    json.RawMessage `json:"details"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// RetentionSummary reports one run of the retention job.
+type RetentionSummary struct {
+	Purged       int `json:"purged"`
+	FilesRemoved int `json:"files_removed"`
+}
@@ -0,0 +1,46 @@
+package models
+
+// CocoDataset is a COCO object detection annotation file. Every image is the
+// source image of one analysis and every annotation one of its objects.
+type CocoDataset struct {
+	Info        CocoInfo         `json:"info"`
+	Images      []CocoImage      `json:"images"`
+	Annotations []CocoAnnotation `json:"annotations"`
+	Categories  []CocoCategory   `json:"categories"`
+}
+
+type CocoInfo struct {
+	Description string `json:"description"`
+	Version     string `json:"version"`
+	DateCreated string `json:"date_created"`
+}
+
+type CocoImage struct {
+	ID           int32  `json:"id"`
+	FileName     string `json:"file_name"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+	DateCaptured string `json:"date_captured,omitempty"`
+	AnalysisID   string `json:"analysis_id,omitempty"`
+	Product      string `json:"product,omitempty"`
+}
+
+// CocoAnnotation is one object. Segmentation holds a single polygon and BBox is
+// [x, y, width, height]; ObjectID and File point back to the stored object and its crop.
+type CocoAnnotation struct {
+	ID           int32       `json:"id"`
+	ImageID      int32       `json:"image_id"`
+	CategoryID   int         `json:"category_id"`
+	Segmentation [][]float64 `json:"segmentation"`
+	Area         float64     `json:"area"`
+	BBox         [4]float64  `json:"bbox"`
+	IsCrowd      int         `json:"iscrowd"`
+	ObjectID     int32       `json:"object_id"`
+	File         string      `json:"file,omitempty"`
+}
+
+type CocoCategory struct {
+	ID            int    `json:"id"`
+	Name          string `json:"name"`
+	Supercategory string `json:"supercategory"`
+}
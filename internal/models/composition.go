@@ -0,0 +1,18 @@
+package models
+
+type ClassComposition struct {
+	Class      string  `json:"class"`
+	Count      int64   `json:"count"`
+	CountShare float64 `json:"count_share"`
+	Area       float64 `json:"area"`
+	AreaShare  float64 `json:"area_share"`
+	Mass       float64 `json:"mass"` // estimated by apportioning analysis mass by object area
+	MassShare  float64 `json:"mass_share"`
+}
+
+type Composition struct {
+	TotalCount int64              `json:"total_count"`
+	TotalArea  float64            `json:"total_area"`
+	TotalMass  float64            `json:"total_mass"`
+	Classes    []ClassComposition `json:"classes"`
+}
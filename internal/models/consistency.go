@@ -0,0 +1,33 @@
+package models
+
+// Outcomes of comparing a stored Stats channel with the one recomputed from objects.
+const (
+	StatsOK       = "ok"       // stored value matches the recomputed one
+	StatsMissing  = "missing"  // nothing stored for the channel
+	StatsCorrupt  = "corrupt"  // stored JSONB can't be decoded into Stats
+	StatsMismatch = "mismatch" // stored value differs from the recomputed one
+	StatsNoData   = "no_data"  // no object has a value for the channel, nothing to compare with
+)
+
+type StatsChannelCheck struct {
+	Channel  string `json:"channel"`
+	Status   string `json:"status"`
+	Stored   *Stats `json:"stored"`
+	Computed *Stats `json:"computed"`
+	Repaired bool   `json:"repaired"`
+}
+
+type StatsConsistencyReport struct {
+	ID         int32               `json:"id"`
+	IDAnalysis string              `json:"id_analysis"`
+	Objects    int                 `json:"objects"`
+	Consistent bool                `json:"consistent"`
+	Repaired   bool                `json:"repaired"`
+	Channels   []StatsChannelCheck `json:"channels"`
+}
+
+type StatsBackfillSummary struct {
+	Checked      int `json:"checked"`
+	Inconsistent int `json:"inconsistent"`
+	Repaired     int `json:"repaired"`
+}
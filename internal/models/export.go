@@ -0,0 +1,53 @@
+package models
+
+// Export file formats.
+const (
+	ExportCSV  = "csv"
+	ExportXLSX = "xlsx"
+)
+
+// ExportRequest holds the options shared by the analysis export endpoints.
+// Columns is a comma separated list of object columns, empty for all of them.
+// Lengths are stored in millimetres and masses in grams; Units and MassUnit
+// convert them on the way out. Decimal "," also switches the CSV delimiter to ";".
+type ExportRequest struct {
+	Format   string `query:"format" validate:"omitempty,oneof=csv xlsx"`
+	Columns  string `query:"columns"`
+	Units    string `query:"units" validate:"omitempty,oneof=mm cm um"`
+	MassUnit string `query:"mass_unit" validate:"omitempty,oneof=g kg mg"`
+	Decimal  string `query:"decimal" validate:"omitempty,oneof=. ,"`
+}
+
+type ExportAnalysesRequest struct {
+	ExportRequest
+	AnalysesFilter
+}
+
+// Dataset export formats.
+const (
+	ExportParquet = "parquet"
+	ExportArrow   = "arrow"
+)
+
+// DatasetExportRequest filters the objects exported for model training. From and
+// To take RFC 3339 timestamps or plain dates; a plain date in To includes that day.
+type DatasetExportRequest struct {
+	Format  string `query:"format" validate:"omitempty,oneof=parquet arrow"`
+	Product string `query:"product"`
+	Class   string `query:"class"`
+	From    string `query:"from"`
+	To      string `query:"to"`
+}
+
+// CocoExportRequest filters the objects exported as a COCO detection dataset.
+// Split takes comma separated train,val,test ratios such as "0.8,0.1,0.1"; empty
+// exports a single annotation file. Images adds the source images to a zip.
+type CocoExportRequest struct {
+	Product string `query:"product"`
+	Class   string `query:"class"`
+	From    string `query:"from"`
+	To      string `query:"to"`
+	Split   string `query:"split"`
+	Seed    int64  `query:"seed"`
+	Images  bool   `query:"images"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// ObjectLabelRequest overrides the class of an object. Reason is kept in the
+// revision history for QA.
+type ObjectLabelRequest struct {
+	Class  string `json:"class" validate:"required"`
+	Reason string `json:"reason" validate:"required"`
+}
+
+// ObjectLabel is one manual label of an object. OriginalClass is the class the
+// pipeline assigned, which is never modified.
+type ObjectLabel struct {
+	ID            int32     `json:"id"`
+	ObjectID      int32     `json:"object_id"`
+	Class         string    `json:"class"`
+	OriginalClass string    `json:"original_class"`
+	IDUser        string    `json:"id_user"`
+	Reason        string    `json:"reason"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// LabelOptions selects how object classes are returned. Class is always the
+// effective label; Original also returns the pipeline's class as original_class.
+type LabelOptions struct {
+	Original bool `query:"original"`
+}
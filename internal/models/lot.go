@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// Lot is a physical sample lot. HarvestDate is formatted as YYYY-MM-DD.
+type Lot struct {
+	ID          int32     `json:"id"`
+	LotNumber   string    `json:"lot_number"`
+	Supplier    string    `json:"supplier,omitempty"`
+	Field       string    `json:"field,omitempty"`
+	HarvestDate string    `json:"harvest_date,omitempty"`
+	Product     string    `json:"product,omitempty"`
+	Notes       string    `json:"notes,omitempty"`
+	IDUser      string    `json:"id_user"`
+	Analyses    int64     `json:"analyses"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type LotRequest struct {
+	LotNumber   string `json:"lot_number" validate:"required"`
+	Supplier    string `json:"supplier"`
+	Field       string `json:"field"`
+	HarvestDate string `json:"harvest_date"`
+	Product     string `json:"product"`
+	Notes       string `json:"notes"`
+}
+
+type GetLotsPaginatedRequest struct {
+	PaginatedRequest
+	Supplier string `query:"supplier"`
+	Product  string `query:"product"`
+}
+
+// LotAnalysesRequest attaches analyses, by id_analysis, to a lot. Analyses already
+// in another lot are moved.
+type LotAnalysesRequest struct {
+	Analyses []string `json:"analyses" validate:"required"`
+}
+
+type LotAnalysesResponse struct {
+	LotID    int32 `json:"lot_id"`
+	Attached int64 `json:"attached"`
+}
+
+// LotStats pools the objects of every analysis of a lot. Stats holds the channels
+// that can be computed from objects, keyed like the analysis Stats fields. Mass and
+// Area sum the analyses they were measured for, and are null if there are none.
+type LotStats struct {
+	LotID       int32            `json:"lot_id"`
+	Analyses    int              `json:"analyses"`
+	Objects     int              `json:"objects"`
+	Mass        *float64         `json:"mass"`
+	Area        *float64         `json:"area"`
+	Stats       map[string]Stats `json:"stats"`
+	Composition Composition      `json:"composition"`
+}
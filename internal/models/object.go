@@ -1,59 +1,59 @@
 package models
 
 type ObjectMetadata struct {
-	ID       int32   `json:"id"`
-	Class    string  `json:"class"`
-	Geometry string  `json:"geometry"`
-	MH       float64 `json:"m_h"`
-	MS       float64 `json:"m_s"`
-	MV       float64 `json:"m_v"`
-	MR       float64 `json:"m_r"`
-	MG       float64 `json:"m_g"`
-	MB       float64 `json:"m_b"`
-	LAvg     float64 `json:"l_avg"`
-	WAvg     float64 `json:"w_avg"`
-	BrtAvg   float64 `json:"brt_avg"`
-	RAvg     float64 `json:"r_avg"`
-	GAvg     float64 `json:"g_avg"`
-	BAvg     float64 `json:"b_avg"`
-	HAvg     float64 `json:"h_avg"`
-	SAvg     float64 `json:"s_avg"`
-	VAvg     float64 `json:"v_avg"`
-	H        float64 `json:"h"`
-	S        float64 `json:"s"`
-	V        float64 `json:"v"`
-	HM       float64 `json:"h_m"`
-	SM       float64 `json:"s_m"`
-	VM       float64 `json:"v_m"`
-	RM       float64 `json:"r_m"`
-	GM       float64 `json:"g_m"`
-	BM       float64 `json:"b_m"`
-	BrtM     float64 `json:"brt_m"`
-	WM       float64 `json:"w_m"`
-	LM       float64 `json:"l_m"`
-	L        float64 `json:"l"`
-	W        float64 `json:"w"`
-	LW       float64 `json:"l_w"`
-	Pr       float64 `json:"pr"`
-	Sq       float64 `json:"sq"`
-	Brt      float64 `json:"brt"`
-	R        float64 `json:"r"`
-	G        float64 `json:"g"`
-	B        float64 `json:"b"`
-	Solid    float64 `json:"solid"`
-	MinH     float64 `json:"min_h"`
-	MinS     float64 `json:"min_s"`
-	MinV     float64 `json:"min_v"`
-	MaxH     float64 `json:"max_h"`
-	MaxS     float64 `json:"max_s"`
-	MaxV     float64 `json:"max_v"`
-	Entropy  float64 `json:"entropy"`
-	ColorRhs string  `json:"color_rhs"`
-	SqSqcrl  float64 `json:"sq_sqcrl"`
-	Hu1      float64 `json:"hu1"`
-	Hu2      float64 `json:"hu2"`
-	Hu3      float64 `json:"hu3"`
-	Hu4      float64 `json:"hu4"`
-	Hu5      float64 `json:"hu5"`
-	Hu6      float64 `json:"hu6"`
+	ID       int32    `json:"id"`
+	Class    string   `json:"class"`
+	Geometry string   `json:"geometry"`
+	MH       *float64 `json:"m_h" v1:"zero"`
+	MS       *float64 `json:"m_s" v1:"zero"`
+	MV       *float64 `json:"m_v" v1:"zero"`
+	MR       *float64 `json:"m_r" v1:"zero"`
+	MG       *float64 `json:"m_g" v1:"zero"`
+	MB       *float64 `json:"m_b" v1:"zero"`
+	LAvg     *float64 `json:"l_avg" v1:"zero"`
+	WAvg     *float64 `json:"w_avg" v1:"zero"`
+	BrtAvg   *float64 `json:"brt_avg" v1:"zero"`
+	RAvg     *float64 `json:"r_avg" v1:"zero"`
+	GAvg     *float64 `json:"g_avg" v1:"zero"`
+	BAvg     *float64 `json:"b_avg" v1:"zero"`
+	HAvg     *float64 `json:"h_avg" v1:"zero"`
+	SAvg     *float64 `json:"s_avg" v1:"zero"`
+	VAvg     *float64 `json:"v_avg" v1:"zero"`
+	H        *float64 `json:"h" v1:"zero"`
+	S        *float64 `json:"s" v1:"zero"`
+	V        *float64 `json:"v" v1:"zero"`
+	HM       *float64 `json:"h_m" v1:"zero"`
+	SM       *float64 `json:"s_m" v1:"zero"`
+	VM       *float64 `json:"v_m" v1:"zero"`
+	RM       *float64 `json:"r_m" v1:"zero"`
+	GM       *float64 `json:"g_m" v1:"zero"`
+	BM       *float64 `json:"b_m" v1:"zero"`
+	BrtM     *float64 `json:"brt_m" v1:"zero"`
+	WM       *float64 `json:"w_m" v1:"zero"`
+	LM       *float64 `json:"l_m" v1:"zero"`
+	L        *float64 `json:"l" v1:"zero"`
+	W        *float64 `json:"w" v1:"zero"`
+	LW       *float64 `json:"l_w" v1:"zero"`
+	Pr       *float64 `json:"pr" v1:"zero"`
+	Sq       *float64 `json:"sq" v1:"zero"`
+	Brt      *float64 `json:"brt" v1:"zero"`
+	R        *float64 `json:"r" v1:"zero"`
+	G        *float64 `json:"g" v1:"zero"`
+	B        *float64 `json:"b" v1:"zero"`
+	Solid    *float64 `json:"solid" v1:"zero"`
+	MinH     *float64 `json:"min_h" v1:"zero"`
+	MinS     *float64 `json:"min_s" v1:"zero"`
+	MinV     *float64 `json:"min_v" v1:"zero"`
+	MaxH     *float64 `json:"max_h" v1:"zero"`
+	MaxS     *float64 `json:"max_s" v1:"zero"`
+	MaxV     *float64 `json:"max_v" v1:"zero"`
+	Entropy  *float64 `json:"entropy" v1:"zero"`
+	ColorRhs string   `json:"color_rhs"`
+	SqSqcrl  *float64 `json:"sq_sqcrl" v1:"zero"`
+	Hu1      *float64 `json:"hu1" v1:"zero"`
+	Hu2      *float64 `json:"hu2" v1:"zero"`
+	Hu3      *float64 `json:"hu3" v1:"zero"`
+	Hu4      *float64 `json:"hu4" v1:"zero"`
+	Hu5      *float64 `json:"hu5" v1:"zero"`
+	Hu6      *float64 `json:"hu6" v1:"zero"`
 }
@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// Product is a catalog entry. Code is the canonical value stored on analyses; Name
+// is localized to the requested language and Names holds every translation keyed
+// by language. Settings are default analysis parameters sent along with each
+// analysis of the product.
+type Product struct {
+	ID        int32             `json:"id"`
+	Code      string            `json:"code"`
+	Name      string            `json:"name"`
+	Names     map[string]string `json:"names"`
+	Aliases   []string          `json:"aliases"`
+	Settings  map[string]any    `json:"settings"`
+	Active    bool              `json:"active"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// ProductRequest creates or replaces a catalog entry. Settings values must be
+// strings, numbers or booleans. Active defaults to true.
+type ProductRequest struct {
+	Code     string            `json:"code" validate:"required"`
+	Names    map[string]string `json:"names"`
+	Aliases  []string          `json:"aliases"`
+	Settings map[string]any    `json:"settings"`
+	Active   *bool             `json:"active"`
+}
+
+type ProductsRequest struct {
+	Lang string `query:"lang"`
+}
+
+// UserProductsRequest restricts a user to the given product codes. An empty list
+// lets the user choose any active product.
+type UserProductsRequest struct {
+	Products []string `json:"products"`
+}
+
+// ProductMapping is one free-text product value found by the normalization and the
+// catalog code it maps to, if any.
+type ProductMapping struct {
+	Value    string `json:"value"`
+	Code     string `json:"code,omitempty"`
+	Uses     int64  `json:"uses"`
+	Analyses int64  `json:"analyses"`
+	Lots     int64  `json:"lots"`
+	Specs    int64  `json:"specs"`
+}
+
+type ProductNormalizeSummary struct {
+	Values   int              `json:"values"`
+	Mapped   int              `json:"mapped"`
+	Unknown  []ProductMapping `json:"unknown"`
+	Mappings []ProductMapping `json:"mappings"`
+}
@@ -0,0 +1,144 @@
+package models
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ProjectionRequest selects the fields of a response by JSON name, e.g.
+// ?fields=id,class,l,w,h_avg, or leaves some out with ?exclude=. Exclude is
+// applied after fields when both are given.
+type ProjectionRequest struct {
+	Fields  string `query:"fields"`
+	Exclude string `query:"exclude"`
+}
+
+// FieldList is the JSON fields of a response model, in declaration order.
+type FieldList struct {
+	names     []string
+	index     map[string]int
+	omitEmpty map[string]bool
+}
+
+// Fields of the models that support projection.
+var (
+	ObjectFields         = fieldListOf(Object{})
+	ObjectMetadataFields = fieldListOf(ObjectMetadata{})
+	AnalysisFields       = fieldListOf(Analysis{})
+)
+
+func fieldListOf(model any) *FieldList {
+	t := reflect.TypeOf(model)
+	list := &FieldList{index: map[string]int{}, omitEmpty: map[string]bool{}}
+	for i := range t.NumField() {
+		name, options, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		list.names = append(list.names, name)
+		list.index[name] = i
+		list.omitEmpty[name] = options == "omitempty"
+	}
+	return list
+}
+
+// Names returns the field names.
+func (l *FieldList) Names() []string {
+	return l.names
+}
+
+// Project returns the projection req asks for. Unknown field names are an error,
+// as is leaving no field at all.
+func (l *FieldList) Project(req ProjectionRequest) (Projection, error) {
+	if strings.TrimSpace(req.Fields) == "" && strings.TrimSpace(req.Exclude) == "" {
+		return Projection{}, nil
+	}
+
+	selected := make(map[string]bool, len(l.names))
+	if strings.TrimSpace(req.Fields) == "" {
+		for _, name := range l.names {
+			selected[name] = true
+		}
+	}
+	for _, name := range splitFields(req.Fields) {
+		if _, ok := l.index[name]; !ok {
+			return Projection{}, fmt.Errorf("unknown field %q", name)
+		}
+		selected[name] = true
+	}
+	for _, name := range splitFields(req.Exclude) {
+		if _, ok := l.index[name]; !ok {
+			return Projection{}, fmt.Errorf("unknown field %q", name)
+		}
+		delete(selected, name)
+	}
+	if len(selected) == 0 {
+		return Projection{}, fmt.Errorf("no fields selected")
+	}
+
+	p := Projection{list: l}
+	for _, name := range l.names {
+		if selected[name] {
+			p.names = append(p.names, name)
+		}
+	}
+	return p, nil
+}
+
+func splitFields(value string) []string {
+	var names []string
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Projection is a subset of the fields of a response model, in declaration order.
+// The zero Projection selects every field.
+type Projection struct {
+	list  *FieldList
+	names []string
+}
+
+// All reports whether every field is selected.
+func (p Projection) All() bool {
+	return p.list == nil
+}
+
+// Has reports whether the named field is selected.
+func (p Projection) Has(name string) bool {
+	if p.All() {
+		return true
+	}
+	for _, selected := range p.names {
+		if selected == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Names returns the selected fields, or nil if every field is.
+func (p Projection) Names() []string {
+	return p.names
+}
+
+// Each calls fn with the name and value of each selected field of v, a value of
+// the projected model or a pointer to one. Like encoding/json, empty omitempty
+// fields are skipped.
+func (p Projection) Each(v any, fn func(name string, value any) error) error {
+	value := reflect.Indirect(reflect.ValueOf(v))
+	for _, name := range p.names {
+		field := value.Field(p.list.index[name])
+		if p.list.omitEmpty[name] && field.IsZero() {
+			continue
+		}
+		if err := fn(name, field.Interface()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestProject(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     ProjectionRequest
+		want    []string
+		all     bool
+		wantErr bool
+	}{
+		{name: "no parameters", all: true},
+		{name: "blank parameters", req: ProjectionRequest{Fields: " ", Exclude: " "}, all: true},
+		{name: "fields in declaration order", req: ProjectionRequest{Fields: "m_h, class,id"}, want: []string{"id", "class", "m_h"}},
+		{name: "exclude only", req: ProjectionRequest{Exclude: "geometry"}, want: withoutField(ObjectMetadataFields.Names(), "geometry")},
+		{name: "exclude applied after fields", req: ProjectionRequest{Fields: "id,class,m_h", Exclude: "class"}, want: []string{"id", "m_h"}},
+		{name: "unknown field", req: ProjectionRequest{Fields: "id,weight"}, wantErr: true},
+		{name: "unknown excluded field", req: ProjectionRequest{Exclude: "weight"}, wantErr: true},
+		{name: "every field excluded", req: ProjectionRequest{Fields: "id", Exclude: "id"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := ObjectMetadataFields.Project(tt.req)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("got projection %v, want an error", p.Names())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p.All() != tt.all {
+				t.Errorf("got all %v, want %v", p.All(), tt.all)
+			}
+			if fmt.Sprint(p.Names()) != fmt.Sprint(tt.want) {
+				t.Errorf("got fields %v, want %v", p.Names(), tt.want)
+			}
+		})
+	}
+}
+
+func TestProjectionEach(t *testing.T) {
+	p, err := ObjectFields.Project(ProjectionRequest{Fields: "id,class,original_class"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.Has("class") || p.Has("geometry") {
+		t.Errorf("got has class %v and geometry %v, want true and false", p.Has("class"), p.Has("geometry"))
+	}
+
+	var got []string
+	err = p.Each(&Object{ID: 7, Class: "broken"}, func(name string, value any) error {
+		got = append(got, fmt.Sprintf("%s=%v", name, value))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// original_class is omitempty and empty, so it's left out
+	if want := "[id=7 class=broken]"; fmt.Sprint(got) != want {
+		t.Errorf("got %v, want %s", got, want)
+	}
+}
+
+func withoutField(names []string, name string) []string {
+	var rest []string
+	for _, n := range names {
+		if n != name {
+			rest = append(rest, n)
+		}
+	}
+	return rest
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// ReportData is what report templates are executed with.
+type ReportData struct {
+	Analysis    Analysis
+	Stats       []ReportStats
+	Composition Composition
+	Verdict     *SpecVerdict
+	HasImage    bool
+	HasLogo     bool
+	GeneratedAt time.Time
+}
+
+// ReportStats is one row of the Stats table. Unit is empty for unitless channels.
+type ReportStats struct {
+	Channel string
+	Unit    string
+	Stats
+}
+
+// ReportHistogram is the distribution of one object feature over equal-width bins.
+type ReportHistogram struct {
+	Feature string
+	Min     float64
+	Max     float64
+	Objects int
+	Counts  []int
+}
@@ -0,0 +1,93 @@
+package models
+
+import "time"
+
+// Review queue selection strategies.
+const (
+	ReviewAnomaly   = "anomaly"    // objects flagged by anomaly detection, most anomalous first
+	ReviewRareClass = "rare_class" // objects of classes below a share of the selected objects
+	ReviewRandom    = "random"     // a random sample of every analysis
+)
+
+// Review item statuses. An item is done once it has RequiredReviews labels.
+const (
+	ReviewPending = "pending"
+	ReviewDone    = "done"
+)
+
+// ReviewPopulateRequest selects objects into the review queue. Analyses holds
+// id_analysis values; when empty, every analysis of Product is considered.
+type ReviewPopulateRequest struct {
+	Strategy        string   `json:"strategy" validate:"required,oneof=anomaly rare_class random"`
+	Analyses        []string `json:"analyses"`
+	Product         string   `json:"product"`
+	Limit           int      `json:"limit"`            // maximum number of items added, 0 for the default
+	PerAnalysis     int      `json:"per_analysis"`     // random: objects sampled per analysis
+	Threshold       float64  `json:"threshold"`        // anomaly: robust z-score threshold
+	MinShare        float64  `json:"min_share"`        // rare_class: classes below this share are rare
+	RequiredReviews int32    `json:"required_reviews"` // labels needed before an item is done
+}
+
+type ReviewPopulateResponse struct {
+	Strategy string `json:"strategy"`
+	Analyses int    `json:"analyses"`
+	Selected int    `json:"selected"`
+	Enqueued int64  `json:"enqueued"` // selected objects that weren't queued already
+}
+
+// ReviewItem is a queued object with what a reviewer needs to label it. ImageURL
+// serves the object's crop.
+type ReviewItem struct {
+	ID              int32              `json:"id"`
+	Strategy        string             `json:"strategy"`
+	Score           float64            `json:"score"`
+	Status          string             `json:"status"`
+	RequiredReviews int32              `json:"required_reviews"`
+	CreatedAt       time.Time          `json:"created_at"`
+	ImageURL        string             `json:"image_url"`
+	Object          Object             `json:"object"`
+	Features        map[string]float64 `json:"features"`
+}
+
+// ReviewSubmitRequest is a reviewer's answer: a class, or Skip when unsure.
+type ReviewSubmitRequest struct {
+	Class string `json:"class"`
+	Skip  bool   `json:"skip"`
+}
+
+type ReviewLabel struct {
+	ID        int32     `json:"id"`
+	QueueID   int32     `json:"queue_id"`
+	IDUser    string    `json:"id_user"`
+	Class     string    `json:"class,omitempty"`
+	Skipped   bool      `json:"skipped"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ReviewAgreement summarizes how consistently reviewers label the same items.
+// PercentAgreement is the share of agreeing label pairs on items with at least
+// two labels, and Kappa corrects it for chance (Fleiss' kappa).
+type ReviewAgreement struct {
+	Queue            map[string]int64    `json:"queue"`
+	Labels           int                 `json:"labels"`
+	Skips            int                 `json:"skips"`
+	MultiReviewed    int                 `json:"multi_reviewed"`
+	PercentAgreement float64             `json:"percent_agreement"`
+	Kappa            float64             `json:"kappa"`
+	Reviewers        []ReviewerAgreement `json:"reviewers"`
+}
+
+// ReviewerAgreement is how often a reviewer agrees with the other reviewers of the
+// same items, over the items that somebody else labeled too.
+type ReviewerAgreement struct {
+	IDUser    string  `json:"id_user"`
+	Labels    int     `json:"labels"`
+	Skips     int     `json:"skips"`
+	Compared  int     `json:"compared"`
+	Agreement float64 `json:"agreement"`
+}
+
+// ReviewExportRequest selects the format of the collected review labels.
+type ReviewExportRequest struct {
+	Format string `query:"format" validate:"omitempty,oneof=csv parquet"`
+}
@@ -0,0 +1,21 @@
+package models
+
+type SimilarObjectsRequest struct {
+	K        int    `query:"k"`
+	Features string `query:"features"` // comma-separated feature or group names
+}
+
+type SimilarObject struct {
+	ObjectID   int32   `json:"object_id"`
+	AnalysisID int64   `json:"analysis_id"`
+	IDAnalysis string  `json:"id_analysis"`
+	Class      string  `json:"class"`
+	File       string  `json:"file"`
+	Distance   float64 `json:"distance"`
+}
+
+type SimilarObjectsResponse struct {
+	ObjectID int32           `json:"object_id"`
+	Features []string        `json:"features"`
+	Items    []SimilarObject `json:"items"`
+}
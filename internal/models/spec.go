@@ -0,0 +1,64 @@
+package models
+
+import "time"
+
+// Spec rule types understood by the evaluation engine.
+const (
+	RuleClassShare = "class_share" // share of objects (by count) of a given class
+	RuleStat       = "stat"        // bound on a field of one of the analysis Stats channels
+	RuleColorRhs   = "color_rhs"   // analysis color_rhs must be one of the allowed values
+)
+
+// Rule severities. A violated "fail" rule fails the analysis, a violated
+// "warn" rule only downgrades a pass to a warning.
+const (
+	SeverityFail = "fail"
+	SeverityWarn = "warn"
+)
+
+// Verdicts assigned to an analysis after grading it against its product spec.
+const (
+	VerdictPass = "pass"
+	VerdictWarn = "warn"
+	VerdictFail = "fail"
+)
+
+type SpecRule struct {
+	Type     string   `json:"type"`
+	Severity string   `json:"severity,omitempty"`
+	Class    string   `json:"class,omitempty"`
+	Channel  string   `json:"channel,omitempty"`
+	Field    string   `json:"field,omitempty"`
+	Min      *float64 `json:"min,omitempty"`
+	Max      *float64 `json:"max,omitempty"`
+	Values   []string `json:"values,omitempty"`
+}
+
+type ProductSpec struct {
+	ID        int32      `json:"id"`
+	Product   string     `json:"product"`
+	Name      string     `json:"name"`
+	Rules     []SpecRule `json:"rules"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+type ProductSpecRequest struct {
+	Product string     `json:"product"`
+	Name    string     `json:"name"`
+	Rules   []SpecRule `json:"rules"`
+}
+
+type SpecViolation struct {
+	Rule     SpecRule    `json:"rule"`
+	Severity string      `json:"severity"`
+	Actual   interface{} `json:"actual"`
+	Message  string      `json:"message"`
+}
+
+type SpecVerdict struct {
+	SpecID      int32           `json:"spec_id"`
+	Verdict     string          `json:"verdict"`
+	Violations  []SpecViolation `json:"violations"`
+	EvaluatedAt time.Time       `json:"evaluated_at"`
+}
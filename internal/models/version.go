@@ -0,0 +1,50 @@
+package models
+
+import "reflect"
+
+// Response versions. Version 1, the default, renders missing measurements as 0 like
+// the service always did; version 2 renders them as null, so "not measured" can be
+// told apart from a measured 0.
+const (
+	ResponseV1 = 1
+	ResponseV2 = 2
+
+	LatestResponseVersion = ResponseV2
+)
+
+// ZeroNulls replaces every missing measurement reachable from v with a zero value,
+// turning a response into its version 1 shape. Measurements are the nullable
+// fields of Analysis, Object and ObjectMetadata tagged v1:"zero"; other nullable
+// fields, such as LotID or the bounds of a spec rule, are left alone. v must be a
+// pointer.
+func ZeroNulls(v any) {
+	zeroNulls(reflect.ValueOf(v))
+}
+
+func zeroNulls(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			zeroNulls(v.Elem())
+		}
+	case reflect.Struct:
+		for i := range v.NumField() {
+			field := v.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			value := v.Field(i)
+			if field.Tag.Get("v1") == "zero" && value.Kind() == reflect.Pointer && value.IsNil() {
+				if value.CanSet() {
+					value.Set(reflect.New(field.Type.Elem()))
+				}
+				continue
+			}
+			zeroNulls(value)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := range v.Len() {
+			zeroNulls(v.Index(i))
+		}
+	}
+}
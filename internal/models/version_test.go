@@ -0,0 +1,56 @@
+package models
+
+import "testing"
+
+func TestZeroNulls(t *testing.T) {
+	mass := 12.5
+	page := &PaginatedResponse[Analysis]{Data: []Analysis{{
+		Mass: &mass,
+		Verdict: &SpecVerdict{
+			Verdict: VerdictFail,
+			Violations: []SpecViolation{{
+				Rule: SpecRule{Type: RuleClassShare, Class: "broken", Min: &mass},
+			}},
+		},
+		Objects: []Object{{ID: 1}},
+	}}}
+
+	ZeroNulls(page)
+
+	analysis := page.Data[0]
+	if analysis.Mass == nil || *analysis.Mass != 12.5 {
+		t.Errorf("got mass %v, want the measured 12.5 kept", analysis.Mass)
+	}
+	if analysis.Area == nil || *analysis.Area != 0 {
+		t.Errorf("got area %v, want 0", analysis.Area)
+	}
+	if analysis.R == nil || *analysis.R != (Stats{}) {
+		t.Errorf("got r %v, want zero stats", analysis.R)
+	}
+	object := analysis.Objects[0]
+	if object.MH == nil || *object.MH != 0 || object.IDImage == nil || *object.IDImage != 0 {
+		t.Errorf("got m_h %v and id_image %v, want 0", object.MH, object.IDImage)
+	}
+
+	// Nullable fields that aren't measurements keep their null
+	if analysis.LotID != nil {
+		t.Errorf("got lot id %v, want nil", *analysis.LotID)
+	}
+	rule := analysis.Verdict.Violations[0].Rule
+	if rule.Max != nil {
+		t.Errorf("got rule max %v, want nil", *rule.Max)
+	}
+	if rule.Min == nil || *rule.Min != 12.5 {
+		t.Errorf("got rule min %v, want 12.5", rule.Min)
+	}
+}
+
+func TestZeroNullsObjectMetadata(t *testing.T) {
+	objects := []ObjectMetadata{{ID: 1}, {ID: 2}}
+	ZeroNulls(&objects)
+	for _, object := range objects {
+		if object.LAvg == nil || object.Hu6 == nil {
+			t.Errorf("object %d: got l_avg %v and hu6 %v, want 0", object.ID, object.LAvg, object.Hu6)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"csort.ru/analysis-service/internal/middleware"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Spec describes a route for the OpenAPI document. Types are given as values,
+// e.g. models.Lot{}, and their schemas are derived from their json, query and
+// validate tags.
+type Spec struct {
+	Summary     string
+	Description string
+	// User marks routes that require the Telegram-User-ID header
+	User bool
+	// Versioned marks routes that honor the API-Version header
+	Versioned bool
+	// Query are structs whose query-tagged fields are query parameters
+	Query []any
+	// Params are query parameters read one by one
+	Params []Param
+	// Headers are request headers other than Telegram-User-ID and API-Version
+	Headers []Param
+	// Body is the JSON request body
+	Body any
+	// Form are the fields of a multipart/form-data request body
+	Form []Param
+	// Result is the result of the success envelope
+	Result any
+	// Status is the success status, 200 by default
+	Status int
+	// NoContent marks routes that may also answer 204 No Content
+	NoContent bool
+	// NDJSON marks list results that can also be streamed as application/x-ndjson
+	NDJSON bool
+	// Produces are the media types of a success body that isn't JSON, which is
+	// sent without the envelope
+	Produces []string
+}
+
+// Param is a request parameter or form field, a string unless Schema says
+// otherwise.
+type Param struct {
+	Name        string
+	Description string
+	Required    bool
+	Schema      *Schema
+}
+
+// Route is a route of the API, as registered with Fiber.
+type Route struct {
+	Method      string
+	Path        string
+	OperationID string
+	Spec        *Spec
+}
+
+// Config describes the API as a whole.
+type Config struct {
+	Title   string
+	Version string
+	// BasePath is the prefix of the route paths, e.g. /api/v1
+	BasePath string
+}
+
+var pathParam = regexp.MustCompile(`:(\w+)`)
+
+// Build generates the document of routes. Every route needs a spec, and an
+// operation id unique in the API.
+func Build(cfg Config, routes []Route) (*Document, error) {
+	b := &builder{schemas: newSchemas()}
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    Info{Title: cfg.Title, Version: cfg.Version},
+		Servers: []Server{{URL: cfg.BasePath}},
+		Paths:   map[string]PathItem{},
+	}
+
+	operationIDs := map[string]bool{}
+	tags := map[string]bool{}
+	for _, route := range routes {
+		if route.Spec == nil {
+			return nil, fmt.Errorf("route %s %s has no OpenAPI spec", route.Method, route.Path)
+		}
+		if operationIDs[route.OperationID] {
+			return nil, fmt.Errorf("route %s %s reuses operation id %q", route.Method, route.Path, route.OperationID)
+		}
+		operationIDs[route.OperationID] = true
+
+		operation := b.operation(route)
+		tags[operation.Tags[0]] = true
+
+		path := pathParam.ReplaceAllString(route.Path, "{$1}")
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = PathItem{}
+		}
+		doc.Paths[path][strings.ToLower(route.Method)] = operation
+	}
+
+	for tag := range tags {
+		doc.Tags = append(doc.Tags, Tag{Name: tag})
+	}
+	sort.Slice(doc.Tags, func(i, j int) bool { return doc.Tags[i].Name < doc.Tags[j].Name })
+
+	doc.Components = Components{
+		Schemas: b.schemas.components,
+		Responses: map[string]Response{
+			"Error": {
+				Description: "Error, as problem details for clients accepting " + middleware.MIMEApplicationProblemJSON,
+				Content: map[string]MediaType{
+					fiber.MIMEApplicationJSON:             {Schema: b.schemas.of(reflect.TypeOf(middleware.ErrorResponse{}))},
+					middleware.MIMEApplicationProblemJSON: {Schema: b.schemas.of(reflect.TypeOf(middleware.Problem{}))},
+				},
+			},
+		},
+	}
+	return doc, nil
+}
+
+type builder struct {
+	schemas *schemas
+}
+
+func (b *builder) operation(route Route) *Operation {
+	spec := route.Spec
+	operation := &Operation{
+		OperationID: route.OperationID,
+		Summary:     spec.Summary,
+		Description: spec.Description,
+		// Routes are grouped by resource, the first segment of their path
+		Tags:      []string{strings.Split(strings.TrimPrefix(route.Path, "/"), "/")[0]},
+		Responses: map[string]Response{},
+	}
+
+	for _, match := range pathParam.FindAllStringSubmatch(route.Path, -1) {
+		operation.Parameters = append(operation.Parameters, Parameter{
+			Name:     match[1],
+			In:       "path",
+			Required: true,
+			Schema:   &Schema{Type: "string"},
+		})
+	}
+	if spec.User {
+		operation.Parameters = append(operation.Parameters, Parameter{
+			Name:        "Telegram-User-ID",
+			In:          "header",
+			Description: "Telegram id of the calling user",
+			Required:    true,
+			Schema:      &Schema{Type: "integer", Format: "int64"},
+		})
+	}
+	if spec.Versioned {
+		operation.Parameters = append(operation.Parameters, Parameter{
+			Name:        "API-Version",
+			In:          "header",
+			Description: "Response version, also accepted as the api_version query parameter. Version 1, the default, sends missing measurements as 0 instead of null.",
+			Schema:      &Schema{Type: "integer", Enum: []any{1, 2}},
+		})
+	}
+	for _, header := range spec.Headers {
+		operation.Parameters = append(operation.Parameters, header.parameter("header"))
+	}
+	for _, query := range spec.Query {
+		operation.Parameters = append(operation.Parameters, b.schemas.parameters(reflect.TypeOf(query))...)
+	}
+	for _, param := range spec.Params {
+		operation.Parameters = append(operation.Parameters, param.parameter("query"))
+	}
+
+	switch {
+	case spec.Body != nil:
+		operation.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]MediaType{fiber.MIMEApplicationJSON: {Schema: b.schemas.of(reflect.TypeOf(spec.Body))}},
+		}
+	case spec.Form != nil:
+		form := &Schema{Type: "object", Properties: map[string]*Schema{}}
+		for _, field := range spec.Form {
+			form.Properties[field.Name] = field.schema()
+			if field.Required {
+				form.Required = append(form.Required, field.Name)
+			}
+		}
+		operation.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]MediaType{fiber.MIMEMultipartForm: {Schema: form}},
+		}
+	}
+
+	status := spec.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := Response{Description: http.StatusText(status)}
+	switch {
+	case status == http.StatusNoContent:
+	case spec.Produces != nil:
+		success.Content = map[string]MediaType{}
+		for _, mediaType := range spec.Produces {
+			success.Content[mediaType] = MediaType{Schema: Binary(mediaType)}
+		}
+	default:
+		result := &Schema{}
+		if spec.Result != nil {
+			result = b.schemas.of(reflect.TypeOf(spec.Result))
+		}
+		success.Content = map[string]MediaType{fiber.MIMEApplicationJSON: {Schema: envelope(result)}}
+		if spec.NDJSON && result.Items != nil {
+			success.Content["application/x-ndjson"] = MediaType{Schema: result.Items}
+			success.Description += ". As application/x-ndjson, one item per line without the envelope."
+		}
+	}
+	operation.Responses[strconv.Itoa(status)] = success
+	if spec.NoContent {
+		operation.Responses[strconv.Itoa(http.StatusNoContent)] = Response{Description: http.StatusText(http.StatusNoContent)}
+	}
+
+	errorResponse := Response{Ref: "#/components/responses/Error"}
+	operation.Responses["4XX"] = errorResponse
+	operation.Responses["5XX"] = errorResponse
+	return operation
+}
+
+// envelope is the schema of the middleware.SuccessResponse around a result.
+func envelope(result *Schema) *Schema {
+	return &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"success": {Type: "boolean", Const: true},
+			"result":  result,
+		},
+		Required: []string{"success", "result"},
+	}
+}
+
+func (p Param) schema() *Schema {
+	if p.Schema != nil {
+		return p.Schema
+	}
+	return &Schema{Type: "string"}
+}
+
+func (p Param) parameter(in string) Parameter {
+	return Parameter{
+		Name:        p.Name,
+		In:          in,
+		Description: p.Description,
+		Required:    p.Required,
+		Schema:      p.schema(),
+	}
+}
@@ -0,0 +1,205 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// schemas derives JSON Schemas from Go types the way encoding/json encodes them.
+// Named structs become components, referenced by name.
+type schemas struct {
+	components map[string]*Schema
+	names      map[reflect.Type]string
+}
+
+func newSchemas() *schemas {
+	return &schemas{
+		components: map[string]*Schema{},
+		names:      map[reflect.Type]string{},
+	}
+}
+
+func (s *schemas) of(t reflect.Type) *Schema {
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case rawMessageType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return nullable(s.of(t.Elem()))
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: s.of(t.Elem())}
+	case reflect.Array:
+		n := t.Len()
+		return &Schema{Type: "array", Items: s.of(t.Elem()), MinItems: &n, MaxItems: &n}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: s.of(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.object(t)
+		}
+		return s.ref(t)
+	}
+	// Interfaces can hold anything
+	return &Schema{}
+}
+
+// ref returns a reference to the component of a named struct, adding it first if
+// needed.
+func (s *schemas) ref(t reflect.Type) *Schema {
+	name, ok := s.names[t]
+	if !ok {
+		name = s.componentName(t)
+		s.names[t] = name
+		// Registered before it is built, for types that refer to themselves
+		s.components[name] = &Schema{}
+		*s.components[name] = *s.object(t)
+	}
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// componentName names the component of a type after the type. Instances of
+// generic types are named after their type arguments too, e.g.
+// PaginatedResponse[models.Lot] is PaginatedResponseLot.
+func (s *schemas) componentName(t reflect.Type) string {
+	name := t.Name()
+	if base, args, ok := strings.Cut(name, "["); ok {
+		name = base
+		for _, arg := range strings.Split(strings.TrimSuffix(args, "]"), ",") {
+			name += arg[strings.LastIndexAny(arg, ".]*")+1:]
+		}
+	}
+	name = string(unicode.ToUpper(rune(name[0]))) + name[1:]
+	if _, taken := s.components[name]; taken {
+		// Same name in another package
+		pkg := t.PkgPath()[strings.LastIndex(t.PkgPath(), "/")+1:]
+		name = string(unicode.ToUpper(rune(pkg[0]))) + pkg[1:] + name
+	}
+	return name
+}
+
+// object returns the schema of a struct from its json tags. Fields with a
+// validate:"required" tag are required, and oneof validations become enums.
+func (s *schemas) object(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	eachField(t, "json", func(field reflect.StructField, name string) {
+		property := s.of(field.Type)
+		if enum := oneOf(field); enum != nil {
+			property.Enum = enum
+		}
+		schema.Properties[name] = property
+		if isRequired(field) {
+			schema.Required = append(schema.Required, name)
+		}
+	})
+	return schema
+}
+
+// parameters returns the query parameters of a struct from its query tags.
+func (s *schemas) parameters(t reflect.Type) []Parameter {
+	var params []Parameter
+	eachField(t, "query", func(field reflect.StructField, name string) {
+		schema := s.of(derefType(field.Type))
+		if enum := oneOf(field); enum != nil {
+			schema.Enum = enum
+		}
+		params = append(params, Parameter{
+			Name:     name,
+			In:       "query",
+			Required: isRequired(field),
+			Schema:   schema,
+		})
+	})
+	return params
+}
+
+// eachField calls fn on the exported fields of a struct that are named in the
+// given tag, or by their Go name for json, with embedded structs flattened.
+func eachField(t reflect.Type, tag string, fn func(field reflect.StructField, name string)) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" && derefType(field.Type).Kind() == reflect.Struct {
+			eachField(derefType(field.Type), tag, fn)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			if tag != "json" {
+				continue
+			}
+			name = field.Name
+		}
+		fn(field, name)
+	}
+}
+
+func isRequired(field reflect.StructField) bool {
+	for _, rule := range strings.Split(field.Tag.Get("validate"), ",") {
+		if rule == "required" {
+			return true
+		}
+	}
+	return false
+}
+
+// oneOf returns the values of a oneof validation, or nil. It is taken to be the
+// last rule, as its values may include a comma, e.g. oneof=. ,
+func oneOf(field reflect.StructField) []any {
+	_, values, ok := strings.Cut(field.Tag.Get("validate"), "oneof=")
+	if !ok {
+		return nil
+	}
+	var enum []any
+	for _, value := range strings.Fields(values) {
+		enum = append(enum, value)
+	}
+	return enum
+}
+
+// nullable allows null in place of a value of schema.
+func nullable(schema *Schema) *Schema {
+	if name, ok := schema.Type.(string); ok && schema.Ref == "" {
+		schema.Type = []string{name, "null"}
+		return schema
+	}
+	return &Schema{AnyOf: []*Schema{schema, {Type: "null"}}}
+}
+
+func derefType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
@@ -17,25 +17,37 @@ FROM analysis
 WHERE id_user = $1
   AND ($2::TEXT IS NULL OR $2 = '' OR product = $2)
   AND ($3::TEXT IS NULL OR $3 = '' OR CAST(id_analysis AS TEXT) LIKE '%' || $3 || '%')
+  AND ($4::TEXT IS NULL OR $4 = '' OR id IN (SELECT analysis_id FROM analysis_verdicts WHERE verdict = $4))
+  AND ($5::TEXT = '' OR id IN (SELECT analysis_id FROM analysis_tags WHERE tag = $5))
+  AND deleted_at IS NULL
 `
 
 type CountAnalysesByUserIDParams struct {
 	IDUser     pgtype.Text `json:"id_user"`
 	Product    string      `json:"product"`
 	IDAnalysis string      `json:"id_analysis"`
+	Verdict    string      `json:"verdict"`
+	Tag        string      `json:"tag"`
 }
 
 func (q *Queries) CountAnalysesByUserID(ctx context.Context, arg CountAnalysesByUserIDParams) (int64, error) {
-	row := q.db.QueryRow(ctx, countAnalysesByUserID, arg.IDUser, arg.Product, arg.IDAnalysis)
+	row := q.db.QueryRow(ctx, countAnalysesByUserID,
+		arg.IDUser,
+		arg.Product,
+		arg.IDAnalysis,
+		arg.Verdict,
+		arg.Tag,
+	)
 	var count int64
 	err := row.Scan(&count)
 	return count, err
 }
 
 const getAnalysesByIDs = `-- name: GetAnalysesByIDs :many
-SELECT id, date_time, product, color_rhs, id_user, telegram_link, text, file_source, scale_mm_pixel, mass, area, r, g, b, h, s, v, lab_l, lab_a, lab_b, w, l, t, file_output, id_analysis
+SELECT id, date_time, product, color_rhs, id_user, telegram_link, text, file_source, scale_mm_pixel, mass, area, r, g, b, h, s, v, lab_l, lab_a, lab_b, w, l, t, file_output, id_analysis, version, deleted_at
 FROM analysis
 WHERE id = ANY($1::int[])
+  AND deleted_at IS NULL
 `
 
 func (q *Queries) GetAnalysesByIDs(ctx context.Context, ids []int32) ([]Analysis, error) {
@@ -73,6 +85,64 @@ func (q *Queries) GetAnalysesByIDs(ctx context.Context, ids []int32) ([]Analysis
 			&i.T,
 			&i.FileOutput,
 			&i.IDAnalysis,
+			&i.Version,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAnalysesByProduct = `-- name: GetAnalysesByProduct :many
+SELECT id, date_time, product, color_rhs, id_user, telegram_link, text, file_source, scale_mm_pixel, mass, area, r, g, b, h, s, v, lab_l, lab_a, lab_b, w, l, t, file_output, id_analysis, version, deleted_at
+FROM analysis
+WHERE product = $1
+  AND deleted_at IS NULL
+ORDER BY id
+`
+
+func (q *Queries) GetAnalysesByProduct(ctx context.Context, product pgtype.Text) ([]Analysis, error) {
+	rows, err := q.db.Query(ctx, getAnalysesByProduct, product)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Analysis{}
+	for rows.Next() {
+		var i Analysis
+		if err := rows.Scan(
+			&i.ID,
+			&i.DateTime,
+			&i.Product,
+			&i.ColorRhs,
+			&i.IDUser,
+			&i.TelegramLink,
+			&i.Text,
+			&i.FileSource,
+			&i.ScaleMmPixel,
+			&i.Mass,
+			&i.Area,
+			&i.R,
+			&i.G,
+			&i.B,
+			&i.H,
+			&i.S,
+			&i.V,
+			&i.LabL,
+			&i.LabA,
+			&i.LabB,
+			&i.W,
+			&i.L,
+			&i.T,
+			&i.FileOutput,
+			&i.IDAnalysis,
+			&i.Version,
+			&i.DeletedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -85,26 +155,32 @@ func (q *Queries) GetAnalysesByIDs(ctx context.Context, ids []int32) ([]Analysis
 }
 
 const getAnalysesByUserTelegramIDPagination = `-- name: GetAnalysesByUserTelegramIDPagination :many
-SELECT id, date_time, product, color_rhs, id_user, telegram_link, text, file_source, scale_mm_pixel, mass, area, r, g, b, h, s, v, lab_l, lab_a, lab_b, w, l, t, file_output, id_analysis
+SELECT id, date_time, product, color_rhs, id_user, telegram_link, text, file_source, scale_mm_pixel, mass, area, r, g, b, h, s, v, lab_l, lab_a, lab_b, w, l, t, file_output, id_analysis, version, deleted_at
 FROM analysis
 WHERE id_user = $1
   AND ($2::TEXT IS NULL OR $2 = '' OR product = $2)
   AND ($3::TEXT IS NULL OR $3 = '' OR CAST(id_analysis AS TEXT) LIKE '%' || $3 || '%')
+  AND ($4::TEXT IS NULL OR $4 = '' OR id IN (SELECT analysis_id FROM analysis_verdicts WHERE verdict = $4))
+  AND ($5::TEXT = '' OR id IN (SELECT analysis_id FROM analysis_tags WHERE tag = $5))
+  AND deleted_at IS NULL
 ORDER BY
-    CASE WHEN $4 = 'date_time' AND $5 = 'asc' THEN date_time END ASC,
-    CASE WHEN $4 = 'date_time' AND $5 = 'desc' THEN date_time END DESC,
-    CASE WHEN $4 = 'id' AND $5 = 'asc' THEN id END ASC,
-    CASE WHEN $4 = 'id' AND $5 = 'desc' THEN id END DESC,
-    CASE WHEN $4 = 'product' AND $5 = 'asc' THEN product END ASC,
-    CASE WHEN $4 = 'product' AND $5 = 'desc' THEN product END DESC
-LIMIT $7::int
-OFFSET $6::int
+    CASE WHEN $6 = 'date_time' AND $7 = 'asc' THEN date_time END ASC,
+    CASE WHEN $6 = 'date_time' AND $7 = 'desc' THEN date_time END DESC,
+    CASE WHEN $6 = 'id' AND $7 = 'asc' THEN id END ASC,
+    CASE WHEN $6 = 'id' AND $7 = 'desc' THEN id END DESC,
+    CASE WHEN $6 = 'product' AND $7 = 'asc' THEN product END ASC,
+    CASE WHEN $6 = 'product' AND $7 = 'desc' THEN product END DESC,
+    id
+LIMIT $9::int
+OFFSET $8::int
 `
 
 type GetAnalysesByUserTelegramIDPaginationParams struct {
 	IDUser     pgtype.Text `json:"id_user"`
 	Product    string      `json:"product"`
 	IDAnalysis string      `json:"id_analysis"`
+	Verdict    string      `json:"verdict"`
+	Tag        string      `json:"tag"`
 	SortBy     interface{} `json:"sort_by"`
 	SortOrder  interface{} `json:"sort_order"`
 	Offset     int32       `json:"offset"`
@@ -116,6 +192,8 @@ func (q *Queries) GetAnalysesByUserTelegramIDPagination(ctx context.Context, arg
 		arg.IDUser,
 		arg.Product,
 		arg.IDAnalysis,
+		arg.Verdict,
+		arg.Tag,
 		arg.SortBy,
 		arg.SortOrder,
 		arg.Offset,
@@ -154,6 +232,8 @@ func (q *Queries) GetAnalysesByUserTelegramIDPagination(ctx context.Context, arg
 			&i.T,
 			&i.FileOutput,
 			&i.IDAnalysis,
+			&i.Version,
+			&i.DeletedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -167,9 +247,10 @@ func (q *Queries) GetAnalysesByUserTelegramIDPagination(ctx context.Context, arg
 
 const getAnalysisByID = `-- name: GetAnalysisByID :one
 
-SELECT id, date_time, product, color_rhs, id_user, telegram_link, text, file_source, scale_mm_pixel, mass, area, r, g, b, h, s, v, lab_l, lab_a, lab_b, w, l, t, file_output, id_analysis
+SELECT id, date_time, product, color_rhs, id_user, telegram_link, text, file_source, scale_mm_pixel, mass, area, r, g, b, h, s, v, lab_l, lab_a, lab_b, w, l, t, file_output, id_analysis, version, deleted_at
 FROM analysis
 WHERE id_analysis = $1
+  AND deleted_at IS NULL
 `
 
 // Queries for the analysis table
@@ -202,6 +283,179 @@ func (q *Queries) GetAnalysisByID(ctx context.Context, idAnalysis pgtype.Text) (
 		&i.T,
 		&i.FileOutput,
 		&i.IDAnalysis,
+		&i.Version,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const getAnalysisByInternalID = `-- name: GetAnalysisByInternalID :one
+SELECT id, date_time, product, color_rhs, id_user, telegram_link, text, file_source, scale_mm_pixel, mass, area, r, g, b, h, s, v, lab_l, lab_a, lab_b, w, l, t, file_output, id_analysis, version, deleted_at
+FROM analysis
+WHERE id = $1
+  AND deleted_at IS NULL
+`
+
+func (q *Queries) GetAnalysisByInternalID(ctx context.Context, id int32) (Analysis, error) {
+	row := q.db.QueryRow(ctx, getAnalysisByInternalID, id)
+	var i Analysis
+	err := row.Scan(
+		&i.ID,
+		&i.DateTime,
+		&i.Product,
+		&i.ColorRhs,
+		&i.IDUser,
+		&i.TelegramLink,
+		&i.Text,
+		&i.FileSource,
+		&i.ScaleMmPixel,
+		&i.Mass,
+		&i.Area,
+		&i.R,
+		&i.G,
+		&i.B,
+		&i.H,
+		&i.S,
+		&i.V,
+		&i.LabL,
+		&i.LabA,
+		&i.LabB,
+		&i.W,
+		&i.L,
+		&i.T,
+		&i.FileOutput,
+		&i.IDAnalysis,
+		&i.Version,
+		&i.DeletedAt,
 	)
 	return i, err
 }
+
+const getAnalysisIDByInternalID = `-- name: GetAnalysisIDByInternalID :one
+SELECT id_analysis
+FROM analysis
+WHERE id = $1
+`
+
+// Also finds soft-deleted analyses, so they can be restored by their internal id.
+func (q *Queries) GetAnalysisIDByInternalID(ctx context.Context, id int32) (pgtype.Text, error) {
+	row := q.db.QueryRow(ctx, getAnalysisIDByInternalID, id)
+	var id_analysis pgtype.Text
+	err := row.Scan(&id_analysis)
+	return id_analysis, err
+}
+
+const listAnalysesAfterID = `-- name: ListAnalysesAfterID :many
+SELECT id, date_time, product, color_rhs, id_user, telegram_link, text, file_source, scale_mm_pixel, mass, area, r, g, b, h, s, v, lab_l, lab_a, lab_b, w, l, t, file_output, id_analysis, version, deleted_at
+FROM analysis
+WHERE id > $1
+  AND deleted_at IS NULL
+ORDER BY id
+LIMIT $2::int
+`
+
+type ListAnalysesAfterIDParams struct {
+	AfterID int32 `json:"after_id"`
+	Limit   int32 `json:"limit"`
+}
+
+func (q *Queries) ListAnalysesAfterID(ctx context.Context, arg ListAnalysesAfterIDParams) ([]Analysis, error) {
+	rows, err := q.db.Query(ctx, listAnalysesAfterID, arg.AfterID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Analysis{}
+	for rows.Next() {
+		var i Analysis
+		if err := rows.Scan(
+			&i.ID,
+			&i.DateTime,
+			&i.Product,
+			&i.ColorRhs,
+			&i.IDUser,
+			&i.TelegramLink,
+			&i.Text,
+			&i.FileSource,
+			&i.ScaleMmPixel,
+			&i.Mass,
+			&i.Area,
+			&i.R,
+			&i.G,
+			&i.B,
+			&i.H,
+			&i.S,
+			&i.V,
+			&i.LabL,
+			&i.LabA,
+			&i.LabB,
+			&i.W,
+			&i.L,
+			&i.T,
+			&i.FileOutput,
+			&i.IDAnalysis,
+			&i.Version,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateAnalysisStats = `-- name: UpdateAnalysisStats :exec
+UPDATE analysis
+SET r = $1,
+    g = $2,
+    b = $3,
+    h = $4,
+    s = $5,
+    v = $6,
+    lab_l = $7,
+    lab_a = $8,
+    lab_b = $9,
+    w = $10,
+    l = $11,
+    t = $12,
+    version = version + 1
+WHERE id = $13
+`
+
+type UpdateAnalysisStatsParams struct {
+	R    []byte `json:"r"`
+	G    []byte `json:"g"`
+	B    []byte `json:"b"`
+	H    []byte `json:"h"`
+	S    []byte `json:"s"`
+	V    []byte `json:"v"`
+	LabL []byte `json:"lab_l"`
+	LabA []byte `json:"lab_a"`
+	LabB []byte `json:"lab_b"`
+	W    []byte `json:"w"`
+	L    []byte `json:"l"`
+	T    []byte `json:"t"`
+	ID   int32  `json:"id"`
+}
+
+func (q *Queries) UpdateAnalysisStats(ctx context.Context, arg UpdateAnalysisStatsParams) error {
+	_, err := q.db.Exec(ctx, updateAnalysisStats,
+		arg.R,
+		arg.G,
+		arg.B,
+		arg.H,
+		arg.S,
+		arg.V,
+		arg.LabL,
+		arg.LabA,
+		arg.LabB,
+		arg.W,
+		arg.L,
+		arg.T,
+		arg.ID,
+	)
+	return err
+}
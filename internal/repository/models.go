@@ -5,35 +5,83 @@
 package repository
 
 import (
+	"encoding/json"
+	"time"
+
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type Analysis struct {
-	ID           int32            `json:"id"`
-	DateTime     pgtype.Timestamp `json:"date_time"`
-	Product      pgtype.Text      `json:"product"`
-	ColorRhs     pgtype.Text      `json:"color_rhs"`
-	IDUser       pgtype.Text      `json:"id_user"`
-	TelegramLink pgtype.Text      `json:"telegram_link"`
-	Text         pgtype.Text      `json:"text"`
-	FileSource   pgtype.Text      `json:"file_source"`
-	ScaleMmPixel pgtype.Float8    `json:"scale_mm_pixel"`
-	Mass         pgtype.Float8    `json:"mass"`
-	Area         pgtype.Float8    `json:"area"`
-	R            []byte           `json:"r"`
-	G            []byte           `json:"g"`
-	B            []byte           `json:"b"`
-	H            []byte           `json:"h"`
-	S            []byte           `json:"s"`
-	V            []byte           `json:"v"`
-	LabL         []byte           `json:"lab_l"`
-	LabA         []byte           `json:"lab_a"`
-	LabB         []byte           `json:"lab_b"`
-	W            []byte           `json:"w"`
-	L            []byte           `json:"l"`
-	T            []byte           `json:"t"`
-	FileOutput   pgtype.Text      `json:"file_output"`
-	IDAnalysis   pgtype.Text      `json:"id_analysis"`
+	ID           int32              `json:"id"`
+	DateTime     pgtype.Timestamp   `json:"date_time"`
+	Product      pgtype.Text        `json:"product"`
+	ColorRhs     pgtype.Text        `json:"color_rhs"`
+	IDUser       pgtype.Text        `json:"id_user"`
+	TelegramLink pgtype.Text        `json:"telegram_link"`
+	Text         pgtype.Text        `json:"text"`
+	FileSource   pgtype.Text        `json:"file_source"`
+	ScaleMmPixel pgtype.Float8      `json:"scale_mm_pixel"`
+	Mass         pgtype.Float8      `json:"mass"`
+	Area         pgtype.Float8      `json:"area"`
+	R            []byte             `json:"r"`
+	G            []byte             `json:"g"`
+	B            []byte             `json:"b"`
+	H            []byte             `json:"h"`
+	S            []byte             `json:"s"`
+	V            []byte             `json:"v"`
+	LabL         []byte             `json:"lab_l"`
+	LabA         []byte             `json:"lab_a"`
+	LabB         []byte             `json:"lab_b"`
+	W            []byte             `json:"w"`
+	L            []byte             `json:"l"`
+	T            []byte             `json:"t"`
+	FileOutput   pgtype.Text        `json:"file_output"`
+	IDAnalysis   pgtype.Text        `json:"id_analysis"`
+	Version      int32              `json:"version"`
+	DeletedAt    pgtype.Timestamptz `json:"deleted_at"`
+}
+
+type AnalysisAudit struct {
+	ID         int32           `json:"id"`
+	AnalysisID int32           `json:"analysis_id"`
+	IDAnalysis pgtype.Text     `json:"id_analysis"`
+	Action     string          `json:"action"`
+	IDUser     pgtype.Text     `json:"id_user"`
+	Details    json.RawMessage `json:"details"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+type AnalysisTag struct {
+	AnalysisID int32     `json:"analysis_id"`
+	Tag        string    `json:"tag"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type AnalysisVerdict struct {
+	AnalysisID  int32           `json:"analysis_id"`
+	SpecID      int32           `json:"spec_id"`
+	Verdict     string          `json:"verdict"`
+	Violations  json.RawMessage `json:"violations"`
+	EvaluatedAt time.Time       `json:"evaluated_at"`
+}
+
+type Lot struct {
+	ID          int32       `json:"id"`
+	LotNumber   string      `json:"lot_number"`
+	Supplier    pgtype.Text `json:"supplier"`
+	Field       pgtype.Text `json:"field"`
+	HarvestDate pgtype.Date `json:"harvest_date"`
+	Product     pgtype.Text `json:"product"`
+	Notes       pgtype.Text `json:"notes"`
+	IDUser      string      `json:"id_user"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+}
+
+type LotAnalysis struct {
+	AnalysisID int32     `json:"analysis_id"`
+	LotID      int32     `json:"lot_id"`
+	AttachedAt time.Time `json:"attached_at"`
 }
 
 type Object struct {
@@ -96,3 +144,66 @@ type Object struct {
 	Hu6        pgtype.Float8 `json:"hu6"`
 	Class      pgtype.Text   `json:"class"`
 }
+
+type ObjectEffectiveLabel struct {
+	ObjectID  int32     `json:"object_id"`
+	Class     string    `json:"class"`
+	IDUser    string    `json:"id_user"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ObjectLabel struct {
+	ID        int32     `json:"id"`
+	ObjectID  int32     `json:"object_id"`
+	Class     string    `json:"class"`
+	IDUser    string    `json:"id_user"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Product struct {
+	ID        int32           `json:"id"`
+	Code      string          `json:"code"`
+	Names     json.RawMessage `json:"names"`
+	Aliases   []string        `json:"aliases"`
+	Settings  json.RawMessage `json:"settings"`
+	Active    bool            `json:"active"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+type ProductSpec struct {
+	ID        int32           `json:"id"`
+	Product   string          `json:"product"`
+	Name      string          `json:"name"`
+	Rules     json.RawMessage `json:"rules"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+type ReviewLabel struct {
+	ID        int32       `json:"id"`
+	QueueID   int32       `json:"queue_id"`
+	IDUser    string      `json:"id_user"`
+	Class     pgtype.Text `json:"class"`
+	Skipped   bool        `json:"skipped"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+type ReviewQueue struct {
+	ID              int32              `json:"id"`
+	ObjectID        int32              `json:"object_id"`
+	Strategy        string             `json:"strategy"`
+	Score           float64            `json:"score"`
+	RequiredReviews int32              `json:"required_reviews"`
+	Status          string             `json:"status"`
+	CreatedAt       time.Time          `json:"created_at"`
+	CompletedAt     pgtype.Timestamptz `json:"completed_at"`
+}
+
+type UserProduct struct {
+	IDUser      string    `json:"id_user"`
+	ProductCode string    `json:"product_code"`
+	CreatedAt   time.Time `json:"created_at"`
+}
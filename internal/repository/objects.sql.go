@@ -14,7 +14,8 @@ import (
 const getObjectByID = `-- name: GetObjectByID :one
 SELECT id, id_analysis, file, m_h, m_s, m_v, m_r, m_g, m_b, l_avg, w_avg, brt_avg, r_avg, g_avg, b_avg, h_avg, s_avg, v_avg, h, s, v, h_m, s_m, v_m, r_m, g_m, b_m, brt_m, w_m, l_m, l, w, l_w, pr, sq, brt, r, g, b, solid, min_h, min_s, min_v, max_h, max_s, max_v, entropy, id_image, color_rhs, geometry, sq_sqcrl, hu1, hu2, hu3, hu4, hu5, hu6, class
 FROM objects
-WHERE id = $1
+WHERE objects.id = $1
+  AND NOT EXISTS (SELECT 1 FROM analysis da WHERE da.id = objects.id_analysis AND da.deleted_at IS NOT NULL)
 `
 
 // Queries for the objects table
@@ -87,7 +88,8 @@ func (q *Queries) GetObjectByID(ctx context.Context, id int32) (Object, error) {
 const getObjectsByAnalysisID = `-- name: GetObjectsByAnalysisID :many
 SELECT id, id_analysis, file, m_h, m_s, m_v, m_r, m_g, m_b, l_avg, w_avg, brt_avg, r_avg, g_avg, b_avg, h_avg, s_avg, v_avg, h, s, v, h_m, s_m, v_m, r_m, g_m, b_m, brt_m, w_m, l_m, l, w, l_w, pr, sq, brt, r, g, b, solid, min_h, min_s, min_v, max_h, max_s, max_v, entropy, id_image, color_rhs, geometry, sq_sqcrl, hu1, hu2, hu3, hu4, hu5, hu6, class
 FROM objects
-WHERE id_analysis = $1
+WHERE objects.id_analysis = $1
+  AND NOT EXISTS (SELECT 1 FROM analysis da WHERE da.id = objects.id_analysis AND da.deleted_at IS NOT NULL)
 ORDER BY id
 `
 
@@ -199,6 +201,7 @@ SELECT
 FROM objects o
 LEFT JOIN analysis a ON o.id_analysis = a.id
 WHERE o.id = ANY($1::int[])
+  AND a.deleted_at IS NULL
 `
 
 type GetObjectsByIDsRow struct {
@@ -390,7 +393,8 @@ func (q *Queries) GetObjectsByIDs(ctx context.Context, ids []int32) ([]GetObject
 const getObjectsImages = `-- name: GetObjectsImages :many
 SELECT id, id_analysis, file
 FROM objects
-WHERE id = ANY($1::int[])
+WHERE objects.id = ANY($1::int[])
+  AND NOT EXISTS (SELECT 1 FROM analysis da WHERE da.id = objects.id_analysis AND da.deleted_at IS NOT NULL)
 `
 
 type GetObjectsImagesRow struct {
@@ -422,7 +426,8 @@ func (q *Queries) GetObjectsImages(ctx context.Context, ids []int32) ([]GetObjec
 const getObjectsImagesForAnalysis = `-- name: GetObjectsImagesForAnalysis :many
 SELECT id, id_analysis, file
 FROM objects
-WHERE id_analysis = $1
+WHERE objects.id_analysis = $1
+  AND NOT EXISTS (SELECT 1 FROM analysis da WHERE da.id = objects.id_analysis AND da.deleted_at IS NOT NULL)
 `
 
 type GetObjectsImagesForAnalysisRow struct {
@@ -454,7 +459,8 @@ func (q *Queries) GetObjectsImagesForAnalysis(ctx context.Context, idAnalysis pg
 const getObjectsMetadata = `-- name: GetObjectsMetadata :many
 SELECT id, id_analysis, m_h, m_s, m_v, m_r, m_g, m_b, l_avg, w_avg, brt_avg, r_avg, g_avg, b_avg, h_avg, s_avg, v_avg, h, s, v, h_m, s_m, v_m, r_m, g_m, b_m, brt_m, w_m, l_m, l, w, l_w, pr, sq, brt, r, g, b, solid, min_h, min_s, min_v, max_h, max_s, max_v, entropy, id_image, color_rhs, geometry, sq_sqcrl, hu1, hu2, hu3, hu4, hu5, hu6
 FROM objects
-WHERE id = ANY($1::int[])
+WHERE objects.id = ANY($1::int[])
+  AND NOT EXISTS (SELECT 1 FROM analysis da WHERE da.id = objects.id_analysis AND da.deleted_at IS NOT NULL)
 `
 
 type GetObjectsMetadataRow struct {
@@ -596,7 +602,8 @@ func (q *Queries) GetObjectsMetadata(ctx context.Context, ids []int32) ([]GetObj
 const getObjectsMetadataForAnalysis = `-- name: GetObjectsMetadataForAnalysis :many
 SELECT id, id_analysis, m_h, m_s, m_v, m_r, m_g, m_b, l_avg, w_avg, brt_avg, r_avg, g_avg, b_avg, h_avg, s_avg, v_avg, h, s, v, h_m, s_m, v_m, r_m, g_m, b_m, brt_m, w_m, l_m, l, w, l_w, pr, sq, brt, r, g, b, solid, min_h, min_s, min_v, max_h, max_s, max_v, entropy, id_image, color_rhs, geometry, sq_sqcrl, hu1, hu2, hu3, hu4, hu5, hu6
 FROM objects
-WHERE id_analysis = $1
+WHERE objects.id_analysis = $1
+  AND NOT EXISTS (SELECT 1 FROM analysis da WHERE da.id = objects.id_analysis AND da.deleted_at IS NOT NULL)
 `
 
 type GetObjectsMetadataForAnalysisRow struct {
@@ -734,3 +741,254 @@ func (q *Queries) GetObjectsMetadataForAnalysis(ctx context.Context, idAnalysis
 	}
 	return items, nil
 }
+
+const listObjectsForExport = `-- name: ListObjectsForExport :many
+SELECT
+    o.id, o.id_analysis, o.file, o.m_h, o.m_s, o.m_v, o.m_r, o.m_g, o.m_b, o.l_avg, o.w_avg, o.brt_avg, o.r_avg, o.g_avg, o.b_avg, o.h_avg, o.s_avg, o.v_avg, o.h, o.s, o.v, o.h_m, o.s_m, o.v_m, o.r_m, o.g_m, o.b_m, o.brt_m, o.w_m, o.l_m, o.l, o.w, o.l_w, o.pr, o.sq, o.brt, o.r, o.g, o.b, o.solid, o.min_h, o.min_s, o.min_v, o.max_h, o.max_s, o.max_v, o.entropy, o.id_image, o.color_rhs, o.geometry, o.sq_sqcrl, o.hu1, o.hu2, o.hu3, o.hu4, o.hu5, o.hu6, o.class,
+    a.id AS analysis_id,
+    a.date_time AS analysis_date_time,
+    a.product AS analysis_product,
+    a.color_rhs AS analysis_color_rhs,
+    a.id_user AS analysis_id_user,
+    a.scale_mm_pixel AS analysis_scale_mm_pixel,
+    a.mass AS analysis_mass,
+    a.area AS analysis_area,
+    a.id_analysis AS analysis_id_analysis,
+    a.file_source AS analysis_file_source,
+    COALESCE(l.class, o.class, '')::TEXT AS effective_class
+FROM objects o
+LEFT JOIN analysis a ON o.id_analysis = a.id
+LEFT JOIN object_effective_labels l ON l.object_id = o.id
+WHERE o.id > $1
+  AND a.id_user = $2
+  AND a.deleted_at IS NULL
+  AND ($3::TEXT = '' OR a.product = $3)
+  AND ($4::TEXT = '' OR COALESCE(l.class, o.class) = $4)
+  AND ($5::TIMESTAMP IS NULL OR a.date_time >= $5)
+  AND ($6::TIMESTAMP IS NULL OR a.date_time < $6)
+ORDER BY o.id
+LIMIT $7::int
+`
+
+type ListObjectsForExportParams struct {
+	AfterID  int32            `json:"after_id"`
+	IDUser   pgtype.Text      `json:"id_user"`
+	Product  string           `json:"product"`
+	Class    string           `json:"class"`
+	DateFrom pgtype.Timestamp `json:"date_from"`
+	DateTo   pgtype.Timestamp `json:"date_to"`
+	Limit    int32            `json:"limit"`
+}
+
+type ListObjectsForExportRow struct {
+	Object               Object           `json:"object"`
+	AnalysisID           pgtype.Int4      `json:"analysis_id"`
+	AnalysisDateTime     pgtype.Timestamp `json:"analysis_date_time"`
+	AnalysisProduct      pgtype.Text      `json:"analysis_product"`
+	AnalysisColorRhs     pgtype.Text      `json:"analysis_color_rhs"`
+	AnalysisIDUser       pgtype.Text      `json:"analysis_id_user"`
+	AnalysisScaleMmPixel pgtype.Float8    `json:"analysis_scale_mm_pixel"`
+	AnalysisMass         pgtype.Float8    `json:"analysis_mass"`
+	AnalysisArea         pgtype.Float8    `json:"analysis_area"`
+	AnalysisIDAnalysis   pgtype.Text      `json:"analysis_id_analysis"`
+	AnalysisFileSource   pgtype.Text      `json:"analysis_file_source"`
+	EffectiveClass       string           `json:"effective_class"`
+}
+
+func (q *Queries) ListObjectsForExport(ctx context.Context, arg ListObjectsForExportParams) ([]ListObjectsForExportRow, error) {
+	rows, err := q.db.Query(ctx, listObjectsForExport,
+		arg.AfterID,
+		arg.IDUser,
+		arg.Product,
+		arg.Class,
+		arg.DateFrom,
+		arg.DateTo,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListObjectsForExportRow{}
+	for rows.Next() {
+		var i ListObjectsForExportRow
+		if err := rows.Scan(
+			&i.Object.ID,
+			&i.Object.IDAnalysis,
+			&i.Object.File,
+			&i.Object.MH,
+			&i.Object.MS,
+			&i.Object.MV,
+			&i.Object.MR,
+			&i.Object.MG,
+			&i.Object.MB,
+			&i.Object.LAvg,
+			&i.Object.WAvg,
+			&i.Object.BrtAvg,
+			&i.Object.RAvg,
+			&i.Object.GAvg,
+			&i.Object.BAvg,
+			&i.Object.HAvg,
+			&i.Object.SAvg,
+			&i.Object.VAvg,
+			&i.Object.H,
+			&i.Object.S,
+			&i.Object.V,
+			&i.Object.HM,
+			&i.Object.SM,
+			&i.Object.VM,
+			&i.Object.RM,
+			&i.Object.GM,
+			&i.Object.BM,
+			&i.Object.BrtM,
+			&i.Object.WM,
+			&i.Object.LM,
+			&i.Object.L,
+			&i.Object.W,
+			&i.Object.LW,
+			&i.Object.Pr,
+			&i.Object.Sq,
+			&i.Object.Brt,
+			&i.Object.R,
+			&i.Object.G,
+			&i.Object.B,
+			&i.Object.Solid,
+			&i.Object.MinH,
+			&i.Object.MinS,
+			&i.Object.MinV,
+			&i.Object.MaxH,
+			&i.Object.MaxS,
+			&i.Object.MaxV,
+			&i.Object.Entropy,
+			&i.Object.IDImage,
+			&i.Object.ColorRhs,
+			&i.Object.Geometry,
+			&i.Object.SqSqcrl,
+			&i.Object.Hu1,
+			&i.Object.Hu2,
+			&i.Object.Hu3,
+			&i.Object.Hu4,
+			&i.Object.Hu5,
+			&i.Object.Hu6,
+			&i.Object.Class,
+			&i.AnalysisID,
+			&i.AnalysisDateTime,
+			&i.AnalysisProduct,
+			&i.AnalysisColorRhs,
+			&i.AnalysisIDUser,
+			&i.AnalysisScaleMmPixel,
+			&i.AnalysisMass,
+			&i.AnalysisArea,
+			&i.AnalysisIDAnalysis,
+			&i.AnalysisFileSource,
+			&i.EffectiveClass,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listObjectsWithOwnerAfterID = `-- name: ListObjectsWithOwnerAfterID :many
+SELECT o.id, o.id_analysis, o.file, o.m_h, o.m_s, o.m_v, o.m_r, o.m_g, o.m_b, o.l_avg, o.w_avg, o.brt_avg, o.r_avg, o.g_avg, o.b_avg, o.h_avg, o.s_avg, o.v_avg, o.h, o.s, o.v, o.h_m, o.s_m, o.v_m, o.r_m, o.g_m, o.b_m, o.brt_m, o.w_m, o.l_m, o.l, o.w, o.l_w, o.pr, o.sq, o.brt, o.r, o.g, o.b, o.solid, o.min_h, o.min_s, o.min_v, o.max_h, o.max_s, o.max_v, o.entropy, o.id_image, o.color_rhs, o.geometry, o.sq_sqcrl, o.hu1, o.hu2, o.hu3, o.hu4, o.hu5, o.hu6, o.class, a.id_user
+FROM objects o
+JOIN analysis a ON a.id = o.id_analysis
+WHERE o.id > $1
+  AND a.deleted_at IS NULL
+ORDER BY o.id
+LIMIT $2::int
+`
+
+type ListObjectsWithOwnerAfterIDParams struct {
+	AfterID int32 `json:"after_id"`
+	Limit   int32 `json:"limit"`
+}
+
+type ListObjectsWithOwnerAfterIDRow struct {
+	Object Object      `json:"object"`
+	IDUser pgtype.Text `json:"id_user"`
+}
+
+func (q *Queries) ListObjectsWithOwnerAfterID(ctx context.Context, arg ListObjectsWithOwnerAfterIDParams) ([]ListObjectsWithOwnerAfterIDRow, error) {
+	rows, err := q.db.Query(ctx, listObjectsWithOwnerAfterID, arg.AfterID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListObjectsWithOwnerAfterIDRow{}
+	for rows.Next() {
+		var i ListObjectsWithOwnerAfterIDRow
+		if err := rows.Scan(
+			&i.Object.ID,
+			&i.Object.IDAnalysis,
+			&i.Object.File,
+			&i.Object.MH,
+			&i.Object.MS,
+			&i.Object.MV,
+			&i.Object.MR,
+			&i.Object.MG,
+			&i.Object.MB,
+			&i.Object.LAvg,
+			&i.Object.WAvg,
+			&i.Object.BrtAvg,
+			&i.Object.RAvg,
+			&i.Object.GAvg,
+			&i.Object.BAvg,
+			&i.Object.HAvg,
+			&i.Object.SAvg,
+			&i.Object.VAvg,
+			&i.Object.H,
+			&i.Object.S,
+			&i.Object.V,
+			&i.Object.HM,
+			&i.Object.SM,
+			&i.Object.VM,
+			&i.Object.RM,
+			&i.Object.GM,
+			&i.Object.BM,
+			&i.Object.BrtM,
+			&i.Object.WM,
+			&i.Object.LM,
+			&i.Object.L,
+			&i.Object.W,
+			&i.Object.LW,
+			&i.Object.Pr,
+			&i.Object.Sq,
+			&i.Object.Brt,
+			&i.Object.R,
+			&i.Object.G,
+			&i.Object.B,
+			&i.Object.Solid,
+			&i.Object.MinH,
+			&i.Object.MinS,
+			&i.Object.MinV,
+			&i.Object.MaxH,
+			&i.Object.MaxS,
+			&i.Object.MaxV,
+			&i.Object.Entropy,
+			&i.Object.IDImage,
+			&i.Object.ColorRhs,
+			&i.Object.Geometry,
+			&i.Object.SqSqcrl,
+			&i.Object.Hu1,
+			&i.Object.Hu2,
+			&i.Object.Hu3,
+			&i.Object.Hu4,
+			&i.Object.Hu5,
+			&i.Object.Hu6,
+			&i.Object.Class,
+			&i.IDUser,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
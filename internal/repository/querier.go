@@ -11,11 +11,50 @@ import (
 )
 
 type Querier interface {
+	// Queries for sample lots
+	// Attaches the user's analyses to a lot. An analysis already in a lot stays there.
+	AttachAnalysesToLot(ctx context.Context, arg AttachAnalysesToLotParams) (int64, error)
+	// Queries for the review queue
+	CompleteReviewItem(ctx context.Context, id int32) error
 	CountAnalysesByUserID(ctx context.Context, arg CountAnalysesByUserIDParams) (int64, error)
+	CountLotAnalyses(ctx context.Context, lotID int32) (int64, error)
+	CountLots(ctx context.Context, arg CountLotsParams) (int64, error)
+	CountReviewItemsByStatus(ctx context.Context) ([]CountReviewItemsByStatusRow, error)
+	CreateLot(ctx context.Context, arg CreateLotParams) (Lot, error)
+	// Queries for manual object labels
+	CreateObjectLabel(ctx context.Context, arg CreateObjectLabelParams) (ObjectLabel, error)
+	// Queries for the product catalog
+	CreateProduct(ctx context.Context, arg CreateProductParams) (Product, error)
+	CreateProductSpec(ctx context.Context, arg CreateProductSpecParams) (ProductSpec, error)
+	DeleteAnalysisVerdict(ctx context.Context, analysisID int32) error
+	DeleteLot(ctx context.Context, arg DeleteLotParams) (int64, error)
+	DeleteProduct(ctx context.Context, code string) (int64, error)
+	DeleteProductSpec(ctx context.Context, id int32) (int64, error)
+	DetachAnalysisFromLot(ctx context.Context, arg DetachAnalysisFromLotParams) (int64, error)
+	EnqueueReviewItems(ctx context.Context, arg EnqueueReviewItemsParams) (int64, error)
 	GetAnalysesByIDs(ctx context.Context, ids []int32) ([]Analysis, error)
+	GetAnalysesByProduct(ctx context.Context, product pgtype.Text) ([]Analysis, error)
 	GetAnalysesByUserTelegramIDPagination(ctx context.Context, arg GetAnalysesByUserTelegramIDPaginationParams) ([]Analysis, error)
 	// Queries for the analysis table
 	GetAnalysisByID(ctx context.Context, idAnalysis pgtype.Text) (Analysis, error)
+	GetAnalysisByInternalID(ctx context.Context, id int32) (Analysis, error)
+	// Also finds soft-deleted analyses, so they can be restored by their internal id.
+	GetAnalysisIDByInternalID(ctx context.Context, id int32) (pgtype.Text, error)
+	// Queries for soft deletes, restores and the retention job
+	// Owner of an analysis, deleted or not, or of a purged one as its tombstone records.
+	GetAnalysisOwner(ctx context.Context, idAnalysis pgtype.Text) (pgtype.Text, error)
+	// Queries for analysis metadata edits and tags
+	GetAnalysisTagsByAnalysisIDs(ctx context.Context, ids []int32) ([]AnalysisTag, error)
+	GetAnalysisVerdictsByAnalysisIDs(ctx context.Context, ids []int32) ([]AnalysisVerdict, error)
+	// Queries for object class composition
+	GetClassCompositionByAnalysisID(ctx context.Context, analysisID pgtype.Int8) ([]GetClassCompositionByAnalysisIDRow, error)
+	GetClassCompositionByLotID(ctx context.Context, lotID int32) ([]GetClassCompositionByLotIDRow, error)
+	GetClassCompositionByUserID(ctx context.Context, arg GetClassCompositionByUserIDParams) ([]GetClassCompositionByUserIDRow, error)
+	GetEffectiveLabelsByAnalysisID(ctx context.Context, analysisID pgtype.Int8) ([]ObjectEffectiveLabel, error)
+	GetEffectiveLabelsByObjectIDs(ctx context.Context, ids []int32) ([]ObjectEffectiveLabel, error)
+	GetLotByID(ctx context.Context, arg GetLotByIDParams) (Lot, error)
+	GetLotMembershipsByAnalysisIDs(ctx context.Context, ids []int32) ([]LotAnalysis, error)
+	GetNextReviewItem(ctx context.Context, arg GetNextReviewItemParams) (ReviewQueue, error)
 	// Queries for the objects table
 	GetObjectByID(ctx context.Context, id int32) (Object, error)
 	GetObjectsByAnalysisID(ctx context.Context, analysisID pgtype.Int8) ([]Object, error)
@@ -24,6 +63,54 @@ type Querier interface {
 	GetObjectsImagesForAnalysis(ctx context.Context, idAnalysis pgtype.Int8) ([]GetObjectsImagesForAnalysisRow, error)
 	GetObjectsMetadata(ctx context.Context, ids []int32) ([]GetObjectsMetadataRow, error)
 	GetObjectsMetadataForAnalysis(ctx context.Context, idAnalysis pgtype.Int8) ([]GetObjectsMetadataForAnalysisRow, error)
+	GetProductByCode(ctx context.Context, code string) (Product, error)
+	GetProductSpecByID(ctx context.Context, id int32) (ProductSpec, error)
+	GetProductSpecByProduct(ctx context.Context, product string) (ProductSpec, error)
+	GetReviewItem(ctx context.Context, id int32) (ReviewQueue, error)
+	ListAnalysesAfterID(ctx context.Context, arg ListAnalysesAfterIDParams) ([]Analysis, error)
+	ListAnalysisAudit(ctx context.Context, idAnalysis pgtype.Text) ([]AnalysisAudit, error)
+	ListExpiredAnalyses(ctx context.Context, arg ListExpiredAnalysesParams) ([]int32, error)
+	ListLotAnalyses(ctx context.Context, lotID int32) ([]Analysis, error)
+	// The given analyses of the user that are in a lot other than lot_id.
+	ListLotConflicts(ctx context.Context, arg ListLotConflictsParams) ([]ListLotConflictsRow, error)
+	ListLotObjects(ctx context.Context, lotID int32) ([]Object, error)
+	ListLots(ctx context.Context, arg ListLotsParams) ([]ListLotsRow, error)
+	ListObjectLabels(ctx context.Context, objectID int32) ([]ObjectLabel, error)
+	ListObjectsForExport(ctx context.Context, arg ListObjectsForExportParams) ([]ListObjectsForExportRow, error)
+	ListObjectsWithOwnerAfterID(ctx context.Context, arg ListObjectsWithOwnerAfterIDParams) ([]ListObjectsWithOwnerAfterIDRow, error)
+	// Queries for the product_specs and analysis_verdicts tables
+	ListProductSpecs(ctx context.Context) ([]ProductSpec, error)
+	// Distinct product values in use, including soft-deleted analyses.
+	ListProductValues(ctx context.Context) ([]ListProductValuesRow, error)
+	ListProducts(ctx context.Context) ([]Product, error)
+	ListReviewLabels(ctx context.Context) ([]ReviewLabel, error)
+	ListReviewLabelsByQueueIDs(ctx context.Context, ids []int32) ([]ReviewLabel, error)
+	ListReviewedObjects(ctx context.Context, arg ListReviewedObjectsParams) ([]ListReviewedObjectsRow, error)
+	// Analyses whose product has a spec but that have no verdict yet, in id order
+	// from after_id.
+	ListUngradedAnalyses(ctx context.Context, arg ListUngradedAnalysesParams) ([]Analysis, error)
+	ListUserProducts(ctx context.Context, idUser string) ([]string, error)
+	// Hard-deletes a soft-deleted analysis with its objects and verdict, leaving a
+	// tombstone in the audit trail. Returns the removed objects and the files that
+	// can be removed.
+	PurgeAnalysis(ctx context.Context, arg PurgeAnalysisParams) (PurgeAnalysisRow, error)
+	// Replaces a free-text product value with a catalog code. A spec is only renamed
+	// if the code has no spec yet.
+	RenameProduct(ctx context.Context, arg RenameProductParams) (RenameProductRow, error)
+	RestoreAnalysis(ctx context.Context, arg RestoreAnalysisParams) (AnalysisAudit, error)
+	SetUserProducts(ctx context.Context, arg SetUserProductsParams) error
+	SoftDeleteAnalysis(ctx context.Context, arg SoftDeleteAnalysisParams) (AnalysisAudit, error)
+	// Applies a metadata edit only if the analysis belongs to the user and is still at
+	// the expected version. Tags are replaced as a whole when replace_tags is set.
+	UpdateAnalysisMetadata(ctx context.Context, arg UpdateAnalysisMetadataParams) (UpdateAnalysisMetadataRow, error)
+	UpdateAnalysisStats(ctx context.Context, arg UpdateAnalysisStatsParams) error
+	UpdateLot(ctx context.Context, arg UpdateLotParams) (Lot, error)
+	UpdateProduct(ctx context.Context, arg UpdateProductParams) (Product, error)
+	// Drops the verdicts graded against the old version in the same statement, as
+	// the product may have changed. The grading job regrades the analyses.
+	UpdateProductSpec(ctx context.Context, arg UpdateProductSpecParams) (ProductSpec, error)
+	UpsertAnalysisVerdict(ctx context.Context, arg UpsertAnalysisVerdictParams) (AnalysisVerdict, error)
+	UpsertReviewLabel(ctx context.Context, arg UpsertReviewLabelParams) (ReviewLabel, error)
 }
 
 var _ Querier = (*Queries)(nil)
@@ -1,13 +1,19 @@
 package server
 
 import (
+	"context"
 	"fmt"
+	"net"
+	"time"
 
+	"csort.ru/analysis-service/databases/migrations"
 	"csort.ru/analysis-service/internal/config"
 	"csort.ru/analysis-service/internal/database"
+	"csort.ru/analysis-service/internal/grpcserver"
 	"csort.ru/analysis-service/internal/handlers"
 	"csort.ru/analysis-service/internal/logger"
 	"csort.ru/analysis-service/internal/middleware"
+	"csort.ru/analysis-service/internal/openapi"
 	"csort.ru/analysis-service/internal/services"
 
 	"github.com/bytedance/sonic"
@@ -15,17 +21,25 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/recover"
+	"google.golang.org/grpc"
 )
 
 type Server struct {
 	app *fiber.App
 	db  *database.DB
+	// grpc serves the gRPC API on grpcPort, nil if it is disabled
+	grpc     *grpc.Server
+	grpcPort string
+	// cancel stops the background jobs
+	cancel context.CancelFunc
 }
 
 type Route struct {
 	Method  string
 	Path    string
 	Handler fiber.Handler
+	// Spec documents the route in the OpenAPI document, which every route needs
+	Spec *openapi.Spec
 }
 
 // New creates a new Fiber application with routes configured.
@@ -45,15 +59,34 @@ func New(cfg *config.Config) (*Server, error) {
 		return nil, fmt.Errorf("failed to initialize database service: %w", err)
 	}
 
+	// Refuse to run against a schema this build wasn't written for
+	migrator, err := database.NewMigrator(db.Pool, migrations.FS)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to load migrations: %w", err)
+	}
+	if err := migrator.Check(context.Background()); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	// Errors are written as problem details for clients and routes that ask for it
+	fmtConfig := middleware.FmtConfig{
+		ProblemTypeBase: cfg.ProblemTypeBase,
+		ProblemRoutes:   cfg.ProblemRoutes,
+	}
+
 	app := fiber.New(fiber.Config{
-		JSONEncoder: sonic.Marshal,
-		JSONDecoder: sonic.Unmarshal,
+		ErrorHandler: middleware.ErrorHandler(fmtConfig),
+		JSONEncoder:  sonic.Marshal,
+		JSONDecoder:  sonic.Unmarshal,
 	})
 	// Add CORS middleware first to handle OPTIONS requests
 	app.Use(cors.New(cors.Config{
 		AllowOrigins:     "http://localhost:5173,http://localhost:3000,http://localhost:8081",
 		AllowMethods:     "GET,POST,HEAD,PUT,DELETE,PATCH,OPTIONS",
-		AllowHeaders:     "Origin, Content-Type, Accept, Authorization, Telegram-User-ID",
+		AllowHeaders:     "Origin, Content-Type, Accept, Authorization, Telegram-User-ID, If-Match, API-Version",
+		ExposeHeaders:    "ETag, API-Version",
 		AllowCredentials: true,
 	}))
 
@@ -76,44 +109,147 @@ func New(cfg *config.Config) (*Server, error) {
 		},
 	}))
 
-	app.Use(middleware.Fmt())
+	app.Use(middleware.Fmt(fmtConfig))
 
 	// Initialize services
-	analysisService := services.NewAnalysisService(database.NewQueries(db.Pool), cfg.AnalysisAPI)
+	productsService := services.NewProductsService(database.NewQueries(db.Pool))
+	specsService := services.NewSpecsService(database.NewQueries(db.Pool), productsService)
+	analysisService := services.NewAnalysisService(database.NewQueries(db.Pool), database.NewObjectPages(db.Pool), specsService, productsService, cfg.AnalysisAPI)
 	objectsService := services.NewObjectsService(database.NewQueries(db.Pool))
+	consistencyService := services.NewConsistencyService(database.NewQueries(db.Pool), specsService, services.DefaultStatsTolerance)
+	compositionService := services.NewCompositionService(database.NewQueries(db.Pool), productsService)
+	anomalyService := services.NewAnomalyService(database.NewQueries(db.Pool))
+	similarityService := services.NewSimilarityService(database.NewQueries(db.Pool))
+	exportService := services.NewExportService(database.NewQueries(db.Pool), productsService)
+	datasetFiles := services.FileRefs{Dir: cfg.DatasetFilesDir, Hosts: cfg.FileHosts}
+	reportFiles := services.FileRefs{Dir: cfg.ReportFilesDir, Hosts: cfg.FileHosts}
+	datasetService := services.NewDatasetService(database.NewQueries(db.Pool), productsService, datasetFiles)
+	labelsService := services.NewLabelsService(database.NewQueries(db.Pool), specsService)
+	reviewService := services.NewReviewService(database.NewQueries(db.Pool), anomalyService, productsService, datasetFiles)
+	lotsService := services.NewLotsService(database.NewQueries(db.Pool), productsService)
+	retentionService := services.NewRetentionService(database.NewQueries(db.Pool), time.Duration(cfg.AnalysisRetentionDays)*24*time.Hour, datasetFiles, reportFiles, similarityService)
+	reportService := services.NewReportService(database.NewQueries(db.Pool), specsService, compositionService, cfg.ReportTemplatesDir, reportFiles)
 
 	// Initialize handlers
-	analysisHandler := handlers.NewAnalysisHandler(analysisService)
+	analysisHandler := handlers.NewAnalysisHandler(analysisService, lotsService)
 	objectsHandler := handlers.NewObjectsHandler(objectsService)
+	specsHandler := handlers.NewSpecsHandler(specsService, cfg.AdminUserIDs)
+	consistencyHandler := handlers.NewConsistencyHandler(consistencyService)
+	compositionHandler := handlers.NewCompositionHandler(compositionService)
+	anomalyHandler := handlers.NewAnomalyHandler(anomalyService)
+	similarityHandler := handlers.NewSimilarityHandler(similarityService)
+	exportHandler := handlers.NewExportHandler(exportService)
+	reportHandler := handlers.NewReportHandler(reportService)
+	datasetHandler := handlers.NewDatasetHandler(datasetService)
+	labelsHandler := handlers.NewLabelsHandler(labelsService)
+	reviewHandler := handlers.NewReviewHandler(reviewService)
+	lotsHandler := handlers.NewLotsHandler(lotsService)
+	productsHandler := handlers.NewProductsHandler(productsService, cfg.AdminUserIDs)
 
 	handlers := &Handlers{
-		AnalysisHandler: analysisHandler,
-		ObjectsHandler:  objectsHandler,
+		AnalysisHandler:    analysisHandler,
+		ObjectsHandler:     objectsHandler,
+		SpecsHandler:       specsHandler,
+		ConsistencyHandler: consistencyHandler,
+		CompositionHandler: compositionHandler,
+		AnomalyHandler:     anomalyHandler,
+		SimilarityHandler:  similarityHandler,
+		ExportHandler:      exportHandler,
+		ReportHandler:      reportHandler,
+		DatasetHandler:     datasetHandler,
+		LabelsHandler:      labelsHandler,
+		ReviewHandler:      reviewHandler,
+		LotsHandler:        lotsHandler,
+		ProductsHandler:    productsHandler,
 	}
 
 	// Define and register routes
 	routes := defineRoutes(handlers)
 
 	// Create the /api/v1 group
-	api := app.Group("/api/v1")
+	api := app.Group(apiBasePath)
 
 	registerRoutes(api, routes)
+	// The API works without its docs, so a broken spec doesn't stop the service
+	if doc, err := buildOpenAPI(routes); err != nil {
+		logger.Logger.Warn().Err(err).Msg("Failed to build OpenAPI document, not serving it")
+	} else if err := registerDocs(api, doc); err != nil {
+		logger.Logger.Warn().Err(err).Msg("Failed to serve OpenAPI document")
+	}
+
+	// Start background jobs
+	ctx, cancel := context.WithCancel(context.Background())
+	go similarityService.Run(ctx, time.Duration(cfg.SimilarityRefreshInterval)*time.Second)
+	go retentionService.Run(ctx, time.Duration(cfg.AnalysisRetentionInterval)*time.Second)
+	go specsService.Run(ctx, time.Duration(cfg.SpecGradingInterval)*time.Second)
 
 	server := &Server{
-		app: app,
-		db:  db,
+		app:    app,
+		db:     db,
+		cancel: cancel,
+	}
+
+	// The gRPC API shares the services of the REST one
+	if cfg.GRPCPort != "" {
+		server.grpc, err = grpcserver.New(grpcserver.Config{
+			Reflection: cfg.GRPCReflection,
+			CertFile:   cfg.GRPCCertFile,
+			KeyFile:    cfg.GRPCKeyFile,
+		}, analysisService, objectsService)
+		if err != nil {
+			cancel()
+			db.Close()
+			return nil, fmt.Errorf("failed to create gRPC server: %w", err)
+		}
+		server.grpcPort = cfg.GRPCPort
 	}
 
 	return server, nil
 }
 
-// Start starts the Fiber application.
+// Start starts the Fiber application, and the gRPC server if it is enabled.
 func (s *Server) Start(port string) error {
+	if s.grpc != nil {
+		listener, err := net.Listen("tcp", fmt.Sprintf(":%s", s.grpcPort))
+		if err != nil {
+			return fmt.Errorf("failed to listen for gRPC: %w", err)
+		}
+		logger.Logger.Info().Str("port", s.grpcPort).Msg("Starting gRPC server")
+		go func() {
+			if err := s.grpc.Serve(listener); err != nil {
+				logger.Logger.Error().Err(err).Msg("gRPC server stopped")
+			}
+		}()
+	}
 	return s.app.Listen(fmt.Sprintf(":%s", port))
 }
 
+// grpcShutdownTimeout is how long Shutdown waits for running gRPC calls.
+const grpcShutdownTimeout = 10 * time.Second
+
 // Shutdown gracefully shuts down the Fiber application.
 func (s *Server) Shutdown() error {
+	// Stop background jobs before the database goes away
+	if s.cancel != nil {
+		s.cancel()
+	}
+
+	// Let running calls finish before the database goes away, but don't wait
+	// forever on long streams
+	if s.grpc != nil {
+		stopped := make(chan struct{})
+		go func() {
+			s.grpc.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-time.After(grpcShutdownTimeout):
+			logger.Logger.Warn().Msg("gRPC calls still running after the shutdown timeout, stopping them")
+			s.grpc.Stop()
+		}
+	}
+
 	// Close database connections
 	if s.db != nil {
 		s.db.Close()
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"encoding/json"
+	"reflect"
+	"runtime"
+	"strings"
+	"unicode"
+
+	"csort.ru/analysis-service/internal/openapi"
+	"github.com/gofiber/fiber/v2"
+)
+
+// apiBasePath is the prefix of the API routes.
+const apiBasePath = "/api/v1"
+
+// buildOpenAPI generates the OpenAPI document of the routes. It fails when a
+// route has no spec or shares its operation id with another.
+func buildOpenAPI(routes []Route) (*openapi.Document, error) {
+	docRoutes := make([]openapi.Route, 0, len(routes))
+	for _, route := range routes {
+		docRoutes = append(docRoutes, openapi.Route{
+			Method:      route.Method,
+			Path:        route.Path,
+			OperationID: operationID(route.Handler),
+			Spec:        route.Spec,
+		})
+	}
+	return openapi.Build(openapi.Config{
+		Title:    "Analysis service API",
+		Version:  "1",
+		BasePath: apiBasePath,
+	}, docRoutes)
+}
+
+// operationID names an operation after its handler, e.g. getAnalysisByID for
+// AnalysisHandler.GetAnalysisByID and healthCheck for healthCheckHandler.
+func operationID(handler fiber.Handler) string {
+	name := runtime.FuncForPC(reflect.ValueOf(handler).Pointer()).Name()
+	name = strings.TrimSuffix(name[strings.LastIndex(name, ".")+1:], "-fm")
+	name = strings.TrimSuffix(name, "Handler")
+	return string(unicode.ToLower(rune(name[0]))) + name[1:]
+}
+
+// registerDocs serves the OpenAPI document at /openapi.json and a docs UI for it
+// at /docs. The document has a media type of its own, so Fmt doesn't wrap it in
+// the success envelope.
+func registerDocs(api fiber.Router, doc *openapi.Document) error {
+	// encoding/json sorts map keys, so the document is the same on every start
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	api.Get("/openapi.json", func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentType, openapi.MIMEType)
+		return c.Send(data)
+	})
+	api.Get("/docs", func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+		return c.SendString(docsPage)
+	})
+	return nil
+}
+
+// docsPage is Swagger UI for the document, relative to /docs.
+const docsPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>Analysis service API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({ url: "openapi.json", dom_id: "#swagger-ui" });
+  </script>
+</body>
+</html>
+`
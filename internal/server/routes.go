@@ -2,29 +2,385 @@ package server
 
 import (
 	"csort.ru/analysis-service/internal/handlers"
+	"csort.ru/analysis-service/internal/models"
+	"csort.ru/analysis-service/internal/openapi"
 	"github.com/gofiber/fiber/v2"
 )
 
 type Handlers struct {
-	AnalysisHandler *handlers.AnalysisHandler
-	ObjectsHandler  *handlers.ObjectsHandler
+	AnalysisHandler    *handlers.AnalysisHandler
+	ObjectsHandler     *handlers.ObjectsHandler
+	SpecsHandler       *handlers.SpecsHandler
+	ConsistencyHandler *handlers.ConsistencyHandler
+	CompositionHandler *handlers.CompositionHandler
+	AnomalyHandler     *handlers.AnomalyHandler
+	SimilarityHandler  *handlers.SimilarityHandler
+	ExportHandler      *handlers.ExportHandler
+	ReportHandler      *handlers.ReportHandler
+	DatasetHandler     *handlers.DatasetHandler
+	LabelsHandler      *handlers.LabelsHandler
+	ReviewHandler      *handlers.ReviewHandler
+	LotsHandler        *handlers.LotsHandler
+	ProductsHandler    *handlers.ProductsHandler
 }
 
 func defineRoutes(h *Handlers) []Route {
 	return []Route{
-		{Method: fiber.MethodGet, Path: "/health", Handler: healthCheckHandler},
-		{Method: fiber.MethodGet, Path: "/analyses", Handler: h.AnalysisHandler.GetAnalyses},
-		{Method: fiber.MethodGet, Path: "/analyses/:id", Handler: h.AnalysisHandler.GetAnalysisByID},
-		{Method: fiber.MethodPost, Path: "/analyses", Handler: h.AnalysisHandler.CreateAnalysis},
-		{Method: fiber.MethodGet, Path: "/analyses/:id/objects", Handler: h.AnalysisHandler.GetAnalysisObjects},
-		{Method: fiber.MethodPost, Path: "/objects", Handler: h.ObjectsHandler.GetObjects},
+		{Method: fiber.MethodGet, Path: "/health", Handler: healthCheckHandler, Spec: &openapi.Spec{
+			Summary: "Check that the service is up",
+			Result:  healthResponse{},
+		}},
+		{Method: fiber.MethodGet, Path: "/analyses", Handler: h.AnalysisHandler.GetAnalyses, Spec: &openapi.Spec{
+			Summary:   "List the caller's analyses",
+			User:      true,
+			Versioned: true,
+			Query:     []any{models.GetAnalysesPaginatedRequest{}, models.ProjectionRequest{}},
+			Result:    models.PaginatedResponse[models.Analysis]{},
+		}},
+		{Method: fiber.MethodGet, Path: "/analyses/composition", Handler: h.CompositionHandler.GetComposition, Spec: &openapi.Spec{
+			Summary: "Class composition pooled over the caller's analyses",
+			User:    true,
+			Query:   []any{models.AnalysesFilter{}},
+			Result:  models.Composition{},
+		}},
+		{Method: fiber.MethodGet, Path: "/analyses/export", Handler: h.ExportHandler.ExportAnalyses, Spec: &openapi.Spec{
+			Summary:  "Export the caller's analyses as a spreadsheet",
+			User:     true,
+			Query:    []any{models.ExportAnalysesRequest{}},
+			Produces: spreadsheetTypes,
+		}},
+		{Method: fiber.MethodGet, Path: "/analyses/:id", Handler: h.AnalysisHandler.GetAnalysisByID, Spec: &openapi.Spec{
+			Summary:   "Get an analysis with its objects",
+			Versioned: true,
+			Query:     []any{models.LabelOptions{}, models.ProjectionRequest{}},
+			Params:    []openapi.Param{idTypeParam},
+			Result:    models.Analysis{},
+		}},
+		{Method: fiber.MethodPatch, Path: "/analyses/:id", Handler: h.AnalysisHandler.PatchAnalysis, Spec: &openapi.Spec{
+			Summary:   "Edit the product, notes and tags of an analysis",
+			User:      true,
+			Versioned: true,
+			Params:    []openapi.Param{idTypeParam},
+			Headers:   []openapi.Param{{Name: "If-Match", Description: "ETag of the analysis version the edit is based on", Required: true}},
+			Body:      models.AnalysisPatchRequest{},
+			Result:    models.Analysis{},
+		}},
+		{Method: fiber.MethodDelete, Path: "/analyses/:id", Handler: h.AnalysisHandler.DeleteAnalysis, Spec: &openapi.Spec{
+			Summary: "Soft-delete an analysis",
+			User:    true,
+			Params:  []openapi.Param{idTypeParam},
+			Status:  fiber.StatusNoContent,
+		}},
+		{Method: fiber.MethodPost, Path: "/analyses/:id/restore", Handler: h.AnalysisHandler.RestoreAnalysis, Spec: &openapi.Spec{
+			Summary:   "Restore a soft-deleted analysis",
+			User:      true,
+			Versioned: true,
+			Params:    []openapi.Param{idTypeParam},
+			Result:    models.Analysis{},
+		}},
+		{Method: fiber.MethodGet, Path: "/analyses/:id/audit", Handler: h.AnalysisHandler.GetAnalysisAudit, Spec: &openapi.Spec{
+			Summary: "Deletion history of an analysis",
+			User:    true,
+			Params:  []openapi.Param{idTypeParam},
+			Result:  []models.AnalysisAuditEntry{},
+		}},
+		{Method: fiber.MethodPost, Path: "/analyses", Handler: h.AnalysisHandler.CreateAnalysis, Spec: &openapi.Spec{
+			Summary:   "Run a new analysis on an image",
+			Versioned: true,
+			Form: []openapi.Param{
+				{Name: "product", Description: "Product code or name", Required: true},
+				{Name: "userID", Description: "Telegram id of the user", Required: true},
+				{Name: "lot_id", Description: "Lot to attach the analysis to", Schema: &openapi.Schema{Type: "integer", Format: "int32"}},
+				{Name: "files", Description: "Image to analyze", Required: true, Schema: openapi.Binary("application/octet-stream")},
+			},
+			Result: models.Analysis{},
+		}},
+		{Method: fiber.MethodGet, Path: "/analyses/:id/objects", Handler: h.AnalysisHandler.GetAnalysisObjects, Spec: &openapi.Spec{
+			Summary:   "List the objects of an analysis",
+			Versioned: true,
+			Query:     []any{models.LabelOptions{}, models.ProjectionRequest{}},
+			Params:    []openapi.Param{requiredIDTypeParam},
+			Result:    []models.Object{},
+			NDJSON:    true,
+		}},
+		{Method: fiber.MethodGet, Path: "/analyses/:id/composition", Handler: h.CompositionHandler.GetAnalysisComposition, Spec: &openapi.Spec{
+			Summary: "Class composition of an analysis",
+			Params:  []openapi.Param{idTypeParam},
+			Result:  models.Composition{},
+		}},
+		{Method: fiber.MethodGet, Path: "/analyses/:id/export", Handler: h.ExportHandler.ExportAnalysis, Spec: &openapi.Spec{
+			Summary:  "Export the objects of an analysis as a spreadsheet",
+			Query:    []any{models.ExportRequest{}},
+			Params:   []openapi.Param{idTypeParam},
+			Produces: spreadsheetTypes,
+		}},
+		{Method: fiber.MethodGet, Path: "/analyses/:id/report.pdf", Handler: h.ReportHandler.GetAnalysisReport, Spec: &openapi.Spec{
+			Summary:  "Render the PDF report of an analysis",
+			Params:   []openapi.Param{idTypeParam, {Name: "template", Description: "Report template, the product's default if omitted"}},
+			Produces: []string{"application/pdf"},
+		}},
+		{Method: fiber.MethodGet, Path: "/analyses/:id/anomalies", Handler: h.AnomalyHandler.GetAnalysisAnomalies, Spec: &openapi.Spec{
+			Summary: "Detect anomalous objects of an analysis",
+			Query:   []any{models.AnomalyRequest{}},
+			Params:  []openapi.Param{idTypeParam},
+			Result:  models.AnomalyReport{},
+		}},
+		{Method: fiber.MethodGet, Path: "/analyses/:id/stats/check", Handler: h.ConsistencyHandler.CheckStats, Spec: &openapi.Spec{
+			Summary: "Check the stored Stats of an analysis against its objects",
+			Params:  []openapi.Param{idTypeParam},
+			Result:  models.StatsConsistencyReport{},
+		}},
+		{Method: fiber.MethodPost, Path: "/analyses/:id/stats/repair", Handler: h.ConsistencyHandler.RepairStats, Spec: &openapi.Spec{
+			Summary:     "Recompute the stored Stats of an analysis from its objects",
+			Description: "Only the owner of the analysis may repair it. A repair bumps the analysis version and regrades it against its product spec.",
+			User:        true,
+			Params:      []openapi.Param{idTypeParam},
+			Result:      models.StatsConsistencyReport{},
+		}},
+		{Method: fiber.MethodPost, Path: "/objects", Handler: h.ObjectsHandler.GetObjects, Spec: &openapi.Spec{
+			Summary:   "Get the measurements of objects by id",
+			Versioned: true,
+			Query:     []any{models.ProjectionRequest{}},
+			Body:      handlers.GetObjectsRequest{},
+			Result:    []*models.ObjectMetadata{},
+		}},
+		{Method: fiber.MethodGet, Path: "/objects/export", Handler: h.DatasetHandler.ExportObjects, Spec: &openapi.Spec{
+			Summary:  "Export objects as a dataset",
+			User:     true,
+			Query:    []any{models.DatasetExportRequest{}},
+			Produces: []string{"application/vnd.apache.parquet", "application/vnd.apache.arrow.stream"},
+		}},
+		{Method: fiber.MethodGet, Path: "/objects/export/coco", Handler: h.DatasetHandler.ExportCoco, Spec: &openapi.Spec{
+			Summary:  "Export objects as a COCO dataset",
+			User:     true,
+			Query:    []any{models.CocoExportRequest{}},
+			Produces: []string{"application/json", "application/zip"},
+		}},
+		{Method: fiber.MethodGet, Path: "/objects/:id/similar", Handler: h.SimilarityHandler.GetSimilarObjects, Spec: &openapi.Spec{
+			Summary: "Find the objects most similar to an object",
+			User:    true,
+			Query:   []any{models.SimilarObjectsRequest{}},
+			Result:  models.SimilarObjectsResponse{},
+		}},
+		{Method: fiber.MethodPatch, Path: "/objects/:id/class", Handler: h.LabelsHandler.RelabelObject, Spec: &openapi.Spec{
+			Summary:     "Relabel an object",
+			Description: "Only the owner of the object's analysis may relabel it.",
+			User:        true,
+			Body:        models.ObjectLabelRequest{},
+			Result:      models.ObjectLabel{},
+		}},
+		{Method: fiber.MethodGet, Path: "/objects/:id/labels", Handler: h.LabelsHandler.GetObjectLabels, Spec: &openapi.Spec{
+			Summary: "Label history of an object",
+			User:    true,
+			Result:  []models.ObjectLabel{},
+		}},
+		{Method: fiber.MethodPost, Path: "/review-queue/populate", Handler: h.ReviewHandler.PopulateQueue, Spec: &openapi.Spec{
+			Summary: "Select objects into the review queue",
+			User:    true,
+			Body:    models.ReviewPopulateRequest{},
+			Result:  models.ReviewPopulateResponse{},
+		}},
+		{Method: fiber.MethodGet, Path: "/review-queue/next", Handler: h.ReviewHandler.GetNextItem, Spec: &openapi.Spec{
+			Summary:   "Next item the caller hasn't reviewed",
+			User:      true,
+			Versioned: true,
+			Params:    []openapi.Param{{Name: "strategy", Description: "Only items selected by this strategy"}},
+			Result:    models.ReviewItem{},
+			NoContent: true,
+		}},
+		{Method: fiber.MethodGet, Path: "/review-queue/agreement", Handler: h.ReviewHandler.GetAgreement, Spec: &openapi.Spec{
+			Summary: "Agreement between reviewers",
+			User:    true,
+			Result:  models.ReviewAgreement{},
+		}},
+		{Method: fiber.MethodGet, Path: "/review-queue/export", Handler: h.ReviewHandler.ExportLabels, Spec: &openapi.Spec{
+			Summary:  "Export review labels",
+			User:     true,
+			Query:    []any{models.ReviewExportRequest{}},
+			Produces: []string{"text/csv", "application/vnd.apache.parquet"},
+		}},
+		{Method: fiber.MethodGet, Path: "/review-queue/:id/image", Handler: h.ReviewHandler.GetItemImage, Spec: &openapi.Spec{
+			Summary:  "Image of the object of a review item",
+			User:     true,
+			Produces: []string{"image/*"},
+		}},
+		{Method: fiber.MethodPost, Path: "/review-queue/:id/labels", Handler: h.ReviewHandler.SubmitLabel, Spec: &openapi.Spec{
+			Summary: "Submit the caller's label for a review item",
+			User:    true,
+			Body:    models.ReviewSubmitRequest{},
+			Result:  models.ReviewLabel{},
+		}},
+		{Method: fiber.MethodGet, Path: "/lots", Handler: h.LotsHandler.ListLots, Spec: &openapi.Spec{
+			Summary: "List lots",
+			User:    true,
+			Query:   []any{models.GetLotsPaginatedRequest{}},
+			Result:  models.PaginatedResponse[models.Lot]{},
+		}},
+		{Method: fiber.MethodPost, Path: "/lots", Handler: h.LotsHandler.CreateLot, Spec: &openapi.Spec{
+			Summary: "Create a lot",
+			User:    true,
+			Body:    models.LotRequest{},
+			Result:  models.Lot{},
+			Status:  fiber.StatusCreated,
+		}},
+		{Method: fiber.MethodGet, Path: "/lots/:id", Handler: h.LotsHandler.GetLot, Spec: &openapi.Spec{
+			Summary: "Get a lot",
+			User:    true,
+			Result:  models.Lot{},
+		}},
+		{Method: fiber.MethodPut, Path: "/lots/:id", Handler: h.LotsHandler.UpdateLot, Spec: &openapi.Spec{
+			Summary: "Update a lot",
+			User:    true,
+			Body:    models.LotRequest{},
+			Result:  models.Lot{},
+		}},
+		{Method: fiber.MethodDelete, Path: "/lots/:id", Handler: h.LotsHandler.DeleteLot, Spec: &openapi.Spec{
+			Summary: "Delete a lot",
+			User:    true,
+			Status:  fiber.StatusNoContent,
+		}},
+		{Method: fiber.MethodGet, Path: "/lots/:id/analyses", Handler: h.LotsHandler.GetLotAnalyses, Spec: &openapi.Spec{
+			Summary:   "List the analyses of a lot",
+			User:      true,
+			Versioned: true,
+			Result:    []models.Analysis{},
+		}},
+		{Method: fiber.MethodPost, Path: "/lots/:id/analyses", Handler: h.LotsHandler.AttachAnalyses, Spec: &openapi.Spec{
+			Summary: "Attach analyses to a lot",
+			User:    true,
+			Body:    models.LotAnalysesRequest{},
+			Result:  models.LotAnalysesResponse{},
+		}},
+		{Method: fiber.MethodDelete, Path: "/lots/:id/analyses/:analysisId", Handler: h.LotsHandler.DetachAnalysis, Spec: &openapi.Spec{
+			Summary: "Detach an analysis from a lot",
+			User:    true,
+			Params:  []openapi.Param{idTypeParam},
+			Status:  fiber.StatusNoContent,
+		}},
+		{Method: fiber.MethodGet, Path: "/lots/:id/stats", Handler: h.LotsHandler.GetLotStats, Spec: &openapi.Spec{
+			Summary:   "Stats and class composition pooled over a lot",
+			User:      true,
+			Versioned: true,
+			Result:    models.LotStats{},
+		}},
+		{Method: fiber.MethodGet, Path: "/products", Handler: h.ProductsHandler.ListProducts, Spec: &openapi.Spec{
+			Summary: "List the products the caller may analyze",
+			User:    true,
+			Query:   []any{models.ProductsRequest{}},
+			Result:  []models.Product{},
+		}},
+		{Method: fiber.MethodGet, Path: "/products/catalog", Handler: h.ProductsHandler.ListCatalog, Spec: &openapi.Spec{
+			Summary: "List every product, including inactive ones",
+			Query:   []any{models.ProductsRequest{}},
+			Result:  []models.Product{},
+		}},
+		{Method: fiber.MethodPost, Path: "/products", Handler: h.ProductsHandler.CreateProduct, Spec: &openapi.Spec{
+			Summary:     "Create a product",
+			Description: adminOnly,
+			User:        true,
+			Body:        models.ProductRequest{},
+			Result:      models.Product{},
+			Status:      fiber.StatusCreated,
+		}},
+		{Method: fiber.MethodGet, Path: "/products/users/:userId", Handler: h.ProductsHandler.GetUserProducts, Spec: &openapi.Spec{
+			Summary: "Products a user is restricted to",
+			Result:  models.UserProductsRequest{},
+		}},
+		{Method: fiber.MethodPut, Path: "/products/users/:userId", Handler: h.ProductsHandler.SetUserProducts, Spec: &openapi.Spec{
+			Summary:     "Restrict a user to products",
+			Description: adminOnly,
+			User:        true,
+			Body:        models.UserProductsRequest{},
+			Result:      models.UserProductsRequest{},
+		}},
+		{Method: fiber.MethodGet, Path: "/products/:code", Handler: h.ProductsHandler.GetProduct, Spec: &openapi.Spec{
+			Summary: "Get a product",
+			Query:   []any{models.ProductsRequest{}},
+			Result:  models.Product{},
+		}},
+		{Method: fiber.MethodPut, Path: "/products/:code", Handler: h.ProductsHandler.UpdateProduct, Spec: &openapi.Spec{
+			Summary:     "Update a product",
+			Description: adminOnly,
+			User:        true,
+			Body:        models.ProductRequest{},
+			Result:      models.Product{},
+		}},
+		{Method: fiber.MethodDelete, Path: "/products/:code", Handler: h.ProductsHandler.DeleteProduct, Spec: &openapi.Spec{
+			Summary:     "Delete a product",
+			Description: adminOnly,
+			User:        true,
+			Status:      fiber.StatusNoContent,
+		}},
+		{Method: fiber.MethodGet, Path: "/product-specs", Handler: h.SpecsHandler.ListSpecs, Spec: &openapi.Spec{
+			Summary: "List product specs",
+			Result:  []models.ProductSpec{},
+		}},
+		{Method: fiber.MethodGet, Path: "/product-specs/:id", Handler: h.SpecsHandler.GetSpec, Spec: &openapi.Spec{
+			Summary: "Get a product spec",
+			Result:  models.ProductSpec{},
+		}},
+		{Method: fiber.MethodPost, Path: "/product-specs", Handler: h.SpecsHandler.CreateSpec, Spec: &openapi.Spec{
+			Summary:     "Create a product spec",
+			Description: adminOnly,
+			User:        true,
+			Body:        models.ProductSpecRequest{},
+			Result:      models.ProductSpec{},
+			Status:      fiber.StatusCreated,
+		}},
+		{Method: fiber.MethodPut, Path: "/product-specs/:id", Handler: h.SpecsHandler.UpdateSpec, Spec: &openapi.Spec{
+			Summary:     "Update a product spec",
+			Description: adminOnly,
+			User:        true,
+			Body:        models.ProductSpecRequest{},
+			Result:      models.ProductSpec{},
+		}},
+		{Method: fiber.MethodDelete, Path: "/product-specs/:id", Handler: h.SpecsHandler.DeleteSpec, Spec: &openapi.Spec{
+			Summary:     "Delete a product spec",
+			Description: adminOnly,
+			User:        true,
+			Status:      fiber.StatusNoContent,
+		}},
+		{Method: fiber.MethodPost, Path: "/product-specs/:id/evaluate", Handler: h.SpecsHandler.EvaluateSpec, Spec: &openapi.Spec{
+			Summary:     "Queue every analysis of the spec's product for regrading",
+			Description: adminOnly + " The analyses are graded in the background.",
+			User:        true,
+			Result: struct {
+				SpecID int32 `json:"spec_id"`
+			}{},
+			Status: fiber.StatusAccepted,
+		}},
 	}
 }
 
+// Parameters, media types and descriptions shared by route specs
+var (
+	adminOnly   = "Only users listed in ADMIN_USER_IDS may call it."
+	idTypeParam = openapi.Param{
+		Name:        "id_type",
+		Description: "Whether the analysis id is its id_analysis, the default, or its internal id",
+		Schema:      &openapi.Schema{Type: "string", Enum: []any{models.IDTypeExternal, models.IDTypeInternal}},
+	}
+	// requiredIDTypeParam is idTypeParam on routes that took the internal id
+	// before id_type existed, where a bare number would be ambiguous
+	requiredIDTypeParam = openapi.Param{
+		Name:        "id_type",
+		Description: "Whether the analysis id is its id_analysis or its internal id",
+		Required:    true,
+		Schema:      idTypeParam.Schema,
+	}
+	spreadsheetTypes = []string{"text/csv", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"}
+)
+
+type healthResponse struct {
+	Status  string `json:"status"`
+	Service string `json:"service"`
+	Version string `json:"version"`
+}
+
 func healthCheckHandler(c *fiber.Ctx) error {
-	return c.JSON(fiber.Map{
-		"status":  "healthy",
-		"service": "analysis-service",
-		"version": "v1",
+	return c.JSON(healthResponse{
+		Status:  "healthy",
+		Service: "analysis-service",
+		Version: "v1",
 	})
 }
@@ -0,0 +1,25 @@
+package server
+
+import "testing"
+
+func TestRoutesHaveSpecs(t *testing.T) {
+	// Method values of nil handlers are enough to name the operations
+	routes := defineRoutes(&Handlers{})
+
+	operationIDs := map[string]string{}
+	for _, route := range routes {
+		name := route.Method + " " + route.Path
+		if route.Spec == nil {
+			t.Errorf("%s has no OpenAPI spec", name)
+		}
+		id := operationID(route.Handler)
+		if other, ok := operationIDs[id]; ok {
+			t.Errorf("%s reuses the operation id %q of %s", name, id, other)
+		}
+		operationIDs[id] = name
+	}
+
+	if _, err := buildOpenAPI(routes); err != nil {
+		t.Errorf("building the OpenAPI document: %v", err)
+	}
+}
@@ -2,8 +2,12 @@ package services
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"bytes"
@@ -11,11 +15,12 @@ import (
 	"mime/multipart"
 	"net/http"
 
-	"encoding/json"
-
+	"csort.ru/analysis-service/internal/apperr"
+	"csort.ru/analysis-service/internal/database"
 	"csort.ru/analysis-service/internal/logger"
 	"csort.ru/analysis-service/internal/models"
 	"csort.ru/analysis-service/internal/repository"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/valyala/fasthttp"
 )
@@ -25,23 +30,44 @@ const (
 	MaxLimit         = 100
 	DefaultSortBy    = "date_time"
 	DefaultSortOrder = "desc"
+
+	MaxAnalysisTags      = 20
+	MaxAnalysisTagLength = 64
 )
 
 var analysisLog = logger.GetLogger("services.analysis")
 
+var (
+	ErrAnalysisNotFound = apperr.NotFound("analysis_not_found", "analysis not found")
+	ErrInvalidMetadata  = apperr.Validation("invalid_metadata", "invalid analysis metadata")
+	ErrVersionMismatch  = apperr.Precondition("version_mismatch", "analysis was modified concurrently")
+	ErrNotDeleted       = apperr.Conflict("not_deleted", "analysis is not deleted")
+	ErrNotOwner         = apperr.Forbidden("not_owner", "analysis belongs to another user")
+
+	ErrAnalysisAPIUnavailable = apperr.UpstreamUnavailable("analysis_api_unavailable", "failed to contact analysis API")
+)
+
 type AnalysisService struct {
 	repo        *repository.Queries
+	objects     *database.ObjectPages
+	specs       *SpecsService
+	products    *ProductsService
 	analysisAPI string
 }
 
-func NewAnalysisService(repo *repository.Queries, analysisAPI string) *AnalysisService {
+func NewAnalysisService(repo *repository.Queries, objects *database.ObjectPages, specs *SpecsService, products *ProductsService, analysisAPI string) *AnalysisService {
 	return &AnalysisService{
 		repo:        repo,
+		objects:     objects,
+		specs:       specs,
+		products:    products,
 		analysisAPI: analysisAPI,
 	}
 }
 
-func (s *AnalysisService) GetAnalyses(ctx context.Context, userID int64, params models.GetAnalysesPaginatedRequest) (*models.PaginatedResponse[models.Analysis], error) {
+// GetAnalyses returns a page of a user's analyses. The tags, lot and verdict of
+// the analyses are only looked up if they are among the projected fields.
+func (s *AnalysisService) GetAnalyses(ctx context.Context, userID int64, params models.GetAnalysesPaginatedRequest, fields models.Projection) (*models.PaginatedResponse[models.Analysis], error) {
 	// Set defaults
 	if params.Limit == 0 {
 		params.Limit = DefaultLimit
@@ -55,14 +81,20 @@ func (s *AnalysisService) GetAnalyses(ctx context.Context, userID int64, params
 	if params.SortOrder == "" {
 		params.SortOrder = DefaultSortOrder
 	}
+	product, err := s.products.CanonicalFilter(ctx, params.Product)
+	if err != nil {
+		return nil, err
+	}
 
 	// Get analyses from repository
 	repoAnalyses, err := s.repo.GetAnalysesByUserTelegramIDPagination(ctx, repository.GetAnalysesByUserTelegramIDPaginationParams{
 		Limit:      params.Limit,
 		Offset:     params.Offset,
 		IDUser:     pgtype.Text{String: fmt.Sprintf("%d", userID), Valid: true},
-		Product:    params.Product,
+		Product:    product,
 		IDAnalysis: params.ID,
+		Verdict:    params.Verdict,
+		Tag:        params.Tag,
 		SortBy:     params.SortBy,
 		SortOrder:  params.SortOrder,
 	})
@@ -74,18 +106,57 @@ func (s *AnalysisService) GetAnalyses(ctx context.Context, userID int64, params
 	// Get total count
 	count, err := s.repo.CountAnalysesByUserID(ctx, repository.CountAnalysesByUserIDParams{
 		IDUser:     pgtype.Text{String: fmt.Sprintf("%d", userID), Valid: true},
-		Product:    params.Product,
+		Product:    product,
 		IDAnalysis: params.ID,
+		Verdict:    params.Verdict,
+		Tag:        params.Tag,
 	})
 	if err != nil {
 		analysisLog.Error().Err(err).Int64("userID", userID).Msg("Failed to count analyses")
 		return nil, err
 	}
 
+	// Attach stored spec verdicts
+	ids := make([]int32, 0, len(repoAnalyses))
+	for _, repoAnalysis := range repoAnalyses {
+		ids = append(ids, repoAnalysis.ID)
+	}
+	var verdicts map[int32]*models.SpecVerdict
+	if fields.Has("verdict") {
+		verdicts, err = s.specs.GetVerdicts(ctx, ids)
+		if err != nil {
+			analysisLog.Error().Err(err).Int64("userID", userID).Msg("Failed to get analysis verdicts")
+			return nil, err
+		}
+	}
+
+	var tags map[int32][]string
+	if fields.Has("tags") {
+		tags, err = s.getTags(ctx, ids)
+		if err != nil {
+			analysisLog.Error().Err(err).Int64("userID", userID).Msg("Failed to get analysis tags")
+			return nil, err
+		}
+	}
+	var lots map[int32]int32
+	if fields.Has("lot_id") {
+		lots, err = lotMemberships(ctx, s.repo, ids)
+		if err != nil {
+			analysisLog.Error().Err(err).Int64("userID", userID).Msg("Failed to get analysis lots")
+			return nil, err
+		}
+	}
+
 	// Convert to service models
 	analyses := make([]models.Analysis, 0, len(repoAnalyses))
 	for _, repoAnalysis := range repoAnalyses {
-		analyses = append(analyses, convertAnalysisFromRepo(repoAnalysis))
+		analysis := convertAnalysisFromRepo(repoAnalysis)
+		analysis.Verdict = verdicts[repoAnalysis.ID]
+		analysis.Tags = tagsOf(tags, repoAnalysis.ID)
+		if lotID, ok := lots[repoAnalysis.ID]; ok {
+			analysis.LotID = &lotID
+		}
+		analyses = append(analyses, analysis)
 	}
 
 	return &models.PaginatedResponse[models.Analysis]{
@@ -96,37 +167,334 @@ func (s *AnalysisService) GetAnalyses(ctx context.Context, userID int64, params
 	}, nil
 }
 
-func (s *AnalysisService) GetAnalysisByID(ctx context.Context, analysisID string) (models.Analysis, error) {
+// GetAnalysisByID returns an analysis with its objects and stored verdict. Like
+// GetAnalyses, it only looks up the projected fields.
+func (s *AnalysisService) GetAnalysisByID(ctx context.Context, ref models.AnalysisRef, labels models.LabelOptions, fields models.Projection) (models.Analysis, error) {
 	// Get analysis
-	repoAnalysis, err := s.repo.GetAnalysisByID(ctx, pgtype.Text{String: analysisID, Valid: true})
+	repoAnalysis, err := resolveAnalysis(ctx, s.repo, ref)
 	if err != nil {
-		analysisLog.Error().Err(err).Str("analysisID", analysisID).Msg("Failed to get analysis")
+		if !errors.Is(err, ErrAnalysisNotFound) {
+			analysisLog.Error().Err(err).Str("analysisID", ref.String()).Msg("Failed to get analysis")
+		}
 		return models.Analysis{}, err
 	}
+	analysisID := repoAnalysis.IDAnalysis.String
 
-	// Get objects
-	objects, err := s.getObjectsForAnalysis(ctx, int64(repoAnalysis.ID))
-	if err != nil {
-		return models.Analysis{}, err
+	var objects []models.Object
+	if fields.Has("objects") {
+		objects, err = s.getObjectsForAnalysis(ctx, int64(repoAnalysis.ID), labels)
+		if err != nil {
+			return models.Analysis{}, err
+		}
+	}
+
+	var tags map[int32][]string
+	if fields.Has("tags") {
+		tags, err = s.getTags(ctx, []int32{repoAnalysis.ID})
+		if err != nil {
+			analysisLog.Error().Err(err).Str("analysisID", analysisID).Msg("Failed to get analysis tags")
+			return models.Analysis{}, err
+		}
+	}
+	var lots map[int32]int32
+	if fields.Has("lot_id") {
+		lots, err = lotMemberships(ctx, s.repo, []int32{repoAnalysis.ID})
+		if err != nil {
+			analysisLog.Error().Err(err).Str("analysisID", analysisID).Msg("Failed to get analysis lot")
+			return models.Analysis{}, err
+		}
 	}
 
 	// Convert and attach objects
 	analysis := convertAnalysisFromRepo(repoAnalysis)
 	analysis.Objects = objects
+	analysis.Tags = tagsOf(tags, repoAnalysis.ID)
+	if lotID, ok := lots[repoAnalysis.ID]; ok {
+		analysis.LotID = &lotID
+	}
+
+	// The verdict is graded by the grading job; failing to read it shouldn't hide
+	// the analysis itself
+	if fields.Has("verdict") {
+		verdict, err := s.specs.GetVerdict(ctx, repoAnalysis.ID)
+		if err != nil {
+			analysisLog.Warn().Err(err).Str("analysisID", analysisID).Msg("Failed to get analysis verdict")
+		} else {
+			analysis.Verdict = verdict
+		}
+	}
 
 	return analysis, nil
 }
 
-func (s *AnalysisService) GetObjectsByAnalysisID(ctx context.Context, analysisID string) ([]models.Object, error) {
-	internalID, err := strconv.ParseInt(analysisID, 10, 64)
+// PatchAnalysis edits the metadata of the user's analysis if it is still at the
+// given version; a zero version edits the current one. A product change regrades
+// the analysis, as it switches its spec.
+func (s *AnalysisService) PatchAnalysis(ctx context.Context, userID int64, ref models.AnalysisRef, version int32, req models.AnalysisPatchRequest) (models.Analysis, error) {
+	analysisID, err := resolveAnalysisID(ctx, s.repo, ref)
+	if err != nil {
+		return models.Analysis{}, err
+	}
+
+	params := repository.UpdateAnalysisMetadataParams{
+		IDAnalysis: pgtype.Text{String: analysisID, Valid: true},
+		IDUser:     pgtype.Text{String: strconv.FormatInt(userID, 10), Valid: true},
+		Version:    version,
+		Tags:       []string{},
+	}
+	if req.Product != nil {
+		if strings.TrimSpace(*req.Product) == "" {
+			return models.Analysis{}, fmt.Errorf("%w: product must not be empty", ErrInvalidMetadata)
+		}
+		product, err := s.products.Canonical(ctx, *req.Product)
+		if err != nil {
+			return models.Analysis{}, err
+		}
+		params.Product = pgtype.Text{String: product, Valid: true}
+	}
+	if req.Text != nil {
+		params.Text = pgtype.Text{String: *req.Text, Valid: true}
+	}
+	if req.Tags != nil {
+		tags, err := normalizeTags(*req.Tags)
+		if err != nil {
+			return models.Analysis{}, err
+		}
+		params.ReplaceTags = true
+		params.Tags = tags
+	}
+
+	if version == 0 {
+		current, err := s.repo.GetAnalysisByID(ctx, params.IDAnalysis)
+		if err != nil {
+			return models.Analysis{}, notFound(err, ErrAnalysisNotFound)
+		}
+		params.Version = current.Version
+	}
+
+	updated, err := s.repo.UpdateAnalysisMetadata(ctx, params)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// The analysis doesn't exist, isn't the user's or someone else edited it first
+		current, err := s.repo.GetAnalysisByID(ctx, params.IDAnalysis)
+		if err != nil {
+			return models.Analysis{}, notFound(err, ErrAnalysisNotFound)
+		}
+		if current.IDUser != params.IDUser {
+			return models.Analysis{}, ErrNotOwner
+		}
+		return models.Analysis{}, fmt.Errorf("%w: current version is %d", ErrVersionMismatch, current.Version)
+	}
+	if err != nil {
+		analysisLog.Error().Err(err).Str("analysisID", analysisID).Msg("Failed to update analysis metadata")
+		return models.Analysis{}, err
+	}
+	analysisLog.Info().Str("analysisID", analysisID).Int32("version", updated.Version).Msg("Analysis metadata updated")
+
+	// Like a relabel, a failed regrade shouldn't fail the edit
+	if req.Product != nil {
+		if err := s.specs.Regrade(ctx, updated.ID); err != nil {
+			analysisLog.Warn().Err(err).Str("analysisID", analysisID).Msg("Failed to regrade analysis after product change")
+		}
+	}
+
+	return s.GetAnalysisByID(ctx, models.AnalysisRef{ID: analysisID}, models.LabelOptions{}, models.Projection{})
+}
+
+// DeleteAnalysis soft-deletes the user's analysis: it and its objects are hidden until
+// restored, or purged for good by the retention job.
+func (s *AnalysisService) DeleteAnalysis(ctx context.Context, userID int64, ref models.AnalysisRef) error {
+	analysisID, err := resolveAnalysisID(ctx, s.repo, ref)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.repo.SoftDeleteAnalysis(ctx, repository.SoftDeleteAnalysisParams{
+		IDAnalysis: pgtype.Text{String: analysisID, Valid: true},
+		IDUser:     pgtype.Text{String: strconv.FormatInt(userID, 10), Valid: true},
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		if err := s.checkOwner(ctx, userID, analysisID); err != nil {
+			return err
+		}
+		// Already deleted
+		return ErrAnalysisNotFound
+	}
+	if err != nil {
+		return err
+	}
+	analysisLog.Info().Str("analysisID", analysisID).Int64("userID", userID).Msg("Analysis deleted")
+	return nil
+}
+
+// RestoreAnalysis brings back the user's soft-deleted analysis if it hasn't been
+// purged yet.
+func (s *AnalysisService) RestoreAnalysis(ctx context.Context, userID int64, ref models.AnalysisRef) (models.Analysis, error) {
+	analysisID, err := resolveAnalysisID(ctx, s.repo, ref)
+	if err != nil {
+		return models.Analysis{}, err
+	}
+
+	_, err = s.repo.RestoreAnalysis(ctx, repository.RestoreAnalysisParams{
+		IDAnalysis: pgtype.Text{String: analysisID, Valid: true},
+		IDUser:     pgtype.Text{String: strconv.FormatInt(userID, 10), Valid: true},
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		if err := s.checkOwner(ctx, userID, analysisID); err != nil {
+			return models.Analysis{}, err
+		}
+		// Tell an analysis that was never deleted from a purged one
+		if _, err := s.repo.GetAnalysisByID(ctx, pgtype.Text{String: analysisID, Valid: true}); err != nil {
+			return models.Analysis{}, notFound(err, ErrAnalysisNotFound)
+		}
+		return models.Analysis{}, ErrNotDeleted
+	}
+	if err != nil {
+		return models.Analysis{}, err
+	}
+	analysisLog.Info().Str("analysisID", analysisID).Int64("userID", userID).Msg("Analysis restored")
+
+	return s.GetAnalysisByID(ctx, models.AnalysisRef{ID: analysisID}, models.LabelOptions{}, models.Projection{})
+}
+
+// GetAudit returns the deletions, restores and purge of the user's analysis,
+// oldest first. It also works for purged analyses, whose tombstone is kept.
+func (s *AnalysisService) GetAudit(ctx context.Context, userID int64, ref models.AnalysisRef) ([]models.AnalysisAuditEntry, error) {
+	analysisID, err := resolveAnalysisID(ctx, s.repo, ref)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkOwner(ctx, userID, analysisID); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.repo.ListAnalysisAudit(ctx, pgtype.Text{String: analysisID, Valid: true})
+	if err != nil {
+		analysisLog.Error().Err(err).Str("analysisID", analysisID).Msg("Failed to get analysis audit trail")
+		return nil, err
+	}
+
+	entries := make([]models.AnalysisAuditEntry, 0, len(rows))
+	for _, row := range rows {
+		entries = append(entries, models.AnalysisAuditEntry{
+			ID:         row.ID,
+			AnalysisID: row.AnalysisID,
+			IDAnalysis: row.IDAnalysis.String,
+			Action:     row.Action,
+			IDUser:     row.IDUser.String,
+			Details:    row.Details,
+			CreatedAt:  row.CreatedAt,
+		})
+	}
+	return entries, nil
+}
+
+// checkOwner tells an analysis of another user, ErrNotOwner, from one that never
+// existed, ErrAnalysisNotFound. Deleted and purged analyses still have an owner.
+func (s *AnalysisService) checkOwner(ctx context.Context, userID int64, analysisID string) error {
+	owner, err := s.repo.GetAnalysisOwner(ctx, pgtype.Text{String: analysisID, Valid: true})
+	if err != nil {
+		return notFound(err, ErrAnalysisNotFound)
+	}
+	if owner.String != strconv.FormatInt(userID, 10) {
+		return ErrNotOwner
+	}
+	return nil
+}
+
+// ObjectIterator calls fn on each object of an analysis in id order, until fn
+// returns an error.
+type ObjectIterator func(ctx context.Context, fn func(models.Object) error) error
+
+// IterObjectsByAnalysisID returns the objects of an analysis as an iterator that
+// reads them from the database a page at a time, for responses too large to build
+// in memory. Only the columns behind the projected fields are read. No connection
+// is held while fn runs, so slow clients of a stream can't tie up the pool. The
+// analysis and its labels are loaded right away, so a missing analysis is
+// reported here rather than midway through its objects.
+func (s *AnalysisService) IterObjectsByAnalysisID(ctx context.Context, ref models.AnalysisRef, labels models.LabelOptions, fields models.Projection) (ObjectIterator, error) {
+	repoAnalysis, err := resolveAnalysis(ctx, s.repo, ref)
 	if err != nil {
 		return nil, err
 	}
 
-	return s.getObjectsForAnalysis(ctx, internalID)
+	analysisID := pgtype.Int8{Int64: int64(repoAnalysis.ID), Valid: true}
+	var effective map[int32]string
+	if fields.Has("class") || fields.Has("original_class") {
+		rows, err := s.repo.GetEffectiveLabelsByAnalysisID(ctx, analysisID)
+		if err != nil {
+			analysisLog.Warn().Err(err).Int32("analysisID", repoAnalysis.ID).Msg("Failed to get object labels")
+			return nil, err
+		}
+		effective = make(map[int32]string, len(rows))
+		for _, row := range rows {
+			effective[row.ObjectID] = row.Class
+		}
+	}
+
+	columns := objectColumns(fields)
+	return func(ctx context.Context, fn func(models.Object) error) error {
+		return s.objects.Each(ctx, analysisID, columns, func(repoObject repository.Object) error {
+			return fn(labeledObject(repoObject, effective, labels))
+		})
+	}, nil
 }
 
-func (s *AnalysisService) getObjectsForAnalysis(ctx context.Context, analysisID int64) ([]models.Object, error) {
+// objectColumns returns the object columns behind the projected fields, or nil
+// for all of them. Effective labels are matched by object id, which is always read.
+func objectColumns(fields models.Projection) []string {
+	if fields.All() {
+		return nil
+	}
+	var columns []string
+	for _, name := range fields.Names() {
+		// The original class is the class column, before effective labels
+		if name == "original_class" {
+			name = "class"
+		}
+		columns = append(columns, name)
+	}
+	slices.Sort(columns)
+	return slices.Compact(columns)
+}
+
+// resolveAnalysis loads the visible analysis a route refers to, or returns
+// ErrAnalysisNotFound.
+func resolveAnalysis(ctx context.Context, repo *repository.Queries, ref models.AnalysisRef) (repository.Analysis, error) {
+	if ref.Type != models.IDTypeInternal {
+		analysis, err := repo.GetAnalysisByID(ctx, pgtype.Text{String: ref.ID, Valid: true})
+		return analysis, notFound(err, ErrAnalysisNotFound)
+	}
+	id, err := strconv.ParseInt(ref.ID, 10, 32)
+	if err != nil {
+		return repository.Analysis{}, ErrAnalysisNotFound
+	}
+	analysis, err := repo.GetAnalysisByInternalID(ctx, int32(id))
+	return analysis, notFound(err, ErrAnalysisNotFound)
+}
+
+// resolveAnalysisID returns the id_analysis a route refers to. Unlike
+// resolveAnalysis it also resolves soft-deleted analyses, and doesn't check that
+// an external id exists.
+func resolveAnalysisID(ctx context.Context, repo *repository.Queries, ref models.AnalysisRef) (string, error) {
+	if ref.Type != models.IDTypeInternal {
+		return ref.ID, nil
+	}
+	id, err := strconv.ParseInt(ref.ID, 10, 32)
+	if err != nil {
+		return "", ErrAnalysisNotFound
+	}
+	idAnalysis, err := repo.GetAnalysisIDByInternalID(ctx, int32(id))
+	if err != nil {
+		return "", notFound(err, ErrAnalysisNotFound)
+	}
+	if !idAnalysis.Valid {
+		return "", ErrAnalysisNotFound
+	}
+	return idAnalysis.String, nil
+}
+
+// getObjectsForAnalysis returns the objects of an analysis with their effective labels.
+func (s *AnalysisService) getObjectsForAnalysis(ctx context.Context, analysisID int64, labels models.LabelOptions) ([]models.Object, error) {
 	repoObjects, err := s.repo.GetObjectsByAnalysisID(ctx, pgtype.Int8{Int64: analysisID, Valid: true})
 	if err != nil {
 		analysisLog.Warn().Err(err).Int64("analysisID", analysisID).Msg("Failed to get objects")
@@ -137,21 +505,99 @@ func (s *AnalysisService) getObjectsForAnalysis(ctx context.Context, analysisID
 		return []models.Object{}, nil
 	}
 
+	ids := make([]int32, len(repoObjects))
+	for i, repoObject := range repoObjects {
+		ids[i] = repoObject.ID
+	}
+	effective, err := effectiveLabels(ctx, s.repo, ids)
+	if err != nil {
+		analysisLog.Warn().Err(err).Int64("analysisID", analysisID).Msg("Failed to get object labels")
+		return nil, err
+	}
+
 	objects := make([]models.Object, 0, len(repoObjects))
 	for _, repoObject := range repoObjects {
-		objects = append(objects, convertObjectFromRepo(repoObject))
+		objects = append(objects, labeledObject(repoObject, effective, labels))
 	}
 
 	return objects, nil
 }
 
-func (s *AnalysisService) ProxyAnalysisAPICall(ctx context.Context, product, userID, fileName string, fileContent io.Reader) (int, http.Header, []byte, error) {
+// labeledObject converts an object with its effective label, keyed by object ID
+// in effective, keeping the pipeline's class too if labels asks for it.
+func labeledObject(repoObject repository.Object, effective map[int32]string, labels models.LabelOptions) models.Object {
+	object := convertObjectFromRepo(repoObject)
+	if labels.Original {
+		object.OriginalClass = object.Class
+	}
+	if class, ok := effective[object.ID]; ok {
+		object.Class = class
+	}
+	return object
+}
+
+// getTags returns the tags of the given analyses keyed by analysis ID.
+func (s *AnalysisService) getTags(ctx context.Context, ids []int32) (map[int32][]string, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	rows, err := s.repo.GetAnalysisTagsByAnalysisIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	tags := make(map[int32][]string)
+	for _, row := range rows {
+		tags[row.AnalysisID] = append(tags[row.AnalysisID], row.Tag)
+	}
+	return tags, nil
+}
+
+// tagsOf returns the tags of an analysis, never nil so that untagged analyses
+// serialize as an empty list.
+func tagsOf(tags map[int32][]string, id int32) []string {
+	if t, ok := tags[id]; ok {
+		return t
+	}
+	return []string{}
+}
+
+// normalizeTags trims and deduplicates tags, keeping their order.
+func normalizeTags(raw []string) ([]string, error) {
+	tags := make([]string, 0, len(raw))
+	seen := make(map[string]bool, len(raw))
+	for _, tag := range raw {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			return nil, fmt.Errorf("%w: tags must not be empty", ErrInvalidMetadata)
+		}
+		if len(tag) > MaxAnalysisTagLength {
+			return nil, fmt.Errorf("%w: tag %q is longer than %d characters", ErrInvalidMetadata, tag, MaxAnalysisTagLength)
+		}
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	if len(tags) > MaxAnalysisTags {
+		return nil, fmt.Errorf("%w: at most %d tags are allowed", ErrInvalidMetadata, MaxAnalysisTags)
+	}
+	return tags, nil
+}
+
+// ResolveProduct maps the product a user picked onto the catalog, see
+// ProductsService.Resolve.
+func (s *AnalysisService) ResolveProduct(ctx context.Context, userID, product string) (models.Product, error) {
+	return s.products.Resolve(ctx, userID, product)
+}
+
+func (s *AnalysisService) ProxyAnalysisAPICall(ctx context.Context, product models.Product, userID, fileName string, fileContent io.Reader) (int, http.Header, []byte, error) {
 	// Create a multipart form buffer
 	var body bytes.Buffer
 	writer := multipart.NewWriter(&body)
 
 	// Add text fields
-	if err := writer.WriteField("product", product); err != nil {
+	if err := writer.WriteField("product", product.Code); err != nil {
 		return 0, nil, nil, err
 	}
 
@@ -159,6 +605,13 @@ func (s *AnalysisService) ProxyAnalysisAPICall(ctx context.Context, product, use
 		return 0, nil, nil, err
 	}
 
+	// Default analysis parameters of the product
+	for _, field := range productFormFields(product.Settings) {
+		if err := writer.WriteField(field[0], field[1]); err != nil {
+			return 0, nil, nil, err
+		}
+	}
+
 	// Create form file field
 	part, err := writer.CreateFormFile("file", fileName)
 	if err != nil {
@@ -191,7 +644,7 @@ func (s *AnalysisService) ProxyAnalysisAPICall(ctx context.Context, product, use
 	client := &fasthttp.Client{}
 	err = client.DoTimeout(req, resp, 2*time.Minute)
 	if err != nil {
-		return 0, nil, nil, err
+		return 0, nil, nil, fmt.Errorf("%w: %w", ErrAnalysisAPIUnavailable, err)
 	}
 
 	// Convert fasthttp response headers to http.Header
@@ -207,6 +660,28 @@ func (s *AnalysisService) ProxyAnalysisAPICall(ctx context.Context, product, use
 	return resp.StatusCode(), headers, responseBody, nil
 }
 
+// AnalysisAPIError turns a failed analysis API response into a domain error. The
+// API rejecting the upload is a validation error; anything else is its failure.
+func AnalysisAPIError(status int, body []byte) error {
+	// The API answers {"Response": "..."} but may fall back to plain text
+	message := string(body)
+	var resp struct {
+		Response string `json:"Response"`
+	}
+	if err := json.Unmarshal(body, &resp); err == nil && resp.Response != "" {
+		message = resp.Response
+	}
+
+	switch status {
+	case fasthttp.StatusBadRequest:
+		return apperr.Validation("analysis_rejected", message)
+	case fasthttp.StatusInternalServerError:
+		return apperr.UpstreamUnavailable("analysis_api_error", "analysis API internal error: "+message)
+	default:
+		return apperr.UpstreamUnavailable("analysis_api_error", fmt.Sprintf("analysis API error (status %d): %s", status, message))
+	}
+}
+
 func convertAnalysisFromRepo(repoAnalysis repository.Analysis) models.Analysis {
 	idAnalysis, err := strconv.ParseInt(repoAnalysis.IDAnalysis.String, 10, 64)
 	if err != nil {
@@ -214,13 +689,16 @@ func convertAnalysisFromRepo(repoAnalysis repository.Analysis) models.Analysis {
 		return models.Analysis{}
 	}
 
-	unmarshalStats := func(data []byte, fieldName string) models.Stats {
-		var stats models.Stats
-		if err := json.Unmarshal(data, &stats); err != nil {
-			analysisLog.Error().Err(err).Str("field", fieldName).Msg("Failed to unmarshal stats")
-			return models.Stats{}
+	// Missing stats stay nil, so they can't be mistaken for all-zero ones
+	unmarshalStats := func(data []byte, fieldName string) *models.Stats {
+		stats, status := decodeStoredStats(data)
+		if status == models.StatsCorrupt {
+			analysisLog.Error().Str("field", fieldName).Msg("Failed to unmarshal stats")
 		}
-		return stats
+		if status != models.StatsOK {
+			return nil
+		}
+		return &stats
 	}
 
 	return models.Analysis{
@@ -232,9 +710,9 @@ func convertAnalysisFromRepo(repoAnalysis repository.Analysis) models.Analysis {
 		TelegramLink: repoAnalysis.TelegramLink.String,
 		Text:         repoAnalysis.Text.String,
 		FileSource:   repoAnalysis.FileSource.String,
-		ScaleMmPixel: repoAnalysis.ScaleMmPixel.Float64,
-		Mass:         repoAnalysis.Mass.Float64,
-		Area:         repoAnalysis.Area.Float64,
+		ScaleMmPixel: float8Ptr(repoAnalysis.ScaleMmPixel),
+		Mass:         float8Ptr(repoAnalysis.Mass),
+		Area:         float8Ptr(repoAnalysis.Area),
 		R:            unmarshalStats(repoAnalysis.R, "R"),
 		G:            unmarshalStats(repoAnalysis.G, "G"),
 		B:            unmarshalStats(repoAnalysis.B, "B"),
@@ -249,6 +727,7 @@ func convertAnalysisFromRepo(repoAnalysis repository.Analysis) models.Analysis {
 		T:            unmarshalStats(repoAnalysis.T, "T"),
 		FileOutput:   repoAnalysis.FileOutput.String,
 		IDAnalysis:   idAnalysis,
+		Version:      repoAnalysis.Version,
 	}
 }
 
@@ -259,58 +738,74 @@ func convertObjectFromRepo(repoObject repository.Object) models.Object {
 		File:       repoObject.File.String,
 		Class:      repoObject.Class.String,
 		Geometry:   repoObject.Geometry.String,
-		MH:         repoObject.MH.Float64,
-		MS:         repoObject.MS.Float64,
-		MV:         repoObject.MV.Float64,
-		MR:         repoObject.MR.Float64,
-		MG:         repoObject.MG.Float64,
-		MB:         repoObject.MB.Float64,
-		LAvg:       repoObject.LAvg.Float64,
-		WAvg:       repoObject.WAvg.Float64,
-		BrtAvg:     repoObject.BrtAvg.Float64,
-		RAvg:       repoObject.RAvg.Float64,
-		GAvg:       repoObject.GAvg.Float64,
-		BAvg:       repoObject.BAvg.Float64,
-		HAvg:       repoObject.HAvg.Float64,
-		SAvg:       repoObject.SAvg.Float64,
-		VAvg:       repoObject.VAvg.Float64,
-		H:          repoObject.H.Float64,
-		S:          repoObject.S.Float64,
-		V:          repoObject.V.Float64,
-		HM:         repoObject.HM.Float64,
-		SM:         repoObject.SM.Float64,
-		VM:         repoObject.VM.Float64,
-		RM:         repoObject.RM.Float64,
-		GM:         repoObject.GM.Float64,
-		BM:         repoObject.BM.Float64,
-		BrtM:       repoObject.BrtM.Float64,
-		WM:         repoObject.WM.Float64,
-		LM:         repoObject.LM.Float64,
-		L:          repoObject.L.Float64,
-		W:          repoObject.W.Float64,
-		LW:         repoObject.LW.Float64,
-		Pr:         repoObject.Pr.Float64,
-		Sq:         repoObject.Sq.Float64,
-		Brt:        repoObject.Brt.Float64,
-		R:          repoObject.R.Float64,
-		G:          repoObject.G.Float64,
-		B:          repoObject.B.Float64,
-		Solid:      repoObject.Solid.Float64,
-		MinH:       repoObject.MinH.Float64,
-		MinS:       repoObject.MinS.Float64,
-		MinV:       repoObject.MinV.Float64,
-		MaxH:       repoObject.MaxH.Float64,
-		MaxS:       repoObject.MaxS.Float64,
-		MaxV:       repoObject.MaxV.Float64,
-		Entropy:    repoObject.Entropy.Float64,
-		IDImage:    repoObject.IDImage.Int64,
+		MH:         float8Ptr(repoObject.MH),
+		MS:         float8Ptr(repoObject.MS),
+		MV:         float8Ptr(repoObject.MV),
+		MR:         float8Ptr(repoObject.MR),
+		MG:         float8Ptr(repoObject.MG),
+		MB:         float8Ptr(repoObject.MB),
+		LAvg:       float8Ptr(repoObject.LAvg),
+		WAvg:       float8Ptr(repoObject.WAvg),
+		BrtAvg:     float8Ptr(repoObject.BrtAvg),
+		RAvg:       float8Ptr(repoObject.RAvg),
+		GAvg:       float8Ptr(repoObject.GAvg),
+		BAvg:       float8Ptr(repoObject.BAvg),
+		HAvg:       float8Ptr(repoObject.HAvg),
+		SAvg:       float8Ptr(repoObject.SAvg),
+		VAvg:       float8Ptr(repoObject.VAvg),
+		H:          float8Ptr(repoObject.H),
+		S:          float8Ptr(repoObject.S),
+		V:          float8Ptr(repoObject.V),
+		HM:         float8Ptr(repoObject.HM),
+		SM:         float8Ptr(repoObject.SM),
+		VM:         float8Ptr(repoObject.VM),
+		RM:         float8Ptr(repoObject.RM),
+		GM:         float8Ptr(repoObject.GM),
+		BM:         float8Ptr(repoObject.BM),
+		BrtM:       float8Ptr(repoObject.BrtM),
+		WM:         float8Ptr(repoObject.WM),
+		LM:         float8Ptr(repoObject.LM),
+		L:          float8Ptr(repoObject.L),
+		W:          float8Ptr(repoObject.W),
+		LW:         float8Ptr(repoObject.LW),
+		Pr:         float8Ptr(repoObject.Pr),
+		Sq:         float8Ptr(repoObject.Sq),
+		Brt:        float8Ptr(repoObject.Brt),
+		R:          float8Ptr(repoObject.R),
+		G:          float8Ptr(repoObject.G),
+		B:          float8Ptr(repoObject.B),
+		Solid:      float8Ptr(repoObject.Solid),
+		MinH:       float8Ptr(repoObject.MinH),
+		MinS:       float8Ptr(repoObject.MinS),
+		MinV:       float8Ptr(repoObject.MinV),
+		MaxH:       float8Ptr(repoObject.MaxH),
+		MaxS:       float8Ptr(repoObject.MaxS),
+		MaxV:       float8Ptr(repoObject.MaxV),
+		Entropy:    float8Ptr(repoObject.Entropy),
+		IDImage:    int8Ptr(repoObject.IDImage),
 		ColorRhs:   repoObject.ColorRhs.String,
-		SqSqcrl:    repoObject.SqSqcrl.Float64,
-		Hu1:        repoObject.Hu1.Float64,
-		Hu2:        repoObject.Hu2.Float64,
-		Hu3:        repoObject.Hu3.Float64,
-		Hu4:        repoObject.Hu4.Float64,
-		Hu5:        repoObject.Hu5.Float64,
-		Hu6:        repoObject.Hu6.Float64,
+		SqSqcrl:    float8Ptr(repoObject.SqSqcrl),
+		Hu1:        float8Ptr(repoObject.Hu1),
+		Hu2:        float8Ptr(repoObject.Hu2),
+		Hu3:        float8Ptr(repoObject.Hu3),
+		Hu4:        float8Ptr(repoObject.Hu4),
+		Hu5:        float8Ptr(repoObject.Hu5),
+		Hu6:        float8Ptr(repoObject.Hu6),
+	}
+}
+
+// float8Ptr returns nil for a NULL column, so a missing measurement stays
+// distinguishable from a zero one.
+func float8Ptr(v pgtype.Float8) *float64 {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Float64
+}
+
+func int8Ptr(v pgtype.Int8) *int64 {
+	if !v.Valid {
+		return nil
 	}
+	return &v.Int64
 }
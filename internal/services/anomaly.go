@@ -0,0 +1,398 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"csort.ru/analysis-service/internal/apperr"
+	"csort.ru/analysis-service/internal/logger"
+	"csort.ru/analysis-service/internal/models"
+	"csort.ru/analysis-service/internal/repository"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	// DefaultRobustZThreshold is the usual cut-off for modified z-scores (Iglewicz & Hoaglin).
+	DefaultRobustZThreshold = 3.5
+	// driverThresholdRatio selects which features are reported as drivers of an anomaly.
+	driverThresholdRatio = 0.5
+)
+
+// defaultAnomalyFeatures covers geometry, color and shape: the usual tells of foreign material.
+var defaultAnomalyFeatures = []string{"l_w", "solid", "sq_sqcrl", "h_avg", "s_avg", "v_avg", "hu"}
+
+// ErrInvalidAnomalyRequest is returned for unsupported methods or thresholds.
+var ErrInvalidAnomalyRequest = apperr.Validation("invalid_anomaly_request", "invalid anomaly request")
+
+var anomalyLog = logger.GetLogger("services.anomaly")
+
+type AnomalyService struct {
+	repo *repository.Queries
+}
+
+func NewAnomalyService(repo *repository.Queries) *AnomalyService {
+	return &AnomalyService{
+		repo: repo,
+	}
+}
+
+// DetectAnomalies scores every object of an analysis and flags the ones that stand out.
+func (s *AnomalyService) DetectAnomalies(ctx context.Context, ref models.AnalysisRef, req models.AnomalyRequest) (models.AnomalyReport, error) {
+	var names []string
+	if req.Features != "" {
+		names = strings.Split(req.Features, ",")
+	}
+	features, err := resolveFeatures(names, defaultAnomalyFeatures)
+	if err != nil {
+		return models.AnomalyReport{}, err
+	}
+
+	method := req.Method
+	if method == "" {
+		method = models.AnomalyRobustZ
+	}
+	if method != models.AnomalyRobustZ && method != models.AnomalyMahalanobis {
+		return models.AnomalyReport{}, fmt.Errorf("%w: unknown method %q", ErrInvalidAnomalyRequest, method)
+	}
+	if req.Threshold < 0 {
+		return models.AnomalyReport{}, fmt.Errorf("%w: threshold must be positive", ErrInvalidAnomalyRequest)
+	}
+
+	repoAnalysis, err := resolveAnalysis(ctx, s.repo, ref)
+	if err != nil {
+		return models.AnomalyReport{}, err
+	}
+	analysisID := repoAnalysis.IDAnalysis.String
+	repoObjects, err := s.repo.GetObjectsByAnalysisID(ctx, pgtype.Int8{Int64: int64(repoAnalysis.ID), Valid: true})
+	if err != nil {
+		anomalyLog.Error().Err(err).Str("analysisID", analysisID).Msg("Failed to get objects")
+		return models.AnomalyReport{}, err
+	}
+	if err := applyEffectiveLabels(ctx, s.repo, repoObjects); err != nil {
+		return models.AnomalyReport{}, err
+	}
+
+	// values[i][j] is feature j of object i, NaN when NULL
+	values := make([][]float64, len(repoObjects))
+	for i := range repoObjects {
+		values[i] = make([]float64, len(features))
+		for j, feature := range features {
+			if v := objectFeatures[feature](&repoObjects[i]); v.Valid {
+				values[i][j] = v.Float64
+			} else {
+				values[i][j] = math.NaN()
+			}
+		}
+	}
+
+	var scores []objectScore
+	threshold := req.Threshold
+	switch method {
+	case models.AnomalyRobustZ:
+		if threshold == 0 {
+			threshold = DefaultRobustZThreshold
+		}
+		scores = robustZScores(values)
+	case models.AnomalyMahalanobis:
+		if threshold == 0 {
+			threshold = mahalanobisThreshold(len(features))
+		}
+		scores = mahalanobisScores(values)
+	}
+
+	report := models.AnomalyReport{
+		AnalysisID: repoAnalysis.ID,
+		Method:     method,
+		Features:   features,
+		Threshold:  threshold,
+		Objects:    len(repoObjects),
+		Items:      []models.ObjectAnomaly{},
+	}
+
+	for i, score := range scores {
+		if !score.scored {
+			continue
+		}
+		report.Scored++
+
+		anomalous := score.score >= threshold
+		if anomalous {
+			report.Anomalies++
+		} else if !req.All {
+			continue
+		}
+
+		drivers := make([]models.AnomalyDriver, 0)
+		for j, contribution := range score.contributions {
+			if math.IsNaN(contribution) || math.Abs(contribution) < threshold*driverThresholdRatio {
+				continue
+			}
+			drivers = append(drivers, models.AnomalyDriver{
+				Feature: features[j],
+				Value:   values[i][j],
+				Score:   contribution,
+			})
+		}
+		sort.Slice(drivers, func(a, b int) bool {
+			return math.Abs(drivers[a].Score) > math.Abs(drivers[b].Score)
+		})
+
+		report.Items = append(report.Items, models.ObjectAnomaly{
+			ObjectID:  repoObjects[i].ID,
+			Class:     repoObjects[i].Class.String,
+			File:      repoObjects[i].File.String,
+			Score:     score.score,
+			Anomalous: anomalous,
+			Drivers:   drivers,
+		})
+	}
+
+	sort.SliceStable(report.Items, func(a, b int) bool {
+		return report.Items[a].Score > report.Items[b].Score
+	})
+
+	return report, nil
+}
+
+type objectScore struct {
+	scored        bool
+	score         float64
+	contributions []float64 // per feature, on the same scale as score
+}
+
+// robustZScores scores each object by its largest modified z-score,
+// 0.6745 * (x - median) / MAD. NULL features are ignored.
+func robustZScores(values [][]float64) []objectScore {
+	scores := make([]objectScore, len(values))
+	if len(values) == 0 {
+		return scores
+	}
+	featureCount := len(values[0])
+
+	for i := range scores {
+		scores[i].contributions = make([]float64, featureCount)
+	}
+
+	for j := 0; j < featureCount; j++ {
+		column := make([]float64, 0, len(values))
+		for i := range values {
+			if !math.IsNaN(values[i][j]) {
+				column = append(column, values[i][j])
+			}
+		}
+
+		center := median(column)
+		deviations := make([]float64, len(column))
+		for k, v := range column {
+			deviations[k] = math.Abs(v - center)
+		}
+		scale := median(deviations) / 0.6745
+		if scale == 0 {
+			// More than half of the values are identical; fall back to the mean absolute deviation
+			scale = mean(deviations) * 1.2533
+		}
+
+		for i := range values {
+			v := values[i][j]
+			if math.IsNaN(v) {
+				scores[i].contributions[j] = math.NaN()
+				continue
+			}
+			z := 0.0
+			if scale > 0 {
+				z = (v - center) / scale
+			}
+			scores[i].contributions[j] = z
+			scores[i].scored = true
+			if math.Abs(z) > scores[i].score {
+				scores[i].score = math.Abs(z)
+			}
+		}
+	}
+
+	return scores
+}
+
+// mahalanobisScores scores each object by its Mahalanobis distance from the
+// mean of the analysis. Objects with a NULL feature are not scored. Feature j
+// contributes d_j * (S^-1 d)_j to the squared distance; drivers report the
+// square root of that so it is on the same scale as the score.
+func mahalanobisScores(values [][]float64) []objectScore {
+	scores := make([]objectScore, len(values))
+	if len(values) == 0 {
+		return scores
+	}
+	featureCount := len(values[0])
+
+	complete := make([]int, 0, len(values))
+	for i, row := range values {
+		ok := true
+		for _, v := range row {
+			if math.IsNaN(v) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			complete = append(complete, i)
+		}
+	}
+	if len(complete) <= featureCount {
+		// Not enough objects to estimate a covariance matrix
+		return scores
+	}
+
+	means := make([]float64, featureCount)
+	for _, i := range complete {
+		for j, v := range values[i] {
+			means[j] += v
+		}
+	}
+	for j := range means {
+		means[j] /= float64(len(complete))
+	}
+
+	covariance := make([][]float64, featureCount)
+	for a := range covariance {
+		covariance[a] = make([]float64, featureCount)
+	}
+	for _, i := range complete {
+		for a := 0; a < featureCount; a++ {
+			for b := 0; b < featureCount; b++ {
+				covariance[a][b] += (values[i][a] - means[a]) * (values[i][b] - means[b])
+			}
+		}
+	}
+	trace := 0.0
+	for a := 0; a < featureCount; a++ {
+		for b := 0; b < featureCount; b++ {
+			covariance[a][b] /= float64(len(complete) - 1)
+		}
+		trace += covariance[a][a]
+	}
+	// A small ridge keeps the matrix invertible when features are collinear or constant
+	ridge := 1e-6 * math.Max(trace/float64(featureCount), 1e-12)
+	for a := 0; a < featureCount; a++ {
+		covariance[a][a] += ridge
+	}
+
+	inverse, ok := invertMatrix(covariance)
+	if !ok {
+		anomalyLog.Warn().Int("features", featureCount).Msg("Covariance matrix is singular, skipping Mahalanobis scoring")
+		return scores
+	}
+
+	diff := make([]float64, featureCount)
+	for _, i := range complete {
+		for j := range diff {
+			diff[j] = values[i][j] - means[j]
+		}
+
+		contributions := make([]float64, featureCount)
+		squared := 0.0
+		for a := 0; a < featureCount; a++ {
+			weighted := 0.0
+			for b := 0; b < featureCount; b++ {
+				weighted += inverse[a][b] * diff[b]
+			}
+			contributions[a] = diff[a] * weighted
+			squared += contributions[a]
+		}
+		for a := range contributions {
+			// Negative terms pull the object towards the mean and don't drive the anomaly
+			contributions[a] = math.Sqrt(math.Max(contributions[a], 0))
+		}
+
+		scores[i] = objectScore{
+			scored:        true,
+			score:         math.Sqrt(math.Max(squared, 0)),
+			contributions: contributions,
+		}
+	}
+
+	return scores
+}
+
+// mahalanobisThreshold approximates sqrt of the 99.9% chi-square quantile with
+// the given degrees of freedom (Wilson-Hilferty).
+func mahalanobisThreshold(degrees int) float64 {
+	const z999 = 3.0902
+	k := float64(degrees)
+	term := 1 - 2/(9*k) + z999*math.Sqrt(2/(9*k))
+	return math.Sqrt(k * term * term * term)
+}
+
+// invertMatrix inverts a square matrix by Gauss-Jordan elimination with partial pivoting.
+func invertMatrix(matrix [][]float64) ([][]float64, bool) {
+	n := len(matrix)
+	augmented := make([][]float64, n)
+	for i := range matrix {
+		augmented[i] = make([]float64, 2*n)
+		copy(augmented[i], matrix[i])
+		augmented[i][n+i] = 1
+	}
+
+	for col := 0; col < n; col++ {
+		pivot := col
+		for row := col + 1; row < n; row++ {
+			if math.Abs(augmented[row][col]) > math.Abs(augmented[pivot][col]) {
+				pivot = row
+			}
+		}
+		if math.Abs(augmented[pivot][col]) < 1e-300 {
+			return nil, false
+		}
+		augmented[col], augmented[pivot] = augmented[pivot], augmented[col]
+
+		scale := augmented[col][col]
+		for k := range augmented[col] {
+			augmented[col][k] /= scale
+		}
+		for row := 0; row < n; row++ {
+			if row == col {
+				continue
+			}
+			factor := augmented[row][col]
+			if factor == 0 {
+				continue
+			}
+			for k := range augmented[row] {
+				augmented[row][k] -= factor * augmented[col][k]
+			}
+		}
+	}
+
+	inverse := make([][]float64, n)
+	for i := range augmented {
+		inverse[i] = augmented[i][n:]
+	}
+	return inverse, true
+}
+
+// median returns the median of values, or 0 for an empty slice. values is not modified.
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 0 {
+		return (sorted[n/2-1] + sorted[n/2]) / 2
+	}
+	return sorted[n/2]
+}
+
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
@@ -0,0 +1,206 @@
+package services
+
+import (
+	"math"
+	"testing"
+)
+
+const scoreTolerance = 1e-4
+
+func approxEqual(a, b, tolerance float64) bool {
+	return math.Abs(a-b) <= tolerance
+}
+
+// column turns one feature's values into rows of a single feature.
+func column(values ...float64) [][]float64 {
+	rows := make([][]float64, len(values))
+	for i, v := range values {
+		rows[i] = []float64{v}
+	}
+	return rows
+}
+
+func TestRobustZScores(t *testing.T) {
+	nan := math.NaN()
+	tests := []struct {
+		name   string
+		values [][]float64
+		// want are the expected z-scores, NaN for unscored objects
+		want []float64
+	}{
+		{
+			// median 3, MAD 1: z = 0.6745 * (x - 3)
+			name:   "outlier",
+			values: column(1, 2, 3, 4, 100),
+			want:   []float64{-1.349, -0.6745, 0, 0.6745, 65.4265},
+		},
+		{
+			// MAD 0, so the mean absolute deviation 0.8 scaled by 1.2533 is used
+			name:   "mean absolute deviation fallback",
+			values: column(1, 1, 1, 1, 5),
+			want:   []float64{0, 0, 0, 0, 4 / (0.8 * 1.2533)},
+		},
+		{
+			name:   "constant feature",
+			values: column(5, 5, 5, 5),
+			want:   []float64{0, 0, 0, 0},
+		},
+		{
+			// NULLs are left out of the median and MAD of the rest
+			name:   "null feature",
+			values: column(nan, 1, 2, 3),
+			want:   []float64{nan, -0.6745, 0, 0.6745},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scores := robustZScores(tt.values)
+			for i, want := range tt.want {
+				got := scores[i]
+				if math.IsNaN(want) {
+					if got.scored || !math.IsNaN(got.contributions[0]) {
+						t.Errorf("object %d: got scored %v, contribution %v, want unscored", i, got.scored, got.contributions[0])
+					}
+					continue
+				}
+				if !got.scored || !approxEqual(got.contributions[0], want, scoreTolerance) || !approxEqual(got.score, math.Abs(want), scoreTolerance) {
+					t.Errorf("object %d: got scored %v, z %v, score %v, want z %v", i, got.scored, got.contributions[0], got.score, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRobustZScoresLargestFeature(t *testing.T) {
+	// The score is the largest absolute z of the object's features
+	scores := robustZScores([][]float64{{1, 10}, {2, 20}, {3, 30}, {4, 40}, {5, 1000}})
+	if !approxEqual(scores[4].score, 0.6745*(1000-30)/10, scoreTolerance) {
+		t.Errorf("got score %v, want the z of the second feature", scores[4].score)
+	}
+}
+
+func TestMahalanobisScores(t *testing.T) {
+	nan := math.NaN()
+	tests := []struct {
+		name   string
+		values [][]float64
+		// want are the expected distances, NaN for unscored objects
+		want []float64
+		// wantContributions are the expected drivers of the first object, if set
+		wantContributions []float64
+	}{
+		{
+			// Mean 3, variance 2.5
+			name:   "one feature",
+			values: column(1, 2, 3, 4, 5),
+			want:   []float64{2 / math.Sqrt(2.5), 1 / math.Sqrt(2.5), 0, 1 / math.Sqrt(2.5), 2 / math.Sqrt(2.5)},
+		},
+		{
+			// Mean (1, 1), covariance [[2/3, 2/3], [2/3, 4/3]] whose inverse is
+			// [[3, -1.5], [-1.5, 1.5]]. (2, 2) is at d = (1, 1): d'S^-1 d = 1.5,
+			// with terms 1.5 and 0.
+			name:              "correlated features",
+			values:            [][]float64{{2, 2}, {0, 0}, {1, 0}, {1, 2}},
+			want:              []float64{math.Sqrt(1.5), math.Sqrt(1.5), math.Sqrt(1.5), math.Sqrt(1.5)},
+			wantContributions: []float64{math.Sqrt(1.5), 0},
+		},
+		{
+			// The ridge keeps the covariance invertible, and the constant feature,
+			// with no deviation, adds nothing: mean 2.5, variance 5/3
+			name:              "constant feature",
+			values:            [][]float64{{4, 5}, {1, 5}, {2, 5}, {3, 5}},
+			want:              []float64{1.5 / math.Sqrt(5.0/3), 1.5 / math.Sqrt(5.0/3), 0.5 / math.Sqrt(5.0/3), 0.5 / math.Sqrt(5.0/3)},
+			wantContributions: []float64{1.5 / math.Sqrt(5.0/3), 0},
+		},
+		{
+			name:   "all constant",
+			values: [][]float64{{1, 5}, {1, 5}, {1, 5}},
+			want:   []float64{0, 0, 0},
+		},
+		{
+			name:   "null feature",
+			values: [][]float64{{nan}, {1}, {2}, {3}},
+			want:   []float64{nan, 1, 0, 1},
+		},
+		{
+			name:   "too few objects",
+			values: [][]float64{{1, 2}, {3, 4}},
+			want:   []float64{nan, nan},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scores := mahalanobisScores(tt.values)
+			for i, want := range tt.want {
+				got := scores[i]
+				if math.IsNaN(want) {
+					if got.scored {
+						t.Errorf("object %d: got score %v, want unscored", i, got.score)
+					}
+					continue
+				}
+				if !got.scored || !approxEqual(got.score, want, scoreTolerance) {
+					t.Errorf("object %d: got scored %v, distance %v, want %v", i, got.scored, got.score, want)
+				}
+			}
+			// Contributions are compared squared, as the square root magnifies the
+			// ridge's share of terms near 0
+			for j, want := range tt.wantContributions {
+				if got := scores[0].contributions[j]; !approxEqual(got*got, want*want, scoreTolerance) {
+					t.Errorf("feature %d: got contribution %v, want %v", j, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestMahalanobisScoresCollinear(t *testing.T) {
+	// The second feature is twice the first, so the covariance is singular
+	// without the ridge. Scores must still be finite.
+	scores := mahalanobisScores([][]float64{{1, 2}, {2, 4}, {3, 6}, {4, 8}, {5, 10}})
+	for i, score := range scores {
+		if !score.scored || math.IsNaN(score.score) || math.IsInf(score.score, 0) {
+			t.Errorf("object %d: got scored %v, distance %v, want a finite distance", i, score.scored, score.score)
+		}
+	}
+}
+
+func TestMahalanobisThreshold(t *testing.T) {
+	// sqrt of the 99.9% chi-square quantiles, which Wilson-Hilferty approximates
+	// to within 2%
+	exact := map[int]float64{
+		1:  math.Sqrt(10.828),
+		2:  math.Sqrt(13.816),
+		3:  math.Sqrt(16.266),
+		5:  math.Sqrt(20.515),
+		7:  math.Sqrt(24.322),
+		10: math.Sqrt(29.588),
+	}
+	for degrees, want := range exact {
+		if got := mahalanobisThreshold(degrees); math.Abs(got/want-1) > 0.02 {
+			t.Errorf("degrees %d: got %v, want about %v", degrees, got, want)
+		}
+	}
+}
+
+func TestInvertMatrix(t *testing.T) {
+	inverse, ok := invertMatrix([][]float64{{4, 7}, {2, 6}})
+	want := [][]float64{{0.6, -0.7}, {-0.2, 0.4}}
+	if !ok {
+		t.Fatal("got singular, want an inverse")
+	}
+	for i := range want {
+		for j := range want[i] {
+			if !approxEqual(inverse[i][j], want[i][j], 1e-12) {
+				t.Errorf("got %v, want %v", inverse, want)
+				return
+			}
+		}
+	}
+
+	if _, ok := invertMatrix([][]float64{{1, 2}, {2, 4}}); ok {
+		t.Error("got an inverse of a singular matrix")
+	}
+}
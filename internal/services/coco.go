@@ -0,0 +1,508 @@
+package services
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"math"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"csort.ru/analysis-service/internal/models"
+	"csort.ru/analysis-service/internal/repository"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// cocoImageTimeout bounds fetching one source image over HTTP.
+const cocoImageTimeout = 30 * time.Second
+
+// cocoSplits are the split names, in the order of the ratios of the split parameter.
+var cocoSplits = []string{"train", "val", "test"}
+
+// geometryNumber matches the coordinates of a stored geometry, whether it is a JSON
+// array of points, a flat list or WKT.
+var geometryNumber = regexp.MustCompile(`[-+]?(?:\d+\.?\d*|\.\d+)(?:[eE][-+]?\d+)?`)
+
+// cocoImage is an image of the export: the source image of an analysis, with
+// what the split and the annotation files need to know about its objects.
+type cocoImage struct {
+	models.CocoImage
+	source string
+	// classes counts the objects of each class
+	classes map[string]int
+	// extent is the smallest image size holding every annotation, used when the
+	// image itself isn't read
+	extent [2]float64
+	split  string
+}
+
+// cocoObject is an exported object with its class and polygon.
+type cocoObject struct {
+	row     *repository.ListObjectsForExportRow
+	class   string
+	polygon []float64
+}
+
+// ExportCoco prepares a COCO detection dataset of the user's objects matching the
+// request. Objects without a class, a parsable geometry or an analysis source
+// image are skipped. A single annotation file is returned as JSON; splits or
+// images make it a zip with annotations/instances_<split>.json and images/<split>/.
+//
+// Objects are paged through twice rather than held in memory: once to collect
+// the images and categories, then once per annotation file to write it.
+func (s *DatasetService) ExportCoco(ctx context.Context, userID int64, req models.CocoExportRequest) (*Export, error) {
+	product, err := s.products.CanonicalFilter(ctx, req.Product)
+	if err != nil {
+		return nil, err
+	}
+	params := repository.ListObjectsForExportParams{
+		IDUser:  pgtype.Text{String: fmt.Sprintf("%d", userID), Valid: true},
+		Product: product,
+		Class:   strings.TrimSpace(req.Class),
+		Limit:   datasetBatchSize,
+	}
+
+	if params.DateFrom, err = parseDatasetTime(req.From, false); err != nil {
+		return nil, err
+	}
+	if params.DateTo, err = parseDatasetTime(req.To, true); err != nil {
+		return nil, err
+	}
+	ratios, err := parseCocoSplit(req.Split)
+	if err != nil {
+		return nil, err
+	}
+
+	name := "coco-" + time.Now().Format("20060102-150405")
+	if ratios == nil && !req.Images {
+		return &Export{
+			Filename:    name + ".json",
+			ContentType: "application/json",
+			write: func(ctx context.Context, w io.Writer) error {
+				images, categories, lastID, err := s.collectCoco(ctx, params)
+				if err != nil {
+					return err
+				}
+				for _, img := range images {
+					img.setExtent()
+				}
+				return s.writeCocoDataset(ctx, w, params, lastID, images, categories)
+			},
+		}, nil
+	}
+
+	return &Export{
+		Filename:    name + ".zip",
+		ContentType: "application/zip",
+		write: func(ctx context.Context, w io.Writer) error {
+			images, categories, lastID, err := s.collectCoco(ctx, params)
+			if err != nil {
+				return err
+			}
+			splits := []string{""}
+			if ratios != nil {
+				splits = splitCocoImages(images, ratios, req.Seed)
+			}
+
+			zw := zip.NewWriter(w)
+			if req.Images {
+				// Images go first so that their sizes are known for the annotations
+				if images, err = s.writeCocoImages(ctx, zw, images); err != nil {
+					return err
+				}
+			} else {
+				for _, img := range images {
+					img.setExtent()
+				}
+			}
+
+			for _, split := range splits {
+				var selected []*cocoImage
+				for _, img := range images {
+					if img.split == split {
+						selected = append(selected, img)
+					}
+				}
+				file := "annotations/instances.json"
+				if split != "" {
+					file = "annotations/instances_" + split + ".json"
+				}
+				fw, err := zw.Create(file)
+				if err != nil {
+					return err
+				}
+				if err := s.writeCocoDataset(ctx, fw, params, lastID, selected, categories); err != nil {
+					return err
+				}
+			}
+			return zw.Close()
+		},
+	}, nil
+}
+
+// eachCocoObject pages through the matching objects up to lastID, 0 for all,
+// and calls fn with those that can be annotated.
+func (s *DatasetService) eachCocoObject(ctx context.Context, params repository.ListObjectsForExportParams, lastID int32, fn func(object cocoObject)) (int, error) {
+	skipped := 0
+	for {
+		rows, err := s.repo.ListObjectsForExport(ctx, params)
+		if err != nil {
+			datasetLog.Error().Err(err).Int32("afterID", params.AfterID).Msg("Failed to list objects for COCO export")
+			return skipped, err
+		}
+
+		for i := range rows {
+			row := &rows[i]
+			if lastID > 0 && row.Object.ID > lastID {
+				return skipped, nil
+			}
+			class := strings.TrimSpace(row.EffectiveClass)
+			source := strings.TrimSpace(row.AnalysisFileSource.String)
+			polygon, err := parseGeometry(row.Object.Geometry.String)
+			if class == "" || source == "" || !row.AnalysisID.Valid || err != nil {
+				skipped++
+				continue
+			}
+			fn(cocoObject{row: row, class: class, polygon: polygon})
+		}
+
+		if len(rows) < datasetBatchSize {
+			return skipped, nil
+		}
+		params.AfterID = rows[len(rows)-1].Object.ID
+	}
+}
+
+// collectCoco collects the images, one per analysis, and the categories of the
+// matching objects, numbered from 1 in the order of their class names. It also
+// returns the last object ID seen, so the annotation files leave out objects
+// added since.
+func (s *DatasetService) collectCoco(ctx context.Context, params repository.ListObjectsForExportParams) ([]*cocoImage, []models.CocoCategory, int32, error) {
+	byAnalysis := make(map[int32]*cocoImage)
+	var images []*cocoImage
+	classes := make(map[string]bool)
+	var lastID int32
+
+	skipped, err := s.eachCocoObject(ctx, params, 0, func(object cocoObject) {
+		row := object.row
+		lastID = row.Object.ID
+		img, ok := byAnalysis[row.AnalysisID.Int32]
+		if !ok {
+			source := strings.TrimSpace(row.AnalysisFileSource.String)
+			img = &cocoImage{
+				CocoImage: models.CocoImage{
+					ID:         row.AnalysisID.Int32,
+					FileName:   cocoFileName(row.AnalysisID.Int32, source),
+					AnalysisID: row.AnalysisIDAnalysis.String,
+					Product:    row.AnalysisProduct.String,
+				},
+				source:  source,
+				classes: make(map[string]int),
+			}
+			if row.AnalysisDateTime.Valid {
+				img.DateCaptured = row.AnalysisDateTime.Time.Format(time.DateTime)
+			}
+			byAnalysis[img.ID] = img
+			images = append(images, img)
+		}
+
+		classes[object.class] = true
+		img.classes[object.class]++
+		bbox := cocoAnnotation(row, object.polygon).BBox
+		img.extent[0] = math.Max(img.extent[0], bbox[0]+bbox[2])
+		img.extent[1] = math.Max(img.extent[1], bbox[1]+bbox[3])
+	})
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	names := make([]string, 0, len(classes))
+	for name := range classes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	categories := make([]models.CocoCategory, len(names))
+	for i, name := range names {
+		categories[i] = models.CocoCategory{ID: i + 1, Name: name, Supercategory: "object"}
+	}
+
+	if skipped > 0 {
+		datasetLog.Info().Int("skipped", skipped).Msg("Skipped objects without class, geometry or source image")
+	}
+	sort.Slice(images, func(i, j int) bool { return images[i].ID < images[j].ID })
+	return images, categories, lastID, nil
+}
+
+// setExtent sizes an image whose file isn't read to fit its annotations.
+func (img *cocoImage) setExtent() {
+	img.Width, img.Height = int(math.Ceil(img.extent[0])), int(math.Ceil(img.extent[1]))
+}
+
+// writeCocoDataset writes the annotation file of images, streaming the annotations
+// of their objects up to lastID. Objects relabeled since the categories were
+// collected to a class without one are left out.
+func (s *DatasetService) writeCocoDataset(ctx context.Context, w io.Writer, params repository.ListObjectsForExportParams, lastID int32, images []*cocoImage, categories []models.CocoCategory) error {
+	selected := make(map[int32]bool, len(images))
+	dataset := models.CocoDataset{
+		Info:       cocoInfo(),
+		Images:     make([]models.CocoImage, 0, len(images)),
+		Categories: categories,
+	}
+	for _, img := range images {
+		selected[img.ID] = true
+		dataset.Images = append(dataset.Images, img.CocoImage)
+	}
+	categoryIDs := make(map[string]int, len(categories))
+	for _, category := range categories {
+		categoryIDs[category.Name] = category.ID
+	}
+
+	// The dataset is written around the annotations array, in field order
+	head, err := json.Marshal(struct {
+		Info   models.CocoInfo    `json:"info"`
+		Images []models.CocoImage `json:"images"`
+	}{dataset.Info, dataset.Images})
+	if err != nil {
+		return err
+	}
+	tail, err := json.Marshal(dataset.Categories)
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	bw.Write(head[:len(head)-1])
+	bw.WriteString(`,"annotations":[`)
+	count := 0
+	var writeErr error
+	_, err = s.eachCocoObject(ctx, params, lastID, func(object cocoObject) {
+		categoryID, ok := categoryIDs[object.class]
+		if writeErr != nil || !ok || !selected[object.row.AnalysisID.Int32] {
+			return
+		}
+		annotation := cocoAnnotation(object.row, object.polygon)
+		annotation.CategoryID = categoryID
+		data, err := json.Marshal(annotation)
+		if err != nil {
+			writeErr = err
+			return
+		}
+		if count > 0 {
+			bw.WriteByte(',')
+		}
+		_, writeErr = bw.Write(data)
+		count++
+	})
+	if err != nil {
+		return err
+	}
+	if writeErr != nil {
+		return writeErr
+	}
+	bw.WriteString(`],"categories":`)
+	bw.Write(tail)
+	bw.WriteString("}\n")
+	return bw.Flush()
+}
+
+// writeCocoImages adds the source images to the archive and reads their sizes.
+// Images that can't be read are dropped with their annotations.
+func (s *DatasetService) writeCocoImages(ctx context.Context, zw *zip.Writer, images []*cocoImage) ([]*cocoImage, error) {
+	kept := images[:0]
+	for _, img := range images {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		data, err := s.files.read(ctx, img.source, cocoImageTimeout)
+		if err != nil {
+			datasetLog.Warn().Err(err).Str("source", img.source).Msg("Failed to read source image, skipping it")
+			continue
+		}
+		config, _, err := image.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			datasetLog.Warn().Err(err).Str("source", img.source).Msg("Failed to decode source image, skipping it")
+			continue
+		}
+		img.Width, img.Height = config.Width, config.Height
+
+		// Images are already compressed, so they are stored as is
+		fw, err := zw.CreateHeader(&zip.FileHeader{Name: path.Join("images", img.split, img.FileName), Method: zip.Store})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := fw.Write(data); err != nil {
+			return nil, err
+		}
+		kept = append(kept, img)
+	}
+	return kept, nil
+}
+
+func cocoInfo() models.CocoInfo {
+	return models.CocoInfo{
+		Description: "csort analysis objects",
+		Version:     "1.0",
+		DateCreated: time.Now().Format(time.RFC3339),
+	}
+}
+
+func cocoAnnotation(row *repository.ListObjectsForExportRow, polygon []float64) models.CocoAnnotation {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	area := 0.0
+	n := len(polygon) / 2
+	for i := 0; i < n; i++ {
+		x, y := polygon[2*i], polygon[2*i+1]
+		nx, ny := polygon[2*((i+1)%n)], polygon[2*((i+1)%n)+1]
+		area += x*ny - nx*y
+		minX, maxX = math.Min(minX, x), math.Max(maxX, x)
+		minY, maxY = math.Min(minY, y), math.Max(maxY, y)
+	}
+
+	return models.CocoAnnotation{
+		ID:           row.Object.ID,
+		ImageID:      row.AnalysisID.Int32,
+		Segmentation: [][]float64{polygon},
+		Area:         math.Abs(area) / 2,
+		BBox:         [4]float64{minX, minY, maxX - minX, maxY - minY},
+		ObjectID:     row.Object.ID,
+		File:         row.Object.File.String,
+	}
+}
+
+// parseGeometry reads the polygon of an object as a flat x1,y1,x2,y2... list.
+func parseGeometry(geometry string) ([]float64, error) {
+	numbers := geometryNumber.FindAllString(geometry, -1)
+	if len(numbers) < 6 || len(numbers)%2 != 0 {
+		return nil, fmt.Errorf("geometry is not a polygon: %d coordinates", len(numbers))
+	}
+	polygon := make([]float64, len(numbers))
+	for i, number := range numbers {
+		v, err := strconv.ParseFloat(number, 64)
+		if err != nil {
+			return nil, err
+		}
+		polygon[i] = v
+	}
+	return polygon, nil
+}
+
+// cocoFileName names an image after its source file, prefixed with the analysis
+// ID as source files of different analyses may share a name.
+func cocoFileName(analysisID int32, source string) string {
+	if isRemoteFileRef(source) {
+		if u, err := url.Parse(source); err == nil {
+			source = u.Path
+		}
+	}
+	base := path.Base(strings.ReplaceAll(source, "\\", "/"))
+	if base == "." || base == "/" {
+		base = "image"
+	}
+	return fmt.Sprintf("%d_%s", analysisID, base)
+}
+
+// parseCocoSplit parses the train,val,test ratios. Missing trailing ratios are
+// zero and the ratios are normalized to sum to one.
+func parseCocoSplit(split string) ([]float64, error) {
+	split = strings.TrimSpace(split)
+	if split == "" {
+		return nil, nil
+	}
+	parts := strings.Split(split, ",")
+	if len(parts) > len(cocoSplits) {
+		return nil, fmt.Errorf("%w: split takes at most %d ratios", ErrInvalidExport, len(cocoSplits))
+	}
+	ratios := make([]float64, len(cocoSplits))
+	sum := 0.0
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil || v < 0 || math.IsInf(v, 0) {
+			return nil, fmt.Errorf("%w: invalid split ratio %q", ErrInvalidExport, part)
+		}
+		ratios[i] = v
+		sum += v
+	}
+	if sum == 0 {
+		return nil, fmt.Errorf("%w: split ratios sum to zero", ErrInvalidExport)
+	}
+	for i := range ratios {
+		ratios[i] /= sum
+	}
+	return ratios, nil
+}
+
+// splitCocoImages assigns the images to splits, stratified by class: every image
+// is grouped under the rarest class it contains and each group is divided by the
+// ratios, in an order shuffled by the seed. It returns the non-empty splits.
+func splitCocoImages(images []*cocoImage, ratios []float64, seed int64) []string {
+	counts := make(map[string]int)
+	for _, img := range images {
+		for class := range img.classes {
+			counts[class]++
+		}
+	}
+
+	groups := make(map[string][]*cocoImage)
+	for _, img := range images {
+		rarest := ""
+		for class := range img.classes {
+			if rarest == "" || counts[class] < counts[rarest] || counts[class] == counts[rarest] && class < rarest {
+				rarest = class
+			}
+		}
+		groups[rarest] = append(groups[rarest], img)
+	}
+
+	for _, group := range groups {
+		keys := make(map[int32]uint64, len(group))
+		for _, img := range group {
+			keys[img.ID] = shuffleKey(seed, img.ID)
+		}
+		sort.Slice(group, func(i, j int) bool { return keys[group[i].ID] < keys[group[j].ID] })
+
+		start, cumulative := 0, 0.0
+		for i, ratio := range ratios {
+			cumulative += ratio
+			end := int(math.Round(cumulative * float64(len(group))))
+			if i == len(ratios)-1 {
+				end = len(group)
+			}
+			for _, img := range group[start:end] {
+				img.split = cocoSplits[i]
+			}
+			start = end
+		}
+	}
+
+	var splits []string
+	for i, ratio := range ratios {
+		if ratio > 0 {
+			splits = append(splits, cocoSplits[i])
+		}
+	}
+	return splits
+}
+
+func shuffleKey(seed int64, id int32) uint64 {
+	var buf [12]byte
+	binary.LittleEndian.PutUint64(buf[:8], uint64(seed))
+	binary.LittleEndian.PutUint32(buf[8:], uint32(id))
+	h := fnv.New64a()
+	h.Write(buf[:])
+	return h.Sum64()
+}
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+
+	"csort.ru/analysis-service/internal/models"
+	"csort.ru/analysis-service/internal/repository"
+)
+
+// testCocoImages returns images with IDs from first, each holding classes.
+func testCocoImages(first int32, n int, classes ...string) []*cocoImage {
+	images := make([]*cocoImage, n)
+	for i := range images {
+		images[i] = &cocoImage{CocoImage: models.CocoImage{ID: first + int32(i)}, classes: map[string]int{}}
+		for _, class := range classes {
+			images[i].classes[class]++
+		}
+	}
+	return images
+}
+
+// splitCounts counts the images of each split holding class.
+func splitCounts(images []*cocoImage, class string) map[string]int {
+	counts := map[string]int{}
+	for _, img := range images {
+		if img.classes[class] > 0 {
+			counts[img.split]++
+		}
+	}
+	return counts
+}
+
+func TestSplitCocoImages(t *testing.T) {
+	// Ergot is in 4 images, wheat in all 14: the ergot images are grouped under
+	// ergot and divided apart from the 10 wheat only ones
+	images := append(testCocoImages(1, 10, "wheat"), testCocoImages(11, 4, "wheat", "ergot", "ergot")...)
+	splits := splitCocoImages(images, []float64{0.5, 0.5, 0}, 7)
+	if fmt.Sprint(splits) != "[train val]" {
+		t.Errorf("got splits %v, want [train val]", splits)
+	}
+	if got := splitCounts(images, "ergot"); got["train"] != 2 || got["val"] != 2 {
+		t.Errorf("got ergot images %v, want 2 in train and 2 in val", got)
+	}
+	if got := splitCounts(images, "wheat"); got["train"] != 7 || got["val"] != 7 {
+		t.Errorf("got wheat images %v, want 7 in train and 7 in val", got)
+	}
+
+	// The same seed gives the same split
+	again := append(testCocoImages(1, 10, "wheat"), testCocoImages(11, 4, "wheat", "ergot", "ergot")...)
+	splitCocoImages(again, []float64{0.5, 0.5, 0}, 7)
+	for i := range images {
+		if images[i].split != again[i].split {
+			t.Fatalf("image %d: got %s, then %s with the same seed", images[i].ID, images[i].split, again[i].split)
+		}
+	}
+}
+
+func TestSplitCocoImagesTies(t *testing.T) {
+	// Oats and barley are both in 2 images, so the tie goes to the first name and
+	// both images form one group, divided between the splits. Grouping each image
+	// under a different class would make two groups of one, both put in train.
+	for i := 0; i < 20; i++ {
+		images := testCocoImages(1, 2, "oats", "barley")
+		splitCocoImages(images, []float64{0.5, 0.5}, int64(i))
+		if got := splitCounts(images, "oats"); got["train"] != 1 || got["val"] != 1 {
+			t.Fatalf("seed %d: got %v, want 1 image in train and 1 in val", i, got)
+		}
+	}
+}
+
+func TestParseCocoSplit(t *testing.T) {
+	tests := []struct {
+		split string
+		want  string // empty for an error
+	}{
+		{split: "", want: "[]"},
+		{split: "8,1,1", want: "[0.8 0.1 0.1]"},
+		{split: " 3 , 1 ", want: "[0.75 0.25 0]"},
+		{split: "1", want: "[1 0 0]"},
+		{split: "1,1,1,1"},
+		{split: "1,-1"},
+		{split: "0,0"},
+		{split: "inf"},
+		{split: "a,b"},
+	}
+	for _, tt := range tests {
+		got, err := parseCocoSplit(tt.split)
+		if tt.want == "" {
+			if !errors.Is(err, ErrInvalidExport) {
+				t.Errorf("parseCocoSplit(%q) = %v, %v, want ErrInvalidExport", tt.split, got, err)
+			}
+			continue
+		}
+		if err != nil || fmt.Sprint(got) != tt.want {
+			t.Errorf("parseCocoSplit(%q) = %v, %v, want %s", tt.split, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseGeometry(t *testing.T) {
+	tests := []struct {
+		geometry string
+		want     string // empty for an error
+	}{
+		{geometry: "[[0,0],[4,0],[4,3]]", want: "[0 0 4 0 4 3]"},
+		{geometry: "POLYGON((1.5 2, 3e1 2, 3 -4.25, 1.5 2))", want: "[1.5 2 30 2 3 -4.25 1.5 2]"},
+		{geometry: "0,0,1,0,1,1,.5,.5", want: "[0 0 1 0 1 1 0.5 0.5]"},
+		{geometry: ""},
+		{geometry: "[[0,0],[4,0]]"},
+		{geometry: "0,0,1,0,1"},
+	}
+	for _, tt := range tests {
+		got, err := parseGeometry(tt.geometry)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("parseGeometry(%q) = %v, want an error", tt.geometry, got)
+			}
+			continue
+		}
+		if err != nil || fmt.Sprint(got) != tt.want {
+			t.Errorf("parseGeometry(%q) = %v, %v, want %s", tt.geometry, got, err, tt.want)
+		}
+	}
+}
+
+func TestCocoAnnotation(t *testing.T) {
+	row := &repository.ListObjectsForExportRow{}
+	row.Object.ID = 5
+	row.AnalysisID.Int32 = 2
+
+	tests := []struct {
+		name    string
+		polygon []float64
+		area    float64
+		bbox    [4]float64
+	}{
+		{name: "rectangle", polygon: []float64{0, 0, 4, 0, 4, 3, 0, 3}, area: 12, bbox: [4]float64{0, 0, 4, 3}},
+		{name: "clockwise triangle", polygon: []float64{1, 2, 1, 5, 5, 2}, area: 6, bbox: [4]float64{1, 2, 4, 3}},
+		// An L shape: a 4x4 square without its 2x2 top right corner
+		{name: "concave", polygon: []float64{0, 0, 4, 0, 4, 2, 2, 2, 2, 4, 0, 4}, area: 12, bbox: [4]float64{0, 0, 4, 4}},
+	}
+	for _, tt := range tests {
+		got := cocoAnnotation(row, tt.polygon)
+		if math.Abs(got.Area-tt.area) > 1e-9 || got.BBox != tt.bbox {
+			t.Errorf("%s: got area %v and bbox %v, want %v and %v", tt.name, got.Area, got.BBox, tt.area, tt.bbox)
+		}
+		if got.ID != 5 || got.ImageID != 2 || len(got.Segmentation) != 1 {
+			t.Errorf("%s: got annotation %+v", tt.name, got)
+		}
+	}
+}
+
+func TestCocoFileName(t *testing.T) {
+	tests := []struct {
+		analysisID int32
+		source     string
+		want       string
+	}{
+		{7, "https://api:8080/files/a/img.jpg?size=full", "7_img.jpg"},
+		{3, `C:\data\img.png`, "3_img.png"},
+		{4, "/data/images/img.png", "4_img.png"},
+		{2, "", "2_image"},
+	}
+	for _, tt := range tests {
+		if got := cocoFileName(tt.analysisID, tt.source); got != tt.want {
+			t.Errorf("cocoFileName(%d, %q) = %q, want %q", tt.analysisID, tt.source, got, tt.want)
+		}
+	}
+}
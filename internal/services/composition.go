@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"csort.ru/analysis-service/internal/logger"
+	"csort.ru/analysis-service/internal/models"
+	"csort.ru/analysis-service/internal/repository"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var compositionLog = logger.GetLogger("services.composition")
+
+type CompositionService struct {
+	repo     *repository.Queries
+	products *ProductsService
+}
+
+func NewCompositionService(repo *repository.Queries, products *ProductsService) *CompositionService {
+	return &CompositionService{
+		repo:     repo,
+		products: products,
+	}
+}
+
+// GetAnalysisComposition breaks the objects of one analysis down by class. The
+// analysis mass is apportioned to the classes by object area.
+func (s *CompositionService) GetAnalysisComposition(ctx context.Context, ref models.AnalysisRef) (models.Composition, error) {
+	repoAnalysis, err := resolveAnalysis(ctx, s.repo, ref)
+	if err != nil {
+		return models.Composition{}, err
+	}
+
+	rows, err := s.repo.GetClassCompositionByAnalysisID(ctx, pgtype.Int8{Int64: int64(repoAnalysis.ID), Valid: true})
+	if err != nil {
+		compositionLog.Error().Err(err).Str("analysisID", repoAnalysis.IDAnalysis.String).Msg("Failed to get class composition")
+		return models.Composition{}, err
+	}
+
+	totalArea := 0.0
+	for _, row := range rows {
+		totalArea += row.Area
+	}
+
+	classes := make([]models.ClassComposition, 0, len(rows))
+	for _, row := range rows {
+		class := models.ClassComposition{
+			Class: row.Class,
+			Count: row.Objects,
+			Area:  row.Area,
+		}
+		if totalArea > 0 {
+			class.Mass = repoAnalysis.Mass.Float64 * row.Area / totalArea
+		}
+		classes = append(classes, class)
+	}
+
+	return buildComposition(classes), nil
+}
+
+// GetComposition pools the class composition of every analysis of the user matching the filter.
+func (s *CompositionService) GetComposition(ctx context.Context, userID int64, filter models.AnalysesFilter) (models.Composition, error) {
+	product, err := s.products.CanonicalFilter(ctx, filter.Product)
+	if err != nil {
+		return models.Composition{}, err
+	}
+
+	rows, err := s.repo.GetClassCompositionByUserID(ctx, repository.GetClassCompositionByUserIDParams{
+		IDUser:     pgtype.Text{String: fmt.Sprintf("%d", userID), Valid: true},
+		Product:    product,
+		IDAnalysis: filter.ID,
+		Verdict:    filter.Verdict,
+		Tag:        filter.Tag,
+	})
+	if err != nil {
+		compositionLog.Error().Err(err).Int64("userID", userID).Msg("Failed to get class composition")
+		return models.Composition{}, err
+	}
+
+	classes := make([]models.ClassComposition, 0, len(rows))
+	for _, row := range rows {
+		classes = append(classes, models.ClassComposition{
+			Class: row.Class,
+			Count: row.Objects,
+			Area:  row.Area,
+			Mass:  row.Mass,
+		})
+	}
+
+	return buildComposition(classes), nil
+}
+
+// buildComposition fills in the totals and the per-class shares.
+func buildComposition(classes []models.ClassComposition) models.Composition {
+	composition := models.Composition{Classes: classes}
+	for _, class := range classes {
+		composition.TotalCount += class.Count
+		composition.TotalArea += class.Area
+		composition.TotalMass += class.Mass
+	}
+
+	for i := range composition.Classes {
+		class := &composition.Classes[i]
+		if composition.TotalCount > 0 {
+			class.CountShare = float64(class.Count) / float64(composition.TotalCount)
+		}
+		if composition.TotalArea > 0 {
+			class.AreaShare = class.Area / composition.TotalArea
+		}
+		if composition.TotalMass > 0 {
+			class.MassShare = class.Mass / composition.TotalMass
+		}
+	}
+
+	return composition
+}
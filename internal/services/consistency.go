@@ -0,0 +1,281 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math"
+	"sort"
+	"strconv"
+
+	"csort.ru/analysis-service/internal/logger"
+	"csort.ru/analysis-service/internal/models"
+	"csort.ru/analysis-service/internal/repository"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// DefaultStatsTolerance is the relative difference under which stored and
+// recomputed Stats values are considered equal. Stats are float32, so exact
+// comparison is pointless.
+const DefaultStatsTolerance = 1e-3
+
+// StatsRepairMode controls which channels a consistency check is allowed to overwrite.
+type StatsRepairMode int
+
+const (
+	StatsRepairNone   StatsRepairMode = iota // report only
+	StatsRepairBroken                        // rewrite missing and corrupt channels
+	StatsRepairAll                           // also rewrite channels that disagree with the objects
+)
+
+var consistencyLog = logger.GetLogger("services.consistency")
+
+// statsSources maps the analysis Stats channels to the per-object column they summarize.
+// lab_l, lab_a, lab_b and t have no per-object counterpart and can't be recomputed.
+var statsSources = []struct {
+	channel string
+	feature string
+}{
+	{"r", "r_avg"},
+	{"g", "g_avg"},
+	{"b", "b_avg"},
+	{"h", "h_avg"},
+	{"s", "s_avg"},
+	{"v", "v_avg"},
+	{"w", "w"},
+	{"l", "l"},
+}
+
+type ConsistencyService struct {
+	repo      *repository.Queries
+	specs     *SpecsService
+	tolerance float64
+}
+
+func NewConsistencyService(repo *repository.Queries, specs *SpecsService, tolerance float64) *ConsistencyService {
+	return &ConsistencyService{
+		repo:      repo,
+		specs:     specs,
+		tolerance: tolerance,
+	}
+}
+
+// CheckAnalysis recomputes the Stats of an analysis from its objects and compares
+// them with the stored JSONB, repairing channels as allowed by mode.
+func (s *ConsistencyService) CheckAnalysis(ctx context.Context, ref models.AnalysisRef, mode StatsRepairMode) (models.StatsConsistencyReport, error) {
+	repoAnalysis, err := resolveAnalysis(ctx, s.repo, ref)
+	if err != nil {
+		return models.StatsConsistencyReport{}, err
+	}
+	return s.checkAnalysis(ctx, repoAnalysis, mode)
+}
+
+// RepairAnalysis is CheckAnalysis for the owner of the analysis, as only they may
+// have its Stats rewritten.
+func (s *ConsistencyService) RepairAnalysis(ctx context.Context, userID int64, ref models.AnalysisRef, mode StatsRepairMode) (models.StatsConsistencyReport, error) {
+	repoAnalysis, err := resolveAnalysis(ctx, s.repo, ref)
+	if err != nil {
+		return models.StatsConsistencyReport{}, err
+	}
+	if repoAnalysis.IDUser.String != strconv.FormatInt(userID, 10) {
+		return models.StatsConsistencyReport{}, ErrNotOwner
+	}
+	return s.checkAnalysis(ctx, repoAnalysis, mode)
+}
+
+// Backfill checks every analysis in batches of batchSize. onReport, if set, is
+// called for each analysis that is not consistent.
+func (s *ConsistencyService) Backfill(ctx context.Context, mode StatsRepairMode, batchSize int32, onReport func(models.StatsConsistencyReport)) (models.StatsBackfillSummary, error) {
+	var summary models.StatsBackfillSummary
+	var afterID int32
+
+	for {
+		batch, err := s.repo.ListAnalysesAfterID(ctx, repository.ListAnalysesAfterIDParams{
+			AfterID: afterID,
+			Limit:   batchSize,
+		})
+		if err != nil {
+			return summary, err
+		}
+		if len(batch) == 0 {
+			return summary, nil
+		}
+
+		for _, repoAnalysis := range batch {
+			report, err := s.checkAnalysis(ctx, repoAnalysis, mode)
+			if err != nil {
+				return summary, err
+			}
+
+			summary.Checked++
+			if !report.Consistent {
+				summary.Inconsistent++
+				if onReport != nil {
+					onReport(report)
+				}
+			}
+			if report.Repaired {
+				summary.Repaired++
+			}
+		}
+
+		afterID = batch[len(batch)-1].ID
+	}
+}
+
+func (s *ConsistencyService) checkAnalysis(ctx context.Context, repoAnalysis repository.Analysis, mode StatsRepairMode) (models.StatsConsistencyReport, error) {
+	repoObjects, err := s.repo.GetObjectsByAnalysisID(ctx, pgtype.Int8{Int64: int64(repoAnalysis.ID), Valid: true})
+	if err != nil {
+		consistencyLog.Error().Err(err).Int32("analysisID", repoAnalysis.ID).Msg("Failed to get objects")
+		return models.StatsConsistencyReport{}, err
+	}
+
+	report := models.StatsConsistencyReport{
+		ID:         repoAnalysis.ID,
+		IDAnalysis: repoAnalysis.IDAnalysis.String,
+		Objects:    len(repoObjects),
+		Consistent: true,
+		Channels:   make([]models.StatsChannelCheck, 0, len(statsSources)),
+	}
+	columns := analysisStatsColumns(&repoAnalysis)
+
+	for _, source := range statsSources {
+		value := objectFeatures[source.feature]
+		values := make([]float64, 0, len(repoObjects))
+		for i := range repoObjects {
+			if v := value(&repoObjects[i]); v.Valid {
+				values = append(values, v.Float64)
+			}
+		}
+
+		check := models.StatsChannelCheck{Channel: source.channel}
+		stored, status := decodeStoredStats(*columns[source.channel])
+		if status == models.StatsOK {
+			check.Stored = &stored
+		}
+
+		if len(values) > 0 {
+			computed := computeStats(values)
+			check.Computed = &computed
+			if status == models.StatsOK && !s.statsEqual(stored, computed) {
+				status = models.StatsMismatch
+			}
+		} else if status == models.StatsOK {
+			status = models.StatsNoData
+		}
+		check.Status = status
+
+		if status == models.StatsMissing || status == models.StatsCorrupt || status == models.StatsMismatch {
+			report.Consistent = false
+		}
+
+		repairable := ((status == models.StatsMissing || status == models.StatsCorrupt) && mode >= StatsRepairBroken) ||
+			(status == models.StatsMismatch && mode >= StatsRepairAll)
+		if repairable && check.Computed != nil {
+			encoded, err := json.Marshal(check.Computed)
+			if err != nil {
+				return models.StatsConsistencyReport{}, err
+			}
+			*columns[source.channel] = encoded
+			check.Repaired = true
+			report.Repaired = true
+		}
+
+		report.Channels = append(report.Channels, check)
+	}
+
+	if report.Repaired {
+		if err := s.repo.UpdateAnalysisStats(ctx, repository.UpdateAnalysisStatsParams{
+			R:    repoAnalysis.R,
+			G:    repoAnalysis.G,
+			B:    repoAnalysis.B,
+			H:    repoAnalysis.H,
+			S:    repoAnalysis.S,
+			V:    repoAnalysis.V,
+			LabL: repoAnalysis.LabL,
+			LabA: repoAnalysis.LabA,
+			LabB: repoAnalysis.LabB,
+			W:    repoAnalysis.W,
+			L:    repoAnalysis.L,
+			T:    repoAnalysis.T,
+			ID:   repoAnalysis.ID,
+		}); err != nil {
+			consistencyLog.Error().Err(err).Int32("analysisID", repoAnalysis.ID).Msg("Failed to store repaired stats")
+			return models.StatsConsistencyReport{}, err
+		}
+		consistencyLog.Info().Int32("analysisID", repoAnalysis.ID).Msg("Repaired analysis stats")
+
+		// Stat rules are graded on the stored Stats. Like a relabel, a failed
+		// regrade shouldn't fail the repair.
+		if err := s.specs.Regrade(ctx, repoAnalysis.ID); err != nil {
+			consistencyLog.Warn().Err(err).Int32("analysisID", repoAnalysis.ID).Msg("Failed to regrade analysis after stats repair")
+		}
+	}
+
+	return report, nil
+}
+
+func (s *ConsistencyService) statsEqual(stored, computed models.Stats) bool {
+	equal := func(a, b float32) bool {
+		return math.Abs(float64(a)-float64(b)) <= s.tolerance*math.Max(1, math.Abs(float64(b)))
+	}
+	return equal(stored.Min, computed.Min) &&
+		equal(stored.Max, computed.Max) &&
+		equal(stored.Avg, computed.Avg) &&
+		equal(stored.Median, computed.Median)
+}
+
+// decodeStoredStats decodes a Stats JSONB column and classifies it.
+func decodeStoredStats(data []byte) (models.Stats, string) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null")) || bytes.Equal(trimmed, []byte("{}")) {
+		return models.Stats{}, models.StatsMissing
+	}
+
+	var stats models.Stats
+	if err := json.Unmarshal(trimmed, &stats); err != nil {
+		return models.Stats{}, models.StatsCorrupt
+	}
+	return stats, models.StatsOK
+}
+
+// computeStats returns min, max, mean and median of a non-empty sample.
+func computeStats(values []float64) models.Stats {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	sum := 0.0
+	for _, v := range sorted {
+		sum += v
+	}
+
+	n := len(sorted)
+	median := sorted[n/2]
+	if n%2 == 0 {
+		median = (sorted[n/2-1] + sorted[n/2]) / 2
+	}
+
+	return models.Stats{
+		Min:    float32(sorted[0]),
+		Max:    float32(sorted[n-1]),
+		Avg:    float32(sum / float64(n)),
+		Median: float32(median),
+	}
+}
+
+func analysisStatsColumns(a *repository.Analysis) map[string]*[]byte {
+	return map[string]*[]byte{
+		"r":     &a.R,
+		"g":     &a.G,
+		"b":     &a.B,
+		"h":     &a.H,
+		"s":     &a.S,
+		"v":     &a.V,
+		"lab_l": &a.LabL,
+		"lab_a": &a.LabA,
+		"lab_b": &a.LabB,
+		"w":     &a.W,
+		"l":     &a.L,
+		"t":     &a.T,
+	}
+}
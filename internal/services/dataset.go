@@ -0,0 +1,258 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"csort.ru/analysis-service/internal/logger"
+	"csort.ru/analysis-service/internal/models"
+	"csort.ru/analysis-service/internal/repository"
+	"csort.ru/analysis-service/pkg/arrow"
+	"csort.ru/analysis-service/pkg/columnar"
+	"csort.ru/analysis-service/pkg/parquet"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var datasetLog = logger.GetLogger("services.dataset")
+
+// datasetBatchSize is the number of objects per query, and per Parquet row group
+// or Arrow record batch.
+const datasetBatchSize = 10000
+
+// datasetColumn is one column of the dataset and how to fill it from a row.
+type datasetColumn struct {
+	field  columnar.Field
+	append func(c *columnar.Column, row *repository.ListObjectsForExportRow)
+}
+
+// datasetColumns lists the exported columns: the object identity and labels, every
+// numeric feature, then the metadata of the analysis the object belongs to. class
+// is the effective label and original_class the one assigned by the pipeline.
+var datasetColumns = buildDatasetColumns()
+
+func buildDatasetColumns() []datasetColumn {
+	columns := []datasetColumn{
+		{columnar.Field{Name: "id", Type: columnar.Int32}, func(c *columnar.Column, row *repository.ListObjectsForExportRow) {
+			c.Int32s = append(c.Int32s, row.Object.ID)
+		}},
+		{columnar.Field{Name: "id_analysis", Type: columnar.Int64, Nullable: true}, func(c *columnar.Column, row *repository.ListObjectsForExportRow) {
+			appendInt8(c, row.Object.IDAnalysis)
+		}},
+		{columnar.Field{Name: "file", Type: columnar.String, Nullable: true}, func(c *columnar.Column, row *repository.ListObjectsForExportRow) {
+			appendText(c, row.Object.File)
+		}},
+		{columnar.Field{Name: "class", Type: columnar.String, Nullable: true}, func(c *columnar.Column, row *repository.ListObjectsForExportRow) {
+			appendText(c, pgtype.Text{String: row.EffectiveClass, Valid: row.EffectiveClass != ""})
+		}},
+		{columnar.Field{Name: "original_class", Type: columnar.String, Nullable: true}, func(c *columnar.Column, row *repository.ListObjectsForExportRow) {
+			appendText(c, row.Object.Class)
+		}},
+		{columnar.Field{Name: "geometry", Type: columnar.String, Nullable: true}, func(c *columnar.Column, row *repository.ListObjectsForExportRow) {
+			appendText(c, row.Object.Geometry)
+		}},
+		{columnar.Field{Name: "color_rhs", Type: columnar.String, Nullable: true}, func(c *columnar.Column, row *repository.ListObjectsForExportRow) {
+			appendText(c, row.Object.ColorRhs)
+		}},
+		{columnar.Field{Name: "id_image", Type: columnar.Int64, Nullable: true}, func(c *columnar.Column, row *repository.ListObjectsForExportRow) {
+			appendInt8(c, row.Object.IDImage)
+		}},
+	}
+
+	for _, name := range objectFeatureNames {
+		feature := objectFeatures[name]
+		columns = append(columns, datasetColumn{
+			columnar.Field{Name: name, Type: columnar.Float64, Nullable: true},
+			func(c *columnar.Column, row *repository.ListObjectsForExportRow) {
+				appendFloat8(c, feature(&row.Object))
+			},
+		})
+	}
+
+	return append(columns,
+		datasetColumn{columnar.Field{Name: "analysis_id", Type: columnar.Int32, Nullable: true}, func(c *columnar.Column, row *repository.ListObjectsForExportRow) {
+			c.Int32s = append(c.Int32s, row.AnalysisID.Int32)
+			c.Valid = append(c.Valid, row.AnalysisID.Valid)
+		}},
+		datasetColumn{columnar.Field{Name: "analysis_id_analysis", Type: columnar.String, Nullable: true}, func(c *columnar.Column, row *repository.ListObjectsForExportRow) {
+			appendText(c, row.AnalysisIDAnalysis)
+		}},
+		datasetColumn{columnar.Field{Name: "analysis_date_time", Type: columnar.Timestamp, Nullable: true}, func(c *columnar.Column, row *repository.ListObjectsForExportRow) {
+			c.Int64s = append(c.Int64s, row.AnalysisDateTime.Time.UnixMicro())
+			c.Valid = append(c.Valid, row.AnalysisDateTime.Valid)
+		}},
+		datasetColumn{columnar.Field{Name: "analysis_product", Type: columnar.String, Nullable: true}, func(c *columnar.Column, row *repository.ListObjectsForExportRow) {
+			appendText(c, row.AnalysisProduct)
+		}},
+		datasetColumn{columnar.Field{Name: "analysis_color_rhs", Type: columnar.String, Nullable: true}, func(c *columnar.Column, row *repository.ListObjectsForExportRow) {
+			appendText(c, row.AnalysisColorRhs)
+		}},
+		datasetColumn{columnar.Field{Name: "analysis_id_user", Type: columnar.String, Nullable: true}, func(c *columnar.Column, row *repository.ListObjectsForExportRow) {
+			appendText(c, row.AnalysisIDUser)
+		}},
+		datasetColumn{columnar.Field{Name: "analysis_scale_mm_pixel", Type: columnar.Float64, Nullable: true}, func(c *columnar.Column, row *repository.ListObjectsForExportRow) {
+			appendFloat8(c, row.AnalysisScaleMmPixel)
+		}},
+		datasetColumn{columnar.Field{Name: "analysis_mass", Type: columnar.Float64, Nullable: true}, func(c *columnar.Column, row *repository.ListObjectsForExportRow) {
+			appendFloat8(c, row.AnalysisMass)
+		}},
+		datasetColumn{columnar.Field{Name: "analysis_area", Type: columnar.Float64, Nullable: true}, func(c *columnar.Column, row *repository.ListObjectsForExportRow) {
+			appendFloat8(c, row.AnalysisArea)
+		}},
+	)
+}
+
+func datasetSchema() columnar.Schema {
+	schema := make(columnar.Schema, len(datasetColumns))
+	for i, column := range datasetColumns {
+		schema[i] = column.field
+	}
+	return schema
+}
+
+// batchWriter is implemented by the Parquet and Arrow writers.
+type batchWriter interface {
+	WriteBatch(b *columnar.Batch) error
+	Close() error
+}
+
+type DatasetService struct {
+	repo     *repository.Queries
+	products *ProductsService
+	files    FileRefs
+}
+
+// NewDatasetService creates a dataset service. Source images are read through files.
+func NewDatasetService(repo *repository.Queries, products *ProductsService, files FileRefs) *DatasetService {
+	return &DatasetService{
+		repo:     repo,
+		products: products,
+		files:    files,
+	}
+}
+
+// ExportObjects prepares a columnar export of the user's objects matching the
+// request, joined with the metadata of their analyses. NULLs are exported as nulls.
+func (s *DatasetService) ExportObjects(ctx context.Context, userID int64, req models.DatasetExportRequest) (*Export, error) {
+	product, err := s.products.CanonicalFilter(ctx, req.Product)
+	if err != nil {
+		return nil, err
+	}
+	params := repository.ListObjectsForExportParams{
+		IDUser:  pgtype.Text{String: fmt.Sprintf("%d", userID), Valid: true},
+		Product: product,
+		Class:   strings.TrimSpace(req.Class),
+		Limit:   datasetBatchSize,
+	}
+
+	if params.DateFrom, err = parseDatasetTime(req.From, false); err != nil {
+		return nil, err
+	}
+	if params.DateTo, err = parseDatasetTime(req.To, true); err != nil {
+		return nil, err
+	}
+
+	format := strings.ToLower(strings.TrimSpace(req.Format))
+	if format == "" {
+		format = models.ExportParquet
+	}
+
+	export := &Export{Filename: "objects-" + time.Now().Format("20060102-150405")}
+	var newWriter func(w io.Writer, schema columnar.Schema) (batchWriter, error)
+	switch format {
+	case models.ExportParquet:
+		export.Filename += ".parquet"
+		export.ContentType = "application/vnd.apache.parquet"
+		newWriter = func(w io.Writer, schema columnar.Schema) (batchWriter, error) {
+			return parquet.NewWriter(w, schema)
+		}
+	case models.ExportArrow:
+		export.Filename += ".arrows"
+		export.ContentType = "application/vnd.apache.arrow.stream"
+		newWriter = func(w io.Writer, schema columnar.Schema) (batchWriter, error) {
+			return arrow.NewWriter(w, schema)
+		}
+	default:
+		return nil, fmt.Errorf("%w: unsupported format %q", ErrInvalidExport, req.Format)
+	}
+
+	export.write = func(ctx context.Context, w io.Writer) error {
+		schema := datasetSchema()
+		bw, err := newWriter(w, schema)
+		if err != nil {
+			return err
+		}
+		if err := s.writeObjects(ctx, bw, schema, params); err != nil {
+			return err
+		}
+		return bw.Close()
+	}
+	return export, nil
+}
+
+// writeObjects pages through the matching objects by ID, one batch per query.
+func (s *DatasetService) writeObjects(ctx context.Context, bw batchWriter, schema columnar.Schema, params repository.ListObjectsForExportParams) error {
+	for {
+		rows, err := s.repo.ListObjectsForExport(ctx, params)
+		if err != nil {
+			datasetLog.Error().Err(err).Int32("afterID", params.AfterID).Msg("Failed to list objects for export")
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+
+		batch := columnar.NewBatch(schema, len(rows))
+		for i := range rows {
+			for j, column := range datasetColumns {
+				column.append(&batch.Columns[j], &rows[i])
+			}
+		}
+		batch.Rows = len(rows)
+		if err := bw.WriteBatch(batch); err != nil {
+			return err
+		}
+
+		if len(rows) < datasetBatchSize {
+			return nil
+		}
+		params.AfterID = rows[len(rows)-1].Object.ID
+	}
+}
+
+// parseDatasetTime parses an RFC 3339 timestamp or a date. A date used as the
+// exclusive upper bound is moved to the next day so that the day is included.
+func parseDatasetTime(value string, upper bool) (pgtype.Timestamp, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return pgtype.Timestamp{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return pgtype.Timestamp{Time: t.UTC(), Valid: true}, nil
+	}
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return pgtype.Timestamp{}, fmt.Errorf("%w: invalid date %q", ErrInvalidExport, value)
+	}
+	if upper {
+		t = t.AddDate(0, 0, 1)
+	}
+	return pgtype.Timestamp{Time: t, Valid: true}, nil
+}
+
+func appendFloat8(c *columnar.Column, v pgtype.Float8) {
+	c.Float64s = append(c.Float64s, v.Float64)
+	c.Valid = append(c.Valid, v.Valid)
+}
+
+func appendInt8(c *columnar.Column, v pgtype.Int8) {
+	c.Int64s = append(c.Int64s, v.Int64)
+	c.Valid = append(c.Valid, v.Valid)
+}
+
+func appendText(c *columnar.Column, v pgtype.Text) {
+	c.Strings = append(c.Strings, v.String)
+	c.Valid = append(c.Valid, v.Valid)
+}
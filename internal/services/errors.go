@@ -0,0 +1,30 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// uniqueViolation is the Postgres error code of a unique constraint violation.
+const uniqueViolation = "23505"
+
+// notFound returns target in place of a repository no-rows error, and any other
+// error as is.
+func notFound(err, target error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return target
+	}
+	return err
+}
+
+// conflict returns target in place of a unique constraint violation, and any other
+// error as is.
+func conflict(err, target error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return target
+	}
+	return err
+}
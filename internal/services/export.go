@@ -0,0 +1,529 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"csort.ru/analysis-service/internal/apperr"
+	"csort.ru/analysis-service/internal/logger"
+	"csort.ru/analysis-service/internal/models"
+	"csort.ru/analysis-service/internal/repository"
+	"csort.ru/analysis-service/pkg/xlsx"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var exportLog = logger.GetLogger("services.export")
+
+var ErrInvalidExport = apperr.Validation("invalid_export", "invalid export request")
+
+// exportPageSize is the number of analyses fetched per query when listing a bulk export.
+const exportPageSize = 500
+
+// Unit conversion factors from the stored units (millimetres and grams).
+var (
+	lengthUnits = map[string]float64{"mm": 1, "cm": 0.1, "um": 1000}
+	massUnits   = map[string]float64{"g": 1, "kg": 0.001, "mg": 1000}
+)
+
+// Quantity kinds of exported values, used to apply unit conversion.
+const (
+	quantityNone = iota
+	quantityLength
+	quantityArea
+	quantityMass
+)
+
+// objectLengthColumns and objectAreaColumns are the object columns carrying physical sizes.
+var (
+	objectLengthColumns = map[string]bool{"l": true, "w": true, "pr": true}
+	objectAreaColumns   = map[string]bool{"sq": true}
+)
+
+type exportColumn struct {
+	name     string
+	quantity int
+	value    func(o *repository.Object) interface{}
+}
+
+// objectExportColumns lists every exportable object column in table order.
+var objectExportColumns = buildObjectExportColumns()
+
+func buildObjectExportColumns() []exportColumn {
+	text := func(get func(o *repository.Object) pgtype.Text) func(o *repository.Object) interface{} {
+		return func(o *repository.Object) interface{} {
+			if v := get(o); v.Valid {
+				return v.String
+			}
+			return nil
+		}
+	}
+
+	columns := []exportColumn{
+		{name: "id", value: func(o *repository.Object) interface{} { return o.ID }},
+		{name: "file", value: text(func(o *repository.Object) pgtype.Text { return o.File })},
+		{name: "class", value: text(func(o *repository.Object) pgtype.Text { return o.Class })},
+		{name: "geometry", value: text(func(o *repository.Object) pgtype.Text { return o.Geometry })},
+	}
+	for _, name := range objectFeatureNames {
+		feature := objectFeatures[name]
+		column := exportColumn{
+			name: name,
+			value: func(o *repository.Object) interface{} {
+				if v := feature(o); v.Valid {
+					return v.Float64
+				}
+				return nil
+			},
+		}
+		switch {
+		case objectLengthColumns[name]:
+			column.quantity = quantityLength
+		case objectAreaColumns[name]:
+			column.quantity = quantityArea
+		}
+		columns = append(columns, column)
+	}
+	return append(columns,
+		exportColumn{name: "id_image", value: func(o *repository.Object) interface{} {
+			if o.IDImage.Valid {
+				return o.IDImage.Int64
+			}
+			return nil
+		}},
+		exportColumn{name: "color_rhs", value: text(func(o *repository.Object) pgtype.Text { return o.ColorRhs })},
+	)
+}
+
+// Export is a prepared export. Everything that can fail validation or lookup has
+// already been checked, so Write only streams the data.
+type Export struct {
+	Filename    string
+	ContentType string
+	write       func(ctx context.Context, w io.Writer) error
+}
+
+// Write streams the export into w.
+func (e *Export) Write(ctx context.Context, w io.Writer) error {
+	return e.write(ctx, w)
+}
+
+type exportOptions struct {
+	format     string
+	columns    []exportColumn
+	lengthUnit string
+	massUnit   string
+	factors    map[int]float64
+	decimal    string
+}
+
+type ExportService struct {
+	repo     *repository.Queries
+	products *ProductsService
+}
+
+func NewExportService(repo *repository.Queries, products *ProductsService) *ExportService {
+	return &ExportService{
+		repo:     repo,
+		products: products,
+	}
+}
+
+// ExportAnalysis prepares the export of one analysis and all of its objects.
+func (s *ExportService) ExportAnalysis(ctx context.Context, ref models.AnalysisRef, req models.ExportRequest) (*Export, error) {
+	opts, err := parseExportOptions(req)
+	if err != nil {
+		return nil, err
+	}
+
+	repoAnalysis, err := resolveAnalysis(ctx, s.repo, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.newExport("analysis-"+repoAnalysis.IDAnalysis.String, opts, []repository.Analysis{repoAnalysis}), nil
+}
+
+// ExportAnalyses prepares the export of every analysis of the user matching the filter.
+func (s *ExportService) ExportAnalyses(ctx context.Context, userID int64, req models.ExportAnalysesRequest) (*Export, error) {
+	opts, err := parseExportOptions(req.ExportRequest)
+	if err != nil {
+		return nil, err
+	}
+	product, err := s.products.CanonicalFilter(ctx, req.Product)
+	if err != nil {
+		return nil, err
+	}
+
+	var analyses []repository.Analysis
+	for offset := int32(0); ; offset += exportPageSize {
+		// Ties are ordered by id, so equal timestamps don't shift between pages
+		page, err := s.repo.GetAnalysesByUserTelegramIDPagination(ctx, repository.GetAnalysesByUserTelegramIDPaginationParams{
+			IDUser:     pgtype.Text{String: fmt.Sprintf("%d", userID), Valid: true},
+			Product:    product,
+			IDAnalysis: req.ID,
+			Verdict:    req.Verdict,
+			Tag:        req.Tag,
+			SortBy:     "date_time",
+			SortOrder:  "asc",
+			Offset:     offset,
+			Limit:      exportPageSize,
+		})
+		if err != nil {
+			exportLog.Error().Err(err).Int64("userID", userID).Msg("Failed to list analyses for export")
+			return nil, err
+		}
+		analyses = append(analyses, page...)
+		if len(page) < exportPageSize {
+			break
+		}
+	}
+
+	return s.newExport(fmt.Sprintf("analyses-%s", time.Now().Format("20060102-150405")), opts, analyses), nil
+}
+
+func (s *ExportService) newExport(name string, opts exportOptions, analyses []repository.Analysis) *Export {
+	export := &Export{Filename: name + "." + opts.format}
+	switch opts.format {
+	case models.ExportXLSX:
+		export.ContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+		export.write = func(ctx context.Context, w io.Writer) error {
+			return s.writeXLSX(ctx, w, opts, analyses)
+		}
+	default:
+		export.ContentType = "text/csv; charset=utf-8"
+		export.write = func(ctx context.Context, w io.Writer) error {
+			return s.writeCSV(ctx, w, opts, analyses)
+		}
+	}
+	return export
+}
+
+// writeCSV writes a single table with one row per object, prefixed with the
+// summary columns of its analysis.
+func (s *ExportService) writeCSV(ctx context.Context, w io.Writer, opts exportOptions, analyses []repository.Analysis) error {
+	cw := csv.NewWriter(w)
+	if opts.decimal == "," {
+		cw.Comma = ';'
+	}
+
+	summaryHeader := analysisSummaryHeader(opts)
+	header := append(append([]string{}, summaryHeader...), objectHeader(opts)...)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	record := make([]string, len(header))
+	for i := range analyses {
+		summary := analysisSummaryRow(&analyses[i], opts)
+		err := s.eachObject(ctx, &analyses[i], func(o *repository.Object) error {
+			for j, cell := range summary {
+				record[j] = formatCSVCell(cell, opts.decimal)
+			}
+			for j, cell := range objectRow(o, opts) {
+				record[len(summary)+j] = formatCSVCell(cell, opts.decimal)
+			}
+			return cw.Write(record)
+		})
+		if err != nil {
+			return err
+		}
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeXLSX writes an "Analyses" sheet with the analysis summaries and Stats
+// and an "Objects" sheet with one row per object.
+func (s *ExportService) writeXLSX(ctx context.Context, w io.Writer, opts exportOptions, analyses []repository.Analysis) error {
+	xw := xlsx.NewWriter(w)
+
+	if err := xw.StartSheet("Analyses"); err != nil {
+		return err
+	}
+	if err := xw.WriteRow(stringCells(append(analysisSummaryHeader(opts), analysisStatsHeader(opts)...))); err != nil {
+		return err
+	}
+	for i := range analyses {
+		row := append(analysisSummaryRow(&analyses[i], opts), analysisStatsRow(&analyses[i], opts)...)
+		if err := xw.WriteRow(xlsxCells(row)); err != nil {
+			return err
+		}
+	}
+
+	if err := xw.StartSheet("Objects"); err != nil {
+		return err
+	}
+	if err := xw.WriteRow(stringCells(append([]string{"id_analysis"}, objectHeader(opts)...))); err != nil {
+		return err
+	}
+	for i := range analyses {
+		idAnalysis := analyses[i].IDAnalysis.String
+		err := s.eachObject(ctx, &analyses[i], func(o *repository.Object) error {
+			return xw.WriteRow(xlsxCells(append([]interface{}{idAnalysis}, objectRow(o, opts)...)))
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return xw.Close()
+}
+
+// eachObject loads the objects of one analysis at a time, so a bulk export
+// never holds more than a single analysis worth of rows.
+func (s *ExportService) eachObject(ctx context.Context, a *repository.Analysis, fn func(o *repository.Object) error) error {
+	repoObjects, err := s.repo.GetObjectsByAnalysisID(ctx, pgtype.Int8{Int64: int64(a.ID), Valid: true})
+	if err != nil {
+		exportLog.Error().Err(err).Int32("analysisID", a.ID).Msg("Failed to get objects for export")
+		return err
+	}
+	if err := applyEffectiveLabels(ctx, s.repo, repoObjects); err != nil {
+		return err
+	}
+	for i := range repoObjects {
+		if err := fn(&repoObjects[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parseExportOptions(req models.ExportRequest) (exportOptions, error) {
+	opts := exportOptions{
+		format:     strings.ToLower(strings.TrimSpace(req.Format)),
+		lengthUnit: strings.TrimSpace(req.Units),
+		massUnit:   strings.TrimSpace(req.MassUnit),
+		decimal:    req.Decimal,
+	}
+
+	if opts.format == "" {
+		opts.format = models.ExportCSV
+	}
+	if opts.format != models.ExportCSV && opts.format != models.ExportXLSX {
+		return exportOptions{}, fmt.Errorf("%w: unsupported format %q", ErrInvalidExport, req.Format)
+	}
+
+	if opts.lengthUnit == "" {
+		opts.lengthUnit = "mm"
+	}
+	lengthFactor, ok := lengthUnits[opts.lengthUnit]
+	if !ok {
+		return exportOptions{}, fmt.Errorf("%w: unsupported length unit %q", ErrInvalidExport, req.Units)
+	}
+	if opts.massUnit == "" {
+		opts.massUnit = "g"
+	}
+	massFactor, ok := massUnits[opts.massUnit]
+	if !ok {
+		return exportOptions{}, fmt.Errorf("%w: unsupported mass unit %q", ErrInvalidExport, req.MassUnit)
+	}
+	opts.factors = map[int]float64{
+		quantityLength: lengthFactor,
+		quantityArea:   lengthFactor * lengthFactor,
+		quantityMass:   massFactor,
+	}
+
+	if opts.decimal == "" {
+		opts.decimal = "."
+	}
+	if opts.decimal != "." && opts.decimal != "," {
+		return exportOptions{}, fmt.Errorf("%w: decimal separator must be \".\" or \",\"", ErrInvalidExport)
+	}
+
+	if strings.TrimSpace(req.Columns) == "" {
+		opts.columns = objectExportColumns
+		return opts, nil
+	}
+	byName := make(map[string]exportColumn, len(objectExportColumns))
+	for _, column := range objectExportColumns {
+		byName[column.name] = column
+	}
+	for _, name := range strings.Split(req.Columns, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		column, ok := byName[name]
+		if !ok {
+			return exportOptions{}, fmt.Errorf("%w: unknown column %q", ErrInvalidExport, name)
+		}
+		opts.columns = append(opts.columns, column)
+	}
+	if len(opts.columns) == 0 {
+		return exportOptions{}, fmt.Errorf("%w: no columns selected", ErrInvalidExport)
+	}
+
+	return opts, nil
+}
+
+// unitSuffix labels a header with the unit a converted quantity is written in.
+func (o exportOptions) unitSuffix(quantity int) string {
+	switch quantity {
+	case quantityLength:
+		return " (" + o.lengthUnit + ")"
+	case quantityArea:
+		return " (" + o.lengthUnit + "2)"
+	case quantityMass:
+		return " (" + o.massUnit + ")"
+	}
+	return ""
+}
+
+func (o exportOptions) convert(value float64, quantity int) float64 {
+	if factor, ok := o.factors[quantity]; ok {
+		return value * factor
+	}
+	return value
+}
+
+func analysisSummaryHeader(opts exportOptions) []string {
+	return []string{
+		"id_analysis",
+		"date_time",
+		"product",
+		"color_rhs",
+		"scale_mm_pixel",
+		"mass" + opts.unitSuffix(quantityMass),
+		"area" + opts.unitSuffix(quantityArea),
+	}
+}
+
+func analysisSummaryRow(a *repository.Analysis, opts exportOptions) []interface{} {
+	row := []interface{}{a.IDAnalysis.String, nil, nil, nil, nil, nil, nil}
+	if a.DateTime.Valid {
+		row[1] = a.DateTime.Time
+	}
+	if a.Product.Valid {
+		row[2] = a.Product.String
+	}
+	if a.ColorRhs.Valid {
+		row[3] = a.ColorRhs.String
+	}
+	if a.ScaleMmPixel.Valid {
+		row[4] = a.ScaleMmPixel.Float64
+	}
+	if a.Mass.Valid {
+		row[5] = opts.convert(a.Mass.Float64, quantityMass)
+	}
+	if a.Area.Valid {
+		row[6] = opts.convert(a.Area.Float64, quantityArea)
+	}
+	return row
+}
+
+// analysisStatsChannels lists the Stats channels in table order. w and l are lengths.
+var analysisStatsChannels = []string{"r", "g", "b", "h", "s", "v", "lab_l", "lab_a", "lab_b", "w", "l", "t"}
+
+func statsQuantity(channel string) int {
+	if channel == "w" || channel == "l" {
+		return quantityLength
+	}
+	return quantityNone
+}
+
+func analysisStatsHeader(opts exportOptions) []string {
+	header := make([]string, 0, len(analysisStatsChannels)*4)
+	for _, channel := range analysisStatsChannels {
+		suffix := opts.unitSuffix(statsQuantity(channel))
+		for _, field := range []string{"min", "max", "avg", "median"} {
+			header = append(header, channel+"_"+field+suffix)
+		}
+	}
+	return header
+}
+
+func analysisStatsRow(a *repository.Analysis, opts exportOptions) []interface{} {
+	columns := analysisStatsColumns(a)
+	row := make([]interface{}, 0, len(analysisStatsChannels)*4)
+	for _, channel := range analysisStatsChannels {
+		stats, status := decodeStoredStats(*columns[channel])
+		if status != models.StatsOK {
+			row = append(row, nil, nil, nil, nil)
+			continue
+		}
+		quantity := statsQuantity(channel)
+		for _, v := range []float32{stats.Min, stats.Max, stats.Avg, stats.Median} {
+			row = append(row, opts.convert(float64(v), quantity))
+		}
+	}
+	return row
+}
+
+func objectHeader(opts exportOptions) []string {
+	header := make([]string, 0, len(opts.columns))
+	for _, column := range opts.columns {
+		header = append(header, column.name+opts.unitSuffix(column.quantity))
+	}
+	return header
+}
+
+func objectRow(o *repository.Object, opts exportOptions) []interface{} {
+	row := make([]interface{}, 0, len(opts.columns))
+	for _, column := range opts.columns {
+		value := column.value(o)
+		if v, ok := value.(float64); ok {
+			value = opts.convert(v, column.quantity)
+		}
+		row = append(row, value)
+	}
+	return row
+}
+
+func stringCells(values []string) []interface{} {
+	cells := make([]interface{}, len(values))
+	for i, v := range values {
+		cells[i] = v
+	}
+	return cells
+}
+
+// neutralizeFormula prefixes text a spreadsheet would read as a formula with a
+// quote, so a product or comment like "=HYPERLINK(...)" stays text.
+func neutralizeFormula(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// xlsxCells neutralizes the text cells of a row in place.
+func xlsxCells(row []interface{}) []interface{} {
+	for i, cell := range row {
+		if v, ok := cell.(string); ok {
+			row[i] = neutralizeFormula(v)
+		}
+	}
+	return row
+}
+
+func formatCSVCell(cell interface{}, decimal string) string {
+	switch v := cell.(type) {
+	case nil:
+		return ""
+	case string:
+		return neutralizeFormula(v)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case float64:
+		s := strconv.FormatFloat(v, 'f', -1, 64)
+		if decimal != "." {
+			s = strings.Replace(s, ".", decimal, 1)
+		}
+		return s
+	case int32:
+		return strconv.FormatInt(int64(v), 10)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	}
+	return fmt.Sprint(cell)
+}
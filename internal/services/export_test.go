@@ -0,0 +1,48 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatCSVCell(t *testing.T) {
+	tests := []struct {
+		name    string
+		cell    interface{}
+		decimal string
+		want    string
+	}{
+		{"nil", nil, ".", ""},
+		{"text", "wheat", ".", "wheat"},
+		{"empty text", "", ".", ""},
+		{"formula", "=HYPERLINK(\"http://x\")", ".", "'=HYPERLINK(\"http://x\")"},
+		{"plus", "+1", ".", "'+1"},
+		{"minus", "-1+2", ".", "'-1+2"},
+		{"at", "@SUM(A1)", ".", "'@SUM(A1)"},
+		{"tab", "\t=1", ".", "'\t=1"},
+		{"carriage return", "\r=1", ".", "'\r=1"},
+		// Only a leading character makes a formula
+		{"inner equals", "a=b", ".", "a=b"},
+		// Numbers aren't text, so negative ones are left alone
+		{"negative number", -1.5, ",", "-1,5"},
+		{"int", int32(-3), ".", "-3"},
+		{"time", time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC), ".", "2024-05-01T12:30:00Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatCSVCell(tt.cell, tt.decimal); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestXLSXCells(t *testing.T) {
+	row := xlsxCells([]interface{}{"=1+1", "wheat", -2.5, nil, "@cmd"})
+	want := []interface{}{"'=1+1", "wheat", -2.5, nil, "'@cmd"}
+	for i := range want {
+		if row[i] != want[i] {
+			t.Errorf("cell %d: got %#v, want %#v", i, row[i], want[i])
+		}
+	}
+}
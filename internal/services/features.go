@@ -0,0 +1,172 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"csort.ru/analysis-service/internal/apperr"
+	"csort.ru/analysis-service/internal/repository"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// objectFeature reads one numeric column of an object row.
+type objectFeature func(o *repository.Object) pgtype.Float8
+
+// objectFeatureNames lists the numeric object columns in table order.
+var objectFeatureNames = []string{
+	"m_h",
+	"m_s",
+	"m_v",
+	"m_r",
+	"m_g",
+	"m_b",
+	"l_avg",
+	"w_avg",
+	"brt_avg",
+	"r_avg",
+	"g_avg",
+	"b_avg",
+	"h_avg",
+	"s_avg",
+	"v_avg",
+	"h",
+	"s",
+	"v",
+	"h_m",
+	"s_m",
+	"v_m",
+	"r_m",
+	"g_m",
+	"b_m",
+	"brt_m",
+	"w_m",
+	"l_m",
+	"l",
+	"w",
+	"l_w",
+	"pr",
+	"sq",
+	"brt",
+	"r",
+	"g",
+	"b",
+	"solid",
+	"min_h",
+	"min_s",
+	"min_v",
+	"max_h",
+	"max_s",
+	"max_v",
+	"entropy",
+	"sq_sqcrl",
+	"hu1",
+	"hu2",
+	"hu3",
+	"hu4",
+	"hu5",
+	"hu6",
+}
+
+// objectFeatures maps numeric object column names to their accessors.
+var objectFeatures = map[string]objectFeature{
+	"m_h":      func(o *repository.Object) pgtype.Float8 { return o.MH },
+	"m_s":      func(o *repository.Object) pgtype.Float8 { return o.MS },
+	"m_v":      func(o *repository.Object) pgtype.Float8 { return o.MV },
+	"m_r":      func(o *repository.Object) pgtype.Float8 { return o.MR },
+	"m_g":      func(o *repository.Object) pgtype.Float8 { return o.MG },
+	"m_b":      func(o *repository.Object) pgtype.Float8 { return o.MB },
+	"l_avg":    func(o *repository.Object) pgtype.Float8 { return o.LAvg },
+	"w_avg":    func(o *repository.Object) pgtype.Float8 { return o.WAvg },
+	"brt_avg":  func(o *repository.Object) pgtype.Float8 { return o.BrtAvg },
+	"r_avg":    func(o *repository.Object) pgtype.Float8 { return o.RAvg },
+	"g_avg":    func(o *repository.Object) pgtype.Float8 { return o.GAvg },
+	"b_avg":    func(o *repository.Object) pgtype.Float8 { return o.BAvg },
+	"h_avg":    func(o *repository.Object) pgtype.Float8 { return o.HAvg },
+	"s_avg":    func(o *repository.Object) pgtype.Float8 { return o.SAvg },
+	"v_avg":    func(o *repository.Object) pgtype.Float8 { return o.VAvg },
+	"h":        func(o *repository.Object) pgtype.Float8 { return o.H },
+	"s":        func(o *repository.Object) pgtype.Float8 { return o.S },
+	"v":        func(o *repository.Object) pgtype.Float8 { return o.V },
+	"h_m":      func(o *repository.Object) pgtype.Float8 { return o.HM },
+	"s_m":      func(o *repository.Object) pgtype.Float8 { return o.SM },
+	"v_m":      func(o *repository.Object) pgtype.Float8 { return o.VM },
+	"r_m":      func(o *repository.Object) pgtype.Float8 { return o.RM },
+	"g_m":      func(o *repository.Object) pgtype.Float8 { return o.GM },
+	"b_m":      func(o *repository.Object) pgtype.Float8 { return o.BM },
+	"brt_m":    func(o *repository.Object) pgtype.Float8 { return o.BrtM },
+	"w_m":      func(o *repository.Object) pgtype.Float8 { return o.WM },
+	"l_m":      func(o *repository.Object) pgtype.Float8 { return o.LM },
+	"l":        func(o *repository.Object) pgtype.Float8 { return o.L },
+	"w":        func(o *repository.Object) pgtype.Float8 { return o.W },
+	"l_w":      func(o *repository.Object) pgtype.Float8 { return o.LW },
+	"pr":       func(o *repository.Object) pgtype.Float8 { return o.Pr },
+	"sq":       func(o *repository.Object) pgtype.Float8 { return o.Sq },
+	"brt":      func(o *repository.Object) pgtype.Float8 { return o.Brt },
+	"r":        func(o *repository.Object) pgtype.Float8 { return o.R },
+	"g":        func(o *repository.Object) pgtype.Float8 { return o.G },
+	"b":        func(o *repository.Object) pgtype.Float8 { return o.B },
+	"solid":    func(o *repository.Object) pgtype.Float8 { return o.Solid },
+	"min_h":    func(o *repository.Object) pgtype.Float8 { return o.MinH },
+	"min_s":    func(o *repository.Object) pgtype.Float8 { return o.MinS },
+	"min_v":    func(o *repository.Object) pgtype.Float8 { return o.MinV },
+	"max_h":    func(o *repository.Object) pgtype.Float8 { return o.MaxH },
+	"max_s":    func(o *repository.Object) pgtype.Float8 { return o.MaxS },
+	"max_v":    func(o *repository.Object) pgtype.Float8 { return o.MaxV },
+	"entropy":  func(o *repository.Object) pgtype.Float8 { return o.Entropy },
+	"sq_sqcrl": func(o *repository.Object) pgtype.Float8 { return o.SqSqcrl },
+	"hu1":      func(o *repository.Object) pgtype.Float8 { return o.Hu1 },
+	"hu2":      func(o *repository.Object) pgtype.Float8 { return o.Hu2 },
+	"hu3":      func(o *repository.Object) pgtype.Float8 { return o.Hu3 },
+	"hu4":      func(o *repository.Object) pgtype.Float8 { return o.Hu4 },
+	"hu5":      func(o *repository.Object) pgtype.Float8 { return o.Hu5 },
+	"hu6":      func(o *repository.Object) pgtype.Float8 { return o.Hu6 },
+}
+
+// objectFeatureGroups are shorthands accepted wherever a feature list is expected.
+var objectFeatureGroups = map[string][]string{
+	"geometry": {"l", "w", "l_w", "pr", "sq", "solid", "sq_sqcrl"},
+	"color":    {"h_avg", "s_avg", "v_avg", "r_avg", "g_avg", "b_avg", "brt_avg"},
+	"hu":       {"hu1", "hu2", "hu3", "hu4", "hu5", "hu6"},
+}
+
+// ErrUnknownFeature is returned when a requested object feature doesn't exist.
+var ErrUnknownFeature = apperr.Validation("unknown_feature", "unknown object feature")
+
+// resolveFeatures expands group names, drops duplicates and checks that every
+// feature exists. An empty list resolves to defaults.
+func resolveFeatures(names []string, defaults []string) ([]string, error) {
+	if len(names) == 0 {
+		names = defaults
+	}
+
+	resolved := make([]string, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			resolved = append(resolved, name)
+		}
+	}
+
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if group, ok := objectFeatureGroups[name]; ok {
+			for _, feature := range group {
+				add(feature)
+			}
+			continue
+		}
+		if _, ok := objectFeatures[name]; !ok {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownFeature, name)
+		}
+		add(name)
+	}
+
+	if len(resolved) == 0 {
+		return nil, fmt.Errorf("%w: no features selected", ErrUnknownFeature)
+	}
+	return resolved, nil
+}
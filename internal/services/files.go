@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+// errFileRefNotAllowed is returned for file references outside FileRefs.
+var errFileRefNotAllowed = errors.New("file reference not allowed")
+
+// FileRefs resolves the file references stored with analyses and objects: HTTP(S)
+// URLs and paths, relative ones against Dir. The references come from the
+// database, so they are confined: paths must resolve inside Dir and URLs must
+// point at one of Hosts. Without a Dir no local file is read.
+type FileRefs struct {
+	Dir string
+	// Hosts are the host names, or host:port pairs, files may be fetched from
+	Hosts []string
+}
+
+// read reads the file ref points at.
+func (f FileRefs) read(ctx context.Context, ref string, timeout time.Duration) ([]byte, error) {
+	if isRemoteFileRef(ref) {
+		if err := f.checkURL(ref); err != nil {
+			return nil, err
+		}
+		if deadline, ok := ctx.Deadline(); ok {
+			timeout = min(timeout, time.Until(deadline))
+		}
+		// Redirects aren't followed, so the host check holds
+		status, body, err := fasthttp.GetTimeout(nil, ref, timeout)
+		if err != nil {
+			return nil, err
+		}
+		if status != fasthttp.StatusOK {
+			return nil, fmt.Errorf("unexpected status %d fetching %s", status, ref)
+		}
+		return body, nil
+	}
+
+	path, err := f.resolve(ref)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(path)
+}
+
+// remove deletes the file ref points at. Files served over HTTP(S) are owned by
+// the analysis service and are left alone, as are files that are already gone.
+func (f FileRefs) remove(ref string) error {
+	if ref == "" || isRemoteFileRef(ref) {
+		return nil
+	}
+	path, err := f.resolve(ref)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// resolve returns the path of a local reference, if it is inside Dir.
+func (f FileRefs) resolve(ref string) (string, error) {
+	if f.Dir == "" {
+		return "", fmt.Errorf("%w: no files directory is configured for %s", errFileRefNotAllowed, ref)
+	}
+	dir, err := filepath.Abs(f.Dir)
+	if err != nil {
+		return "", err
+	}
+	path := ref
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	path = filepath.Clean(path)
+
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%w: %s is outside %s", errFileRefNotAllowed, ref, f.Dir)
+	}
+	return path, nil
+}
+
+// checkURL checks that a remote reference points at an allowed host.
+func (f FileRefs) checkURL(ref string) error {
+	u, err := url.Parse(ref)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errFileRefNotAllowed, err)
+	}
+	host, hostname := strings.ToLower(u.Host), strings.ToLower(u.Hostname())
+	if hostname == "" || u.User != nil || !slices.ContainsFunc(f.Hosts, func(allowed string) bool {
+		allowed = strings.ToLower(allowed)
+		return allowed == host || allowed == hostname
+	}) {
+		return fmt.Errorf("%w: host of %s is not allowed", errFileRefNotAllowed, ref)
+	}
+	return nil
+}
+
+func isRemoteFileRef(ref string) bool {
+	return strings.HasPrefix(ref, "http://") || strings.HasPrefix(ref, "https://")
+}
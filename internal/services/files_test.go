@@ -0,0 +1,103 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileRefsResolve(t *testing.T) {
+	dir := t.TempDir()
+	files := FileRefs{Dir: dir}
+
+	tests := []struct {
+		ref  string
+		want string // empty if the reference is refused
+	}{
+		{ref: "crops/1.png", want: filepath.Join(dir, "crops", "1.png")},
+		{ref: "crops/../2.png", want: filepath.Join(dir, "2.png")},
+		{ref: filepath.Join(dir, "3.png"), want: filepath.Join(dir, "3.png")},
+		{ref: "../outside.png"},
+		{ref: "crops/../../outside.png"},
+		{ref: "/etc/passwd"},
+		{ref: dir + "-sibling/1.png"},
+	}
+	for _, tt := range tests {
+		got, err := files.resolve(tt.ref)
+		if tt.want == "" {
+			if !errors.Is(err, errFileRefNotAllowed) {
+				t.Errorf("resolve(%q) = %q, %v, want errFileRefNotAllowed", tt.ref, got, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("resolve(%q) = %q, %v, want %q", tt.ref, got, err, tt.want)
+		}
+	}
+
+	if _, err := (FileRefs{}).resolve("crops/1.png"); !errors.Is(err, errFileRefNotAllowed) {
+		t.Errorf("got %v without a directory, want errFileRefNotAllowed", err)
+	}
+}
+
+func TestFileRefsCheckURL(t *testing.T) {
+	files := FileRefs{Hosts: []string{"api:8080", "files.local"}}
+
+	tests := []struct {
+		ref     string
+		allowed bool
+	}{
+		{ref: "http://api:8080/images/1.jpg", allowed: true},
+		{ref: "https://files.local/images/1.jpg", allowed: true},
+		{ref: "http://FILES.local:9000/images/1.jpg", allowed: true},
+		{ref: "http://api/images/1.jpg"},
+		{ref: "http://api:9090/images/1.jpg"},
+		{ref: "http://169.254.169.254/latest/meta-data"},
+		{ref: "http://user@files.local/images/1.jpg"},
+		{ref: "http://files.local.evil.com/images/1.jpg"},
+	}
+	for _, tt := range tests {
+		err := files.checkURL(tt.ref)
+		if tt.allowed && err != nil {
+			t.Errorf("checkURL(%q) = %v, want allowed", tt.ref, err)
+		}
+		if !tt.allowed && !errors.Is(err, errFileRefNotAllowed) {
+			t.Errorf("checkURL(%q) = %v, want errFileRefNotAllowed", tt.ref, err)
+		}
+	}
+}
+
+func TestFileRefsReadAndRemove(t *testing.T) {
+	dir := t.TempDir()
+	files := FileRefs{Dir: dir}
+	if err := os.WriteFile(filepath.Join(dir, "1.png"), []byte("png"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := files.read(context.Background(), "1.png", time.Second)
+	if err != nil || string(data) != "png" {
+		t.Fatalf("read = %q, %v, want the file", data, err)
+	}
+	if _, err := files.read(context.Background(), "http://elsewhere/1.png", time.Second); !errors.Is(err, errFileRefNotAllowed) {
+		t.Errorf("read of a URL off the allow-list = %v, want errFileRefNotAllowed", err)
+	}
+
+	if err := files.remove("1.png"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "1.png")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("file still exists after remove: %v", err)
+	}
+	// Files that are already gone and remote files are left alone
+	for _, ref := range []string{"", "1.png", "http://api:8080/1.png"} {
+		if err := files.remove(ref); err != nil {
+			t.Errorf("remove(%q) = %v, want nil", ref, err)
+		}
+	}
+	if err := files.remove("../1.png"); !errors.Is(err, errFileRefNotAllowed) {
+		t.Errorf("remove outside the directory = %v, want errFileRefNotAllowed", err)
+	}
+}
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"csort.ru/analysis-service/internal/apperr"
+	"csort.ru/analysis-service/internal/logger"
+	"csort.ru/analysis-service/internal/models"
+	"csort.ru/analysis-service/internal/repository"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var labelsLog = logger.GetLogger("services.labels")
+
+var ErrInvalidLabel = apperr.Validation("invalid_label", "invalid object label")
+
+type LabelsService struct {
+	repo  *repository.Queries
+	specs *SpecsService
+}
+
+func NewLabelsService(repo *repository.Queries, specs *SpecsService) *LabelsService {
+	return &LabelsService{
+		repo:  repo,
+		specs: specs,
+	}
+}
+
+// Relabel records a manual label of an object of the user's analysis and regrades
+// the analysis, as class share rules depend on the labels.
+func (s *LabelsService) Relabel(ctx context.Context, userID int64, objectID int32, req models.ObjectLabelRequest) (models.ObjectLabel, error) {
+	class := strings.TrimSpace(req.Class)
+	reason := strings.TrimSpace(req.Reason)
+	if class == "" {
+		return models.ObjectLabel{}, fmt.Errorf("%w: class is required", ErrInvalidLabel)
+	}
+	if reason == "" {
+		return models.ObjectLabel{}, fmt.Errorf("%w: reason is required", ErrInvalidLabel)
+	}
+
+	object, err := s.getOwnObject(ctx, userID, objectID)
+	if err != nil {
+		return models.ObjectLabel{}, err
+	}
+
+	row, err := s.repo.CreateObjectLabel(ctx, repository.CreateObjectLabelParams{
+		ObjectID: objectID,
+		Class:    class,
+		IDUser:   strconv.FormatInt(userID, 10),
+		Reason:   reason,
+	})
+	if err != nil {
+		labelsLog.Error().Err(err).Int32("objectID", objectID).Msg("Failed to store object label")
+		return models.ObjectLabel{}, err
+	}
+	labelsLog.Info().Int32("objectID", objectID).Str("class", class).Int64("userID", userID).Msg("Object relabeled")
+
+	if object.IDAnalysis.Valid {
+		if err := s.specs.Regrade(ctx, int32(object.IDAnalysis.Int64)); err != nil {
+			labelsLog.Warn().Err(err).Int64("analysisID", object.IDAnalysis.Int64).Msg("Failed to regrade analysis after relabeling")
+		}
+	}
+
+	return convertLabelFromRepo(row, object.Class.String), nil
+}
+
+// History lists the manual labels of an object of the user's analysis, latest first.
+func (s *LabelsService) History(ctx context.Context, userID int64, objectID int32) ([]models.ObjectLabel, error) {
+	object, err := s.getOwnObject(ctx, userID, objectID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.repo.ListObjectLabels(ctx, objectID)
+	if err != nil {
+		labelsLog.Error().Err(err).Int32("objectID", objectID).Msg("Failed to list object labels")
+		return nil, err
+	}
+
+	labels := make([]models.ObjectLabel, 0, len(rows))
+	for _, row := range rows {
+		labels = append(labels, convertLabelFromRepo(row, object.Class.String))
+	}
+	return labels, nil
+}
+
+// getOwnObject returns an object if its analysis belongs to the user, and
+// ErrNotOwner if it belongs to another one.
+func (s *LabelsService) getOwnObject(ctx context.Context, userID int64, objectID int32) (repository.Object, error) {
+	object, err := s.repo.GetObjectByID(ctx, objectID)
+	if err != nil {
+		return repository.Object{}, notFound(err, ErrObjectNotFound)
+	}
+	if !object.IDAnalysis.Valid {
+		return repository.Object{}, ErrNotOwner
+	}
+	analysis, err := s.repo.GetAnalysisByInternalID(ctx, int32(object.IDAnalysis.Int64))
+	if err != nil {
+		return repository.Object{}, notFound(err, ErrObjectNotFound)
+	}
+	if analysis.IDUser.String != strconv.FormatInt(userID, 10) {
+		return repository.Object{}, ErrNotOwner
+	}
+	return object, nil
+}
+
+// effectiveLabels returns the latest manual label of the relabeled objects among ids.
+func effectiveLabels(ctx context.Context, repo *repository.Queries, ids []int32) (map[int32]string, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	rows, err := repo.GetEffectiveLabelsByObjectIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	labels := make(map[int32]string, len(rows))
+	for _, row := range rows {
+		labels[row.ObjectID] = row.Class
+	}
+	return labels, nil
+}
+
+// applyEffectiveLabels replaces the pipeline's class of the relabeled objects with
+// their latest manual label.
+func applyEffectiveLabels(ctx context.Context, repo *repository.Queries, objects []repository.Object) error {
+	ids := make([]int32, len(objects))
+	for i := range objects {
+		ids[i] = objects[i].ID
+	}
+	labels, err := effectiveLabels(ctx, repo, ids)
+	if err != nil {
+		return err
+	}
+	for i := range objects {
+		if class, ok := labels[objects[i].ID]; ok {
+			objects[i].Class = pgtype.Text{String: class, Valid: true}
+		}
+	}
+	return nil
+}
+
+func convertLabelFromRepo(row repository.ObjectLabel, originalClass string) models.ObjectLabel {
+	return models.ObjectLabel{
+		ID:            row.ID,
+		ObjectID:      row.ObjectID,
+		Class:         row.Class,
+		OriginalClass: originalClass,
+		IDUser:        row.IDUser,
+		Reason:        row.Reason,
+		CreatedAt:     row.CreatedAt,
+	}
+}
@@ -0,0 +1,445 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"csort.ru/analysis-service/internal/apperr"
+	"csort.ru/analysis-service/internal/logger"
+	"csort.ru/analysis-service/internal/models"
+	"csort.ru/analysis-service/internal/repository"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var lotsLog = logger.GetLogger("services.lots")
+
+var (
+	ErrInvalidLot       = apperr.Validation("invalid_lot", "invalid lot")
+	ErrLotNotFound      = apperr.NotFound("lot_not_found", "lot not found")
+	ErrLotExists        = apperr.Conflict("lot_exists", "a lot with this number already exists for the supplier")
+	ErrAnalysisNotInLot = apperr.NotFound("analysis_not_in_lot", "analysis is not in the lot")
+	ErrInAnotherLot     = apperr.Conflict("analysis_in_another_lot", "analysis is already in another lot")
+)
+
+// LotsService manages the sample lots of users. A lot, like an analysis, belongs to
+// the user who created it and only holds analyses of that user.
+type LotsService struct {
+	repo     *repository.Queries
+	products *ProductsService
+}
+
+func NewLotsService(repo *repository.Queries, products *ProductsService) *LotsService {
+	return &LotsService{
+		repo:     repo,
+		products: products,
+	}
+}
+
+func (s *LotsService) ListLots(ctx context.Context, userID int64, params models.GetLotsPaginatedRequest) (*models.PaginatedResponse[models.Lot], error) {
+	if params.Limit == 0 {
+		params.Limit = DefaultLimit
+	}
+	if params.Limit > MaxLimit {
+		params.Limit = MaxLimit
+	}
+	product, err := s.products.CanonicalFilter(ctx, params.Product)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.repo.ListLots(ctx, repository.ListLotsParams{
+		IDUser:   strconv.FormatInt(userID, 10),
+		Supplier: params.Supplier,
+		Product:  product,
+		Limit:    params.Limit,
+		Offset:   params.Offset,
+	})
+	if err != nil {
+		lotsLog.Error().Err(err).Msg("Failed to list lots")
+		return nil, err
+	}
+
+	count, err := s.repo.CountLots(ctx, repository.CountLotsParams{
+		IDUser:   strconv.FormatInt(userID, 10),
+		Supplier: params.Supplier,
+		Product:  product,
+	})
+	if err != nil {
+		lotsLog.Error().Err(err).Msg("Failed to count lots")
+		return nil, err
+	}
+
+	lots := make([]models.Lot, 0, len(rows))
+	for _, row := range rows {
+		lot := convertLotFromRepo(repository.Lot{
+			ID:          row.ID,
+			LotNumber:   row.LotNumber,
+			Supplier:    row.Supplier,
+			Field:       row.Field,
+			HarvestDate: row.HarvestDate,
+			Product:     row.Product,
+			Notes:       row.Notes,
+			IDUser:      row.IDUser,
+			CreatedAt:   row.CreatedAt,
+			UpdatedAt:   row.UpdatedAt,
+		})
+		lot.Analyses = row.Analyses
+		lots = append(lots, lot)
+	}
+
+	return &models.PaginatedResponse[models.Lot]{
+		Data:   lots,
+		Total:  count,
+		Limit:  params.Limit,
+		Offset: params.Offset,
+	}, nil
+}
+
+func (s *LotsService) GetLot(ctx context.Context, userID int64, id int32) (models.Lot, error) {
+	row, err := s.getLot(ctx, userID, id)
+	if err != nil {
+		return models.Lot{}, err
+	}
+
+	analyses, err := s.repo.CountLotAnalyses(ctx, id)
+	if err != nil {
+		lotsLog.Error().Err(err).Int32("lotID", id).Msg("Failed to count lot analyses")
+		return models.Lot{}, err
+	}
+
+	lot := convertLotFromRepo(row)
+	lot.Analyses = analyses
+	return lot, nil
+}
+
+func (s *LotsService) CreateLot(ctx context.Context, userID int64, req models.LotRequest) (models.Lot, error) {
+	params, err := s.validateLotRequest(ctx, req)
+	if err != nil {
+		return models.Lot{}, err
+	}
+
+	row, err := s.repo.CreateLot(ctx, repository.CreateLotParams{
+		LotNumber:   params.LotNumber,
+		Supplier:    params.Supplier,
+		Field:       params.Field,
+		HarvestDate: params.HarvestDate,
+		Product:     params.Product,
+		Notes:       params.Notes,
+		IDUser:      strconv.FormatInt(userID, 10),
+	})
+	if err != nil {
+		lotsLog.Error().Err(err).Str("lotNumber", params.LotNumber).Msg("Failed to create lot")
+		return models.Lot{}, conflict(err, ErrLotExists)
+	}
+	lotsLog.Info().Int32("lotID", row.ID).Str("lotNumber", row.LotNumber).Int64("userID", userID).Msg("Lot created")
+
+	return convertLotFromRepo(row), nil
+}
+
+// UpdateLot replaces the details of a lot; its analyses are kept.
+func (s *LotsService) UpdateLot(ctx context.Context, userID int64, id int32, req models.LotRequest) (models.Lot, error) {
+	params, err := s.validateLotRequest(ctx, req)
+	if err != nil {
+		return models.Lot{}, err
+	}
+
+	row, err := s.repo.UpdateLot(ctx, repository.UpdateLotParams{
+		LotNumber:   params.LotNumber,
+		Supplier:    params.Supplier,
+		Field:       params.Field,
+		HarvestDate: params.HarvestDate,
+		Product:     params.Product,
+		Notes:       params.Notes,
+		ID:          id,
+		IDUser:      strconv.FormatInt(userID, 10),
+	})
+	if err != nil {
+		return models.Lot{}, conflict(notFound(err, ErrLotNotFound), ErrLotExists)
+	}
+
+	return s.GetLot(ctx, userID, row.ID)
+}
+
+// DeleteLot deletes a lot. Its analyses are only detached, never deleted.
+func (s *LotsService) DeleteLot(ctx context.Context, userID int64, id int32) error {
+	affected, err := s.repo.DeleteLot(ctx, repository.DeleteLotParams{
+		ID:     id,
+		IDUser: strconv.FormatInt(userID, 10),
+	})
+	if err != nil {
+		lotsLog.Error().Err(err).Int32("lotID", id).Msg("Failed to delete lot")
+		return err
+	}
+	if affected == 0 {
+		return ErrLotNotFound
+	}
+	return nil
+}
+
+// GetLotAnalyses lists the analyses of a lot, oldest first, without their objects.
+func (s *LotsService) GetLotAnalyses(ctx context.Context, userID int64, id int32) ([]models.Analysis, error) {
+	if _, err := s.getLot(ctx, userID, id); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.repo.ListLotAnalyses(ctx, id)
+	if err != nil {
+		lotsLog.Error().Err(err).Int32("lotID", id).Msg("Failed to list lot analyses")
+		return nil, err
+	}
+
+	analyses := make([]models.Analysis, 0, len(rows))
+	for _, row := range rows {
+		analysis := convertAnalysisFromRepo(row)
+		analysis.LotID = &id
+		analyses = append(analyses, analysis)
+	}
+	return analyses, nil
+}
+
+// AttachAnalyses adds the user's analyses to a lot and returns how many were newly
+// attached. Unknown and deleted analyses, those of other users and those already
+// in the lot are skipped. An analysis in another lot isn't moved: it has to be
+// detached from there first.
+func (s *LotsService) AttachAnalyses(ctx context.Context, userID int64, id int32, refs []models.AnalysisRef) (models.LotAnalysesResponse, error) {
+	var given []models.AnalysisRef
+	for _, ref := range refs {
+		if ref.ID = strings.TrimSpace(ref.ID); ref.ID != "" {
+			given = append(given, ref)
+		}
+	}
+	if len(given) == 0 {
+		return models.LotAnalysesResponse{}, fmt.Errorf("%w: no analyses given", ErrInvalidLot)
+	}
+
+	if _, err := s.getLot(ctx, userID, id); err != nil {
+		return models.LotAnalysesResponse{}, err
+	}
+
+	ids := make([]string, 0, len(given))
+	for _, ref := range given {
+		analysisID, err := resolveAnalysisID(ctx, s.repo, ref)
+		if errors.Is(err, ErrAnalysisNotFound) {
+			continue
+		}
+		if err != nil {
+			return models.LotAnalysesResponse{}, err
+		}
+		ids = append(ids, analysisID)
+	}
+
+	owner := pgtype.Text{String: strconv.FormatInt(userID, 10), Valid: true}
+	conflicts, err := s.repo.ListLotConflicts(ctx, repository.ListLotConflictsParams{
+		IDAnalyses: ids,
+		IDUser:     owner,
+		LotID:      id,
+	})
+	if err != nil {
+		lotsLog.Error().Err(err).Int32("lotID", id).Msg("Failed to check lots of analyses")
+		return models.LotAnalysesResponse{}, err
+	}
+	if len(conflicts) > 0 {
+		in := make([]string, len(conflicts))
+		for i, row := range conflicts {
+			in[i] = fmt.Sprintf("%s is in lot %d", row.IDAnalysis.String, row.LotID)
+		}
+		return models.LotAnalysesResponse{}, fmt.Errorf("%w: %s", ErrInAnotherLot, strings.Join(in, ", "))
+	}
+
+	attached, err := s.repo.AttachAnalysesToLot(ctx, repository.AttachAnalysesToLotParams{
+		LotID:      id,
+		IDAnalyses: ids,
+		IDUser:     owner,
+	})
+	if err != nil {
+		lotsLog.Error().Err(err).Int32("lotID", id).Msg("Failed to attach analyses to lot")
+		return models.LotAnalysesResponse{}, err
+	}
+	lotsLog.Info().Int32("lotID", id).Int64("attached", attached).Msg("Analyses attached to lot")
+
+	return models.LotAnalysesResponse{LotID: id, Attached: attached}, nil
+}
+
+// DetachAnalysis removes the user's analysis from their lot.
+func (s *LotsService) DetachAnalysis(ctx context.Context, userID int64, id int32, ref models.AnalysisRef) error {
+	if _, err := s.getLot(ctx, userID, id); err != nil {
+		return err
+	}
+	analysisID, err := resolveAnalysisID(ctx, s.repo, ref)
+	if err != nil {
+		return err
+	}
+
+	affected, err := s.repo.DetachAnalysisFromLot(ctx, repository.DetachAnalysisFromLotParams{
+		LotID:      id,
+		IDAnalysis: pgtype.Text{String: analysisID, Valid: true},
+		IDUser:     pgtype.Text{String: strconv.FormatInt(userID, 10), Valid: true},
+	})
+	if err != nil {
+		lotsLog.Error().Err(err).Int32("lotID", id).Str("analysisID", analysisID).Msg("Failed to detach analysis from lot")
+		return err
+	}
+	if affected == 0 {
+		return ErrAnalysisNotInLot
+	}
+	return nil
+}
+
+// GetLotStats pools the objects of every analysis of a lot into lot-level Stats
+// and a class composition, with manual labels applied.
+func (s *LotsService) GetLotStats(ctx context.Context, userID int64, id int32) (models.LotStats, error) {
+	if _, err := s.getLot(ctx, userID, id); err != nil {
+		return models.LotStats{}, err
+	}
+
+	analyses, err := s.repo.ListLotAnalyses(ctx, id)
+	if err != nil {
+		lotsLog.Error().Err(err).Int32("lotID", id).Msg("Failed to list lot analyses")
+		return models.LotStats{}, err
+	}
+	objects, err := s.repo.ListLotObjects(ctx, id)
+	if err != nil {
+		lotsLog.Error().Err(err).Int32("lotID", id).Msg("Failed to list lot objects")
+		return models.LotStats{}, err
+	}
+	rows, err := s.repo.GetClassCompositionByLotID(ctx, id)
+	if err != nil {
+		lotsLog.Error().Err(err).Int32("lotID", id).Msg("Failed to get lot class composition")
+		return models.LotStats{}, err
+	}
+
+	stats := models.LotStats{
+		LotID:    id,
+		Analyses: len(analyses),
+		Objects:  len(objects),
+		Stats:    make(map[string]models.Stats, len(statsSources)),
+	}
+	stats.Mass = sumFloat8(analyses, func(a *repository.Analysis) pgtype.Float8 { return a.Mass })
+	stats.Area = sumFloat8(analyses, func(a *repository.Analysis) pgtype.Float8 { return a.Area })
+
+	for _, source := range statsSources {
+		value := objectFeatures[source.feature]
+		values := make([]float64, 0, len(objects))
+		for i := range objects {
+			if v := value(&objects[i]); v.Valid {
+				values = append(values, v.Float64)
+			}
+		}
+		if len(values) > 0 {
+			stats.Stats[source.channel] = computeStats(values)
+		}
+	}
+
+	classes := make([]models.ClassComposition, 0, len(rows))
+	for _, row := range rows {
+		classes = append(classes, models.ClassComposition{
+			Class: row.Class,
+			Count: row.Objects,
+			Area:  row.Area,
+			Mass:  row.Mass,
+		})
+	}
+	stats.Composition = buildComposition(classes)
+
+	return stats, nil
+}
+
+// getLot returns a lot of the user; the lots of other users aren't found.
+func (s *LotsService) getLot(ctx context.Context, userID int64, id int32) (repository.Lot, error) {
+	row, err := s.repo.GetLotByID(ctx, repository.GetLotByIDParams{
+		ID:     id,
+		IDUser: strconv.FormatInt(userID, 10),
+	})
+	if err != nil {
+		return repository.Lot{}, notFound(err, ErrLotNotFound)
+	}
+	return row, nil
+}
+
+// sumFloat8 sums a column over the analyses it isn't NULL for; nil if it is NULL
+// for all of them.
+func sumFloat8(analyses []repository.Analysis, value func(*repository.Analysis) pgtype.Float8) *float64 {
+	var sum *float64
+	for i := range analyses {
+		if v := value(&analyses[i]); v.Valid {
+			if sum == nil {
+				sum = new(float64)
+			}
+			*sum += v.Float64
+		}
+	}
+	return sum
+}
+
+// lotMemberships returns the lot of each of the given analyses that belongs to one.
+func lotMemberships(ctx context.Context, repo *repository.Queries, ids []int32) (map[int32]int32, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	rows, err := repo.GetLotMembershipsByAnalysisIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	lots := make(map[int32]int32, len(rows))
+	for _, row := range rows {
+		lots[row.AnalysisID] = row.LotID
+	}
+	return lots, nil
+}
+
+// validateLotRequest trims a lot request into the columns to store, with the
+// product mapped onto its catalog code.
+func (s *LotsService) validateLotRequest(ctx context.Context, req models.LotRequest) (repository.CreateLotParams, error) {
+	optional := func(value string) pgtype.Text {
+		value = strings.TrimSpace(value)
+		return pgtype.Text{String: value, Valid: value != ""}
+	}
+
+	params := repository.CreateLotParams{
+		LotNumber: strings.TrimSpace(req.LotNumber),
+		Supplier:  optional(req.Supplier),
+		Field:     optional(req.Field),
+		Product:   optional(req.Product),
+		Notes:     optional(req.Notes),
+	}
+	if params.LotNumber == "" {
+		return params, fmt.Errorf("%w: lot_number is required", ErrInvalidLot)
+	}
+	if harvestDate := strings.TrimSpace(req.HarvestDate); harvestDate != "" {
+		date, err := time.Parse(time.DateOnly, harvestDate)
+		if err != nil {
+			return params, fmt.Errorf("%w: harvest_date must be formatted as YYYY-MM-DD", ErrInvalidLot)
+		}
+		params.HarvestDate = pgtype.Date{Time: date, Valid: true}
+	}
+	if params.Product.Valid {
+		product, err := s.products.Canonical(ctx, params.Product.String)
+		if err != nil {
+			return params, err
+		}
+		params.Product.String = product
+	}
+	return params, nil
+}
+
+func convertLotFromRepo(row repository.Lot) models.Lot {
+	lot := models.Lot{
+		ID:        row.ID,
+		LotNumber: row.LotNumber,
+		Supplier:  row.Supplier.String,
+		Field:     row.Field.String,
+		Product:   row.Product.String,
+		Notes:     row.Notes.String,
+		IDUser:    row.IDUser,
+		CreatedAt: row.CreatedAt,
+		UpdatedAt: row.UpdatedAt,
+	}
+	if row.HarvestDate.Valid {
+		lot.HarvestDate = row.HarvestDate.Time.Format(time.DateOnly)
+	}
+	return lot
+}
@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 
+	"csort.ru/analysis-service/internal/apperr"
 	"csort.ru/analysis-service/internal/logger"
 	"csort.ru/analysis-service/internal/models"
 	"csort.ru/analysis-service/internal/repository"
@@ -10,6 +11,8 @@ import (
 
 var objectsServiceLog = logger.GetLogger("services.objects")
 
+var ErrObjectNotFound = apperr.NotFound("object_not_found", "object not found")
+
 type ObjectsService struct {
 	repo *repository.Queries
 }
@@ -29,58 +32,58 @@ func (s *ObjectsService) GetObjects(ctx context.Context, objectIds []int32) ([]*
 	for _, row := range rows {
 		objects = append(objects, &models.ObjectMetadata{
 			ID:       row.ID,
-			MH:       row.MH.Float64,
-			MS:       row.MS.Float64,
-			MV:       row.MV.Float64,
-			MR:       row.MR.Float64,
-			MG:       row.MG.Float64,
-			MB:       row.MB.Float64,
-			LAvg:     row.LAvg.Float64,
-			WAvg:     row.WAvg.Float64,
-			BrtAvg:   row.BrtAvg.Float64,
-			RAvg:     row.RAvg.Float64,
-			GAvg:     row.GAvg.Float64,
-			BAvg:     row.BAvg.Float64,
-			HAvg:     row.HAvg.Float64,
-			SAvg:     row.SAvg.Float64,
-			VAvg:     row.VAvg.Float64,
-			H:        row.H.Float64,
-			S:        row.S.Float64,
-			V:        row.V.Float64,
-			HM:       row.HM.Float64,
-			SM:       row.SM.Float64,
-			VM:       row.VM.Float64,
-			RM:       row.RM.Float64,
-			GM:       row.GM.Float64,
-			BM:       row.BM.Float64,
-			BrtM:     row.BrtM.Float64,
-			WM:       row.WM.Float64,
-			LM:       row.LM.Float64,
-			L:        row.L.Float64,
-			W:        row.W.Float64,
-			LW:       row.LW.Float64,
-			Pr:       row.Pr.Float64,
-			Sq:       row.Sq.Float64,
-			Brt:      row.Brt.Float64,
-			R:        row.R.Float64,
-			G:        row.G.Float64,
-			B:        row.B.Float64,
-			Solid:    row.Solid.Float64,
-			MinH:     row.MinH.Float64,
-			MinS:     row.MinS.Float64,
-			MinV:     row.MinV.Float64,
-			MaxH:     row.MaxH.Float64,
-			MaxS:     row.MaxS.Float64,
-			MaxV:     row.MaxV.Float64,
-			Entropy:  row.Entropy.Float64,
+			MH:       float8Ptr(row.MH),
+			MS:       float8Ptr(row.MS),
+			MV:       float8Ptr(row.MV),
+			MR:       float8Ptr(row.MR),
+			MG:       float8Ptr(row.MG),
+			MB:       float8Ptr(row.MB),
+			LAvg:     float8Ptr(row.LAvg),
+			WAvg:     float8Ptr(row.WAvg),
+			BrtAvg:   float8Ptr(row.BrtAvg),
+			RAvg:     float8Ptr(row.RAvg),
+			GAvg:     float8Ptr(row.GAvg),
+			BAvg:     float8Ptr(row.BAvg),
+			HAvg:     float8Ptr(row.HAvg),
+			SAvg:     float8Ptr(row.SAvg),
+			VAvg:     float8Ptr(row.VAvg),
+			H:        float8Ptr(row.H),
+			S:        float8Ptr(row.S),
+			V:        float8Ptr(row.V),
+			HM:       float8Ptr(row.HM),
+			SM:       float8Ptr(row.SM),
+			VM:       float8Ptr(row.VM),
+			RM:       float8Ptr(row.RM),
+			GM:       float8Ptr(row.GM),
+			BM:       float8Ptr(row.BM),
+			BrtM:     float8Ptr(row.BrtM),
+			WM:       float8Ptr(row.WM),
+			LM:       float8Ptr(row.LM),
+			L:        float8Ptr(row.L),
+			W:        float8Ptr(row.W),
+			LW:       float8Ptr(row.LW),
+			Pr:       float8Ptr(row.Pr),
+			Sq:       float8Ptr(row.Sq),
+			Brt:      float8Ptr(row.Brt),
+			R:        float8Ptr(row.R),
+			G:        float8Ptr(row.G),
+			B:        float8Ptr(row.B),
+			Solid:    float8Ptr(row.Solid),
+			MinH:     float8Ptr(row.MinH),
+			MinS:     float8Ptr(row.MinS),
+			MinV:     float8Ptr(row.MinV),
+			MaxH:     float8Ptr(row.MaxH),
+			MaxS:     float8Ptr(row.MaxS),
+			MaxV:     float8Ptr(row.MaxV),
+			Entropy:  float8Ptr(row.Entropy),
 			ColorRhs: row.ColorRhs.String,
-			SqSqcrl:  row.SqSqcrl.Float64,
-			Hu1:      row.Hu1.Float64,
-			Hu2:      row.Hu2.Float64,
-			Hu3:      row.Hu3.Float64,
-			Hu4:      row.Hu4.Float64,
-			Hu5:      row.Hu5.Float64,
-			Hu6:      row.Hu6.Float64,
+			SqSqcrl:  float8Ptr(row.SqSqcrl),
+			Hu1:      float8Ptr(row.Hu1),
+			Hu2:      float8Ptr(row.Hu2),
+			Hu3:      float8Ptr(row.Hu3),
+			Hu4:      float8Ptr(row.Hu4),
+			Hu5:      float8Ptr(row.Hu5),
+			Hu6:      float8Ptr(row.Hu6),
 		})
 	}
 	return objects, nil
@@ -0,0 +1,507 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"csort.ru/analysis-service/internal/apperr"
+	"csort.ru/analysis-service/internal/logger"
+	"csort.ru/analysis-service/internal/models"
+	"csort.ru/analysis-service/internal/repository"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// DefaultProductLang is the language product names fall back to.
+const DefaultProductLang = "en"
+
+var productsLog = logger.GetLogger("services.products")
+
+var (
+	ErrInvalidProduct    = apperr.Validation("invalid_product", "invalid product")
+	ErrUnknownProduct    = apperr.Validation("unknown_product", "unknown product")
+	ErrProductNotAllowed = apperr.Forbidden("product_not_allowed", "product not allowed")
+	ErrProductNotFound   = apperr.NotFound("product_not_found", "product not found")
+	ErrProductExists     = apperr.Conflict("product_exists", "a product with this code already exists")
+)
+
+var productCodePattern = regexp.MustCompile(`^[a-z0-9_-]+$`)
+
+// reservedProductSettings are the analysis API form fields that product settings
+// must not override.
+var reservedProductSettings = []string{"product", "userID", "file"}
+
+type ProductsService struct {
+	repo *repository.Queries
+}
+
+func NewProductsService(repo *repository.Queries) *ProductsService {
+	return &ProductsService{
+		repo: repo,
+	}
+}
+
+// ListProducts returns the whole catalog, including inactive products.
+func (s *ProductsService) ListProducts(ctx context.Context, lang string) ([]models.Product, error) {
+	rows, err := s.repo.ListProducts(ctx)
+	if err != nil {
+		productsLog.Error().Err(err).Msg("Failed to list products")
+		return nil, err
+	}
+
+	products := make([]models.Product, 0, len(rows))
+	for _, row := range rows {
+		products = append(products, convertProductFromRepo(row, lang))
+	}
+	return products, nil
+}
+
+// ListForUser returns the active products a user may choose from: the ones they
+// are restricted to, or every active product if they aren't restricted.
+func (s *ProductsService) ListForUser(ctx context.Context, userID int64, lang string) ([]models.Product, error) {
+	rows, err := s.repo.ListProducts(ctx)
+	if err != nil {
+		productsLog.Error().Err(err).Msg("Failed to list products")
+		return nil, err
+	}
+	allowed, err := s.repo.ListUserProducts(ctx, strconv.FormatInt(userID, 10))
+	if err != nil {
+		productsLog.Error().Err(err).Int64("userID", userID).Msg("Failed to list user products")
+		return nil, err
+	}
+
+	products := make([]models.Product, 0, len(rows))
+	for _, row := range rows {
+		if !row.Active || (len(allowed) > 0 && !slices.Contains(allowed, row.Code)) {
+			continue
+		}
+		products = append(products, convertProductFromRepo(row, lang))
+	}
+	return products, nil
+}
+
+func (s *ProductsService) GetProduct(ctx context.Context, code, lang string) (models.Product, error) {
+	row, err := s.repo.GetProductByCode(ctx, code)
+	if err != nil {
+		return models.Product{}, notFound(err, ErrProductNotFound)
+	}
+	return convertProductFromRepo(row, lang), nil
+}
+
+func (s *ProductsService) CreateProduct(ctx context.Context, req models.ProductRequest) (models.Product, error) {
+	params, err := s.validateProductRequest(ctx, "", req)
+	if err != nil {
+		return models.Product{}, err
+	}
+
+	row, err := s.repo.CreateProduct(ctx, params)
+	if err != nil {
+		productsLog.Error().Err(err).Str("code", params.Code).Msg("Failed to create product")
+		return models.Product{}, conflict(err, ErrProductExists)
+	}
+	productsLog.Info().Str("code", row.Code).Msg("Product created")
+
+	return convertProductFromRepo(row, ""), nil
+}
+
+// UpdateProduct replaces the names, aliases, settings and state of a product. The
+// code is immutable, as analyses refer to it.
+func (s *ProductsService) UpdateProduct(ctx context.Context, code string, req models.ProductRequest) (models.Product, error) {
+	if req.Code == "" {
+		req.Code = code
+	}
+	params, err := s.validateProductRequest(ctx, code, req)
+	if err != nil {
+		return models.Product{}, err
+	}
+	if params.Code != code {
+		return models.Product{}, fmt.Errorf("%w: code cannot be changed", ErrInvalidProduct)
+	}
+
+	row, err := s.repo.UpdateProduct(ctx, repository.UpdateProductParams{
+		Names:    params.Names,
+		Aliases:  params.Aliases,
+		Settings: params.Settings,
+		Active:   params.Active,
+		Code:     code,
+	})
+	if err != nil {
+		return models.Product{}, notFound(err, ErrProductNotFound)
+	}
+
+	return convertProductFromRepo(row, ""), nil
+}
+
+// DeleteProduct removes a product from the catalog. Analyses keep their product
+// code; deactivating the product is usually what's wanted instead.
+func (s *ProductsService) DeleteProduct(ctx context.Context, code string) error {
+	affected, err := s.repo.DeleteProduct(ctx, code)
+	if err != nil {
+		productsLog.Error().Err(err).Str("code", code).Msg("Failed to delete product")
+		return err
+	}
+	if affected == 0 {
+		return ErrProductNotFound
+	}
+	return nil
+}
+
+func (s *ProductsService) GetUserProducts(ctx context.Context, userID string) ([]string, error) {
+	codes, err := s.repo.ListUserProducts(ctx, userID)
+	if err != nil {
+		productsLog.Error().Err(err).Str("userID", userID).Msg("Failed to list user products")
+		return nil, err
+	}
+	if codes == nil {
+		codes = []string{}
+	}
+	return codes, nil
+}
+
+// SetUserProducts replaces the products a user is restricted to. Every code must
+// be in the catalog.
+func (s *ProductsService) SetUserProducts(ctx context.Context, userID string, codes []string) ([]string, error) {
+	catalog, err := s.loadCatalog(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	unique := make([]string, 0, len(codes))
+	for _, code := range codes {
+		code = strings.TrimSpace(code)
+		if _, ok := catalog.products[code]; !ok {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownProduct, code)
+		}
+		if !slices.Contains(unique, code) {
+			unique = append(unique, code)
+		}
+	}
+
+	if err := s.repo.SetUserProducts(ctx, repository.SetUserProductsParams{
+		IDUser: userID,
+		Codes:  unique,
+	}); err != nil {
+		productsLog.Error().Err(err).Str("userID", userID).Msg("Failed to set user products")
+		return nil, err
+	}
+	productsLog.Info().Str("userID", userID).Strs("products", unique).Msg("User products updated")
+
+	return s.GetUserProducts(ctx, userID)
+}
+
+// Resolve maps a product entered by a user, by code, alias or localized name, onto
+// the catalog and checks that the user may choose it. Until the catalog is set up
+// the trimmed value is passed through as is.
+func (s *ProductsService) Resolve(ctx context.Context, userID, value string) (models.Product, error) {
+	row, found, err := s.lookup(ctx, value)
+	if err != nil || !found {
+		return models.Product{Code: row.Code}, err
+	}
+
+	if !row.Active {
+		return models.Product{}, fmt.Errorf("%w: %s is no longer offered", ErrProductNotAllowed, row.Code)
+	}
+	allowed, err := s.repo.ListUserProducts(ctx, userID)
+	if err != nil {
+		productsLog.Error().Err(err).Str("userID", userID).Msg("Failed to list user products")
+		return models.Product{}, err
+	}
+	if len(allowed) > 0 && !slices.Contains(allowed, row.Code) {
+		return models.Product{}, fmt.Errorf("%w: %s", ErrProductNotAllowed, row.Code)
+	}
+
+	return convertProductFromRepo(row, ""), nil
+}
+
+// Canonical maps a product onto its catalog code without checking who may use it,
+// so inactive products are accepted too.
+func (s *ProductsService) Canonical(ctx context.Context, value string) (string, error) {
+	row, _, err := s.lookup(ctx, value)
+	return row.Code, err
+}
+
+// CanonicalFilter maps a product filter onto its catalog code, so filtering by a
+// name or alias finds the analyses stored under the code. A value that matches no
+// product is kept as is, as values the catalog doesn't cover are left on old rows.
+func (s *ProductsService) CanonicalFilter(ctx context.Context, value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", nil
+	}
+	code, err := s.Canonical(ctx, value)
+	if errors.Is(err, ErrUnknownProduct) {
+		return value, nil
+	}
+	return code, err
+}
+
+// lookup finds the catalog product matching value. found is false, with the
+// trimmed value as Code, if the catalog is empty.
+func (s *ProductsService) lookup(ctx context.Context, value string) (row repository.Product, found bool, err error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return repository.Product{}, false, fmt.Errorf("%w: product is required", ErrInvalidProduct)
+	}
+
+	catalog, err := s.loadCatalog(ctx)
+	if err != nil {
+		return repository.Product{}, false, err
+	}
+	if len(catalog.products) == 0 {
+		return repository.Product{Code: value}, false, nil
+	}
+
+	row, ok := catalog.lookup(value)
+	if !ok {
+		return repository.Product{}, false, fmt.Errorf("%w: %q", ErrUnknownProduct, value)
+	}
+	return row, true, nil
+}
+
+// Normalize maps every free-text product value on analyses, lots and specs onto
+// the catalog and rewrites it to the product code, unless dryRun is set. Values
+// that match no product are reported and left alone.
+func (s *ProductsService) Normalize(ctx context.Context, dryRun bool) (models.ProductNormalizeSummary, error) {
+	catalog, err := s.loadCatalog(ctx)
+	if err != nil {
+		return models.ProductNormalizeSummary{}, err
+	}
+	values, err := s.repo.ListProductValues(ctx)
+	if err != nil {
+		productsLog.Error().Err(err).Msg("Failed to list product values")
+		return models.ProductNormalizeSummary{}, err
+	}
+
+	summary := models.ProductNormalizeSummary{
+		Values:   len(values),
+		Unknown:  []models.ProductMapping{},
+		Mappings: []models.ProductMapping{},
+	}
+	for _, value := range values {
+		if _, ok := catalog.products[value.Product]; ok {
+			continue
+		}
+
+		mapping := models.ProductMapping{Value: value.Product, Uses: value.Uses}
+		row, ok := catalog.lookup(value.Product)
+		if !ok {
+			summary.Unknown = append(summary.Unknown, mapping)
+			continue
+		}
+		mapping.Code = row.Code
+
+		if !dryRun {
+			renamed, err := s.repo.RenameProduct(ctx, repository.RenameProductParams{
+				Code:    pgtype.Text{String: row.Code, Valid: true},
+				Product: pgtype.Text{String: value.Product, Valid: true},
+			})
+			if err != nil {
+				productsLog.Error().Err(err).Str("value", value.Product).Str("code", row.Code).Msg("Failed to rename product")
+				return summary, err
+			}
+			mapping.Analyses = renamed.Analyses
+			mapping.Lots = renamed.Lots
+			mapping.Specs = renamed.Specs
+		}
+		summary.Mapped++
+		summary.Mappings = append(summary.Mappings, mapping)
+	}
+
+	return summary, nil
+}
+
+// productCatalog indexes products by code and by every normalized code, alias and
+// name, so user input can be matched regardless of case, spacing or language.
+type productCatalog struct {
+	products map[string]repository.Product
+	keys     map[string]string
+}
+
+func (s *ProductsService) loadCatalog(ctx context.Context) (*productCatalog, error) {
+	rows, err := s.repo.ListProducts(ctx)
+	if err != nil {
+		productsLog.Error().Err(err).Msg("Failed to list products")
+		return nil, err
+	}
+
+	catalog := &productCatalog{
+		products: make(map[string]repository.Product, len(rows)),
+		keys:     make(map[string]string, len(rows)),
+	}
+	for _, row := range rows {
+		catalog.products[row.Code] = row
+		for _, key := range productKeys(row.Code, row.Aliases, decodeProductNames(row.Names)) {
+			catalog.keys[key] = row.Code
+		}
+	}
+	return catalog, nil
+}
+
+func (c *productCatalog) lookup(value string) (repository.Product, bool) {
+	code, ok := c.keys[productKey(value)]
+	if !ok {
+		return repository.Product{}, false
+	}
+	return c.products[code], true
+}
+
+// productKey normalizes a product value for matching: lower case, single spaces.
+func productKey(value string) string {
+	return strings.Join(strings.Fields(strings.ToLower(value)), " ")
+}
+
+func productKeys(code string, aliases []string, names map[string]string) []string {
+	keys := []string{productKey(code)}
+	for _, alias := range aliases {
+		keys = append(keys, productKey(alias))
+	}
+	for _, name := range names {
+		keys = append(keys, productKey(name))
+	}
+	return keys
+}
+
+// validateProductRequest normalizes a product request and checks that none of its
+// code, aliases and names already match another product. current is the code of
+// the product being updated, if any.
+func (s *ProductsService) validateProductRequest(ctx context.Context, current string, req models.ProductRequest) (repository.CreateProductParams, error) {
+	params := repository.CreateProductParams{
+		Code:    strings.ToLower(strings.TrimSpace(req.Code)),
+		Aliases: []string{},
+		Active:  req.Active == nil || *req.Active,
+	}
+	if !productCodePattern.MatchString(params.Code) {
+		return params, fmt.Errorf("%w: code must consist of lower-case letters, digits, - and _", ErrInvalidProduct)
+	}
+
+	names := make(map[string]string, len(req.Names))
+	for lang, name := range req.Names {
+		lang = strings.ToLower(strings.TrimSpace(lang))
+		name = strings.TrimSpace(name)
+		if lang == "" || name == "" {
+			return params, fmt.Errorf("%w: names must map a language to a non-empty name", ErrInvalidProduct)
+		}
+		names[lang] = name
+	}
+	for _, alias := range req.Aliases {
+		alias = productKey(alias)
+		if alias == "" {
+			return params, fmt.Errorf("%w: aliases must not be empty", ErrInvalidProduct)
+		}
+		if !slices.Contains(params.Aliases, alias) {
+			params.Aliases = append(params.Aliases, alias)
+		}
+	}
+	for key, value := range req.Settings {
+		if slices.Contains(reservedProductSettings, key) {
+			return params, fmt.Errorf("%w: setting %q is reserved", ErrInvalidProduct, key)
+		}
+		switch value.(type) {
+		case string, float64, bool:
+		default:
+			return params, fmt.Errorf("%w: setting %q must be a string, number or boolean", ErrInvalidProduct, key)
+		}
+	}
+
+	var err error
+	if params.Names, err = json.Marshal(names); err != nil {
+		return params, err
+	}
+	settings := req.Settings
+	if settings == nil {
+		settings = map[string]any{}
+	}
+	if params.Settings, err = json.Marshal(settings); err != nil {
+		return params, err
+	}
+
+	catalog, err := s.loadCatalog(ctx)
+	if err != nil {
+		return params, err
+	}
+	for _, key := range productKeys(params.Code, params.Aliases, names) {
+		if code, ok := catalog.keys[key]; ok && code != current && code != params.Code {
+			return params, fmt.Errorf("%w: %q already refers to %s", ErrInvalidProduct, key, code)
+		}
+	}
+
+	return params, nil
+}
+
+// productFormFields formats product settings as analysis API form fields, in key order.
+func productFormFields(settings map[string]any) [][2]string {
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	fields := make([][2]string, 0, len(keys))
+	for _, key := range keys {
+		var value string
+		switch v := settings[key].(type) {
+		case string:
+			value = v
+		case float64:
+			value = strconv.FormatFloat(v, 'f', -1, 64)
+		case bool:
+			value = strconv.FormatBool(v)
+		default:
+			continue
+		}
+		fields = append(fields, [2]string{key, value})
+	}
+	return fields
+}
+
+func decodeProductNames(raw json.RawMessage) map[string]string {
+	names := map[string]string{}
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &names); err != nil {
+			productsLog.Warn().Err(err).Msg("Failed to decode product names")
+		}
+	}
+	return names
+}
+
+// localizedProductName picks the name for lang, trying its base language (ru for
+// ru-RU) and DefaultProductLang before falling back to the code.
+func localizedProductName(code string, names map[string]string, lang string) string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	base, _, _ := strings.Cut(lang, "-")
+	for _, candidate := range []string{lang, base, DefaultProductLang} {
+		if name, ok := names[candidate]; ok {
+			return name
+		}
+	}
+	return code
+}
+
+func convertProductFromRepo(row repository.Product, lang string) models.Product {
+	product := models.Product{
+		ID:        row.ID,
+		Code:      row.Code,
+		Names:     decodeProductNames(row.Names),
+		Aliases:   row.Aliases,
+		Settings:  map[string]any{},
+		Active:    row.Active,
+		CreatedAt: row.CreatedAt,
+		UpdatedAt: row.UpdatedAt,
+	}
+	product.Name = localizedProductName(row.Code, product.Names, lang)
+	if product.Aliases == nil {
+		product.Aliases = []string{}
+	}
+	if len(row.Settings) > 0 {
+		if err := json.Unmarshal(row.Settings, &product.Settings); err != nil {
+			productsLog.Warn().Err(err).Str("code", row.Code).Msg("Failed to decode product settings")
+		}
+	}
+	return product
+}
@@ -0,0 +1,301 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"csort.ru/analysis-service/internal/apperr"
+	"csort.ru/analysis-service/internal/logger"
+	"csort.ru/analysis-service/internal/models"
+	"csort.ru/analysis-service/internal/repository"
+	"csort.ru/analysis-service/pkg/pdf"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var reportLog = logger.GetLogger("services.report")
+
+var ErrUnknownReportTemplate = apperr.Validation("unknown_report_template", "unknown report template")
+
+//go:embed templates/report.tmpl
+var defaultReportTemplate string
+
+const (
+	reportHistogramBins = 20
+	reportImageMaxSide  = 800
+	reportImageTimeout  = 15 * time.Second
+)
+
+var reportTemplateName = regexp.MustCompile(`^[a-z0-9_-]+$`)
+
+// reportVerdictColors are the badge colors of the verdicts in the report.
+var reportVerdictColors = map[string]string{
+	models.VerdictPass: "#2e7d32",
+	models.VerdictWarn: "#ef6c00",
+	models.VerdictFail: "#c62828",
+}
+
+type ReportService struct {
+	repo         *repository.Queries
+	specs        *SpecsService
+	composition  *CompositionService
+	templatesDir string
+	files        FileRefs
+}
+
+// NewReportService creates a report service. templatesDir holds organization
+// templates and may be empty. Output images are read through files.
+func NewReportService(repo *repository.Queries, specs *SpecsService, composition *CompositionService, templatesDir string, files FileRefs) *ReportService {
+	return &ReportService{
+		repo:         repo,
+		specs:        specs,
+		composition:  composition,
+		templatesDir: templatesDir,
+		files:        files,
+	}
+}
+
+// RenderAnalysisReport renders the PDF certificate of an analysis with the named
+// template, or the built-in one if templateName is empty.
+func (s *ReportService) RenderAnalysisReport(ctx context.Context, ref models.AnalysisRef, templateName string) ([]byte, error) {
+	source, logoPath, err := s.loadTemplate(templateName)
+	if err != nil {
+		return nil, err
+	}
+
+	repoAnalysis, err := resolveAnalysis(ctx, s.repo, ref)
+	if err != nil {
+		return nil, err
+	}
+	analysisID := repoAnalysis.IDAnalysis.String
+	repoObjects, err := s.repo.GetObjectsByAnalysisID(ctx, pgtype.Int8{Int64: int64(repoAnalysis.ID), Valid: true})
+	if err != nil {
+		reportLog.Error().Err(err).Str("analysisID", analysisID).Msg("Failed to get objects")
+		return nil, err
+	}
+	if err := applyEffectiveLabels(ctx, s.repo, repoObjects); err != nil {
+		return nil, err
+	}
+
+	analysis := convertAnalysisFromRepo(repoAnalysis)
+	analysis.Objects = make([]models.Object, 0, len(repoObjects))
+	for _, repoObject := range repoObjects {
+		analysis.Objects = append(analysis.Objects, convertObjectFromRepo(repoObject))
+	}
+
+	composition, err := s.composition.GetAnalysisComposition(ctx, models.AnalysisRef{ID: analysisID})
+	if err != nil {
+		return nil, err
+	}
+
+	data := models.ReportData{
+		Analysis:    analysis,
+		Stats:       reportStats(&repoAnalysis),
+		Composition: composition,
+		GeneratedAt: time.Now(),
+	}
+
+	// Like the analysis endpoint, a missing verdict shouldn't prevent the report
+	verdict, err := s.specs.GetVerdict(ctx, analysis.ID)
+	if err != nil {
+		reportLog.Warn().Err(err).Str("analysisID", analysisID).Msg("Failed to get analysis verdict")
+	} else {
+		data.Verdict = verdict
+	}
+
+	doc := pdf.New()
+	layout := pdf.NewLayout(doc)
+
+	// Images are optional, the report is still useful without them
+	if analysis.FileOutput != "" {
+		if img, err := s.loadImage(ctx, analysis.FileOutput); err != nil {
+			reportLog.Warn().Err(err).Str("analysisID", analysisID).Msg("Failed to load output image for report")
+		} else if thumbnail, err := doc.AddImage(img, reportImageMaxSide); err != nil {
+			reportLog.Warn().Err(err).Str("analysisID", analysisID).Msg("Failed to embed output image in report")
+		} else {
+			layout.RegisterImage("output", thumbnail)
+			data.HasImage = true
+		}
+	}
+	if logoPath != "" {
+		if img, err := s.loadImage(ctx, logoPath); err == nil {
+			if logo, err := doc.AddImage(img, reportImageMaxSide); err == nil {
+				layout.RegisterImage("logo", logo)
+				data.HasLogo = true
+			}
+		}
+	}
+
+	tmpl, err := template.New("report").Funcs(reportFuncs(repoObjects)).Parse(source)
+	if err != nil {
+		reportLog.Error().Err(err).Str("template", templateName).Msg("Failed to parse report template")
+		return nil, err
+	}
+	var markup bytes.Buffer
+	if err := tmpl.Execute(&markup, data); err != nil {
+		reportLog.Error().Err(err).Str("template", templateName).Msg("Failed to execute report template")
+		return nil, err
+	}
+
+	if err := layout.Render(markup.String()); err != nil {
+		reportLog.Error().Err(err).Str("template", templateName).Msg("Failed to lay out report")
+		return nil, err
+	}
+	layout.Finish()
+
+	var out bytes.Buffer
+	if _, err := doc.WriteTo(&out); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// loadTemplate returns the template source and, for organization templates, the
+// path of its logo if there is one.
+func (s *ReportService) loadTemplate(name string) (string, string, error) {
+	if name == "" || name == "default" {
+		return defaultReportTemplate, "", nil
+	}
+	if s.templatesDir == "" || !reportTemplateName.MatchString(name) {
+		return "", "", fmt.Errorf("%w: %q", ErrUnknownReportTemplate, name)
+	}
+
+	source, err := os.ReadFile(filepath.Join(s.templatesDir, name+".tmpl"))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", "", fmt.Errorf("%w: %q", ErrUnknownReportTemplate, name)
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	for _, ext := range []string{".png", ".jpg", ".jpeg"} {
+		logoPath := filepath.Join(s.templatesDir, name+ext)
+		if _, err := os.Stat(logoPath); err == nil {
+			return string(source), logoPath, nil
+		}
+	}
+	return string(source), "", nil
+}
+
+// loadImage reads an image from an HTTP(S) URL or a file path.
+func (s *ReportService) loadImage(ctx context.Context, ref string) (image.Image, error) {
+	data, err := s.files.read(ctx, ref, reportImageTimeout)
+	if err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	return img, err
+}
+
+// reportStats lists the Stats channels that have a stored value.
+func reportStats(a *repository.Analysis) []models.ReportStats {
+	columns := analysisStatsColumns(a)
+	var rows []models.ReportStats
+	for _, channel := range analysisStatsChannels {
+		stats, status := decodeStoredStats(*columns[channel])
+		if status != models.StatsOK {
+			continue
+		}
+		row := models.ReportStats{Channel: channel, Stats: stats}
+		if statsQuantity(channel) == quantityLength {
+			row.Unit = "mm"
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// reportHistogram bins the non-null values of a feature into equal-width bins.
+func reportHistogram(objects []repository.Object, feature string, bins int) (*models.ReportHistogram, error) {
+	value, ok := objectFeatures[feature]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownFeature, feature)
+	}
+
+	histogram := &models.ReportHistogram{Feature: feature, Counts: make([]int, bins), Min: math.Inf(1), Max: math.Inf(-1)}
+	values := make([]float64, 0, len(objects))
+	for i := range objects {
+		if v := value(&objects[i]); v.Valid && !math.IsNaN(v.Float64) {
+			values = append(values, v.Float64)
+			histogram.Min = math.Min(histogram.Min, v.Float64)
+			histogram.Max = math.Max(histogram.Max, v.Float64)
+		}
+	}
+	histogram.Objects = len(values)
+	if len(values) == 0 {
+		histogram.Min, histogram.Max = 0, 0
+		return histogram, nil
+	}
+
+	width := (histogram.Max - histogram.Min) / float64(bins)
+	for _, v := range values {
+		bin := 0
+		if width > 0 {
+			bin = min(int((v-histogram.Min)/width), bins-1)
+		}
+		histogram.Counts[bin]++
+	}
+	return histogram, nil
+}
+
+// reportFuncs are the helpers available to report templates.
+func reportFuncs(objects []repository.Object) template.FuncMap {
+	return template.FuncMap{
+		"histogram": func(feature string) (*models.ReportHistogram, error) {
+			return reportHistogram(objects, feature, reportHistogramBins)
+		},
+		"list": func(values ...string) []string {
+			return values
+		},
+		"num": func(v interface{}, digits int) string {
+			switch n := v.(type) {
+			case *float64:
+				if n == nil {
+					return "n/a"
+				}
+				return strconv.FormatFloat(*n, 'f', digits, 64)
+			case float64:
+				return strconv.FormatFloat(n, 'f', digits, 64)
+			case float32:
+				return strconv.FormatFloat(float64(n), 'f', digits, 32)
+			}
+			return fmt.Sprint(v)
+		},
+		"pct": func(share float64) string {
+			return strconv.FormatFloat(share*100, 'f', 1, 64) + "%"
+		},
+		"join": func(counts []int) string {
+			parts := make([]string, len(counts))
+			for i, c := range counts {
+				parts[i] = strconv.Itoa(c)
+			}
+			return strings.Join(parts, ",")
+		},
+		"date": func(t time.Time) string {
+			return t.Format("2006-01-02 15:04")
+		},
+		// esc keeps free text from breaking the line and argument structure of the markup
+		"esc": func(s string) string {
+			return strings.NewReplacer("|", "/", "\n", " ", "\r", " ").Replace(s)
+		},
+		"upper": strings.ToUpper,
+		"verdictColor": func(verdict string) string {
+			if color, ok := reportVerdictColors[verdict]; ok {
+				return color
+			}
+			return "#6e6e6e"
+		},
+	}
+}
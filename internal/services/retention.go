@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"csort.ru/analysis-service/internal/logger"
+	"csort.ru/analysis-service/internal/models"
+	"csort.ru/analysis-service/internal/repository"
+	"github.com/jackc/pgx/v5"
+)
+
+const retentionBatch = 100
+
+var retentionLog = logger.GetLogger("services.retention")
+
+// RetentionService purges soft-deleted analyses once they have been deleted for
+// longer than the retention period: their rows, objects and stored files.
+type RetentionService struct {
+	repo       *repository.Queries
+	period     time.Duration
+	files      FileRefs
+	output     FileRefs
+	similarity *SimilarityService
+}
+
+// NewRetentionService creates the retention job. Source images and object crops
+// are removed through files, output images through output, and purged objects
+// are dropped from the similarity index.
+func NewRetentionService(repo *repository.Queries, period time.Duration, files, output FileRefs, similarity *SimilarityService) *RetentionService {
+	return &RetentionService{
+		repo:       repo,
+		period:     period,
+		files:      files,
+		output:     output,
+		similarity: similarity,
+	}
+}
+
+// Run purges expired analyses now and then every interval until ctx is done.
+// With a non-positive period or interval soft-deleted analyses are kept forever.
+func (s *RetentionService) Run(ctx context.Context, interval time.Duration) {
+	if s.period <= 0 || interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := s.Purge(ctx); err != nil && ctx.Err() == nil {
+			retentionLog.Error().Err(err).Msg("Analysis retention run failed")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Purge hard-deletes every analysis soft-deleted before the retention period.
+func (s *RetentionService) Purge(ctx context.Context) (models.RetentionSummary, error) {
+	var summary models.RetentionSummary
+	cutoff := time.Now().Add(-s.period)
+
+	for {
+		ids, err := s.repo.ListExpiredAnalyses(ctx, repository.ListExpiredAnalysesParams{
+			DeletedBefore: cutoff,
+			Limit:         retentionBatch,
+		})
+		if err != nil {
+			return summary, err
+		}
+
+		for _, id := range ids {
+			purged, err := s.repo.PurgeAnalysis(ctx, repository.PurgeAnalysisParams{
+				ID:            id,
+				DeletedBefore: cutoff,
+			})
+			if errors.Is(err, pgx.ErrNoRows) {
+				// Restored since it was listed
+				continue
+			}
+			if err != nil {
+				return summary, err
+			}
+			summary.Purged++
+			summary.FilesRemoved += s.removeFiles(purged)
+			s.similarity.Remove(purged.ObjectIds)
+			retentionLog.Info().Int32("id", purged.ID).Str("idAnalysis", purged.IDAnalysis.String).Msg("Analysis purged")
+		}
+
+		if len(ids) < retentionBatch {
+			break
+		}
+	}
+
+	if summary.Purged > 0 {
+		retentionLog.Info().Int("purged", summary.Purged).Int("filesRemoved", summary.FilesRemoved).Msg("Analysis retention run finished")
+	}
+	return summary, nil
+}
+
+// removeFiles deletes the stored files of a purged analysis. The rows are already
+// gone, so a file that can't be removed is only logged.
+func (s *RetentionService) removeFiles(purged repository.PurgeAnalysisRow) int {
+	type fileRef struct {
+		ref   string
+		files FileRefs
+	}
+	refs := []fileRef{{purged.FileSource.String, s.files}, {purged.FileOutput.String, s.output}}
+	for _, file := range purged.ObjectFiles {
+		refs = append(refs, fileRef{file, s.files})
+	}
+
+	removed := 0
+	for _, file := range refs {
+		if file.ref == "" || isRemoteFileRef(file.ref) {
+			continue
+		}
+		if err := file.files.remove(file.ref); err != nil {
+			retentionLog.Warn().Err(err).Int32("id", purged.ID).Str("file", file.ref).Msg("Failed to remove file of purged analysis")
+			continue
+		}
+		removed++
+	}
+	return removed
+}
@@ -0,0 +1,696 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"csort.ru/analysis-service/internal/apperr"
+	"csort.ru/analysis-service/internal/logger"
+	"csort.ru/analysis-service/internal/models"
+	"csort.ru/analysis-service/internal/repository"
+	"csort.ru/analysis-service/pkg/columnar"
+	"csort.ru/analysis-service/pkg/parquet"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var reviewLog = logger.GetLogger("services.review")
+
+var (
+	ErrInvalidReview      = apperr.Validation("invalid_review", "invalid review request")
+	ErrReviewItemNotFound = apperr.NotFound("review_item_not_found", "review item not found")
+	ErrReviewQueueEmpty   = apperr.NotFound("review_queue_empty", "no items left to review")
+	ErrNoObjectImage      = apperr.NotFound("image_not_found", "object has no image")
+	ErrImageNotFound      = apperr.NotFound("image_not_found", "image not found")
+)
+
+const (
+	defaultReviewLimit       = 500
+	maxReviewLimit           = 10000
+	defaultReviewPerAnalysis = 5
+	defaultReviewMinShare    = 0.05
+	maxRequiredReviews       = 10
+	reviewExportPageSize     = 1000
+	reviewImageTimeout       = 15 * time.Second
+)
+
+type ReviewService struct {
+	repo     *repository.Queries
+	anomaly  *AnomalyService
+	products *ProductsService
+	files    FileRefs
+}
+
+// NewReviewService creates a review service. Object crops are read through files.
+func NewReviewService(repo *repository.Queries, anomaly *AnomalyService, products *ProductsService, files FileRefs) *ReviewService {
+	return &ReviewService{
+		repo:     repo,
+		anomaly:  anomaly,
+		products: products,
+		files:    files,
+	}
+}
+
+// reviewCandidate is an object selected by a strategy, with its priority score.
+type reviewCandidate struct {
+	objectID int32
+	score    float64
+}
+
+// Populate selects objects of the requested analyses with a strategy and adds the
+// ones that aren't queued yet to the review queue.
+func (s *ReviewService) Populate(ctx context.Context, req models.ReviewPopulateRequest) (models.ReviewPopulateResponse, error) {
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultReviewLimit
+	}
+	if limit < 0 || limit > maxReviewLimit {
+		return models.ReviewPopulateResponse{}, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidReview, maxReviewLimit)
+	}
+	requiredReviews := req.RequiredReviews
+	if requiredReviews == 0 {
+		requiredReviews = 1
+	}
+	if requiredReviews < 0 || requiredReviews > maxRequiredReviews {
+		return models.ReviewPopulateResponse{}, fmt.Errorf("%w: required_reviews must be between 1 and %d", ErrInvalidReview, maxRequiredReviews)
+	}
+	if req.PerAnalysis < 0 || req.Threshold < 0 || req.MinShare < 0 || req.MinShare > 1 {
+		return models.ReviewPopulateResponse{}, fmt.Errorf("%w: per_analysis, threshold and min_share must be positive", ErrInvalidReview)
+	}
+
+	analyses, err := s.reviewAnalyses(ctx, req)
+	if err != nil {
+		return models.ReviewPopulateResponse{}, err
+	}
+
+	var candidates []reviewCandidate
+	switch req.Strategy {
+	case models.ReviewAnomaly:
+		candidates, err = s.selectAnomalies(ctx, analyses, req.Threshold)
+	case models.ReviewRareClass:
+		candidates, err = s.selectRareClasses(ctx, analyses, req.MinShare)
+	case models.ReviewRandom:
+		candidates, err = s.selectRandom(ctx, analyses, req.PerAnalysis)
+	default:
+		return models.ReviewPopulateResponse{}, fmt.Errorf("%w: unknown strategy %q", ErrInvalidReview, req.Strategy)
+	}
+	if err != nil {
+		return models.ReviewPopulateResponse{}, err
+	}
+
+	// Highest scores first, so the limit keeps the most useful objects
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].score > candidates[j].score })
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+
+	response := models.ReviewPopulateResponse{
+		Strategy: req.Strategy,
+		Analyses: len(analyses),
+		Selected: len(candidates),
+	}
+	if len(candidates) == 0 {
+		return response, nil
+	}
+
+	ids := make([]int32, len(candidates))
+	scores := make([]float64, len(candidates))
+	for i, candidate := range candidates {
+		ids[i] = candidate.objectID
+		scores[i] = candidate.score
+	}
+	response.Enqueued, err = s.repo.EnqueueReviewItems(ctx, repository.EnqueueReviewItemsParams{
+		ObjectIds:       ids,
+		Strategy:        req.Strategy,
+		Scores:          scores,
+		RequiredReviews: requiredReviews,
+	})
+	if err != nil {
+		reviewLog.Error().Err(err).Str("strategy", req.Strategy).Msg("Failed to enqueue review items")
+		return models.ReviewPopulateResponse{}, err
+	}
+
+	reviewLog.Info().Str("strategy", req.Strategy).Int("selected", response.Selected).Int64("enqueued", response.Enqueued).Msg("Populated review queue")
+	return response, nil
+}
+
+func (s *ReviewService) reviewAnalyses(ctx context.Context, req models.ReviewPopulateRequest) ([]repository.Analysis, error) {
+	if len(req.Analyses) == 0 {
+		product, err := s.products.CanonicalFilter(ctx, req.Product)
+		if err != nil {
+			return nil, err
+		}
+		if product == "" {
+			return nil, fmt.Errorf("%w: analyses or product is required", ErrInvalidReview)
+		}
+		return s.repo.GetAnalysesByProduct(ctx, pgtype.Text{String: product, Valid: true})
+	}
+
+	analyses := make([]repository.Analysis, 0, len(req.Analyses))
+	for _, id := range req.Analyses {
+		analysis, err := s.repo.GetAnalysisByID(ctx, pgtype.Text{String: id, Valid: true})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%w: analysis %q not found", ErrInvalidReview, id)
+		}
+		if err != nil {
+			return nil, err
+		}
+		analyses = append(analyses, analysis)
+	}
+	return analyses, nil
+}
+
+// selectAnomalies takes the objects flagged with the default anomaly features,
+// scored by their robust z-score.
+func (s *ReviewService) selectAnomalies(ctx context.Context, analyses []repository.Analysis, threshold float64) ([]reviewCandidate, error) {
+	var candidates []reviewCandidate
+	for _, analysis := range analyses {
+		report, err := s.anomaly.DetectAnomalies(ctx, models.AnalysisRef{ID: analysis.IDAnalysis.String}, models.AnomalyRequest{Threshold: threshold})
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range report.Items {
+			candidates = append(candidates, reviewCandidate{objectID: item.ObjectID, score: item.Score})
+		}
+	}
+	return candidates, nil
+}
+
+// selectRareClasses takes the objects whose effective class makes up less than
+// minShare of the selected analyses, rarest first.
+func (s *ReviewService) selectRareClasses(ctx context.Context, analyses []repository.Analysis, minShare float64) ([]reviewCandidate, error) {
+	if minShare == 0 {
+		minShare = defaultReviewMinShare
+	}
+
+	var objects []reviewCandidate
+	var classes []string
+	counts := make(map[string]int)
+	for _, analysis := range analyses {
+		repoObjects, err := s.analysisObjects(ctx, analysis.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, object := range repoObjects {
+			class := object.Class.String
+			objects = append(objects, reviewCandidate{objectID: object.ID})
+			classes = append(classes, class)
+			counts[class]++
+		}
+	}
+
+	var candidates []reviewCandidate
+	for i, candidate := range objects {
+		share := float64(counts[classes[i]]) / float64(len(objects))
+		if share < minShare {
+			candidate.score = 1 - share
+			candidates = append(candidates, candidate)
+		}
+	}
+	return candidates, nil
+}
+
+// selectRandom samples perAnalysis objects of every analysis.
+func (s *ReviewService) selectRandom(ctx context.Context, analyses []repository.Analysis, perAnalysis int) ([]reviewCandidate, error) {
+	if perAnalysis == 0 {
+		perAnalysis = defaultReviewPerAnalysis
+	}
+
+	var candidates []reviewCandidate
+	for _, analysis := range analyses {
+		repoObjects, err := s.analysisObjects(ctx, analysis.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, i := range rand.Perm(len(repoObjects))[:min(perAnalysis, len(repoObjects))] {
+			candidates = append(candidates, reviewCandidate{objectID: repoObjects[i].ID})
+		}
+	}
+	return candidates, nil
+}
+
+func (s *ReviewService) analysisObjects(ctx context.Context, analysisID int32) ([]repository.Object, error) {
+	repoObjects, err := s.repo.GetObjectsByAnalysisID(ctx, pgtype.Int8{Int64: int64(analysisID), Valid: true})
+	if err != nil {
+		reviewLog.Error().Err(err).Int32("analysisID", analysisID).Msg("Failed to get objects")
+		return nil, err
+	}
+	if err := applyEffectiveLabels(ctx, s.repo, repoObjects); err != nil {
+		return nil, err
+	}
+	return repoObjects, nil
+}
+
+// Next returns the oldest pending item the user hasn't answered yet, optionally
+// only of one strategy.
+func (s *ReviewService) Next(ctx context.Context, userID int64, strategy string) (models.ReviewItem, error) {
+	row, err := s.repo.GetNextReviewItem(ctx, repository.GetNextReviewItemParams{
+		Strategy: strings.TrimSpace(strategy),
+		IDUser:   strconv.FormatInt(userID, 10),
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.ReviewItem{}, ErrReviewQueueEmpty
+	}
+	if err != nil {
+		reviewLog.Error().Err(err).Int64("userID", userID).Msg("Failed to get next review item")
+		return models.ReviewItem{}, err
+	}
+
+	object, err := s.repo.GetObjectByID(ctx, row.ObjectID)
+	if err != nil {
+		return models.ReviewItem{}, notFound(err, ErrObjectNotFound)
+	}
+	repoObjects := []repository.Object{object}
+	if err := applyEffectiveLabels(ctx, s.repo, repoObjects); err != nil {
+		return models.ReviewItem{}, err
+	}
+
+	features := make(map[string]float64, len(objectFeatureNames))
+	for _, name := range objectFeatureNames {
+		if v := objectFeatures[name](&repoObjects[0]); v.Valid {
+			features[name] = v.Float64
+		}
+	}
+
+	return models.ReviewItem{
+		ID:              row.ID,
+		Strategy:        row.Strategy,
+		Score:           row.Score,
+		Status:          row.Status,
+		RequiredReviews: row.RequiredReviews,
+		CreatedAt:       row.CreatedAt,
+		Object:          convertObjectFromRepo(repoObjects[0]),
+		Features:        features,
+	}, nil
+}
+
+// Submit records the user's label of an item, or that they skipped it, and marks
+// the item done once it has enough labels. Answering again replaces the answer.
+func (s *ReviewService) Submit(ctx context.Context, userID int64, itemID int32, req models.ReviewSubmitRequest) (models.ReviewLabel, error) {
+	class := strings.TrimSpace(req.Class)
+	if class == "" && !req.Skip {
+		return models.ReviewLabel{}, fmt.Errorf("%w: class is required unless the item is skipped", ErrInvalidReview)
+	}
+	if req.Skip {
+		class = ""
+	}
+
+	if _, err := s.repo.GetReviewItem(ctx, itemID); err != nil {
+		return models.ReviewLabel{}, notFound(err, ErrReviewItemNotFound)
+	}
+
+	row, err := s.repo.UpsertReviewLabel(ctx, repository.UpsertReviewLabelParams{
+		QueueID: itemID,
+		IDUser:  strconv.FormatInt(userID, 10),
+		Class:   pgtype.Text{String: class, Valid: class != ""},
+		Skipped: req.Skip,
+	})
+	if err != nil {
+		reviewLog.Error().Err(err).Int32("itemID", itemID).Msg("Failed to store review label")
+		return models.ReviewLabel{}, err
+	}
+	if err := s.repo.CompleteReviewItem(ctx, itemID); err != nil {
+		reviewLog.Error().Err(err).Int32("itemID", itemID).Msg("Failed to complete review item")
+		return models.ReviewLabel{}, err
+	}
+
+	return convertReviewLabelFromRepo(row), nil
+}
+
+// Image reads the crop of the object of an item.
+func (s *ReviewService) Image(ctx context.Context, itemID int32) ([]byte, error) {
+	item, err := s.repo.GetReviewItem(ctx, itemID)
+	if err != nil {
+		return nil, notFound(err, ErrReviewItemNotFound)
+	}
+	object, err := s.repo.GetObjectByID(ctx, item.ObjectID)
+	if err != nil {
+		return nil, notFound(err, ErrObjectNotFound)
+	}
+	if strings.TrimSpace(object.File.String) == "" {
+		return nil, ErrNoObjectImage
+	}
+	image, err := s.files.read(ctx, object.File.String, reviewImageTimeout)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrImageNotFound
+	}
+	return image, err
+}
+
+// Agreement computes how consistently the reviewers label the same items.
+func (s *ReviewService) Agreement(ctx context.Context) (models.ReviewAgreement, error) {
+	statuses, err := s.repo.CountReviewItemsByStatus(ctx)
+	if err != nil {
+		return models.ReviewAgreement{}, err
+	}
+	rows, err := s.repo.ListReviewLabels(ctx)
+	if err != nil {
+		reviewLog.Error().Err(err).Msg("Failed to list review labels")
+		return models.ReviewAgreement{}, err
+	}
+
+	return reviewAgreement(statuses, rows), nil
+}
+
+// reviewAgreement computes the agreement of the labels: the share of agreeing
+// pairs of labels of the same item and Fleiss' kappa over the items labeled more
+// than once, and every reviewer's agreement with the others.
+func reviewAgreement(statuses []repository.CountReviewItemsByStatusRow, rows []repository.ReviewLabel) models.ReviewAgreement {
+	agreement := models.ReviewAgreement{
+		Queue:     make(map[string]int64, len(statuses)),
+		Reviewers: []models.ReviewerAgreement{},
+	}
+	for _, status := range statuses {
+		agreement.Queue[status.Status] = status.Items
+	}
+
+	// Labels of every item, skips left out
+	items := make(map[int32][]repository.ReviewLabel)
+	reviewers := make(map[string]*models.ReviewerAgreement)
+	for _, row := range rows {
+		reviewer, ok := reviewers[row.IDUser]
+		if !ok {
+			reviewer = &models.ReviewerAgreement{IDUser: row.IDUser}
+			reviewers[row.IDUser] = reviewer
+		}
+		if row.Skipped {
+			agreement.Skips++
+			reviewer.Skips++
+			continue
+		}
+		agreement.Labels++
+		reviewer.Labels++
+		items[row.QueueID] = append(items[row.QueueID], row)
+	}
+
+	// Fleiss' kappa, allowing a different number of labels per item
+	classTotals := make(map[string]int)
+	totalLabels := 0
+	observed := 0.0
+	for _, labels := range items {
+		n := len(labels)
+		if n < 2 {
+			continue
+		}
+		agreement.MultiReviewed++
+
+		counts := make(map[string]int)
+		for _, label := range labels {
+			counts[label.Class.String]++
+		}
+		agreeingPairs := 0
+		for class, count := range counts {
+			agreeingPairs += count * (count - 1)
+			classTotals[class] += count
+		}
+		totalLabels += n
+		observed += float64(agreeingPairs) / float64(n*(n-1))
+
+		for _, label := range labels {
+			reviewer := reviewers[label.IDUser]
+			reviewer.Compared++
+			reviewer.Agreement += float64(counts[label.Class.String]-1) / float64(n-1)
+		}
+	}
+
+	if agreement.MultiReviewed > 0 {
+		agreement.PercentAgreement = observed / float64(agreement.MultiReviewed)
+		expected := 0.0
+		for _, count := range classTotals {
+			p := float64(count) / float64(totalLabels)
+			expected += p * p
+		}
+		if expected < 1 {
+			agreement.Kappa = (agreement.PercentAgreement - expected) / (1 - expected)
+		} else {
+			// Everybody used a single class, so there is nothing to correct for
+			agreement.Kappa = 1
+		}
+	}
+
+	for _, reviewer := range reviewers {
+		if reviewer.Compared > 0 {
+			reviewer.Agreement /= float64(reviewer.Compared)
+		}
+		agreement.Reviewers = append(agreement.Reviewers, *reviewer)
+	}
+	sort.Slice(agreement.Reviewers, func(i, j int) bool {
+		return agreement.Reviewers[i].IDUser < agreement.Reviewers[j].IDUser
+	})
+
+	return agreement
+}
+
+// reviewExportRow is a reviewed object with the consensus of its labels: the class
+// with the most votes, empty on a tie.
+type reviewExportRow struct {
+	*repository.ListReviewedObjectsRow
+	consensus string
+	votes     int
+	reviews   int
+	reviewers []string
+}
+
+type reviewColumn struct {
+	field  columnar.Field
+	append func(c *columnar.Column, row *reviewExportRow)
+}
+
+var reviewColumns = buildReviewColumns()
+
+func buildReviewColumns() []reviewColumn {
+	columns := []reviewColumn{
+		{columnar.Field{Name: "queue_id", Type: columnar.Int32}, func(c *columnar.Column, row *reviewExportRow) {
+			c.Int32s = append(c.Int32s, row.QueueID)
+		}},
+		{columnar.Field{Name: "object_id", Type: columnar.Int32}, func(c *columnar.Column, row *reviewExportRow) {
+			c.Int32s = append(c.Int32s, row.Object.ID)
+		}},
+		{columnar.Field{Name: "id_analysis", Type: columnar.Int64, Nullable: true}, func(c *columnar.Column, row *reviewExportRow) {
+			appendInt8(c, row.Object.IDAnalysis)
+		}},
+		{columnar.Field{Name: "file", Type: columnar.String, Nullable: true}, func(c *columnar.Column, row *reviewExportRow) {
+			appendText(c, row.Object.File)
+		}},
+		{columnar.Field{Name: "geometry", Type: columnar.String, Nullable: true}, func(c *columnar.Column, row *reviewExportRow) {
+			appendText(c, row.Object.Geometry)
+		}},
+		{columnar.Field{Name: "strategy", Type: columnar.String}, func(c *columnar.Column, row *reviewExportRow) {
+			c.Strings = append(c.Strings, row.Strategy)
+		}},
+		{columnar.Field{Name: "status", Type: columnar.String}, func(c *columnar.Column, row *reviewExportRow) {
+			c.Strings = append(c.Strings, row.Status)
+		}},
+		{columnar.Field{Name: "original_class", Type: columnar.String, Nullable: true}, func(c *columnar.Column, row *reviewExportRow) {
+			appendText(c, row.Object.Class)
+		}},
+		{columnar.Field{Name: "class", Type: columnar.String, Nullable: true}, func(c *columnar.Column, row *reviewExportRow) {
+			appendText(c, pgtype.Text{String: row.EffectiveClass, Valid: row.EffectiveClass != ""})
+		}},
+		{columnar.Field{Name: "review_class", Type: columnar.String, Nullable: true}, func(c *columnar.Column, row *reviewExportRow) {
+			appendText(c, pgtype.Text{String: row.consensus, Valid: row.consensus != ""})
+		}},
+		{columnar.Field{Name: "review_votes", Type: columnar.Int32}, func(c *columnar.Column, row *reviewExportRow) {
+			c.Int32s = append(c.Int32s, int32(row.votes))
+		}},
+		{columnar.Field{Name: "reviews", Type: columnar.Int32}, func(c *columnar.Column, row *reviewExportRow) {
+			c.Int32s = append(c.Int32s, int32(row.reviews))
+		}},
+		{columnar.Field{Name: "reviewers", Type: columnar.String}, func(c *columnar.Column, row *reviewExportRow) {
+			c.Strings = append(c.Strings, strings.Join(row.reviewers, ","))
+		}},
+	}
+
+	for _, name := range objectFeatureNames {
+		feature := objectFeatures[name]
+		columns = append(columns, reviewColumn{
+			columnar.Field{Name: name, Type: columnar.Float64, Nullable: true},
+			func(c *columnar.Column, row *reviewExportRow) {
+				appendFloat8(c, feature(&row.Object))
+			},
+		})
+	}
+	return columns
+}
+
+// Export prepares the objects with at least one review label, with their
+// consensus class and features, as CSV or Parquet training data.
+func (s *ReviewService) Export(ctx context.Context, req models.ReviewExportRequest) (*Export, error) {
+	format := strings.ToLower(strings.TrimSpace(req.Format))
+	if format == "" {
+		format = models.ExportCSV
+	}
+
+	schema := make(columnar.Schema, len(reviewColumns))
+	for i, column := range reviewColumns {
+		schema[i] = column.field
+	}
+
+	export := &Export{Filename: "review-labels-" + time.Now().Format("20060102-150405")}
+	switch format {
+	case models.ExportCSV:
+		export.Filename += ".csv"
+		export.ContentType = "text/csv; charset=utf-8"
+		export.write = func(ctx context.Context, w io.Writer) error {
+			cw := csv.NewWriter(w)
+			header := make([]string, len(schema))
+			for i, field := range schema {
+				header[i] = field.Name
+			}
+			if err := cw.Write(header); err != nil {
+				return err
+			}
+			err := s.eachReviewBatch(ctx, schema, func(b *columnar.Batch) error {
+				record := make([]string, len(schema))
+				for row := 0; row < b.Rows; row++ {
+					for i, field := range schema {
+						record[i] = columnCell(field, &b.Columns[i], row)
+					}
+					if err := cw.Write(record); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			cw.Flush()
+			return cw.Error()
+		}
+	case models.ExportParquet:
+		export.Filename += ".parquet"
+		export.ContentType = "application/vnd.apache.parquet"
+		export.write = func(ctx context.Context, w io.Writer) error {
+			pw, err := parquet.NewWriter(w, schema)
+			if err != nil {
+				return err
+			}
+			if err := s.eachReviewBatch(ctx, schema, pw.WriteBatch); err != nil {
+				return err
+			}
+			return pw.Close()
+		}
+	default:
+		return nil, fmt.Errorf("%w: unsupported format %q", ErrInvalidExport, req.Format)
+	}
+	return export, nil
+}
+
+// eachReviewBatch pages through the reviewed objects and passes them on as batches.
+func (s *ReviewService) eachReviewBatch(ctx context.Context, schema columnar.Schema, fn func(b *columnar.Batch) error) error {
+	params := repository.ListReviewedObjectsParams{Limit: reviewExportPageSize}
+	for {
+		rows, err := s.repo.ListReviewedObjects(ctx, params)
+		if err != nil {
+			reviewLog.Error().Err(err).Int32("afterID", params.AfterID).Msg("Failed to list reviewed objects")
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+
+		ids := make([]int32, len(rows))
+		for i, row := range rows {
+			ids[i] = row.QueueID
+		}
+		labels, err := s.repo.ListReviewLabelsByQueueIDs(ctx, ids)
+		if err != nil {
+			return err
+		}
+		byItem := make(map[int32][]repository.ReviewLabel, len(rows))
+		for _, label := range labels {
+			if !label.Skipped {
+				byItem[label.QueueID] = append(byItem[label.QueueID], label)
+			}
+		}
+
+		batch := columnar.NewBatch(schema, len(rows))
+		for i := range rows {
+			row := reviewExportRow{ListReviewedObjectsRow: &rows[i]}
+			row.consensus, row.votes = reviewConsensus(byItem[rows[i].QueueID])
+			for _, label := range byItem[rows[i].QueueID] {
+				row.reviews++
+				row.reviewers = append(row.reviewers, label.IDUser)
+			}
+			for j, column := range reviewColumns {
+				column.append(&batch.Columns[j], &row)
+			}
+		}
+		batch.Rows = len(rows)
+		if err := fn(batch); err != nil {
+			return err
+		}
+
+		if len(rows) < reviewExportPageSize {
+			return nil
+		}
+		params.AfterID = rows[len(rows)-1].QueueID
+	}
+}
+
+// reviewConsensus returns the class with the most votes and its votes. A tie has
+// no consensus.
+func reviewConsensus(labels []repository.ReviewLabel) (string, int) {
+	counts := make(map[string]int)
+	for _, label := range labels {
+		counts[label.Class.String]++
+	}
+	consensus, votes, tied := "", 0, false
+	for class, count := range counts {
+		switch {
+		case count > votes:
+			consensus, votes, tied = class, count, false
+		case count == votes:
+			tied = true
+		}
+	}
+	if tied {
+		return "", votes
+	}
+	return consensus, votes
+}
+
+// columnCell formats one value of a batch for CSV; NULL is an empty cell.
+func columnCell(field columnar.Field, c *columnar.Column, row int) string {
+	if !c.IsValid(row) {
+		return ""
+	}
+	switch field.Type {
+	case columnar.Int32:
+		return strconv.FormatInt(int64(c.Int32s[row]), 10)
+	case columnar.Int64:
+		return strconv.FormatInt(c.Int64s[row], 10)
+	case columnar.Timestamp:
+		return time.UnixMicro(c.Int64s[row]).UTC().Format(time.RFC3339)
+	case columnar.Float64:
+		if math.IsNaN(c.Float64s[row]) {
+			return ""
+		}
+		return strconv.FormatFloat(c.Float64s[row], 'g', -1, 64)
+	case columnar.String:
+		return neutralizeFormula(c.Strings[row])
+	}
+	return ""
+}
+
+func convertReviewLabelFromRepo(row repository.ReviewLabel) models.ReviewLabel {
+	return models.ReviewLabel{
+		ID:        row.ID,
+		QueueID:   row.QueueID,
+		IDUser:    row.IDUser,
+		Class:     row.Class.String,
+		Skipped:   row.Skipped,
+		CreatedAt: row.CreatedAt,
+	}
+}
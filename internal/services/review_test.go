@@ -0,0 +1,141 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+
+	"csort.ru/analysis-service/internal/repository"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// labels returns the labels of an item, one per "user:class" vote; an empty
+// class is a skip.
+func labels(item int32, votes ...string) []repository.ReviewLabel {
+	rows := make([]repository.ReviewLabel, len(votes))
+	for i, vote := range votes {
+		var user, class string
+		fmt.Sscanf(vote, "%1s:%s", &user, &class)
+		rows[i] = repository.ReviewLabel{QueueID: item, IDUser: user, Class: pgtype.Text{String: class, Valid: class != ""}, Skipped: class == ""}
+	}
+	return rows
+}
+
+func TestReviewAgreement(t *testing.T) {
+	tests := []struct {
+		name    string
+		rows    []repository.ReviewLabel
+		multi   int
+		percent float64
+		kappa   float64
+		// reviewers are the expected "user compared agreement" of every reviewer
+		reviewers string
+	}{
+		{
+			// Per item agreeing pairs: 6/6, 0/2 and 4/12, so P = (1 + 0 + 1/3) / 3 = 4/9.
+			// Classes over the 9 labels: a 6, b 3, so Pe = 4/9 + 1/9 = 5/9 and
+			// kappa = (4/9 - 5/9) / (4/9) = -1/4. Item 4 has a single label.
+			name: "different numbers of raters",
+			rows: concat(
+				labels(1, "1:a", "2:a", "3:a"),
+				labels(2, "1:a", "2:b"),
+				labels(3, "1:b", "2:b", "3:a", "4:a"),
+				labels(4, "1:a", "3:"),
+			),
+			multi:     3,
+			percent:   4.0 / 9,
+			kappa:     -0.25,
+			reviewers: "[1 3 0.4444 2 3 0.4444 3 2 0.6667 4 1 0.3333]",
+		},
+		{
+			// P = 1 and Pe = 1/2
+			name:      "perfect agreement",
+			rows:      concat(labels(1, "1:a", "2:a"), labels(2, "1:b", "2:b")),
+			multi:     2,
+			percent:   1,
+			kappa:     1,
+			reviewers: "[1 2 1.0000 2 2 1.0000]",
+		},
+		{
+			// Every pair disagrees: P = 0, Pe = 1/2
+			name:      "perfect disagreement",
+			rows:      concat(labels(1, "1:a", "2:b"), labels(2, "1:b", "2:a")),
+			multi:     2,
+			percent:   0,
+			kappa:     -1,
+			reviewers: "[1 2 0.0000 2 2 0.0000]",
+		},
+		{
+			// Pe = 1 leaves nothing to correct for
+			name:      "a single class",
+			rows:      concat(labels(1, "1:a", "2:a", "3:a")),
+			multi:     1,
+			percent:   1,
+			kappa:     1,
+			reviewers: "[1 1 1.0000 2 1 1.0000 3 1 1.0000]",
+		},
+		{
+			name:      "no item labeled twice",
+			rows:      concat(labels(1, "1:a"), labels(2, "2:b", "1:")),
+			reviewers: "[1 0 0.0000 2 0 0.0000]",
+		},
+	}
+	for _, tt := range tests {
+		got := reviewAgreement(nil, tt.rows)
+		if got.MultiReviewed != tt.multi || !approxEqual(got.PercentAgreement, tt.percent, 1e-9) || !approxEqual(got.Kappa, tt.kappa, 1e-9) {
+			t.Errorf("%s: got %d items, agreement %v and kappa %v, want %d, %v and %v",
+				tt.name, got.MultiReviewed, got.PercentAgreement, got.Kappa, tt.multi, tt.percent, tt.kappa)
+		}
+		var reviewers []string
+		for _, reviewer := range got.Reviewers {
+			reviewers = append(reviewers, fmt.Sprintf("%s %d %.4f", reviewer.IDUser, reviewer.Compared, reviewer.Agreement))
+		}
+		if fmt.Sprint(reviewers) != tt.reviewers {
+			t.Errorf("%s: got reviewers %v, want %s", tt.name, reviewers, tt.reviewers)
+		}
+	}
+}
+
+func TestReviewAgreementCounts(t *testing.T) {
+	statuses := []repository.CountReviewItemsByStatusRow{{Status: "pending", Items: 3}, {Status: "reviewed", Items: 2}}
+	got := reviewAgreement(statuses, concat(labels(1, "1:a", "2:a"), labels(2, "1:", "2:", "3:b")))
+	if got.Labels != 3 || got.Skips != 2 || got.Queue["pending"] != 3 || got.Queue["reviewed"] != 2 {
+		t.Errorf("got %d labels, %d skips and queue %v", got.Labels, got.Skips, got.Queue)
+	}
+	if r := got.Reviewers[0]; r.IDUser != "1" || r.Labels != 1 || r.Skips != 1 {
+		t.Errorf("got reviewer %+v, want 1 label and 1 skip", r)
+	}
+}
+
+func TestReviewConsensus(t *testing.T) {
+	tests := []struct {
+		votes     []string
+		consensus string
+		count     int
+	}{
+		{votes: []string{"1:a", "2:a", "3:b"}, consensus: "a", count: 2},
+		{votes: []string{"1:a", "2:b", "3:b", "4:c", "5:c", "6:c"}, consensus: "c", count: 3},
+		// A tie below the winner doesn't matter
+		{votes: []string{"1:a", "2:b", "3:c", "4:c"}, consensus: "c", count: 2},
+		{votes: []string{"1:a", "2:b"}, count: 1},
+		{votes: []string{"1:a", "2:a", "3:b", "4:b", "5:c"}, count: 2},
+		{votes: []string{"1:a"}, consensus: "a", count: 1},
+		{},
+	}
+	for _, tt := range tests {
+		// Map iteration order varies, so every case is tried a few times
+		for i := 0; i < 10; i++ {
+			consensus, count := reviewConsensus(labels(1, tt.votes...))
+			if consensus != tt.consensus || count != tt.count {
+				t.Fatalf("reviewConsensus(%v) = %q, %d, want %q, %d", tt.votes, consensus, count, tt.consensus, tt.count)
+			}
+		}
+	}
+}
+
+func concat(items ...[]repository.ReviewLabel) []repository.ReviewLabel {
+	var rows []repository.ReviewLabel
+	for _, item := range items {
+		rows = append(rows, item...)
+	}
+	return rows
+}
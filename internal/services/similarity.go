@@ -0,0 +1,432 @@
+package services
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"csort.ru/analysis-service/internal/apperr"
+	"csort.ru/analysis-service/internal/logger"
+	"csort.ru/analysis-service/internal/models"
+	"csort.ru/analysis-service/internal/repository"
+)
+
+const (
+	DefaultSimilarK = 10
+	MaxSimilarK     = 100
+	similarityBatch = 5000
+	// similarityRescanWindow is how many ids below the highest indexed one each
+	// refresh scans again, for objects committed after others with higher ids,
+	// e.g. by concurrent pipeline transactions
+	similarityRescanWindow = 10000
+	minSharedFeatures      = 0.5 // fraction of the selected features both objects must have
+)
+
+var defaultSimilarityFeatures = []string{"geometry", "color", "hu"}
+
+var (
+	// ErrObjectNotIndexed is returned when the object doesn't exist or isn't visible to the caller.
+	ErrObjectNotIndexed = apperr.NotFound("object_not_found", "object not found")
+	// ErrSimilarityIndexBuilding is returned until the first build of the index is done.
+	ErrSimilarityIndexBuilding = apperr.UpstreamUnavailable("similarity_index_building", "similarity index is still being built")
+)
+
+var similarityLog = logger.GetLogger("services.similarity")
+
+// similarityEntry is one object in the index. values holds every numeric
+// feature in objectFeatureNames order, NaN where the column is NULL.
+type similarityEntry struct {
+	objectID int32
+	userID   string
+	values   []float32
+}
+
+// featureMoments accumulates mean and variance with Welford's algorithm so
+// normalization can be updated as objects are added.
+type featureMoments struct {
+	count int64
+	mean  float64
+	m2    float64
+}
+
+func (m *featureMoments) add(v float64) {
+	m.count++
+	delta := v - m.mean
+	m.mean += delta / float64(m.count)
+	m.m2 += delta * (v - m.mean)
+}
+
+// remove takes a value added earlier back out, reversing add.
+func (m *featureMoments) remove(v float64) {
+	if m.count <= 1 {
+		*m = featureMoments{}
+		return
+	}
+	mean := m.mean
+	m.count--
+	m.mean -= (v - mean) / float64(m.count)
+	m.m2 = max(m.m2-(v-m.mean)*(v-mean), 0)
+}
+
+func (m *featureMoments) std() float64 {
+	if m.count < 2 {
+		return 0
+	}
+	return math.Sqrt(m.m2 / float64(m.count-1))
+}
+
+// SimilarityService answers nearest-neighbour queries over object feature
+// vectors from an in-memory index partitioned by owner. The index is filled
+// incrementally by Run: each refresh loads the objects with an ID above the last
+// one seen, less a trailing window for objects committed late.
+type SimilarityService struct {
+	repo *repository.Queries
+
+	refreshMu sync.Mutex // serializes refreshes
+
+	mu       sync.RWMutex
+	entries  []similarityEntry
+	byObject map[int32]int
+	byUser   map[string][]int
+	moments  []featureMoments
+	lastID   int32
+	// built is set once the first refresh has finished
+	built bool
+}
+
+func NewSimilarityService(repo *repository.Queries) *SimilarityService {
+	return &SimilarityService{
+		repo:     repo,
+		byObject: make(map[int32]int),
+		byUser:   make(map[string][]int),
+		moments:  make([]featureMoments, len(objectFeatureNames)),
+	}
+}
+
+// Run builds the index and then refreshes it every interval until ctx is done.
+// With a non-positive interval the index is only built once.
+func (s *SimilarityService) Run(ctx context.Context, interval time.Duration) {
+	if err := s.Refresh(ctx); err != nil {
+		similarityLog.Error().Err(err).Msg("Initial similarity index build failed")
+	}
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Refresh(ctx); err != nil {
+				similarityLog.Error().Err(err).Msg("Similarity index refresh failed")
+			}
+		}
+	}
+}
+
+// Refresh adds the objects created since the last refresh to the index. Ids
+// are handed out before commit, so an object can become visible after others
+// with higher ids; the last similarityRescanWindow ids are scanned again to
+// pick those up.
+func (s *SimilarityService) Refresh(ctx context.Context) error {
+	s.refreshMu.Lock()
+	defer s.refreshMu.Unlock()
+
+	s.mu.RLock()
+	afterID := max(s.lastID-similarityRescanWindow, 0)
+	s.mu.RUnlock()
+
+	added := 0
+	for {
+		rows, err := s.repo.ListObjectsWithOwnerAfterID(ctx, repository.ListObjectsWithOwnerAfterIDParams{
+			AfterID: afterID,
+			Limit:   similarityBatch,
+		})
+		if err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			break
+		}
+
+		s.mu.Lock()
+		for i := range rows {
+			if s.addLocked(&rows[i]) {
+				added++
+			}
+		}
+		s.mu.Unlock()
+
+		afterID = rows[len(rows)-1].Object.ID
+		if len(rows) < similarityBatch {
+			break
+		}
+	}
+
+	s.mu.Lock()
+	s.built = true
+	s.mu.Unlock()
+
+	if added > 0 {
+		similarityLog.Info().Int("added", added).Int32("lastObjectID", afterID).Msg("Similarity index refreshed")
+	}
+	return nil
+}
+
+// addLocked adds an object to the index, and reports false if it already was.
+func (s *SimilarityService) addLocked(row *repository.ListObjectsWithOwnerAfterIDRow) bool {
+	if _, ok := s.byObject[row.Object.ID]; ok {
+		return false
+	}
+
+	entry := similarityEntry{
+		objectID: row.Object.ID,
+		userID:   row.IDUser.String,
+		values:   make([]float32, len(objectFeatureNames)),
+	}
+	for j, name := range objectFeatureNames {
+		v := objectFeatures[name](&row.Object)
+		if !v.Valid {
+			entry.values[j] = float32(math.NaN())
+			continue
+		}
+		entry.values[j] = float32(v.Float64)
+		s.moments[j].add(v.Float64)
+	}
+
+	index := len(s.entries)
+	s.entries = append(s.entries, entry)
+	s.byObject[entry.objectID] = index
+	s.byUser[entry.userID] = append(s.byUser[entry.userID], index)
+	if entry.objectID > s.lastID {
+		s.lastID = entry.objectID
+	}
+	return true
+}
+
+// Remove drops objects from the index, e.g. those of a purged analysis, so they
+// no longer take the place of neighbours that still exist.
+func (s *SimilarityService) Remove(objectIDs []int32) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, id := range objectIDs {
+		if index, ok := s.byObject[id]; ok {
+			s.removeLocked(index)
+		}
+	}
+}
+
+// removeLocked removes the entry at index, moving the last entry into its place.
+func (s *SimilarityService) removeLocked(index int) {
+	entry := s.entries[index]
+	for j, v := range entry.values {
+		if !math.IsNaN(float64(v)) {
+			s.moments[j].remove(float64(v))
+		}
+	}
+	delete(s.byObject, entry.objectID)
+	s.byUser[entry.userID] = replaceIndex(s.byUser[entry.userID], index, -1)
+	if len(s.byUser[entry.userID]) == 0 {
+		delete(s.byUser, entry.userID)
+	}
+
+	last := len(s.entries) - 1
+	if index != last {
+		moved := s.entries[last]
+		s.entries[index] = moved
+		s.byObject[moved.objectID] = index
+		s.byUser[moved.userID] = replaceIndex(s.byUser[moved.userID], last, index)
+	}
+	s.entries[last] = similarityEntry{}
+	s.entries = s.entries[:last]
+}
+
+// replaceIndex replaces from with to in indexes, or drops it if to is negative.
+func replaceIndex(indexes []int, from, to int) []int {
+	for i, index := range indexes {
+		if index != from {
+			continue
+		}
+		if to < 0 {
+			return append(indexes[:i], indexes[i+1:]...)
+		}
+		indexes[i] = to
+		break
+	}
+	return indexes
+}
+
+// FindSimilar returns the k objects of userID closest to objectID in the
+// z-score normalized space of the requested features. It searches the index as
+// Run last refreshed it, so objects of analyses that arrived since aren't found
+// until the next refresh.
+func (s *SimilarityService) FindSimilar(ctx context.Context, userID int64, objectID int32, req models.SimilarObjectsRequest) (models.SimilarObjectsResponse, error) {
+	var names []string
+	if req.Features != "" {
+		names = strings.Split(req.Features, ",")
+	}
+	features, err := resolveFeatures(names, defaultSimilarityFeatures)
+	if err != nil {
+		return models.SimilarObjectsResponse{}, err
+	}
+
+	k := req.K
+	if k <= 0 {
+		k = DefaultSimilarK
+	}
+	if k > MaxSimilarK {
+		k = MaxSimilarK
+	}
+
+	columns := make([]int, len(features))
+	for i, feature := range features {
+		for j, name := range objectFeatureNames {
+			if name == feature {
+				columns[i] = j
+				break
+			}
+		}
+	}
+
+	// Objects of soft-deleted analyses stay indexed but can't be searched from
+	if _, err := s.repo.GetObjectByID(ctx, objectID); err != nil {
+		return models.SimilarObjectsResponse{}, notFound(err, ErrObjectNotIndexed)
+	}
+
+	owner := fmt.Sprintf("%d", userID)
+	neighbours, err := s.search(owner, objectID, columns, k)
+	if err != nil {
+		return models.SimilarObjectsResponse{}, err
+	}
+
+	response := models.SimilarObjectsResponse{
+		ObjectID: objectID,
+		Features: features,
+		Items:    make([]models.SimilarObject, 0, len(neighbours)),
+	}
+	if len(neighbours) == 0 {
+		return response, nil
+	}
+
+	ids := make([]int32, 0, len(neighbours))
+	for _, n := range neighbours {
+		ids = append(ids, n.objectID)
+	}
+	rows, err := s.repo.GetObjectsByIDs(ctx, ids)
+	if err != nil {
+		similarityLog.Error().Err(err).Int32("objectID", objectID).Msg("Failed to load similar objects")
+		return models.SimilarObjectsResponse{}, err
+	}
+	byID := make(map[int32]repository.GetObjectsByIDsRow, len(rows))
+	for _, row := range rows {
+		byID[row.ID] = row
+	}
+	labels, err := effectiveLabels(ctx, s.repo, ids)
+	if err != nil {
+		return models.SimilarObjectsResponse{}, err
+	}
+
+	for _, n := range neighbours {
+		row, ok := byID[n.objectID]
+		if !ok || row.AnalysisIDUser.String != owner {
+			continue
+		}
+		class := row.Class.String
+		if label, ok := labels[row.ID]; ok {
+			class = label
+		}
+		response.Items = append(response.Items, models.SimilarObject{
+			ObjectID:   row.ID,
+			AnalysisID: row.IDAnalysis.Int64,
+			IDAnalysis: row.AnalysisIDAnalysis.String,
+			Class:      class,
+			File:       row.File.String,
+			Distance:   n.distance,
+		})
+	}
+
+	return response, nil
+}
+
+type neighbour struct {
+	objectID int32
+	distance float64
+}
+
+// neighbourHeap is a max-heap on distance holding the best k candidates so far.
+type neighbourHeap []neighbour
+
+func (h neighbourHeap) Len() int            { return len(h) }
+func (h neighbourHeap) Less(i, j int) bool  { return h[i].distance > h[j].distance }
+func (h neighbourHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *neighbourHeap) Push(x interface{}) { *h = append(*h, x.(neighbour)) }
+func (h *neighbourHeap) Pop() interface{} {
+	old := *h
+	n := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return n
+}
+
+func (s *SimilarityService) search(owner string, objectID int32, columns []int, k int) ([]neighbour, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !s.built {
+		return nil, ErrSimilarityIndexBuilding
+	}
+	queryIndex, ok := s.byObject[objectID]
+	if !ok || s.entries[queryIndex].userID != owner {
+		return nil, ErrObjectNotIndexed
+	}
+	query := s.entries[queryIndex].values
+
+	scales := make([]float64, len(columns))
+	for i, column := range columns {
+		scales[i] = s.moments[column].std()
+	}
+	minShared := int(math.Ceil(float64(len(columns)) * minSharedFeatures))
+
+	best := make(neighbourHeap, 0, k+1)
+	for _, index := range s.byUser[owner] {
+		if index == queryIndex {
+			continue
+		}
+		candidate := s.entries[index].values
+
+		sum := 0.0
+		shared := 0
+		for i, column := range columns {
+			a, b := query[column], candidate[column]
+			if scales[i] == 0 || math.IsNaN(float64(a)) || math.IsNaN(float64(b)) {
+				continue
+			}
+			d := float64(a-b) / scales[i]
+			sum += d * d
+			shared++
+		}
+		if shared == 0 || shared < minShared {
+			continue
+		}
+		// Scale up as if every selected feature was present, so objects with NULLs aren't favoured
+		distance := math.Sqrt(sum * float64(len(columns)) / float64(shared))
+
+		if len(best) < k {
+			heap.Push(&best, neighbour{objectID: s.entries[index].objectID, distance: distance})
+		} else if distance < best[0].distance {
+			best[0] = neighbour{objectID: s.entries[index].objectID, distance: distance}
+			heap.Fix(&best, 0)
+		}
+	}
+
+	sort.Slice(best, func(i, j int) bool { return best[i].distance < best[j].distance })
+	return best, nil
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"testing"
+
+	"csort.ru/analysis-service/internal/repository"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// indexObject adds an object of user with the given l_avg, if valid, to s.
+func indexObject(s *SimilarityService, id int32, user string, length float64, valid bool) {
+	row := repository.ListObjectsWithOwnerAfterIDRow{IDUser: pgtype.Text{String: user, Valid: true}}
+	row.Object.ID = id
+	row.Object.LAvg = pgtype.Float8{Float64: length, Valid: valid}
+	s.addLocked(&row)
+}
+
+func TestSimilarityRemove(t *testing.T) {
+	s := NewSimilarityService(nil)
+	indexObject(s, 1, "a", 1, true)
+	indexObject(s, 2, "a", 2, true)
+	indexObject(s, 3, "b", 4, true)
+	indexObject(s, 4, "a", 0, false)
+	indexObject(s, 5, "b", 8, true)
+
+	// Object 1 is replaced by the last entry, 6 was never indexed
+	s.Remove([]int32{1, 3, 6})
+
+	if len(s.entries) != 3 || len(s.byObject) != 3 {
+		t.Fatalf("got %d entries and %d ids, want 3", len(s.entries), len(s.byObject))
+	}
+	for id, index := range s.byObject {
+		if s.entries[index].objectID != id {
+			t.Errorf("object %d points at entry %d of object %d", id, index, s.entries[index].objectID)
+		}
+	}
+	for user, indexes := range s.byUser {
+		for _, index := range indexes {
+			if s.entries[index].userID != user {
+				t.Errorf("user %s lists entry %d of user %s", user, index, s.entries[index].userID)
+			}
+		}
+	}
+	if len(s.byUser["a"]) != 2 || len(s.byUser["b"]) != 1 {
+		t.Errorf("got users %v, want 2 entries of a and 1 of b", s.byUser)
+	}
+
+	// The moments are those of 2 and 8 alone: mean 5, sample variance 18
+	column := 0
+	for j, name := range objectFeatureNames {
+		if name == "l_avg" {
+			column = j
+		}
+	}
+	moments := s.moments[column]
+	if moments.count != 2 || !approxEqual(moments.mean, 5, 1e-9) || !approxEqual(moments.std()*moments.std(), 18, 1e-9) {
+		t.Errorf("got moments %+v, want 2 values with mean 5 and variance 18", moments)
+	}
+
+	s.Remove([]int32{2, 4, 5})
+	if len(s.entries) != 0 || len(s.byUser) != 0 || s.moments[column] != (featureMoments{}) {
+		t.Errorf("got %d entries, users %v and moments %+v after removing everything", len(s.entries), s.byUser, s.moments[column])
+	}
+}
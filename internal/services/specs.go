@@ -0,0 +1,614 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"csort.ru/analysis-service/internal/apperr"
+	"csort.ru/analysis-service/internal/logger"
+	"csort.ru/analysis-service/internal/models"
+	"csort.ru/analysis-service/internal/repository"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var specsLog = logger.GetLogger("services.specs")
+
+var (
+	// ErrInvalidSpec is returned when a product spec fails validation.
+	ErrInvalidSpec  = apperr.Validation("invalid_spec", "invalid product spec")
+	ErrSpecNotFound = apperr.NotFound("spec_not_found", "product spec not found")
+	ErrSpecExists   = apperr.Conflict("spec_exists", "a spec for this product already exists")
+)
+
+// gradingBatch is how many ungraded analyses the grading job loads at a time.
+const gradingBatch = 100
+
+type SpecsService struct {
+	repo     *repository.Queries
+	products *ProductsService
+	// wake makes the grading job run before its next tick, after a spec changed
+	wake chan struct{}
+
+	mu sync.Mutex
+	// evaluate are the specs whose analyses the grading job regrades on its next run
+	evaluate map[int32]struct{}
+}
+
+func NewSpecsService(repo *repository.Queries, products *ProductsService) *SpecsService {
+	return &SpecsService{
+		repo:     repo,
+		products: products,
+		wake:     make(chan struct{}, 1),
+		evaluate: map[int32]struct{}{},
+	}
+}
+
+func (s *SpecsService) ListSpecs(ctx context.Context) ([]models.ProductSpec, error) {
+	rows, err := s.repo.ListProductSpecs(ctx)
+	if err != nil {
+		specsLog.Error().Err(err).Msg("Failed to list product specs")
+		return nil, err
+	}
+
+	specs := make([]models.ProductSpec, 0, len(rows))
+	for _, row := range rows {
+		specs = append(specs, convertSpecFromRepo(row))
+	}
+	return specs, nil
+}
+
+func (s *SpecsService) GetSpec(ctx context.Context, id int32) (models.ProductSpec, error) {
+	row, err := s.repo.GetProductSpecByID(ctx, id)
+	if err != nil {
+		return models.ProductSpec{}, notFound(err, ErrSpecNotFound)
+	}
+	return convertSpecFromRepo(row), nil
+}
+
+// CreateSpec stores a new product spec. The analyses of its product are graded
+// by the grading job.
+func (s *SpecsService) CreateSpec(ctx context.Context, req models.ProductSpecRequest) (models.ProductSpec, error) {
+	rules, err := s.validateSpecRequest(ctx, &req)
+	if err != nil {
+		return models.ProductSpec{}, err
+	}
+
+	row, err := s.repo.CreateProductSpec(ctx, repository.CreateProductSpecParams{
+		Product: req.Product,
+		Name:    req.Name,
+		Rules:   rules,
+	})
+	if err != nil {
+		specsLog.Error().Err(err).Str("product", req.Product).Msg("Failed to create product spec")
+		return models.ProductSpec{}, conflict(err, ErrSpecExists)
+	}
+
+	s.wakeGrader()
+	return convertSpecFromRepo(row), nil
+}
+
+// UpdateSpec replaces a product spec and drops the verdicts graded against the
+// old version. The grading job regrades the analyses it applies to.
+func (s *SpecsService) UpdateSpec(ctx context.Context, id int32, req models.ProductSpecRequest) (models.ProductSpec, error) {
+	rules, err := s.validateSpecRequest(ctx, &req)
+	if err != nil {
+		return models.ProductSpec{}, err
+	}
+
+	row, err := s.repo.UpdateProductSpec(ctx, repository.UpdateProductSpecParams{
+		ID:      id,
+		Product: req.Product,
+		Name:    req.Name,
+		Rules:   rules,
+	})
+	if err != nil {
+		return models.ProductSpec{}, conflict(notFound(err, ErrSpecNotFound), ErrSpecExists)
+	}
+
+	s.wakeGrader()
+	return convertSpecFromRepo(row), nil
+}
+
+func (s *SpecsService) DeleteSpec(ctx context.Context, id int32) error {
+	affected, err := s.repo.DeleteProductSpec(ctx, id)
+	if err != nil {
+		specsLog.Error().Err(err).Int32("specID", id).Msg("Failed to delete product spec")
+		return err
+	}
+	if affected == 0 {
+		return ErrSpecNotFound
+	}
+	return nil
+}
+
+// EvaluateSpec queues every analysis of the spec's product for regrading. The
+// grading job grades them, so a product with many analyses doesn't hold up the
+// request.
+func (s *SpecsService) EvaluateSpec(ctx context.Context, id int32) error {
+	if _, err := s.GetSpec(ctx, id); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.evaluate[id] = struct{}{}
+	s.mu.Unlock()
+	s.wakeGrader()
+	return nil
+}
+
+// Run grades the analyses that have no verdict yet every interval, and whenever
+// a spec is created, updated or queued for evaluation, until ctx is done. Analyses are graded here
+// rather than when they are read, so reads don't write and the verdict filter
+// covers every analysis.
+func (s *SpecsService) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := s.GradePending(ctx); err != nil && ctx.Err() == nil {
+			specsLog.Error().Err(err).Msg("Grading run failed")
+		}
+		s.evaluateQueued(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+// wakeGrader makes the grading job run now rather than at its next tick.
+func (s *SpecsService) wakeGrader() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+		// A run is already pending
+	}
+}
+
+// GradePending grades every analysis whose product has a spec but that has no
+// verdict, and returns how many were graded.
+func (s *SpecsService) GradePending(ctx context.Context) (int, error) {
+	specs := map[string]models.ProductSpec{}
+	graded := 0
+	var afterID int32
+	for {
+		repoAnalyses, err := s.repo.ListUngradedAnalyses(ctx, repository.ListUngradedAnalysesParams{
+			AfterID: afterID,
+			Limit:   gradingBatch,
+		})
+		if err != nil {
+			return graded, err
+		}
+		if len(repoAnalyses) == 0 {
+			break
+		}
+
+		for _, repoAnalysis := range repoAnalyses {
+			afterID = repoAnalysis.ID
+			spec, ok := specs[repoAnalysis.Product.String]
+			if !ok {
+				row, err := s.repo.GetProductSpecByProduct(ctx, repoAnalysis.Product.String)
+				if errors.Is(err, pgx.ErrNoRows) {
+					// Deleted since the batch was loaded
+					continue
+				}
+				if err != nil {
+					return graded, err
+				}
+				spec = convertSpecFromRepo(row)
+				specs[spec.Product] = spec
+			}
+
+			ok, err := s.gradeStored(ctx, spec, repoAnalysis)
+			if err != nil {
+				return graded, err
+			}
+			if ok {
+				graded++
+			}
+		}
+	}
+
+	if graded > 0 {
+		specsLog.Info().Int("analyses", graded).Msg("Graded analyses against product specs")
+	}
+	return graded, nil
+}
+
+// Regrade grades a stored analysis against the spec of its product and stores
+// the verdict, or drops its verdict if the product has no spec. It is called
+// when something the verdict depends on changes, such as the product or the
+// class of an object.
+func (s *SpecsService) Regrade(ctx context.Context, analysisID int32) error {
+	repoAnalyses, err := s.repo.GetAnalysesByIDs(ctx, []int32{analysisID})
+	if err != nil || len(repoAnalyses) == 0 {
+		return err
+	}
+
+	row, err := s.repo.GetProductSpecByProduct(ctx, repoAnalyses[0].Product.String)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// No spec (any more) for this product: make sure no stale verdict is left behind
+		return s.repo.DeleteAnalysisVerdict(ctx, analysisID)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = s.gradeStored(ctx, convertSpecFromRepo(row), repoAnalyses[0])
+	return err
+}
+
+// GetVerdict returns the stored verdict of an analysis, or nil if it hasn't been
+// graded.
+func (s *SpecsService) GetVerdict(ctx context.Context, analysisID int32) (*models.SpecVerdict, error) {
+	verdicts, err := s.GetVerdicts(ctx, []int32{analysisID})
+	if err != nil {
+		return nil, err
+	}
+	return verdicts[analysisID], nil
+}
+
+// GetVerdicts returns the stored verdicts of the given analyses keyed by analysis ID.
+func (s *SpecsService) GetVerdicts(ctx context.Context, analysisIDs []int32) (map[int32]*models.SpecVerdict, error) {
+	rows, err := s.repo.GetAnalysisVerdictsByAnalysisIDs(ctx, analysisIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	verdicts := make(map[int32]*models.SpecVerdict, len(rows))
+	for _, row := range rows {
+		verdict := convertVerdictFromRepo(row)
+		verdicts[row.AnalysisID] = &verdict
+	}
+	return verdicts, nil
+}
+
+// evaluateQueued regrades the analyses of the specs queued by EvaluateSpec. A spec
+// that fails stays queued for the next run.
+func (s *SpecsService) evaluateQueued(ctx context.Context) {
+	s.mu.Lock()
+	queued := s.evaluate
+	s.evaluate = map[int32]struct{}{}
+	s.mu.Unlock()
+
+	for id := range queued {
+		spec, err := s.GetSpec(ctx, id)
+		if errors.Is(err, ErrSpecNotFound) {
+			// Deleted since it was queued
+			continue
+		}
+		if err == nil {
+			_, err = s.evaluateProduct(ctx, spec)
+		}
+		if err != nil {
+			if ctx.Err() == nil {
+				specsLog.Error().Err(err).Int32("specID", id).Msg("Failed to evaluate product spec")
+			}
+			s.mu.Lock()
+			s.evaluate[id] = struct{}{}
+			s.mu.Unlock()
+		}
+	}
+}
+
+func (s *SpecsService) evaluateProduct(ctx context.Context, spec models.ProductSpec) (int, error) {
+	repoAnalyses, err := s.repo.GetAnalysesByProduct(ctx, pgtype.Text{String: spec.Product, Valid: true})
+	if err != nil {
+		specsLog.Error().Err(err).Str("product", spec.Product).Msg("Failed to get analyses for product")
+		return 0, err
+	}
+
+	for _, repoAnalysis := range repoAnalyses {
+		if _, err := s.gradeStored(ctx, spec, repoAnalysis); err != nil {
+			return 0, err
+		}
+	}
+
+	specsLog.Info().Int32("specID", spec.ID).Str("product", spec.Product).Int("analyses", len(repoAnalyses)).Msg("Graded analyses against product spec")
+	return len(repoAnalyses), nil
+}
+
+// gradeStored grades a stored analysis with its objects against spec and stores
+// the verdict. It reports false for an analysis that can't be converted.
+func (s *SpecsService) gradeStored(ctx context.Context, spec models.ProductSpec, repoAnalysis repository.Analysis) (bool, error) {
+	analysis := convertAnalysisFromRepo(repoAnalysis)
+	if analysis.ID == 0 {
+		// Conversion failed and was already logged
+		return false, nil
+	}
+
+	repoObjects, err := s.repo.GetObjectsByAnalysisID(ctx, pgtype.Int8{Int64: int64(repoAnalysis.ID), Valid: true})
+	if err != nil {
+		return false, err
+	}
+	if err := applyEffectiveLabels(ctx, s.repo, repoObjects); err != nil {
+		return false, err
+	}
+	analysis.Objects = make([]models.Object, 0, len(repoObjects))
+	for _, repoObject := range repoObjects {
+		analysis.Objects = append(analysis.Objects, convertObjectFromRepo(repoObject))
+	}
+
+	if _, err := s.storeVerdict(ctx, analysis.ID, gradeAnalysis(spec, analysis)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *SpecsService) storeVerdict(ctx context.Context, analysisID int32, verdict models.SpecVerdict) (*models.SpecVerdict, error) {
+	violations, err := json.Marshal(verdict.Violations)
+	if err != nil {
+		return nil, err
+	}
+
+	row, err := s.repo.UpsertAnalysisVerdict(ctx, repository.UpsertAnalysisVerdictParams{
+		AnalysisID: analysisID,
+		SpecID:     verdict.SpecID,
+		Verdict:    verdict.Verdict,
+		Violations: violations,
+	})
+	if err != nil {
+		specsLog.Error().Err(err).Int32("analysisID", analysisID).Msg("Failed to store analysis verdict")
+		return nil, err
+	}
+
+	stored := convertVerdictFromRepo(row)
+	return &stored, nil
+}
+
+// gradeAnalysis checks every rule of the spec against the analysis. Any violated
+// "fail" rule fails the analysis; otherwise any violated "warn" rule makes it a warning.
+func gradeAnalysis(spec models.ProductSpec, analysis models.Analysis) models.SpecVerdict {
+	verdict := models.SpecVerdict{
+		SpecID:     spec.ID,
+		Verdict:    models.VerdictPass,
+		Violations: []models.SpecViolation{},
+	}
+
+	for _, rule := range spec.Rules {
+		violation := checkRule(rule, analysis)
+		if violation == nil {
+			continue
+		}
+		verdict.Violations = append(verdict.Violations, *violation)
+
+		if violation.Severity == models.SeverityFail {
+			verdict.Verdict = models.VerdictFail
+		} else if verdict.Verdict == models.VerdictPass {
+			verdict.Verdict = models.VerdictWarn
+		}
+	}
+
+	return verdict
+}
+
+// checkRule returns a violation if the analysis breaks the rule, nil otherwise.
+func checkRule(rule models.SpecRule, analysis models.Analysis) *models.SpecViolation {
+	severity := rule.Severity
+	if severity == "" {
+		severity = models.SeverityFail
+	}
+
+	switch rule.Type {
+	case models.RuleClassShare:
+		share := 0.0
+		if len(analysis.Objects) > 0 {
+			matched := 0
+			for _, object := range analysis.Objects {
+				if object.Class == rule.Class {
+					matched++
+				}
+			}
+			share = float64(matched) / float64(len(analysis.Objects))
+		}
+		if withinBounds(share, rule.Min, rule.Max) {
+			return nil
+		}
+		return &models.SpecViolation{
+			Rule:     rule,
+			Severity: severity,
+			Actual:   share,
+			Message:  fmt.Sprintf("share of class %q is %.4f, expected %s", rule.Class, share, formatBounds(rule.Min, rule.Max)),
+		}
+
+	case models.RuleStat:
+		// A channel without stats can't be shown to be within bounds
+		stats, _ := statsChannel(analysis, rule.Channel)
+		if stats == nil {
+			return &models.SpecViolation{
+				Rule:     rule,
+				Severity: severity,
+				Actual:   nil,
+				Message:  fmt.Sprintf("%s has no stats", rule.Channel),
+			}
+		}
+		value, _ := statsField(*stats, rule.Field)
+		if withinBounds(value, rule.Min, rule.Max) {
+			return nil
+		}
+		return &models.SpecViolation{
+			Rule:     rule,
+			Severity: severity,
+			Actual:   value,
+			Message:  fmt.Sprintf("%s %s is %.4f, expected %s", rule.Channel, rule.Field, value, formatBounds(rule.Min, rule.Max)),
+		}
+
+	case models.RuleColorRhs:
+		for _, allowed := range rule.Values {
+			if strings.EqualFold(strings.TrimSpace(allowed), strings.TrimSpace(analysis.ColorRhs)) {
+				return nil
+			}
+		}
+		return &models.SpecViolation{
+			Rule:     rule,
+			Severity: severity,
+			Actual:   analysis.ColorRhs,
+			Message:  fmt.Sprintf("color_rhs %q is not one of %s", analysis.ColorRhs, strings.Join(rule.Values, ", ")),
+		}
+	}
+
+	return nil
+}
+
+// validateSpecRequest checks the request, maps its product onto the catalog code
+// and returns its rules encoded for storage.
+func (s *SpecsService) validateSpecRequest(ctx context.Context, req *models.ProductSpecRequest) ([]byte, error) {
+	req.Product = strings.TrimSpace(req.Product)
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Product == "" {
+		return nil, fmt.Errorf("%w: product is required", ErrInvalidSpec)
+	}
+	product, err := s.products.Canonical(ctx, req.Product)
+	if err != nil {
+		return nil, err
+	}
+	req.Product = product
+	if req.Name == "" {
+		req.Name = req.Product
+	}
+	if req.Rules == nil {
+		req.Rules = []models.SpecRule{}
+	}
+
+	for i, rule := range req.Rules {
+		if rule.Severity != "" && rule.Severity != models.SeverityFail && rule.Severity != models.SeverityWarn {
+			return nil, fmt.Errorf("%w: rule %d: severity must be %q or %q", ErrInvalidSpec, i, models.SeverityFail, models.SeverityWarn)
+		}
+
+		switch rule.Type {
+		case models.RuleClassShare:
+			if rule.Class == "" {
+				return nil, fmt.Errorf("%w: rule %d: class is required", ErrInvalidSpec, i)
+			}
+			if rule.Min == nil && rule.Max == nil {
+				return nil, fmt.Errorf("%w: rule %d: min or max is required", ErrInvalidSpec, i)
+			}
+		case models.RuleStat:
+			if _, ok := statsChannel(models.Analysis{}, rule.Channel); !ok {
+				return nil, fmt.Errorf("%w: rule %d: unknown channel %q", ErrInvalidSpec, i, rule.Channel)
+			}
+			if _, ok := statsField(models.Stats{}, rule.Field); !ok {
+				return nil, fmt.Errorf("%w: rule %d: unknown field %q", ErrInvalidSpec, i, rule.Field)
+			}
+			if rule.Min == nil && rule.Max == nil {
+				return nil, fmt.Errorf("%w: rule %d: min or max is required", ErrInvalidSpec, i)
+			}
+		case models.RuleColorRhs:
+			if len(rule.Values) == 0 {
+				return nil, fmt.Errorf("%w: rule %d: values are required", ErrInvalidSpec, i)
+			}
+		default:
+			return nil, fmt.Errorf("%w: rule %d: unknown type %q", ErrInvalidSpec, i, rule.Type)
+		}
+	}
+
+	return json.Marshal(req.Rules)
+}
+
+// statsChannel returns the Stats of a channel, nil if the analysis has none.
+func statsChannel(analysis models.Analysis, channel string) (*models.Stats, bool) {
+	switch channel {
+	case "r":
+		return analysis.R, true
+	case "g":
+		return analysis.G, true
+	case "b":
+		return analysis.B, true
+	case "h":
+		return analysis.H, true
+	case "s":
+		return analysis.S, true
+	case "v":
+		return analysis.V, true
+	case "lab_l":
+		return analysis.LabL, true
+	case "lab_a":
+		return analysis.LabA, true
+	case "lab_b":
+		return analysis.LabB, true
+	case "w":
+		return analysis.W, true
+	case "l":
+		return analysis.L, true
+	case "t":
+		return analysis.T, true
+	}
+	return nil, false
+}
+
+func statsField(stats models.Stats, field string) (float64, bool) {
+	switch field {
+	case "min":
+		return float64(stats.Min), true
+	case "max":
+		return float64(stats.Max), true
+	case "avg":
+		return float64(stats.Avg), true
+	case "median":
+		return float64(stats.Median), true
+	}
+	return 0, false
+}
+
+func withinBounds(value float64, lower, upper *float64) bool {
+	if lower != nil && value < *lower {
+		return false
+	}
+	if upper != nil && value > *upper {
+		return false
+	}
+	return true
+}
+
+func formatBounds(lower, upper *float64) string {
+	switch {
+	case lower != nil && upper != nil:
+		return fmt.Sprintf("between %g and %g", *lower, *upper)
+	case lower != nil:
+		return fmt.Sprintf(">= %g", *lower)
+	case upper != nil:
+		return fmt.Sprintf("<= %g", *upper)
+	}
+	return "any value"
+}
+
+func convertSpecFromRepo(row repository.ProductSpec) models.ProductSpec {
+	rules := []models.SpecRule{}
+	if err := json.Unmarshal(row.Rules, &rules); err != nil {
+		specsLog.Error().Err(err).Int32("specID", row.ID).Msg("Failed to unmarshal spec rules")
+	}
+
+	return models.ProductSpec{
+		ID:        row.ID,
+		Product:   row.Product,
+		Name:      row.Name,
+		Rules:     rules,
+		CreatedAt: row.CreatedAt,
+		UpdatedAt: row.UpdatedAt,
+	}
+}
+
+func convertVerdictFromRepo(row repository.AnalysisVerdict) models.SpecVerdict {
+	violations := []models.SpecViolation{}
+	if err := json.Unmarshal(row.Violations, &violations); err != nil {
+		specsLog.Error().Err(err).Int32("analysisID", row.AnalysisID).Msg("Failed to unmarshal verdict violations")
+	}
+
+	return models.SpecVerdict{
+		SpecID:      row.SpecID,
+		Verdict:     row.Verdict,
+		Violations:  violations,
+		EvaluatedAt: row.EvaluatedAt,
+	}
+}
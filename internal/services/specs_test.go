@@ -0,0 +1,153 @@
+package services
+
+import (
+	"testing"
+
+	"csort.ru/analysis-service/internal/models"
+)
+
+func bound(v float64) *float64 { return &v }
+
+// classes returns an analysis with one object per class given.
+func classes(names ...string) models.Analysis {
+	analysis := models.Analysis{Objects: make([]models.Object, len(names))}
+	for i, name := range names {
+		analysis.Objects[i].Class = name
+	}
+	return analysis
+}
+
+func TestCheckRule(t *testing.T) {
+	tests := []struct {
+		name     string
+		rule     models.SpecRule
+		analysis models.Analysis
+		// want is the severity of the expected violation, "" for none
+		want   string
+		actual interface{}
+	}{
+		{
+			name:     "class share within bounds",
+			rule:     models.SpecRule{Type: models.RuleClassShare, Class: "broken", Max: bound(0.5)},
+			analysis: classes("broken", "whole", "whole", "whole"),
+		},
+		{
+			name:     "class share on the bound",
+			rule:     models.SpecRule{Type: models.RuleClassShare, Class: "broken", Min: bound(0.25), Max: bound(0.25)},
+			analysis: classes("broken", "whole", "whole", "whole"),
+		},
+		{
+			name:     "class share above max",
+			rule:     models.SpecRule{Type: models.RuleClassShare, Class: "broken", Max: bound(0.1)},
+			analysis: classes("broken", "whole"),
+			want:     models.SeverityFail,
+			actual:   0.5,
+		},
+		{
+			name:     "class share below min",
+			rule:     models.SpecRule{Type: models.RuleClassShare, Severity: models.SeverityWarn, Class: "whole", Min: bound(0.9)},
+			analysis: classes("broken", "whole"),
+			want:     models.SeverityWarn,
+			actual:   0.5,
+		},
+		{
+			// No objects is a share of 0, not a division by zero
+			name:     "class share without objects",
+			rule:     models.SpecRule{Type: models.RuleClassShare, Class: "whole", Min: bound(0.5)},
+			analysis: classes(),
+			want:     models.SeverityFail,
+			actual:   0.0,
+		},
+		{
+			name:     "stat within bounds",
+			rule:     models.SpecRule{Type: models.RuleStat, Channel: "r", Field: "avg", Min: bound(100), Max: bound(150)},
+			analysis: models.Analysis{R: &models.Stats{Avg: 120}},
+		},
+		{
+			name:     "stat out of bounds",
+			rule:     models.SpecRule{Type: models.RuleStat, Channel: "r", Field: "median", Max: bound(100)},
+			analysis: models.Analysis{R: &models.Stats{Median: 118}},
+			want:     models.SeverityFail,
+			actual:   118.0,
+		},
+		{
+			// A missing channel can't be shown to be within bounds
+			name:     "stat with missing channel",
+			rule:     models.SpecRule{Type: models.RuleStat, Channel: "g", Field: "avg", Max: bound(100)},
+			analysis: models.Analysis{R: &models.Stats{Avg: 120}},
+			want:     models.SeverityFail,
+			actual:   nil,
+		},
+		{
+			name:     "color rhs ignores case and spaces",
+			rule:     models.SpecRule{Type: models.RuleColorRhs, Values: []string{"Yellow 2A", "yellow 3B"}},
+			analysis: models.Analysis{ColorRhs: " YELLOW 2a"},
+		},
+		{
+			name:     "color rhs not allowed",
+			rule:     models.SpecRule{Type: models.RuleColorRhs, Severity: models.SeverityWarn, Values: []string{"yellow 2A"}},
+			analysis: models.Analysis{ColorRhs: "green 1A"},
+			want:     models.SeverityWarn,
+			actual:   "green 1A",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violation := checkRule(tt.rule, tt.analysis)
+			if tt.want == "" {
+				if violation != nil {
+					t.Fatalf("got violation %q, want none", violation.Message)
+				}
+				return
+			}
+			if violation == nil {
+				t.Fatalf("got no violation, want a %s", tt.want)
+			}
+			if violation.Severity != tt.want {
+				t.Errorf("got severity %q, want %q", violation.Severity, tt.want)
+			}
+			if violation.Actual != tt.actual {
+				t.Errorf("got actual %v, want %v", violation.Actual, tt.actual)
+			}
+			if violation.Message == "" {
+				t.Error("got an empty message")
+			}
+		})
+	}
+}
+
+func TestGradeAnalysis(t *testing.T) {
+	// The analysis has half its objects broken and no color
+	analysis := classes("broken", "whole")
+	pass := models.SpecRule{Type: models.RuleClassShare, Class: "broken", Max: bound(0.5)}
+	warn := models.SpecRule{Type: models.RuleClassShare, Severity: models.SeverityWarn, Class: "broken", Max: bound(0.1)}
+	fail := models.SpecRule{Type: models.RuleColorRhs, Values: []string{"yellow 2A"}}
+
+	tests := []struct {
+		name           string
+		rules          []models.SpecRule
+		want           string
+		wantViolations int
+	}{
+		{"no rules", nil, models.VerdictPass, 0},
+		{"all pass", []models.SpecRule{pass}, models.VerdictPass, 0},
+		{"warning", []models.SpecRule{pass, warn}, models.VerdictWarn, 1},
+		{"fail after warning", []models.SpecRule{warn, fail}, models.VerdictFail, 2},
+		// A later warning doesn't soften a failure
+		{"warning after fail", []models.SpecRule{fail, warn, pass}, models.VerdictFail, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verdict := gradeAnalysis(models.ProductSpec{ID: 3, Rules: tt.rules}, analysis)
+			if verdict.SpecID != 3 {
+				t.Errorf("got spec %d, want 3", verdict.SpecID)
+			}
+			if verdict.Verdict != tt.want {
+				t.Errorf("got verdict %q, want %q", verdict.Verdict, tt.want)
+			}
+			if verdict.Violations == nil || len(verdict.Violations) != tt.wantViolations {
+				t.Errorf("got violations %+v, want %d", verdict.Violations, tt.wantViolations)
+			}
+		})
+	}
+}
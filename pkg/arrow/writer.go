@@ -0,0 +1,61 @@
+// Package arrow writes the Apache Arrow IPC streaming format.
+//
+// Only flat schemas of the columnar package types are supported. Each batch is
+// written as one record batch, uncompressed, so readers can start consuming the
+// stream before it ends. The encoding is left to the Arrow library's ipc package.
+package arrow
+
+import (
+	"errors"
+	"io"
+
+	"csort.ru/analysis-service/pkg/columnar"
+	arrowgo "github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+)
+
+type Writer struct {
+	ipc         *ipc.Writer
+	schema      columnar.Schema
+	arrowSchema *arrowgo.Schema
+	closed      bool
+}
+
+// NewWriter starts a stream with the given schema. The schema message is
+// written with the first batch, or by Close if there is none.
+func NewWriter(w io.Writer, schema columnar.Schema) (*Writer, error) {
+	if len(schema) == 0 {
+		return nil, errors.New("arrow: empty schema")
+	}
+	arrowSchema, err := columnar.ArrowSchema(schema)
+	if err != nil {
+		return nil, err
+	}
+	return &Writer{
+		ipc:         ipc.NewWriter(w, ipc.WithSchema(arrowSchema)),
+		schema:      schema,
+		arrowSchema: arrowSchema,
+	}, nil
+}
+
+// WriteBatch writes the batch as a record batch.
+func (w *Writer) WriteBatch(b *columnar.Batch) error {
+	if w.closed {
+		return errors.New("arrow: writer is closed")
+	}
+	record, err := b.Record(w.schema, w.arrowSchema)
+	if err != nil {
+		return err
+	}
+	defer record.Release()
+	return w.ipc.Write(record)
+}
+
+// Close writes the end-of-stream marker. It doesn't close the underlying writer.
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	return w.ipc.Close()
+}
@@ -0,0 +1,195 @@
+package arrow
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"testing"
+
+	"csort.ru/analysis-service/pkg/columnar"
+	arrowgo "github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+)
+
+var testSchema = columnar.Schema{
+	{Name: "id", Type: columnar.Int32},
+	{Name: "id_image", Type: columnar.Int64, Nullable: true},
+	{Name: "l", Type: columnar.Float64, Nullable: true},
+	{Name: "class", Type: columnar.String, Nullable: true},
+	{Name: "date_time", Type: columnar.Timestamp},
+}
+
+func testBatches() []*columnar.Batch {
+	// Nine rows, so the validity bitmaps span two bytes
+	first := columnar.NewBatch(testSchema, 9)
+	first.Rows = 9
+	first.Columns[0].Int32s = []int32{1, 2, 3, 4, 5, 6, 7, 8, math.MinInt32}
+	first.Columns[1].Int64s = []int64{10, 0, 12, 13, 14, 15, 16, 17, 0}
+	first.Columns[1].Valid = []bool{true, false, true, true, true, true, true, true, false}
+	first.Columns[2].Float64s = []float64{1.5, 2, 3, 4, 5, 6, 7, 8, math.Inf(1)}
+	first.Columns[2].Valid = []bool{true, true, true, true, true, true, true, true, true}
+	first.Columns[3].Strings = []string{"пшеница", "", "ignored", "a", "b", "c", "d", "e", "barley"}
+	first.Columns[3].Valid = []bool{true, true, false, true, true, true, true, true, true}
+	first.Columns[4].Int64s = []int64{0, 1714566600000000, -1, 3, 4, 5, 6, 7, math.MaxInt64}
+
+	second := columnar.NewBatch(testSchema, 1)
+	second.Rows = 1
+	second.Columns[0].Int32s = []int32{42}
+	second.Columns[1].Int64s = []int64{0}
+	second.Columns[1].Valid = []bool{false}
+	second.Columns[2].Float64s = []float64{0.5}
+	second.Columns[2].Valid = []bool{true}
+	second.Columns[3].Strings = []string{"oats"}
+	second.Columns[3].Valid = []bool{true}
+	second.Columns[4].Int64s = []int64{42}
+	return []*columnar.Batch{first, second}
+}
+
+// batchValues formats column i of b, nulls as "null".
+func batchValues(b *columnar.Batch, field columnar.Field, i int) []string {
+	c := &b.Columns[i]
+	values := make([]string, b.Rows)
+	for row := range values {
+		switch {
+		case !c.IsValid(row):
+			values[row] = "null"
+		case field.Type == columnar.Int32:
+			values[row] = fmt.Sprint(c.Int32s[row])
+		case field.Type == columnar.Int64 || field.Type == columnar.Timestamp:
+			values[row] = fmt.Sprint(c.Int64s[row])
+		case field.Type == columnar.Float64:
+			values[row] = fmt.Sprint(c.Float64s[row])
+		case field.Type == columnar.String:
+			values[row] = fmt.Sprintf("%q", c.Strings[row])
+		}
+	}
+	return values
+}
+
+// arrayValues formats an array decoded by the Arrow library like batchValues.
+func arrayValues(t *testing.T, arr arrowgo.Array) []string {
+	t.Helper()
+	values := make([]string, arr.Len())
+	for row := range values {
+		if arr.IsNull(row) {
+			values[row] = "null"
+			continue
+		}
+		switch a := arr.(type) {
+		case *array.Int32:
+			values[row] = fmt.Sprint(a.Value(row))
+		case *array.Int64:
+			values[row] = fmt.Sprint(a.Value(row))
+		case *array.Timestamp:
+			values[row] = fmt.Sprint(int64(a.Value(row)))
+		case *array.Float64:
+			values[row] = fmt.Sprint(a.Value(row))
+		case *array.String:
+			values[row] = fmt.Sprintf("%q", a.Value(row))
+		default:
+			t.Fatalf("unexpected array type %s", arr.DataType())
+		}
+	}
+	return values
+}
+
+// TestWriterRoundTrip reads the stream back with the Arrow library's own reader.
+func TestWriterRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, testSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	batches := testBatches()
+	for _, b := range batches {
+		if err := w.WriteBatch(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := ipc.NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Release()
+
+	wantTypes := []string{"int32", "int64", "float64", "utf8", "timestamp[us]"}
+	fields := r.Schema().Fields()
+	if len(fields) != len(testSchema) {
+		t.Fatalf("got %d fields, want %d", len(fields), len(testSchema))
+	}
+	for i, field := range fields {
+		want := testSchema[i]
+		if field.Name != want.Name || field.Nullable != want.Nullable || field.Type.String() != wantTypes[i] {
+			t.Errorf("field %d: got %s nullable %v type %s, want %+v of %s", i, field.Name, field.Nullable, field.Type, want, wantTypes[i])
+		}
+	}
+
+	m := 0
+	for ; r.Next(); m++ {
+		if m >= len(batches) {
+			t.Fatalf("got more than %d record batches", len(batches))
+		}
+		record := r.RecordBatch()
+		b := batches[m]
+		if record.NumRows() != int64(b.Rows) {
+			t.Errorf("batch %d: got %d rows, want %d", m, record.NumRows(), b.Rows)
+		}
+		for i, field := range testSchema {
+			got := arrayValues(t, record.Column(i))
+			if want := batchValues(b, field, i); fmt.Sprint(got) != fmt.Sprint(want) {
+				t.Errorf("batch %d, column %s: got %v, want %v", m, field.Name, got, want)
+			}
+		}
+	}
+	if err := r.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if m != len(batches) {
+		t.Errorf("got %d record batches, want %d", m, len(batches))
+	}
+}
+
+func TestWriterEmptyStream(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, testSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The schema is still written, so readers know the columns of an empty export
+	r, err := ipc.NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Release()
+	if len(r.Schema().Fields()) != len(testSchema) {
+		t.Errorf("got %d fields, want %d", len(r.Schema().Fields()), len(testSchema))
+	}
+	if r.Next() {
+		t.Error("got a record batch from an empty stream")
+	}
+	if err := r.Err(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWriterRejectsInvalidBatch(t *testing.T) {
+	w, err := NewWriter(io.Discard, testSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := testBatches()[0]
+	b.Columns[3].Valid = b.Columns[3].Valid[:1]
+	if err := w.WriteBatch(b); err == nil {
+		t.Error("got no error for a validity shorter than the batch")
+	}
+}
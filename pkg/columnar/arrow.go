@@ -0,0 +1,66 @@
+package columnar
+
+import (
+	"fmt"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+)
+
+// ArrowSchema returns the Arrow schema of a columnar schema, for the writers
+// that encode batches through the Arrow library.
+func ArrowSchema(schema Schema) (*arrow.Schema, error) {
+	fields := make([]arrow.Field, len(schema))
+	for i, field := range schema {
+		var typ arrow.DataType
+		switch field.Type {
+		case Int32:
+			typ = arrow.PrimitiveTypes.Int32
+		case Int64:
+			typ = arrow.PrimitiveTypes.Int64
+		case Float64:
+			typ = arrow.PrimitiveTypes.Float64
+		case String:
+			typ = arrow.BinaryTypes.String
+		case Timestamp:
+			typ = &arrow.TimestampType{Unit: arrow.Microsecond}
+		default:
+			return nil, fmt.Errorf("columnar: unsupported type of column %q", field.Name)
+		}
+		fields[i] = arrow.Field{Name: field.Name, Type: typ, Nullable: field.Nullable}
+	}
+	return arrow.NewSchema(fields, nil), nil
+}
+
+// Record copies the batch into an Arrow record of the schema returned by
+// ArrowSchema. The caller releases it.
+func (b *Batch) Record(schema Schema, arrowSchema *arrow.Schema) (arrow.RecordBatch, error) {
+	if err := b.Validate(schema); err != nil {
+		return nil, err
+	}
+
+	rb := array.NewRecordBuilder(memory.DefaultAllocator, arrowSchema)
+	defer rb.Release()
+	rb.Reserve(b.Rows)
+	for i, field := range schema {
+		c := &b.Columns[i]
+		switch field.Type {
+		case Int32:
+			rb.Field(i).(*array.Int32Builder).AppendValues(c.Int32s, c.Valid)
+		case Int64:
+			rb.Field(i).(*array.Int64Builder).AppendValues(c.Int64s, c.Valid)
+		case Float64:
+			rb.Field(i).(*array.Float64Builder).AppendValues(c.Float64s, c.Valid)
+		case String:
+			rb.Field(i).(*array.StringBuilder).AppendValues(c.Strings, c.Valid)
+		case Timestamp:
+			values := make([]arrow.Timestamp, len(c.Int64s))
+			for row, v := range c.Int64s {
+				values[row] = arrow.Timestamp(v)
+			}
+			rb.Field(i).(*array.TimestampBuilder).AppendValues(values, c.Valid)
+		}
+	}
+	return rb.NewRecordBatch(), nil
+}
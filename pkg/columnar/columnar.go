@@ -0,0 +1,106 @@
+// Package columnar holds the schema and batch types shared by the columnar file writers.
+package columnar
+
+import "fmt"
+
+type Type int
+
+const (
+	Int32 Type = iota
+	Int64
+	Float64
+	String
+	// Timestamp holds microseconds since the Unix epoch, without a time zone, in Int64s.
+	Timestamp
+)
+
+type Field struct {
+	Name     string
+	Type     Type
+	Nullable bool
+}
+
+type Schema []Field
+
+// Column holds the values of one field. Only the slice matching the field type
+// is used; it has a slot for every row, null rows included. Valid is nil when
+// every value is present.
+type Column struct {
+	Valid    []bool
+	Int32s   []int32
+	Int64s   []int64
+	Float64s []float64
+	Strings  []string
+}
+
+// IsValid reports whether row i holds a value.
+func (c *Column) IsValid(i int) bool {
+	return c.Valid == nil || c.Valid[i]
+}
+
+// NullCount returns the number of null rows.
+func (c *Column) NullCount() int {
+	n := 0
+	for _, valid := range c.Valid {
+		if !valid {
+			n++
+		}
+	}
+	return n
+}
+
+// Batch is a set of rows stored column by column, in schema order.
+type Batch struct {
+	Rows    int
+	Columns []Column
+}
+
+// NewBatch allocates a batch with room for capacity rows.
+func NewBatch(schema Schema, capacity int) *Batch {
+	b := &Batch{Columns: make([]Column, len(schema))}
+	for i, field := range schema {
+		c := &b.Columns[i]
+		if field.Nullable {
+			c.Valid = make([]bool, 0, capacity)
+		}
+		switch field.Type {
+		case Int32:
+			c.Int32s = make([]int32, 0, capacity)
+		case Int64, Timestamp:
+			c.Int64s = make([]int64, 0, capacity)
+		case Float64:
+			c.Float64s = make([]float64, 0, capacity)
+		case String:
+			c.Strings = make([]string, 0, capacity)
+		}
+	}
+	return b
+}
+
+// Validate checks that the batch has a column per field, each with Rows values.
+func (b *Batch) Validate(schema Schema) error {
+	if len(b.Columns) != len(schema) {
+		return fmt.Errorf("columnar: batch has %d columns, schema has %d", len(b.Columns), len(schema))
+	}
+	for i, field := range schema {
+		c := &b.Columns[i]
+		var n int
+		switch field.Type {
+		case Int32:
+			n = len(c.Int32s)
+		case Int64, Timestamp:
+			n = len(c.Int64s)
+		case Float64:
+			n = len(c.Float64s)
+		case String:
+			n = len(c.Strings)
+		}
+		if n != b.Rows || (c.Valid != nil && len(c.Valid) != b.Rows) {
+			return fmt.Errorf("columnar: column %q has %d values, batch has %d rows", field.Name, n, b.Rows)
+		}
+		if c.Valid != nil && !field.Nullable {
+			return fmt.Errorf("columnar: column %q is not nullable", field.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+// Package parquet writes Apache Parquet files as a stream of row groups.
+//
+// Only flat schemas of the columnar package types are supported. Every batch
+// becomes one row group, gzip compressed; NULLs are kept through definition
+// levels. The encoding is left to the Arrow library's pqarrow package.
+package parquet
+
+import (
+	"errors"
+	"io"
+
+	"csort.ru/analysis-service/pkg/columnar"
+	arrowgo "github.com/apache/arrow-go/v18/arrow"
+	parquetgo "github.com/apache/arrow-go/v18/parquet"
+	"github.com/apache/arrow-go/v18/parquet/compress"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+)
+
+const createdBy = "csort analysis-service"
+
+type Writer struct {
+	fw          *pqarrow.FileWriter
+	schema      columnar.Schema
+	arrowSchema *arrowgo.Schema
+	closed      bool
+}
+
+// NewWriter starts a Parquet file with the given schema.
+func NewWriter(w io.Writer, schema columnar.Schema) (*Writer, error) {
+	if len(schema) == 0 {
+		return nil, errors.New("parquet: empty schema")
+	}
+	arrowSchema, err := columnar.ArrowSchema(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	props := parquetgo.NewWriterProperties(
+		parquetgo.WithCompression(compress.Codecs.Gzip),
+		parquetgo.WithCreatedBy(createdBy),
+	)
+	// The file writer closes its sink if it can, so it gets w without its Close
+	fw, err := pqarrow.NewFileWriter(arrowSchema, struct{ io.Writer }{w}, props, pqarrow.DefaultWriterProps())
+	if err != nil {
+		return nil, err
+	}
+	return &Writer{fw: fw, schema: schema, arrowSchema: arrowSchema}, nil
+}
+
+// WriteBatch writes the batch as a row group.
+func (w *Writer) WriteBatch(b *columnar.Batch) error {
+	if w.closed {
+		return errors.New("parquet: writer is closed")
+	}
+	record, err := b.Record(w.schema, w.arrowSchema)
+	if err != nil {
+		return err
+	}
+	defer record.Release()
+	if record.NumRows() == 0 {
+		return nil
+	}
+	return w.fw.Write(record)
+}
+
+// Close writes the file footer. It doesn't close the underlying writer.
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	return w.fw.Close()
+}
@@ -0,0 +1,224 @@
+package parquet
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"testing"
+
+	"csort.ru/analysis-service/pkg/columnar"
+	arrowgo "github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet/compress"
+	"github.com/apache/arrow-go/v18/parquet/file"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+)
+
+var testSchema = columnar.Schema{
+	{Name: "id", Type: columnar.Int32},
+	{Name: "id_image", Type: columnar.Int64, Nullable: true},
+	{Name: "l", Type: columnar.Float64, Nullable: true},
+	{Name: "class", Type: columnar.String, Nullable: true},
+	{Name: "date_time", Type: columnar.Timestamp},
+}
+
+// testBatches returns two batches covering nulls, runs of nulls, empty and
+// non-ASCII strings and extreme values.
+func testBatches() []*columnar.Batch {
+	first := columnar.NewBatch(testSchema, 4)
+	first.Rows = 4
+	first.Columns[0].Int32s = []int32{1, 2, math.MaxInt32, math.MinInt32}
+	first.Columns[1].Int64s = []int64{10, 0, 0, -1}
+	first.Columns[1].Valid = []bool{true, false, false, true}
+	first.Columns[2].Float64s = []float64{1.5, 0, math.Inf(-1), 2.25}
+	first.Columns[2].Valid = []bool{true, false, true, true}
+	first.Columns[3].Strings = []string{"пшеница", "", "", "barley"}
+	first.Columns[3].Valid = []bool{true, true, false, true}
+	first.Columns[4].Int64s = []int64{0, 1714566600000000, -1, math.MaxInt64}
+
+	second := columnar.NewBatch(testSchema, 1)
+	second.Rows = 1
+	second.Columns[0].Int32s = []int32{5}
+	second.Columns[1].Int64s = []int64{7}
+	second.Columns[1].Valid = []bool{true}
+	second.Columns[2].Float64s = []float64{0}
+	second.Columns[2].Valid = []bool{false}
+	second.Columns[3].Strings = []string{"oats"}
+	second.Columns[3].Valid = []bool{true}
+	second.Columns[4].Int64s = []int64{42}
+	return []*columnar.Batch{first, second}
+}
+
+// batchValues formats column i of b, nulls as "null".
+func batchValues(b *columnar.Batch, field columnar.Field, i int) []string {
+	c := &b.Columns[i]
+	values := make([]string, b.Rows)
+	for row := range values {
+		switch {
+		case !c.IsValid(row):
+			values[row] = "null"
+		case field.Type == columnar.Int32:
+			values[row] = fmt.Sprint(c.Int32s[row])
+		case field.Type == columnar.Int64 || field.Type == columnar.Timestamp:
+			values[row] = fmt.Sprint(c.Int64s[row])
+		case field.Type == columnar.Float64:
+			values[row] = fmt.Sprint(c.Float64s[row])
+		case field.Type == columnar.String:
+			values[row] = fmt.Sprintf("%q", c.Strings[row])
+		}
+	}
+	return values
+}
+
+// columnValues formats a column decoded by the Arrow library like batchValues.
+func columnValues(t *testing.T, column *arrowgo.Chunked) []string {
+	t.Helper()
+	var values []string
+	for _, arr := range column.Chunks() {
+		for row := 0; row < arr.Len(); row++ {
+			if arr.IsNull(row) {
+				values = append(values, "null")
+				continue
+			}
+			switch a := arr.(type) {
+			case *array.Int32:
+				values = append(values, fmt.Sprint(a.Value(row)))
+			case *array.Int64:
+				values = append(values, fmt.Sprint(a.Value(row)))
+			case *array.Timestamp:
+				values = append(values, fmt.Sprint(int64(a.Value(row))))
+			case *array.Float64:
+				values = append(values, fmt.Sprint(a.Value(row)))
+			case *array.String:
+				values = append(values, fmt.Sprintf("%q", a.Value(row)))
+			default:
+				t.Fatalf("unexpected array type %s", arr.DataType())
+			}
+		}
+	}
+	return values
+}
+
+// TestWriterRoundTrip reads the file back with the Arrow library's own Parquet
+// reader.
+func TestWriterRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, testSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	batches := testBatches()
+	for _, b := range batches {
+		if err := w.WriteBatch(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.WriteBatch(columnar.NewBatch(testSchema, 0)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	pf, err := file.NewParquetReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pf.Close()
+
+	metadata := pf.MetaData()
+	if metadata.NumRows != 5 || metadata.GetCreatedBy() != createdBy {
+		t.Errorf("got %d rows created by %q, want 5 by %q", metadata.NumRows, metadata.GetCreatedBy(), createdBy)
+	}
+	wantTypes := []string{"INT32 Int(bitWidth=32, isSigned=true)", "INT64 Int(bitWidth=64, isSigned=true)", "DOUBLE None", "BYTE_ARRAY String", "INT64 Timestamp"}
+	schema := metadata.Schema
+	if schema.NumColumns() != len(testSchema) {
+		t.Fatalf("got %d columns, want %d", schema.NumColumns(), len(testSchema))
+	}
+	for i, field := range testSchema {
+		column := schema.Column(i)
+		typ := column.PhysicalType().String() + " " + column.LogicalType().String()
+		if column.Name() != field.Name || (column.MaxDefinitionLevel() == 1) != field.Nullable || !strings.HasPrefix(typ, wantTypes[i]) {
+			t.Errorf("column %d: got %s nullable %v type %s, want %+v of %s", i, column.Name(), column.MaxDefinitionLevel() == 1, typ, field, wantTypes[i])
+		}
+	}
+
+	// The empty batch writes no row group
+	if pf.NumRowGroups() != len(batches) {
+		t.Fatalf("got %d row groups, want %d", pf.NumRowGroups(), len(batches))
+	}
+	fr, err := pqarrow.NewFileReader(pf, pqarrow.ArrowReadProperties{}, memory.DefaultAllocator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for g, b := range batches {
+		group := metadata.RowGroup(g)
+		if group.NumRows() != int64(b.Rows) {
+			t.Errorf("row group %d: got %d rows, want %d", g, group.NumRows(), b.Rows)
+		}
+		for i := range testSchema {
+			chunk, err := group.ColumnChunk(i)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if chunk.Compression() != compress.Codecs.Gzip {
+				t.Errorf("row group %d, column %d: got codec %s, want gzip", g, i, chunk.Compression())
+			}
+		}
+
+		table, err := fr.ReadRowGroups(context.Background(), []int{0, 1, 2, 3, 4}, []int{g})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, field := range testSchema {
+			got := columnValues(t, table.Column(i).Data())
+			if want := batchValues(b, field, i); fmt.Sprint(got) != fmt.Sprint(want) {
+				t.Errorf("row group %d, column %s: got %v, want %v", g, field.Name, got, want)
+			}
+		}
+		table.Release()
+	}
+}
+
+func TestWriterDoesNotCloseOutput(t *testing.T) {
+	out := &closeRecorder{}
+	w, err := NewWriter(out, testSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if out.closed {
+		t.Error("closing the writer closed its output")
+	}
+}
+
+type closeRecorder struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestWriterRejectsInvalidBatch(t *testing.T) {
+	w, err := NewWriter(io.Discard, testSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := testBatches()[0]
+	b.Columns[0].Int32s = b.Columns[0].Int32s[:2]
+	if err := w.WriteBatch(b); err == nil {
+		t.Error("got no error for a column shorter than the batch")
+	}
+	if _, err := NewWriter(io.Discard, nil); err == nil {
+		t.Error("got no error for an empty schema")
+	}
+}
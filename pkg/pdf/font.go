@@ -0,0 +1,466 @@
+package pdf
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math/bits"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf16"
+)
+
+//go:embed fonts/DejaVuSans.ttf
+var dejaVuSans []byte
+
+//go:embed fonts/DejaVuSans-Bold.ttf
+var dejaVuSansBold []byte
+
+// faces returns the parsed fonts, indexed by Font. They are parsed on first use
+// so programs that don't write PDFs don't pay for it.
+var faces = sync.OnceValue(func() [2]*trueType {
+	return [2]*trueType{
+		mustParseTrueType("DejaVuSans", dejaVuSans),
+		mustParseTrueType("DejaVuSans-Bold", dejaVuSansBold),
+	}
+})
+
+// trueType is a TrueType font, parsed as far as needed to measure text and embed
+// a subset of it.
+type trueType struct {
+	name       string // PostScript name
+	unitsPerEm int
+	ascent     int // in font units, like the remaining metrics
+	descent    int
+	capHeight  int
+	bbox       [4]int
+	cmap       map[rune]uint16
+	advances   []uint16 // per glyph
+	loca       []uint32 // glyph offsets into glyf, numGlyphs+1 entries
+	tables     map[string][]byte
+}
+
+// glyph is a glyph id with the character it was set for, which ToUnicode maps it back to.
+type glyph struct {
+	id uint16
+	r  rune
+}
+
+func mustParseTrueType(name string, data []byte) *trueType {
+	f, err := parseTrueType(name, data)
+	if err != nil {
+		panic(fmt.Sprintf("pdf: embedded font %s: %v", name, err))
+	}
+	return f
+}
+
+func parseTrueType(name string, data []byte) (*trueType, error) {
+	if len(data) < 12 {
+		return nil, errors.New("truncated offset table")
+	}
+	f := &trueType{name: name, tables: map[string][]byte{}}
+	numTables := int(binary.BigEndian.Uint16(data[4:]))
+	for i := 0; i < numTables; i++ {
+		record := 12 + 16*i
+		if record+16 > len(data) {
+			return nil, errors.New("truncated table directory")
+		}
+		offset := int(binary.BigEndian.Uint32(data[record+8:]))
+		length := int(binary.BigEndian.Uint32(data[record+12:]))
+		if offset < 0 || length < 0 || offset+length > len(data) {
+			return nil, fmt.Errorf("table %q out of bounds", data[record:record+4])
+		}
+		f.tables[string(data[record:record+4])] = data[offset : offset+length]
+	}
+	// cmap is optional, as subsets are used by glyph id
+	for _, tag := range []string{"head", "hhea", "maxp", "hmtx", "loca", "glyf"} {
+		if f.tables[tag] == nil {
+			return nil, fmt.Errorf("missing %s table", tag)
+		}
+	}
+
+	head, hhea, maxp := f.tables["head"], f.tables["hhea"], f.tables["maxp"]
+	if len(head) < 54 || len(hhea) < 36 || len(maxp) < 6 {
+		return nil, errors.New("truncated head, hhea or maxp table")
+	}
+	f.unitsPerEm = int(binary.BigEndian.Uint16(head[18:]))
+	for i := range f.bbox {
+		f.bbox[i] = int(int16(binary.BigEndian.Uint16(head[36+2*i:])))
+	}
+	f.ascent = int(int16(binary.BigEndian.Uint16(hhea[4:])))
+	f.descent = int(int16(binary.BigEndian.Uint16(hhea[6:])))
+	f.capHeight = f.ascent
+	if os2 := f.tables["OS/2"]; len(os2) >= 90 && binary.BigEndian.Uint16(os2) >= 2 {
+		f.capHeight = int(int16(binary.BigEndian.Uint16(os2[88:])))
+	}
+	if f.unitsPerEm == 0 {
+		return nil, errors.New("zero units per em")
+	}
+
+	numGlyphs := int(binary.BigEndian.Uint16(maxp[4:]))
+	numMetrics := int(binary.BigEndian.Uint16(hhea[34:]))
+	hmtx := f.tables["hmtx"]
+	if numMetrics == 0 || numMetrics > numGlyphs || len(hmtx) < 4*numMetrics {
+		return nil, errors.New("invalid horizontal metrics")
+	}
+	f.advances = make([]uint16, numGlyphs)
+	for i := range f.advances {
+		// Glyphs past the last metric share its advance
+		f.advances[i] = binary.BigEndian.Uint16(hmtx[4*min(i, numMetrics-1):])
+	}
+
+	loca, longOffsets := f.tables["loca"], binary.BigEndian.Uint16(head[50:]) == 1
+	f.loca = make([]uint32, numGlyphs+1)
+	for i := range f.loca {
+		switch {
+		case longOffsets && 4*i+4 <= len(loca):
+			f.loca[i] = binary.BigEndian.Uint32(loca[4*i:])
+		case !longOffsets && 2*i+2 <= len(loca):
+			f.loca[i] = 2 * uint32(binary.BigEndian.Uint16(loca[2*i:]))
+		default:
+			return nil, errors.New("truncated loca table")
+		}
+		if f.loca[i] > uint32(len(f.tables["glyf"])) || (i > 0 && f.loca[i] < f.loca[i-1]) {
+			return nil, errors.New("invalid loca table")
+		}
+	}
+
+	if cmap, ok := f.tables["cmap"]; ok {
+		var err error
+		if f.cmap, err = parseCmap(cmap); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// parseCmap reads the Unicode character map, preferring the full repertoire
+// (format 12) over the Basic Multilingual Plane one (format 4).
+func parseCmap(data []byte) (map[rune]uint16, error) {
+	if len(data) < 4 {
+		return nil, errors.New("truncated cmap table")
+	}
+	var bmp, full []byte
+	for i := 0; i < int(binary.BigEndian.Uint16(data[2:])); i++ {
+		record := 4 + 8*i
+		if record+8 > len(data) {
+			return nil, errors.New("truncated cmap table")
+		}
+		platform, encoding := binary.BigEndian.Uint16(data[record:]), binary.BigEndian.Uint16(data[record+2:])
+		offset := int(binary.BigEndian.Uint32(data[record+4:]))
+		if offset+4 > len(data) {
+			return nil, errors.New("truncated cmap table")
+		}
+		switch subtable := data[offset:]; {
+		case platform == 3 && encoding == 10 && binary.BigEndian.Uint16(subtable) == 12:
+			full = subtable
+		case platform == 3 && encoding == 1 && binary.BigEndian.Uint16(subtable) == 4:
+			bmp = subtable
+		}
+	}
+
+	cmap := map[rune]uint16{}
+	switch {
+	case full != nil:
+		if len(full) < 16 {
+			return nil, errors.New("truncated cmap subtable")
+		}
+		groups := int(binary.BigEndian.Uint32(full[12:]))
+		if 16+12*groups > len(full) {
+			return nil, errors.New("truncated cmap subtable")
+		}
+		for i := 0; i < groups; i++ {
+			group := full[16+12*i:]
+			start, end := binary.BigEndian.Uint32(group), binary.BigEndian.Uint32(group[4:])
+			gid := binary.BigEndian.Uint32(group[8:])
+			for c := start; c <= end && c <= 0x10FFFF; c++ {
+				cmap[rune(c)] = uint16(gid + c - start)
+			}
+		}
+	case bmp != nil:
+		if len(bmp) < 14 {
+			return nil, errors.New("truncated cmap subtable")
+		}
+		segX2 := int(binary.BigEndian.Uint16(bmp[6:]))
+		if 16+4*segX2 > len(bmp) {
+			return nil, errors.New("truncated cmap subtable")
+		}
+		for seg := 0; seg < segX2; seg += 2 {
+			end := int(binary.BigEndian.Uint16(bmp[14+seg:]))
+			start := int(binary.BigEndian.Uint16(bmp[16+segX2+seg:]))
+			delta := binary.BigEndian.Uint16(bmp[16+2*segX2+seg:])
+			rangeAt := 16 + 3*segX2 + seg
+			rangeOffset := int(binary.BigEndian.Uint16(bmp[rangeAt:]))
+			for c := start; c <= end && c != 0xFFFF; c++ {
+				gid := uint16(c) + delta
+				if rangeOffset != 0 {
+					at := rangeAt + rangeOffset + 2*(c-start)
+					if at+2 > len(bmp) {
+						return nil, errors.New("truncated cmap subtable")
+					}
+					if gid = binary.BigEndian.Uint16(bmp[at:]); gid != 0 {
+						gid += delta
+					}
+				}
+				if gid != 0 {
+					cmap[rune(c)] = gid
+				}
+			}
+		}
+	default:
+		return nil, errors.New("no Unicode cmap subtable")
+	}
+	return cmap, nil
+}
+
+// glyphs maps s to glyphs. Tabs and line breaks become spaces, other control
+// characters are dropped, and characters the font lacks are shown as "?".
+func (f *trueType) glyphs(s string) []glyph {
+	out := make([]glyph, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r == '\t' || r == '\n' || r == '\r':
+			r = ' '
+		case r < 0x20:
+			continue
+		}
+		id, ok := f.cmap[r]
+		if !ok {
+			r, id = '?', f.cmap['?']
+		}
+		out = append(out, glyph{id: id, r: r})
+	}
+	return out
+}
+
+// width returns the advance of a glyph in 1/1000 of the font size.
+func (f *trueType) width(id uint16) int {
+	if int(id) >= len(f.advances) {
+		return 0
+	}
+	return (int(f.advances[id])*1000 + f.unitsPerEm/2) / f.unitsPerEm
+}
+
+// scale converts font units to 1/1000 of the font size.
+func (f *trueType) scale(v int) int {
+	return v * 1000 / f.unitsPerEm
+}
+
+// glyphData returns the outline of a glyph, empty for glyphs without one.
+func (f *trueType) glyphData(id uint16) []byte {
+	if int(id)+1 >= len(f.loca) {
+		return nil
+	}
+	return f.tables["glyf"][f.loca[id]:f.loca[id+1]]
+}
+
+// components returns the glyphs a composite glyph is built from.
+func (f *trueType) components(id uint16) []uint16 {
+	const (
+		argsAreWords   = 0x0001
+		haveScale      = 0x0008
+		moreComponents = 0x0020
+		haveXYScale    = 0x0040
+		haveTwoByTwo   = 0x0080
+	)
+	data := f.glyphData(id)
+	if len(data) < 10 || int16(binary.BigEndian.Uint16(data)) >= 0 {
+		return nil
+	}
+	var ids []uint16
+	for at := 10; at+4 <= len(data); {
+		flags := binary.BigEndian.Uint16(data[at:])
+		ids = append(ids, binary.BigEndian.Uint16(data[at+2:]))
+		at += 4
+		if flags&argsAreWords != 0 {
+			at += 4
+		} else {
+			at += 2
+		}
+		switch {
+		case flags&haveScale != 0:
+			at += 2
+		case flags&haveXYScale != 0:
+			at += 4
+		case flags&haveTwoByTwo != 0:
+			at += 8
+		}
+		if flags&moreComponents == 0 {
+			break
+		}
+	}
+	return ids
+}
+
+// subset returns a font file with the outlines of the used glyphs only.
+// Glyph ids are kept, so text set with the full font shows the same glyphs.
+func (f *trueType) subset(used map[uint16]rune) []byte {
+	keep := map[uint16]bool{}
+	pending := append(sortedGlyphs(used), 0) // .notdef is always included
+	for len(pending) > 0 {
+		id := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if keep[id] {
+			continue
+		}
+		keep[id] = true
+		for _, component := range f.components(id) {
+			if !keep[component] {
+				pending = append(pending, component)
+			}
+		}
+	}
+
+	var glyf bytes.Buffer
+	loca := make([]byte, 4*len(f.loca))
+	for id := 0; id < len(f.loca)-1; id++ {
+		binary.BigEndian.PutUint32(loca[4*id:], uint32(glyf.Len()))
+		if keep[uint16(id)] {
+			glyf.Write(f.glyphData(uint16(id)))
+			for glyf.Len()%4 != 0 {
+				glyf.WriteByte(0)
+			}
+		}
+	}
+	binary.BigEndian.PutUint32(loca[4*(len(f.loca)-1):], uint32(glyf.Len()))
+
+	head := slices.Clone(f.tables["head"])
+	binary.BigEndian.PutUint32(head[8:], 0)  // checkSumAdjustment, set below
+	binary.BigEndian.PutUint16(head[50:], 1) // long loca offsets
+
+	tables := map[string][]byte{"head": head, "loca": loca, "glyf": glyf.Bytes()}
+	// The instructions are kept so the glyphs hint as in the full font
+	for _, tag := range []string{"hhea", "hmtx", "maxp", "cvt ", "fpgm", "prep"} {
+		if data, ok := f.tables[tag]; ok {
+			tables[tag] = data
+		}
+	}
+
+	font := writeSfnt(tables)
+	binary.BigEndian.PutUint32(font[headOffset(font):][8:], 0xB1B0AFBA-checksum(font))
+	return font
+}
+
+// writeSfnt assembles tables into a font file.
+func writeSfnt(tables map[string][]byte) []byte {
+	tags := make([]string, 0, len(tables))
+	for tag := range tables {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	n := len(tags)
+	searchRange := 16 << (bits.Len(uint(n)) - 1)
+	header := make([]byte, 12+16*n)
+	binary.BigEndian.PutUint32(header, 0x00010000)
+	binary.BigEndian.PutUint16(header[4:], uint16(n))
+	binary.BigEndian.PutUint16(header[6:], uint16(searchRange))
+	binary.BigEndian.PutUint16(header[8:], uint16(bits.Len(uint(n))-1))
+	binary.BigEndian.PutUint16(header[10:], uint16(16*n-searchRange))
+
+	body := make([]byte, 0)
+	for i, tag := range tags {
+		data := tables[tag]
+		record := header[12+16*i:]
+		copy(record, tag)
+		binary.BigEndian.PutUint32(record[4:], checksum(data))
+		binary.BigEndian.PutUint32(record[8:], uint32(len(header)+len(body)))
+		binary.BigEndian.PutUint32(record[12:], uint32(len(data)))
+		body = append(body, data...)
+		for len(body)%4 != 0 {
+			body = append(body, 0)
+		}
+	}
+	return append(header, body...)
+}
+
+// headOffset returns the offset of the head table in a font written by writeSfnt.
+func headOffset(font []byte) int {
+	for i := 0; i < int(binary.BigEndian.Uint16(font[4:])); i++ {
+		if record := font[12+16*i:]; string(record[:4]) == "head" {
+			return int(binary.BigEndian.Uint32(record[8:]))
+		}
+	}
+	return 0
+}
+
+// checksum is the sum of data as big-endian uint32s, zero-padded.
+func checksum(data []byte) uint32 {
+	var sum uint32
+	for i := 0; i < len(data); i += 4 {
+		var word [4]byte
+		copy(word[:], data[i:])
+		sum += binary.BigEndian.Uint32(word[:])
+	}
+	return sum
+}
+
+// subsetTag derives the six-letter tag that marks a subset font's name from its glyphs.
+func subsetTag(used map[uint16]rune) string {
+	ids := sortedGlyphs(used)
+
+	h := fnv.New32a()
+	for _, id := range ids {
+		h.Write([]byte{byte(id >> 8), byte(id)})
+	}
+	sum := h.Sum32()
+	tag := make([]byte, 6)
+	for i := range tag {
+		tag[i] = 'A' + byte(sum%26)
+		sum /= 26
+	}
+	return string(tag)
+}
+
+// toUnicode writes the CMap that maps glyph ids back to text, for copying and search.
+func toUnicode(used map[uint16]rune) []byte {
+	ids := sortedGlyphs(used)
+
+	var b strings.Builder
+	b.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n" +
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n" +
+		"/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n" +
+		"1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+	// At most 100 mappings are allowed per block
+	for start := 0; start < len(ids); start += 100 {
+		block := ids[start:min(start+100, len(ids))]
+		fmt.Fprintf(&b, "%d beginbfchar\n", len(block))
+		for _, id := range block {
+			fmt.Fprintf(&b, "<%04X> <", id)
+			for _, unit := range utf16.Encode([]rune{used[id]}) {
+				fmt.Fprintf(&b, "%04X", unit)
+			}
+			b.WriteString(">\n")
+		}
+		b.WriteString("endbfchar\n")
+	}
+	b.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n")
+	return []byte(b.String())
+}
+
+// widths writes the /W array of the used glyphs.
+func (f *trueType) widths(used map[uint16]rune) string {
+	ids := sortedGlyphs(used)
+
+	var b strings.Builder
+	b.WriteString("[")
+	for _, id := range ids {
+		fmt.Fprintf(&b, " %d [%d]", id, f.width(id))
+	}
+	b.WriteString(" ]")
+	return b.String()
+}
+
+func sortedGlyphs(used map[uint16]rune) []uint16 {
+	ids := make([]uint16, 0, len(used))
+	for id := range used {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
@@ -0,0 +1,376 @@
+package pdf
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Layout flows blocks top to bottom over as many pages as needed. Blocks are
+// described by a line-oriented markup, one command per line, with the arguments
+// separated by "|":
+//
+//	accent #rrggbb              color used by titles, headings and table headers
+//	footer text                 text printed at the bottom of every page
+//	title text
+//	subtitle text
+//	heading text
+//	text text                   wrapped paragraph
+//	field label|value
+//	table col|col|...           starts a table; following "row" lines add rows
+//	row cell|cell|...
+//	bar label|fraction|display  horizontal bar, fraction in [0, 1]
+//	histogram title|min|max|count,count,...
+//	badge text|#rrggbb
+//	image name|max height       an image registered with RegisterImage
+//	spacer points
+//	rule
+//	pagebreak
+//
+// Blank lines and lines starting with "#" are ignored.
+type Layout struct {
+	doc    *Document
+	page   *Page
+	y      float64
+	accent Color
+	footer string
+	images map[string]*Image
+
+	// column widths and header of the table being written, nil outside a table
+	table  []float64
+	header []string
+	rows   int
+}
+
+const (
+	margin       = 40.0
+	contentWidth = PageWidth - 2*margin
+	bottom       = PageHeight - margin - 20 // leaves room for the footer
+)
+
+var (
+	gray      = Color{110, 110, 110}
+	lightGray = Color{238, 238, 238}
+)
+
+func NewLayout(doc *Document) *Layout {
+	return &Layout{
+		doc:    doc,
+		accent: Color{33, 82, 140},
+		images: make(map[string]*Image),
+	}
+}
+
+func (l *Layout) RegisterImage(name string, img *Image) {
+	l.images[name] = img
+}
+
+// Render lays out the markup after whatever was rendered before.
+func (l *Layout) Render(markup string) error {
+	scanner := bufio.NewScanner(strings.NewReader(markup))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		command, rest, _ := strings.Cut(text, " ")
+		if err := l.command(command, strings.Split(strings.TrimSpace(rest), "|")); err != nil {
+			return fmt.Errorf("pdf: line %d: %w", line, err)
+		}
+	}
+	return scanner.Err()
+}
+
+// Finish draws the page footers. Call it once after the last Render.
+func (l *Layout) Finish() {
+	pages := l.doc.Pages()
+	if len(pages) == 0 {
+		l.newPage()
+		pages = l.doc.Pages()
+	}
+	for i, page := range pages {
+		page.SetStrokeColor(lightGray)
+		page.SetLineWidth(0.5)
+		page.Line(margin, PageHeight-margin-8, PageWidth-margin, PageHeight-margin-8)
+		page.SetFillColor(gray)
+		if l.footer != "" {
+			page.Text(margin, PageHeight-margin+4, Regular, 8, fit(l.footer, Regular, 8, contentWidth-60))
+		}
+		number := fmt.Sprintf("%d / %d", i+1, len(pages))
+		page.Text(PageWidth-margin-TextWidth(Regular, 8, number), PageHeight-margin+4, Regular, 8, number)
+	}
+}
+
+func (l *Layout) command(command string, args []string) error {
+	arg := func(i int) string {
+		if i < len(args) {
+			return strings.TrimSpace(args[i])
+		}
+		return ""
+	}
+
+	if command != "row" {
+		l.table = nil
+	}
+
+	switch command {
+	case "accent":
+		c, err := ParseColor(arg(0))
+		if err != nil {
+			return err
+		}
+		l.accent = c
+	case "footer":
+		l.footer = strings.Join(args, "|")
+	case "title":
+		l.ensure(30)
+		l.page.SetFillColor(l.accent)
+		l.page.Text(margin, l.y+20, Bold, 20, fit(arg(0), Bold, 20, contentWidth))
+		l.y += 30
+	case "subtitle":
+		l.ensure(18)
+		l.page.SetFillColor(gray)
+		l.page.Text(margin, l.y+11, Regular, 11, fit(arg(0), Regular, 11, contentWidth))
+		l.y += 18
+	case "heading":
+		// keep a heading together with at least a few lines of what follows
+		l.ensure(70)
+		l.y += 10
+		l.page.SetFillColor(l.accent)
+		l.page.Text(margin, l.y+13, Bold, 13, fit(arg(0), Bold, 13, contentWidth))
+		l.page.SetStrokeColor(l.accent)
+		l.page.SetLineWidth(1)
+		l.page.Line(margin, l.y+18, PageWidth-margin, l.y+18)
+		l.y += 26
+	case "text":
+		for _, line := range wrap(strings.Join(args, "|"), Regular, 10, contentWidth) {
+			l.ensure(14)
+			l.page.SetFillColor(Black)
+			l.page.Text(margin, l.y+10, Regular, 10, line)
+			l.y += 14
+		}
+	case "field":
+		l.ensure(15)
+		l.page.SetFillColor(gray)
+		l.page.Text(margin, l.y+10, Bold, 10, fit(arg(0), Bold, 10, 140))
+		l.page.SetFillColor(Black)
+		l.page.Text(margin+150, l.y+10, Regular, 10, fit(arg(1), Regular, 10, contentWidth-150))
+		l.y += 15
+	case "table":
+		l.ensure(36)
+		width := contentWidth / float64(len(args))
+		l.table = make([]float64, len(args))
+		for i := range l.table {
+			l.table[i] = width
+		}
+		l.rows = 0
+		l.tableHeader(args)
+	case "row":
+		if l.table == nil {
+			return fmt.Errorf("row outside of a table")
+		}
+		if l.ensure(16) {
+			l.tableHeader(nil)
+		}
+		if l.rows%2 == 1 {
+			l.page.SetFillColor(lightGray)
+			l.page.Rect(margin, l.y, contentWidth, 16, true)
+		}
+		l.page.SetFillColor(Black)
+		x := margin
+		for i, width := range l.table {
+			l.page.Text(x+4, l.y+11, Regular, 9, fit(arg(i), Regular, 9, width-8))
+			x += width
+		}
+		l.rows++
+		l.y += 16
+	case "bar":
+		fraction, err := parseFloat(arg(1))
+		if err != nil {
+			return err
+		}
+		fraction = math.Max(0, math.Min(1, fraction))
+		l.ensure(16)
+		l.page.SetFillColor(Black)
+		l.page.Text(margin, l.y+11, Regular, 9, fit(arg(0), Regular, 9, 140))
+		barWidth := contentWidth - 150 - 70
+		l.page.SetFillColor(lightGray)
+		l.page.Rect(margin+150, l.y+3, barWidth, 10, true)
+		l.page.SetFillColor(l.accent)
+		l.page.Rect(margin+150, l.y+3, barWidth*fraction, 10, true)
+		l.page.SetFillColor(Black)
+		l.page.Text(PageWidth-margin-TextWidth(Regular, 9, arg(2)), l.y+11, Regular, 9, arg(2))
+		l.y += 16
+	case "histogram":
+		return l.histogram(arg(0), arg(1), arg(2), arg(3))
+	case "badge":
+		c, err := ParseColor(arg(1))
+		if err != nil {
+			return err
+		}
+		l.ensure(30)
+		width := TextWidth(Bold, 12, arg(0)) + 24
+		l.page.SetFillColor(c)
+		l.page.Rect(margin, l.y+2, width, 22, true)
+		l.page.SetFillColor(White)
+		l.page.Text(margin+12, l.y+17, Bold, 12, arg(0))
+		l.y += 30
+	case "image":
+		img, ok := l.images[arg(0)]
+		if !ok {
+			// optional images the caller couldn't provide are simply left out
+			return nil
+		}
+		maxHeight, err := parseFloat(arg(1))
+		if err != nil || maxHeight <= 0 {
+			maxHeight = 200
+		}
+		w, h := float64(img.Width), float64(img.Height)
+		scale := math.Min(contentWidth/w, maxHeight/h)
+		w, h = w*scale, h*scale
+		l.ensure(h + 6)
+		l.page.Image(img, margin, l.y, w, h)
+		l.y += h + 6
+	case "spacer":
+		v, err := parseFloat(arg(0))
+		if err != nil {
+			return err
+		}
+		l.ensure(0)
+		l.y += v
+	case "rule":
+		l.ensure(10)
+		l.page.SetStrokeColor(lightGray)
+		l.page.SetLineWidth(0.5)
+		l.page.Line(margin, l.y+5, PageWidth-margin, l.y+5)
+		l.y += 10
+	case "pagebreak":
+		l.newPage()
+	default:
+		return fmt.Errorf("unknown command %q", command)
+	}
+	return nil
+}
+
+// ensure starts a new page unless height points still fit on the current one.
+// It reports whether a new page was started.
+func (l *Layout) ensure(height float64) bool {
+	if l.page != nil && l.y+height <= bottom {
+		return false
+	}
+	l.newPage()
+	return true
+}
+
+func (l *Layout) newPage() {
+	l.page = l.doc.AddPage()
+	l.y = margin
+}
+
+// tableHeader draws the header row. Called with nil it repeats the previous
+// header on a new page.
+func (l *Layout) tableHeader(header []string) {
+	if header != nil {
+		l.header = header
+	}
+	l.page.SetFillColor(l.accent)
+	l.page.Rect(margin, l.y, contentWidth, 18, true)
+	l.page.SetFillColor(White)
+	x := margin
+	for i, width := range l.table {
+		cell := ""
+		if i < len(l.header) {
+			cell = strings.TrimSpace(l.header[i])
+		}
+		l.page.Text(x+4, l.y+12.5, Bold, 9, fit(cell, Bold, 9, width-8))
+		x += width
+	}
+	l.y += 18
+}
+
+func (l *Layout) histogram(title, lower, upper, counts string) error {
+	var values []float64
+	peak := 0.0
+	for _, s := range strings.Split(counts, ",") {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+		v, err := parseFloat(s)
+		if err != nil {
+			return err
+		}
+		values = append(values, v)
+		peak = math.Max(peak, v)
+	}
+
+	const chartHeight = 80
+	l.ensure(chartHeight + 36)
+	l.page.SetFillColor(Black)
+	l.page.Text(margin, l.y+10, Bold, 10, fit(title, Bold, 10, contentWidth))
+	l.y += 16
+
+	l.page.SetStrokeColor(gray)
+	l.page.SetLineWidth(0.5)
+	l.page.Line(margin, l.y+chartHeight, PageWidth-margin, l.y+chartHeight)
+	if len(values) > 0 && peak > 0 {
+		width := contentWidth / float64(len(values))
+		l.page.SetFillColor(l.accent)
+		for i, v := range values {
+			h := chartHeight * v / peak
+			l.page.Rect(margin+float64(i)*width+0.5, l.y+chartHeight-h, width-1, h, true)
+		}
+	}
+
+	l.page.SetFillColor(gray)
+	l.page.Text(margin, l.y+chartHeight+10, Regular, 8, lower)
+	l.page.Text(PageWidth-margin-TextWidth(Regular, 8, upper), l.y+chartHeight+10, Regular, 8, upper)
+	l.y += chartHeight + 20
+	return nil
+}
+
+// fit truncates s with an ellipsis so that it is at most width points wide.
+func fit(s string, font Font, size, width float64) string {
+	if TextWidth(font, size, s) <= width {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && TextWidth(font, size, string(runes)+"…") > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "…"
+}
+
+// wrap breaks s into lines at most width points wide.
+func wrap(s string, font Font, size, width float64) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(s) {
+		candidate := word
+		if line != "" {
+			candidate = line + " " + word
+		}
+		if line != "" && TextWidth(font, size, candidate) > width {
+			lines = append(lines, line)
+			candidate = word
+		}
+		line = fit(candidate, font, size, width)
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func parseFloat(s string) (float64, error) {
+	v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", s)
+	}
+	return v, nil
+}
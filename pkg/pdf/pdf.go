@@ -0,0 +1,353 @@
+// Package pdf writes simple single-column PDF documents without external dependencies.
+//
+// Text is set in DejaVu Sans, embedded as a subset of the glyphs the document
+// uses, so Cyrillic and the other scripts the font covers print as entered.
+// Characters the font lacks are replaced with "?". Images are embedded as JPEG.
+package pdf
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// A4 page size in points.
+const (
+	PageWidth  = 595.28
+	PageHeight = 841.89
+)
+
+type Font int
+
+const (
+	Regular Font = iota
+	Bold
+)
+
+type Color struct {
+	R, G, B uint8
+}
+
+var (
+	Black = Color{0, 0, 0}
+	White = Color{255, 255, 255}
+)
+
+// ParseColor parses a "#rrggbb" color.
+func ParseColor(s string) (Color, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "#")
+	if len(s) != 6 {
+		return Color{}, fmt.Errorf("pdf: invalid color %q", s)
+	}
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return Color{}, fmt.Errorf("pdf: invalid color %q", s)
+	}
+	return Color{uint8(v >> 16), uint8(v >> 8), uint8(v)}, nil
+}
+
+// Image is a JPEG image that can be placed on any page of the document it was added to.
+type Image struct {
+	name          string
+	data          []byte
+	Width, Height int
+}
+
+type Document struct {
+	pages  []*Page
+	images []*Image
+}
+
+func New() *Document {
+	return &Document{}
+}
+
+// AddPage appends an empty A4 page.
+func (d *Document) AddPage() *Page {
+	p := &Page{}
+	d.pages = append(d.pages, p)
+	return p
+}
+
+func (d *Document) Pages() []*Page {
+	return d.pages
+}
+
+// AddImage encodes img as JPEG, scaled down so that neither side exceeds maxSide
+// pixels (0 keeps the original size). Transparent areas are flattened onto white.
+func (d *Document) AddImage(img image.Image, maxSide int) (*Image, error) {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w == 0 || h == 0 {
+		return nil, fmt.Errorf("pdf: empty image")
+	}
+	if maxSide > 0 && (w > maxSide || h > maxSide) {
+		if w >= h {
+			w, h = maxSide, max(1, h*maxSide/w)
+		} else {
+			w, h = max(1, w*maxSide/h), maxSide
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			r, g, bl, a := img.At(b.Min.X+x*b.Dx()/w, b.Min.Y+y*b.Dy()/h).RGBA()
+			// Composite premultiplied colour over white
+			white := 0xffff - a
+			dst.Set(x, y, color.RGBA64{uint16(r + white), uint16(g + white), uint16(bl + white), 0xffff})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, err
+	}
+
+	added := &Image{
+		name:   fmt.Sprintf("Im%d", len(d.images)+1),
+		data:   buf.Bytes(),
+		Width:  w,
+		Height: h,
+	}
+	d.images = append(d.images, added)
+	return added, nil
+}
+
+// Page collects the drawing operations of one page. Coordinates are in points
+// from the top-left corner; text is positioned by its baseline.
+type Page struct {
+	content bytes.Buffer
+	images  []*Image
+	glyphs  [2]map[uint16]rune // glyphs used per font, with the characters they show
+}
+
+func (p *Page) SetFillColor(c Color) {
+	fmt.Fprintf(&p.content, "%s %s %s rg\n", colorComponent(c.R), colorComponent(c.G), colorComponent(c.B))
+}
+
+func (p *Page) SetStrokeColor(c Color) {
+	fmt.Fprintf(&p.content, "%s %s %s RG\n", colorComponent(c.R), colorComponent(c.G), colorComponent(c.B))
+}
+
+func (p *Page) SetLineWidth(w float64) {
+	fmt.Fprintf(&p.content, "%s w\n", num(w))
+}
+
+// Text draws s in the current fill color.
+func (p *Page) Text(x, y float64, font Font, size float64, s string) {
+	fmt.Fprintf(&p.content, "BT /F%d %s Tf %s %s Td <", font+1, num(size), num(x), num(PageHeight-y))
+	if p.glyphs[font] == nil {
+		p.glyphs[font] = map[uint16]rune{}
+	}
+	for _, g := range faces()[font].glyphs(s) {
+		if _, ok := p.glyphs[font][g.id]; !ok {
+			p.glyphs[font][g.id] = g.r
+		}
+		fmt.Fprintf(&p.content, "%04X", g.id)
+	}
+	p.content.WriteString("> Tj ET\n")
+}
+
+// Rect draws a rectangle, filled with the fill color or stroked with the stroke color.
+func (p *Page) Rect(x, y, w, h float64, fill bool) {
+	op := "S"
+	if fill {
+		op = "f"
+	}
+	fmt.Fprintf(&p.content, "%s %s %s %s re %s\n", num(x), num(PageHeight-y-h), num(w), num(h), op)
+}
+
+func (p *Page) Line(x1, y1, x2, y2 float64) {
+	fmt.Fprintf(&p.content, "%s %s m %s %s l S\n", num(x1), num(PageHeight-y1), num(x2), num(PageHeight-y2))
+}
+
+// Image draws img stretched to the given box.
+func (p *Page) Image(img *Image, x, y, w, h float64) {
+	found := false
+	for _, used := range p.images {
+		found = found || used == img
+	}
+	if !found {
+		p.images = append(p.images, img)
+	}
+	fmt.Fprintf(&p.content, "q %s 0 0 %s %s %s cm /%s Do Q\n", num(w), num(h), num(x), num(PageHeight-y-h), img.name)
+}
+
+// TextWidth returns the width of s in points when set in font at size.
+func TextWidth(font Font, size float64, s string) float64 {
+	face := faces()[font]
+	total := 0
+	for _, g := range face.glyphs(s) {
+		total += face.width(g.id)
+	}
+	return float64(total) * size / 1000
+}
+
+// WriteTo serializes the document.
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	pw := &writer{w: bufio.NewWriter(w)}
+
+	// Fixed objects first, then images, then a page and a content object per page
+	const (
+		catalogObj = 1
+		pagesObj   = 2
+		fontObj    = 3 // regular, bold is fontObj+fontObjs
+		fontObjs   = 5 // font, descendant font, descriptor, font file and ToUnicode
+		firstImage = fontObj + 2*fontObjs
+	)
+	imageObjs := make(map[*Image]int, len(d.images))
+	for i, img := range d.images {
+		imageObjs[img] = firstImage + i
+	}
+	firstPage := firstImage + len(d.images)
+
+	pw.printf("%%PDF-1.4\n%%\xe2\xe3\xcf\xd3\n")
+
+	pw.object(catalogObj, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesObj))
+
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+2*i)
+	}
+	pw.object(pagesObj, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+
+	for font, face := range faces() {
+		used := map[uint16]rune{}
+		for _, page := range d.pages {
+			for id, r := range page.glyphs[font] {
+				if _, ok := used[id]; !ok {
+					used[id] = r
+				}
+			}
+		}
+		if err := pw.font(fontObj+fontObjs*font, face, used); err != nil {
+			return pw.n, err
+		}
+	}
+
+	for _, img := range d.images {
+		pw.stream(imageObjs[img], fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /DCTDecode",
+			img.Width, img.Height), img.data)
+	}
+
+	for i, page := range d.pages {
+		var xobjects strings.Builder
+		for _, img := range page.images {
+			fmt.Fprintf(&xobjects, " /%s %d 0 R", img.name, imageObjs[img])
+		}
+		pw.object(firstPage+2*i, fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %s %s] /Resources << /Font << /F1 %d 0 R /F2 %d 0 R >> /XObject <<%s >> >> /Contents %d 0 R >>",
+			pagesObj, num(PageWidth), num(PageHeight), fontObj, fontObj+fontObjs, xobjects.String(), firstPage+2*i+1))
+
+		compressed, err := deflate(page.content.Bytes())
+		if err != nil {
+			return pw.n, err
+		}
+		pw.stream(firstPage+2*i+1, "/Filter /FlateDecode", compressed)
+	}
+
+	xref := pw.n
+	pw.printf("xref\n0 %d\n0000000000 65535 f \n", len(pw.offsets)+1)
+	for _, offset := range pw.offsets {
+		pw.printf("%010d 00000 n \n", offset)
+	}
+	pw.printf("trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(pw.offsets)+1, catalogObj, xref)
+
+	if pw.err != nil {
+		return pw.n, pw.err
+	}
+	return pw.n, pw.w.Flush()
+}
+
+// writer tracks the byte offsets of objects for the cross-reference table.
+// Objects must be written in ascending order starting from 1.
+type writer struct {
+	w       *bufio.Writer
+	n       int64
+	offsets []int64
+	err     error
+}
+
+func (w *writer) printf(format string, args ...interface{}) {
+	if w.err != nil {
+		return
+	}
+	n, err := fmt.Fprintf(w.w, format, args...)
+	w.n += int64(n)
+	w.err = err
+}
+
+func (w *writer) write(data []byte) {
+	if w.err != nil {
+		return
+	}
+	n, err := w.w.Write(data)
+	w.n += int64(n)
+	w.err = err
+}
+
+func (w *writer) object(id int, body string) {
+	w.offsets = append(w.offsets, w.n)
+	w.printf("%d 0 obj\n%s\nendobj\n", id, body)
+}
+
+func (w *writer) stream(id int, dict string, data []byte) {
+	w.offsets = append(w.offsets, w.n)
+	w.printf("%d 0 obj\n<< %s /Length %d >>\nstream\n", id, dict, len(data))
+	w.write(data)
+	w.printf("\nendstream\nendobj\n")
+}
+
+// font writes a Type 0 font with Identity-H encoding, so text is written as glyph
+// ids, over the subset of face with the used glyphs. It takes the five objects
+// from id on.
+func (w *writer) font(id int, face *trueType, used map[uint16]rune) error {
+	name := subsetTag(used) + "+" + face.name
+	w.object(id, fmt.Sprintf("<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /Identity-H /DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>",
+		name, id+1, id+4))
+	w.object(id+1, fmt.Sprintf("<< /Type /Font /Subtype /CIDFontType2 /BaseFont /%s /CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> /FontDescriptor %d 0 R /W %s /CIDToGIDMap /Identity >>",
+		name, id+2, face.widths(used)))
+	w.object(id+2, fmt.Sprintf("<< /Type /FontDescriptor /FontName /%s /Flags 32 /FontBBox [%d %d %d %d] /ItalicAngle 0 /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 %d 0 R >>",
+		name, face.scale(face.bbox[0]), face.scale(face.bbox[1]), face.scale(face.bbox[2]), face.scale(face.bbox[3]),
+		face.scale(face.ascent), face.scale(face.descent), face.scale(face.capHeight), id+3))
+
+	file := face.subset(used)
+	compressed, err := deflate(file)
+	if err != nil {
+		return err
+	}
+	w.stream(id+3, fmt.Sprintf("/Length1 %d /Filter /FlateDecode", len(file)), compressed)
+
+	if compressed, err = deflate(toUnicode(used)); err != nil {
+		return err
+	}
+	w.stream(id+4, "/Filter /FlateDecode", compressed)
+	return nil
+}
+
+func deflate(data []byte) ([]byte, error) {
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return compressed.Bytes(), nil
+}
+
+func num(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func colorComponent(c uint8) string {
+	return strconv.FormatFloat(float64(c)/255, 'f', 3, 64)
+}
@@ -0,0 +1,258 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+// pdfObject is an indirect object of a written document.
+type pdfObject struct {
+	dict   string
+	stream []byte // decompressed, nil for objects without one
+}
+
+var (
+	objectPattern = regexp.MustCompile(`(?s)^(\d+) 0 obj\n(.*?)\n(endobj|stream\n)`)
+	lengthPattern = regexp.MustCompile(`/Length (\d+) >>$`)
+)
+
+// readObjects checks the cross-reference table of a document and reads the
+// objects at the offsets it lists.
+func readObjects(t *testing.T, data []byte) map[int]pdfObject {
+	t.Helper()
+	if !bytes.HasPrefix(data, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(data, []byte("%%EOF\n")) {
+		t.Fatal("missing header or trailer")
+	}
+
+	startxref := bytes.LastIndex(data, []byte("startxref\n"))
+	if startxref < 0 {
+		t.Fatal("missing startxref")
+	}
+	xref, err := strconv.Atoi(strings.Fields(string(data[startxref+len("startxref\n"):]))[0])
+	if err != nil || !bytes.HasPrefix(data[xref:], []byte("xref\n")) {
+		t.Fatalf("startxref %d doesn't point at the xref table", xref)
+	}
+
+	lines := strings.Split(string(data[xref:startxref]), "\n")
+	var first, count int
+	if _, err := fmt.Sscan(lines[1], &first, &count); err != nil || first != 0 {
+		t.Fatalf("invalid xref subsection %q", lines[1])
+	}
+	if !strings.Contains(string(data[startxref-100:startxref]), "/Size "+strconv.Itoa(count)+" ") {
+		t.Errorf("trailer /Size doesn't match the %d xref entries", count)
+	}
+
+	objects := make(map[int]pdfObject, count)
+	for id := 1; id < count; id++ {
+		entry := lines[2+id]
+		if len(entry) != 19 || !strings.HasSuffix(entry, " 00000 n ") {
+			t.Fatalf("invalid xref entry %q", entry)
+		}
+		offset, _ := strconv.Atoi(entry[:10])
+		match := objectPattern.FindSubmatch(data[offset:])
+		if match == nil || string(match[1]) != strconv.Itoa(id) {
+			t.Fatalf("xref offset %d of object %d doesn't point at it", offset, id)
+		}
+
+		object := pdfObject{dict: string(match[2])}
+		if string(match[3]) == "stream\n" {
+			length := lengthPattern.FindStringSubmatch(object.dict)
+			if length == nil {
+				t.Fatalf("stream %d without length", id)
+			}
+			n, _ := strconv.Atoi(length[1])
+			start := offset + len(match[0])
+			raw := data[start : start+n]
+			if !bytes.HasPrefix(data[start+n:], []byte("\nendstream\nendobj\n")) {
+				t.Fatalf("stream %d doesn't end at its length", id)
+			}
+			object.stream = raw
+			if strings.Contains(object.dict, "/FlateDecode") {
+				zr, err := zlib.NewReader(bytes.NewReader(raw))
+				if err != nil {
+					t.Fatalf("stream %d: %v", id, err)
+				}
+				if object.stream, err = io.ReadAll(zr); err != nil {
+					t.Fatalf("stream %d: %v", id, err)
+				}
+			}
+		}
+		objects[id] = object
+	}
+	return objects
+}
+
+// ref returns the object id a dictionary refers to under key.
+func ref(t *testing.T, dict, key string) int {
+	t.Helper()
+	match := regexp.MustCompile(regexp.QuoteMeta(key) + ` ?(\d+) 0 R`).FindStringSubmatch(dict)
+	if match == nil {
+		t.Fatalf("%s not found in %q", key, dict)
+	}
+	id, _ := strconv.Atoi(match[1])
+	return id
+}
+
+// readCMap parses the bfchar mappings of a ToUnicode CMap.
+func readCMap(t *testing.T, cmap []byte) map[string]string {
+	t.Helper()
+	mappings := map[string]string{}
+	for _, match := range regexp.MustCompile(`<([0-9A-F]{4})> <([0-9A-F]+)>`).FindAllStringSubmatch(string(cmap), -1) {
+		raw, err := hex.DecodeString(match[2])
+		if err != nil {
+			t.Fatal(err)
+		}
+		units := make([]uint16, len(raw)/2)
+		for i := range units {
+			units[i] = uint16(raw[2*i])<<8 | uint16(raw[2*i+1])
+		}
+		mappings[match[1]] = string(utf16.Decode(units))
+	}
+	return mappings
+}
+
+// extractText returns the strings drawn on a page, decoded through the
+// ToUnicode maps of its fonts as a PDF reader would.
+func extractText(t *testing.T, objects map[int]pdfObject, pageID int) []string {
+	t.Helper()
+	page := objects[pageID].dict
+	cmaps := map[string]map[string]string{}
+	for _, name := range []string{"/F1", "/F2"} {
+		font := objects[ref(t, page, name)].dict
+		if !strings.Contains(font, "/Subtype /Type0") || !strings.Contains(font, "/Encoding /Identity-H") {
+			t.Fatalf("font %s isn't a Type 0 font: %q", name, font)
+		}
+		cmaps[name] = readCMap(t, objects[ref(t, font, "/ToUnicode")].stream)
+	}
+
+	var text []string
+	content := objects[ref(t, page, "/Contents")].stream
+	for _, match := range regexp.MustCompile(`(/F\d) \S+ Tf \S+ \S+ Td <([0-9A-F]*)> Tj`).FindAllStringSubmatch(string(content), -1) {
+		var s strings.Builder
+		for i := 0; i+4 <= len(match[2]); i += 4 {
+			r, ok := cmaps[match[1]][match[2][i:i+4]]
+			if !ok {
+				t.Fatalf("glyph %s of %s has no ToUnicode mapping", match[2][i:i+4], match[1])
+			}
+			s.WriteString(r)
+		}
+		text = append(text, s.String())
+	}
+	return text
+}
+
+func TestWriteTo(t *testing.T) {
+	doc := New()
+	page := doc.AddPage()
+	page.Text(40, 40, Bold, 14, "Сертификат качества №12")
+	page.Text(40, 60, Regular, 10, "Продукт: пшеница (Triticum) \\ 100%")
+	page.Text(40, 80, Regular, 10, "tab\tand\x01control")
+	page.Text(40, 100, Regular, 10, "日本")
+	doc.AddPage().Text(40, 40, Regular, 10, "Страница 2")
+
+	var buf bytes.Buffer
+	n, err := doc.WriteTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(buf.Len()) {
+		t.Errorf("got %d bytes written, counted %d", buf.Len(), n)
+	}
+
+	objects := readObjects(t, buf.Bytes())
+	kids := regexp.MustCompile(`(\d+) 0 R`).FindAllStringSubmatch(objects[ref(t, objects[1].dict, "/Pages")].dict, -1)
+	if len(kids) != 2 {
+		t.Fatalf("got %d pages, want 2", len(kids))
+	}
+	pageIDs := make([]int, len(kids))
+	for i, kid := range kids {
+		pageIDs[i], _ = strconv.Atoi(kid[1])
+	}
+
+	want := []string{
+		"Сертификат качества №12",
+		"Продукт: пшеница (Triticum) \\ 100%",
+		"tab andcontrol",
+		"??",
+	}
+	got := extractText(t, objects, pageIDs[0])
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got text %q, want %q", got, want)
+	}
+	if got := extractText(t, objects, pageIDs[1]); len(got) != 1 || got[0] != "Страница 2" {
+		t.Errorf("got second page text %q, want Страница 2", got)
+	}
+}
+
+func TestFontSubset(t *testing.T) {
+	doc := New()
+	doc.AddPage().Text(0, 0, Regular, 10, "Ёж")
+
+	var buf bytes.Buffer
+	if _, err := doc.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	objects := readObjects(t, buf.Bytes())
+
+	descendant := objects[ref(t, objects[3].dict, "/DescendantFonts [")].dict
+	descriptor := objects[ref(t, descendant, "/FontDescriptor")].dict
+	file := objects[ref(t, descriptor, "/FontFile2")]
+	if !strings.Contains(file.dict, "/Length1 "+strconv.Itoa(len(file.stream))+" ") {
+		t.Errorf("font file /Length1 doesn't match its %d bytes: %q", len(file.stream), file.dict)
+	}
+	if !regexp.MustCompile(`^/[A-Z]{6}\+DejaVuSans$`).MatchString(regexp.MustCompile(`/FontName (\S+)`).FindStringSubmatch(descriptor)[1]) {
+		t.Errorf("got descriptor %q, want a tagged subset name", descriptor)
+	}
+	if size := len(file.stream); size >= len(dejaVuSans)/4 {
+		t.Errorf("got a %d byte font file, want a subset of the %d byte font", size, len(dejaVuSans))
+	}
+
+	subset, err := parseTrueType("subset", file.stream)
+	if err != nil {
+		t.Fatalf("parsing subset: %v", err)
+	}
+	full := faces()[Regular]
+	if checksum(file.stream) != 0xB1B0AFBA {
+		t.Error("subset checksum adjustment is wrong")
+	}
+	for _, r := range "Ёжa" {
+		id := full.cmap[r]
+		// Ё is a composite of Е and the diaeresis, which must come along
+		hasOutline := len(subset.glyphData(id)) > 0
+		if wantOutline := r != 'a'; hasOutline != wantOutline {
+			t.Errorf("%c: got outline %v, want %v", r, hasOutline, wantOutline)
+		}
+		for _, component := range full.components(id) {
+			if len(subset.glyphData(component)) == 0 {
+				t.Errorf("%c: component %d missing from the subset", r, component)
+			}
+		}
+	}
+	if !bytes.Equal(subset.glyphData(full.cmap['ж']), full.glyphData(full.cmap['ж'])) {
+		t.Error("subset outline of ж differs from the font's")
+	}
+}
+
+func TestTextWidth(t *testing.T) {
+	face := faces()[Regular]
+	if got, want := TextWidth(Regular, 10, "Пш"), float64(face.width(face.cmap['П'])+face.width(face.cmap['ш']))/100; got != want {
+		t.Errorf("got width %v, want %v", got, want)
+	}
+	if TextWidth(Regular, 10, "Пшеница") <= 0 {
+		t.Error("got no width for Cyrillic text")
+	}
+	if TextWidth(Bold, 10, "Пшеница") <= TextWidth(Regular, 10, "Пшеница") {
+		t.Error("got bold text no wider than regular")
+	}
+	if got, want := TextWidth(Regular, 10, "日"), TextWidth(Regular, 10, "?"); got != want {
+		t.Errorf("got width %v for a missing character, want the width of ? %v", got, want)
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"github.com/gofiber/fiber/v2"
 )
 
+// ParseParamWithType reads a route parameter as T. A missing or malformed
+// parameter is returned as a 400 *fiber.Error for the error handler to write.
 func ParseParamWithType[T any](c *fiber.Ctx, paramName string, defaultValue ...T) (T, error) {
 	var zero T
 	param := c.Params(paramName)
@@ -15,7 +17,7 @@ func ParseParamWithType[T any](c *fiber.Ctx, paramName string, defaultValue ...T
 		if len(defaultValue) > 0 {
 			return defaultValue[0], nil
 		}
-		return zero, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": fmt.Sprintf("Missing %s parameter", paramName)})
+		return zero, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Missing %s parameter", paramName))
 	}
 
 	// Handle type conversion based on T
@@ -46,7 +48,7 @@ func ParseParamWithType[T any](c *fiber.Ctx, paramName string, defaultValue ...T
 	}
 
 	if err != nil {
-		return zero, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": fmt.Sprintf("Invalid %s parameter: %v", paramName, err)})
+		return zero, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Invalid %s parameter: %v", paramName, err))
 	}
 
 	return result.(T), nil
@@ -0,0 +1,226 @@
+// Package xlsx writes minimal Office Open XML spreadsheets as a stream.
+//
+// Rows are written straight into the zip archive as they arrive, so memory use
+// doesn't grow with the number of rows. Only what's needed for tabular exports is
+// supported: multiple sheets, inline strings and numbers, no styles or formulas.
+package xlsx
+
+import (
+	"archive/zip"
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	maxSheetName = 31
+
+	xmlHeader        = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n"
+	spreadsheetNS    = "http://schemas.openxmlformats.org/spreadsheetml/2006/main"
+	relationshipsNS  = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+	packageRelsNS    = "http://schemas.openxmlformats.org/package/2006/relationships"
+	contentTypesNS   = "http://schemas.openxmlformats.org/package/2006/content-types"
+	worksheetRelType = relationshipsNS + "/worksheet"
+	documentRelType  = relationshipsNS + "/officeDocument"
+)
+
+// Writer streams a workbook. Call StartSheet before writing rows and Close at the end.
+type Writer struct {
+	zw     *zip.Writer
+	sheet  *bufio.Writer
+	sheets []string
+	closed bool
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{zw: zip.NewWriter(w)}
+}
+
+// StartSheet finishes the current sheet, if any, and starts a new one.
+func (w *Writer) StartSheet(name string) error {
+	if w.closed {
+		return errors.New("xlsx: writer is closed")
+	}
+	if err := w.finishSheet(); err != nil {
+		return err
+	}
+
+	name = sanitizeSheetName(name, len(w.sheets)+1)
+	part, err := w.zw.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", len(w.sheets)+1))
+	if err != nil {
+		return err
+	}
+	w.sheets = append(w.sheets, name)
+	w.sheet = bufio.NewWriter(part)
+
+	_, err = w.sheet.WriteString(xmlHeader + `<worksheet xmlns="` + spreadsheetNS + `"><sheetData>`)
+	return err
+}
+
+// WriteRow appends a row to the current sheet. Supported cell values are nil
+// (empty cell), string, float64/float32 (NaN and Inf become empty), signed
+// integers, bool and time.Time (written as RFC 3339 text).
+func (w *Writer) WriteRow(cells []interface{}) error {
+	if w.sheet == nil {
+		return errors.New("xlsx: no sheet started")
+	}
+
+	b := w.sheet
+	b.WriteString("<row>")
+	for _, cell := range cells {
+		switch v := cell.(type) {
+		case nil:
+			b.WriteString("<c/>")
+		case string:
+			writeString(b, v)
+		case time.Time:
+			writeString(b, v.Format(time.RFC3339))
+		case bool:
+			if v {
+				b.WriteString(`<c t="b"><v>1</v></c>`)
+			} else {
+				b.WriteString(`<c t="b"><v>0</v></c>`)
+			}
+		case float64:
+			writeNumber(b, v)
+		case float32:
+			writeNumber(b, float64(v))
+		case int:
+			writeNumber(b, float64(v))
+		case int32:
+			writeNumber(b, float64(v))
+		case int64:
+			writeNumber(b, float64(v))
+		default:
+			writeString(b, fmt.Sprint(v))
+		}
+	}
+	_, err := b.WriteString("</row>")
+	return err
+}
+
+// Close finishes the last sheet and writes the workbook metadata.
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	if len(w.sheets) == 0 {
+		// A workbook needs at least one sheet to open
+		if err := w.StartSheet("Sheet1"); err != nil {
+			return err
+		}
+	}
+	if err := w.finishSheet(); err != nil {
+		return err
+	}
+	w.closed = true
+
+	var workbook, workbookRels, contentTypes strings.Builder
+	workbook.WriteString(xmlHeader + `<workbook xmlns="` + spreadsheetNS + `" xmlns:r="` + relationshipsNS + `"><sheets>`)
+	workbookRels.WriteString(xmlHeader + `<Relationships xmlns="` + packageRelsNS + `">`)
+	contentTypes.WriteString(xmlHeader + `<Types xmlns="` + contentTypesNS + `">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	for i, name := range w.sheets {
+		fmt.Fprintf(&workbook, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, escape(name), i+1, i+1)
+		fmt.Fprintf(&workbookRels, `<Relationship Id="rId%d" Type="%s" Target="worksheets/sheet%d.xml"/>`, i+1, worksheetRelType, i+1)
+		fmt.Fprintf(&contentTypes, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i+1)
+	}
+	workbook.WriteString(`</sheets></workbook>`)
+	workbookRels.WriteString(`</Relationships>`)
+	contentTypes.WriteString(`</Types>`)
+
+	parts := []struct{ name, body string }{
+		{"xl/workbook.xml", workbook.String()},
+		{"xl/_rels/workbook.xml.rels", workbookRels.String()},
+		{"_rels/.rels", xmlHeader + `<Relationships xmlns="` + packageRelsNS + `"><Relationship Id="rId1" Type="` + documentRelType + `" Target="xl/workbook.xml"/></Relationships>`},
+		{"[Content_Types].xml", contentTypes.String()},
+	}
+	for _, part := range parts {
+		f, err := w.zw.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, part.body); err != nil {
+			return err
+		}
+	}
+
+	return w.zw.Close()
+}
+
+func (w *Writer) finishSheet() error {
+	if w.sheet == nil {
+		return nil
+	}
+	if _, err := w.sheet.WriteString(`</sheetData></worksheet>`); err != nil {
+		return err
+	}
+	err := w.sheet.Flush()
+	w.sheet = nil
+	return err
+}
+
+func writeString(b *bufio.Writer, s string) {
+	b.WriteString(`<c t="inlineStr"><is><t xml:space="preserve">`)
+	b.WriteString(escape(s))
+	b.WriteString(`</t></is></c>`)
+}
+
+func writeNumber(b *bufio.Writer, v float64) {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		b.WriteString("<c/>")
+		return
+	}
+	b.WriteString("<c><v>")
+	b.WriteString(strconv.FormatFloat(v, 'g', -1, 64))
+	b.WriteString("</v></c>")
+}
+
+// escape escapes XML special characters and drops characters XML 1.0 can't represent.
+func escape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '<':
+			b.WriteString("&lt;")
+		case r == '>':
+			b.WriteString("&gt;")
+		case r == '&':
+			b.WriteString("&amp;")
+		case r == '"':
+			b.WriteString("&quot;")
+		case r == '\t' || r == '\n' || r == '\r':
+			b.WriteRune(r)
+		case r < 0x20 || r == utf8.RuneError || (r >= 0xFFFE && r <= 0xFFFF):
+			// not allowed in XML 1.0
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// sanitizeSheetName applies Excel's sheet name rules: at most 31 characters and none of []:*?/\.
+func sanitizeSheetName(name string, index int) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(name))
+	if utf8.RuneCountInString(name) > maxSheetName {
+		name = string([]rune(name)[:maxSheetName])
+	}
+	if name == "" {
+		name = fmt.Sprintf("Sheet%d", index)
+	}
+	return name
+}
@@ -0,0 +1,177 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"math"
+	"testing"
+	"time"
+)
+
+// cell is a cell of a sheet as read back from its XML.
+type cell struct {
+	Type   string `xml:"t,attr"`
+	Value  string `xml:"v"`
+	Inline string `xml:"is>t"`
+}
+
+type worksheet struct {
+	Rows []struct {
+		Cells []cell `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// readParts unzips a workbook into its parts.
+func readParts(t *testing.T, data []byte) map[string][]byte {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("reading zip: %v", err)
+	}
+	parts := make(map[string][]byte, len(zr.File))
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("opening %s: %v", f.Name, err)
+		}
+		body, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("reading %s: %v", f.Name, err)
+		}
+		parts[f.Name] = body
+	}
+	return parts
+}
+
+func readSheet(t *testing.T, parts map[string][]byte, name string) worksheet {
+	t.Helper()
+	body, ok := parts[name]
+	if !ok {
+		t.Fatalf("missing part %s", name)
+	}
+	var sheet worksheet
+	if err := xml.Unmarshal(body, &sheet); err != nil {
+		t.Fatalf("parsing %s: %v", name, err)
+	}
+	return sheet
+}
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	if err := w.StartSheet("Analyses"); err != nil {
+		t.Fatal(err)
+	}
+	at := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	rows := [][]interface{}{
+		{"name", "mass"},
+		{"пшеница <1&2>", 1.5, int32(7), int64(-3), true, false, nil, math.NaN(), at, "a\x01b"},
+	}
+	for _, row := range rows {
+		if err := w.WriteRow(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.StartSheet("Objects: [all]"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteRow([]interface{}{float32(0.25)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	parts := readParts(t, buf.Bytes())
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels"} {
+		if _, ok := parts[name]; !ok {
+			t.Errorf("missing part %s", name)
+		}
+	}
+	// Strings are written inline, so there is no shared strings table to keep in memory
+	if _, ok := parts["xl/sharedStrings.xml"]; ok {
+		t.Error("got a shared strings part, want inline strings")
+	}
+
+	var workbook struct {
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := xml.Unmarshal(parts["xl/workbook.xml"], &workbook); err != nil {
+		t.Fatalf("parsing workbook: %v", err)
+	}
+	if len(workbook.Sheets) != 2 || workbook.Sheets[0].Name != "Analyses" || workbook.Sheets[1].Name != "Objects_ _all_" {
+		t.Errorf("got sheets %+v, want Analyses and Objects_ _all_", workbook.Sheets)
+	}
+
+	sheet := readSheet(t, parts, "xl/worksheets/sheet1.xml")
+	if len(sheet.Rows) != 2 {
+		t.Fatalf("got %d rows, want 2", len(sheet.Rows))
+	}
+	want := []cell{
+		{Type: "inlineStr", Inline: "пшеница <1&2>"},
+		{Value: "1.5"},
+		{Value: "7"},
+		{Value: "-3"},
+		{Type: "b", Value: "1"},
+		{Type: "b", Value: "0"},
+		{},
+		{},
+		{Type: "inlineStr", Inline: "2024-05-01T12:30:00Z"},
+		{Type: "inlineStr", Inline: "ab"},
+	}
+	got := sheet.Rows[1].Cells
+	if len(got) != len(want) {
+		t.Fatalf("got %d cells, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("cell %d: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	sheet = readSheet(t, parts, "xl/worksheets/sheet2.xml")
+	if len(sheet.Rows) != 1 || len(sheet.Rows[0].Cells) != 1 || sheet.Rows[0].Cells[0].Value != "0.25" {
+		t.Errorf("got second sheet %+v, want a single 0.25", sheet.Rows)
+	}
+}
+
+func TestWriterEmpty(t *testing.T) {
+	// A workbook without sheets still gets one, or it won't open
+	var buf bytes.Buffer
+	if err := NewWriter(&buf).Close(); err != nil {
+		t.Fatal(err)
+	}
+	parts := readParts(t, buf.Bytes())
+	if sheet := readSheet(t, parts, "xl/worksheets/sheet1.xml"); len(sheet.Rows) != 0 {
+		t.Errorf("got %d rows, want none", len(sheet.Rows))
+	}
+}
+
+func TestWriteRowWithoutSheet(t *testing.T) {
+	if err := NewWriter(io.Discard).WriteRow([]interface{}{"a"}); err == nil {
+		t.Error("got no error writing before StartSheet")
+	}
+}
+
+func TestSanitizeSheetName(t *testing.T) {
+	tests := []struct {
+		name  string
+		index int
+		want  string
+	}{
+		{"Sheet", 1, "Sheet"},
+		{"a/b\\c?d*e[f]g:h", 1, "a_b_c_d_e_f_g_h"},
+		{"  ", 3, "Sheet3"},
+		{"абвгдеёжзийклмнопрстуфхцчшщъыьэюя", 1, "абвгдеёжзийклмнопрстуфхцчшщъыьэ"},
+	}
+	for _, tt := range tests {
+		if got := sanitizeSheetName(tt.name, tt.index); got != tt.want {
+			t.Errorf("sanitizeSheetName(%q, %d) = %q, want %q", tt.name, tt.index, got, tt.want)
+		}
+	}
+}